@@ -28,6 +28,12 @@ func main() {
 		log.Fatal("DISCORD_TOKEN is required. Please set it in your .env file.")
 	}
 
+	// Abilities are needed for every gopher, so the bot can't run without them
+	if err := game.LoadAbilities(cfg.DataPath); err != nil {
+		log.Fatalf("Error loading abilities: %v", err)
+	}
+	log.Printf("Loaded %d abilities", len(game.AbilityTemplates))
+
 	// Create Discord session
 	dg, err := discordgo.New("Bot " + cfg.DiscordToken)
 	if err != nil {
@@ -52,6 +58,15 @@ func main() {
 	partyRepo := storage.NewPartyRepo(db, gopherRepo, trainerRepo)
 	battleRepo := storage.NewBattleRepo(db)
 	itemRepo := storage.NewItemRepo(db)
+	pvpRepo := storage.NewPvPRepo(db)
+	tradeRepo := storage.NewTradeRepo(db)
+	statsRepo := storage.NewStatsRepo(db)
+	gopherdexRepo := storage.NewGopherdexRepo(db)
+	achievementRepo := storage.NewAchievementRepo(db)
+	questRepo := storage.NewQuestRepo(db)
+	abilityRepo := storage.NewAbilityRepo(db)
+	replayRepo := storage.NewReplayRepo(db)
+	badgeRepo := storage.NewBadgeRepo(db)
 
 	// Initialize gopherkon generator (now uses gopherize.me artwork structure)
 	log.Println("Initializing sprite generator...")
@@ -72,11 +87,27 @@ func main() {
 		gopherRepo,
 		partyRepo,
 		battleRepo,
+		abilityRepo,
 		generator,
 		evolutionService,
 		assetsPath,
 	)
 
+	// Store abilities for gophers caught before abilities were persisted
+	if backfilled, err := gameService.BackfillAbilities(); err != nil {
+		log.Printf("Error backfilling abilities: %v", err)
+	} else if backfilled > 0 {
+		log.Printf("Stored abilities for %d existing gophers", backfilled)
+	}
+
+	// Load NPC trainers and wild areas; /wild, trainer battles and replays all need them
+	roster, err := game.LoadRoster(cfg.DataPath)
+	if err != nil {
+		log.Fatalf("Error loading trainer data: %v", err)
+	}
+	gameService.SetRoster(roster)
+	log.Printf("Loaded %d NPC trainers and %d wild areas", len(roster.Trainers), len(roster.Areas))
+
 	// Set event manager for evolution service
 	eventManager := gameService.GetEventManager()
 	evolutionService.SetEventManager(eventManager)
@@ -87,6 +118,16 @@ func main() {
 		log.Printf("Event announcements will be sent to channel: %s", cfg.EventAnnounceChannel)
 	}
 
+	// Initialize achievement service
+	achievementService := game.NewAchievementService(achievementRepo, trainerRepo)
+
+	// Initialize quest service
+	questService := game.NewQuestService(questRepo, trainerRepo)
+
+	// Initialize item service
+	itemService := game.NewItemService(trainerRepo, itemRepo)
+	itemService.SetEvolutionService(evolutionService)
+
 	// Initialize handlers
 	handlers := discord.NewHandlers(
 		gameService,
@@ -95,8 +136,23 @@ func main() {
 		partyRepo,
 		battleRepo,
 		itemRepo,
+		pvpRepo,
+		tradeRepo,
+		statsRepo,
+		gopherdexRepo,
+		replayRepo,
+		badgeRepo,
+		achievementService,
+		questService,
+		itemService,
 	)
 
+	// Play matchmade PvP battles in one channel if configured
+	if cfg.PvPChannel != "" {
+		handlers.SetPvPChannel(cfg.PvPChannel)
+		log.Printf("Matchmade PvP battles will be played in channel: %s", cfg.PvPChannel)
+	}
+
 	// Register event handlers
 	dg.AddHandler(func(s *discordgo.Session, r *discordgo.Ready) {
 		log.Printf("Bot is ready! Logged in as: %v#%v", s.State.User.Username, s.State.User.Discriminator)
@@ -147,6 +203,23 @@ func main() {
 		log.Println("Automatic event scheduling is disabled")
 	}
 
+	// Spawn raid bosses in the announcement channel
+	if cfg.AutoRaidInterval > 0 && cfg.EventAnnounceChannel != "" {
+		log.Printf("Automatic raids enabled (interval: %d hours)", cfg.AutoRaidInterval)
+		go startRaidScheduler(dg, handlers, cfg.EventAnnounceChannel, cfg.AutoRaidInterval)
+	} else {
+		log.Println("Automatic raids are disabled")
+	}
+
+	// Return escrow from trade offers nobody answered
+	go startTradeExpiryScheduler(tradeRepo)
+
+	// Remove unfinished quests from previous days and weeks
+	go startQuestCleanupScheduler(questRepo)
+
+	// Pair up trainers in the PvP queue as their rating windows widen
+	go startMatchmakingScheduler(dg, handlers)
+
 	log.Println("Bot is running. Press CTRL-C to exit.")
 
 	// Wait for interrupt signal
@@ -179,6 +252,57 @@ func startEventScheduler(s *discordgo.Session, eventManager *game.EventManager,
 	}
 }
 
+// startRaidScheduler runs in the background and spawns a raid boss at regular intervals
+func startRaidScheduler(s *discordgo.Session, handlers *discord.Handlers, channelID string, intervalHours int) {
+	ticker := time.NewTicker(time.Duration(intervalHours) * time.Hour)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if err := handlers.SpawnRaid(s, channelID, game.RaidDefaultLevel); err != nil {
+			log.Printf("Error spawning raid: %v", err)
+		} else {
+			log.Printf("Auto-spawned a raid boss in channel %s", channelID)
+		}
+	}
+}
+
+// startMatchmakingScheduler periodically matches queued PvP trainers and drops those who waited too long
+func startMatchmakingScheduler(s *discordgo.Session, handlers *discord.Handlers) {
+	ticker := time.NewTicker(10 * time.Second)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		handlers.RunMatchmaking(s)
+	}
+}
+
+// startTradeExpiryScheduler periodically expires stale trade offers and refunds their escrow
+func startTradeExpiryScheduler(tradeRepo *storage.TradeRepo) {
+	ticker := time.NewTicker(10 * time.Minute)
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		expired, err := tradeRepo.ExpireTrades()
+		if err != nil {
+			log.Printf("Error expiring trades: %v", err)
+		} else if expired > 0 {
+			log.Printf("Expired %d stale trade offers", expired)
+		}
+	}
+}
+
+// startQuestCleanupScheduler periodically deletes expired quests that were never completed
+func startQuestCleanupScheduler(questRepo *storage.QuestRepo) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+
+	for ; ; <-ticker.C {
+		if err := questRepo.CleanupExpired(); err != nil {
+			log.Printf("Error cleaning up expired quests: %v", err)
+		}
+	}
+}
+
 // startAutoEvent starts a random event and announces it in Discord
 func startAutoEvent(s *discordgo.Session, eventManager *game.EventManager, duration time.Duration) {
 	// Check if there are already too many active events (max 2 at once)
@@ -0,0 +1,398 @@
+// Command sim runs AI-vs-AI battles through the battle engine, without Discord or sprites,
+// and reports win rates per archetype and type matchup, battle length and ability usage.
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gophermon-bot/internal/game"
+)
+
+var allArchetypes = []game.Archetype{
+	game.ArchetypeHacker,
+	game.ArchetypeTank,
+	game.ArchetypeSpeedy,
+	game.ArchetypeSupport,
+	game.ArchetypeMage,
+}
+
+type simConfig struct {
+	Archetypes []game.Archetype `json:"archetypes"`
+	Rarity     string           `json:"rarity"`
+	Level      int              `json:"level"`
+	Stage      int              `json:"evolution_stage"`
+	DualType   bool             `json:"dual_type"`
+	Battles    int              `json:"battles_per_matchup"`
+	Strategy   string           `json:"strategy"`
+	Seed       int64            `json:"seed"`
+}
+
+// record is a win/loss tally
+type record struct {
+	Battles int     `json:"battles"`
+	Wins    int     `json:"wins"`
+	Losses  int     `json:"losses"`
+	Draws   int     `json:"draws"`
+	WinRate float64 `json:"win_rate"`
+}
+
+func (r *record) add(won, draw bool) {
+	r.Battles++
+	switch {
+	case draw:
+		r.Draws++
+	case won:
+		r.Wins++
+	default:
+		r.Losses++
+	}
+}
+
+type archetypeRow struct {
+	Archetype string `json:"archetype"`
+	record
+}
+
+type matchupRow struct {
+	Type     string `json:"type"`
+	Opponent string `json:"opponent"`
+	record
+}
+
+type abilityRow struct {
+	Ability     string  `json:"ability"`
+	Uses        int     `json:"uses"`
+	Damage      int     `json:"damage"`
+	AvgDamage   float64 `json:"avg_damage"`
+	UsageShare  float64 `json:"usage_share"`
+	DamageShare float64 `json:"damage_share"`
+}
+
+type report struct {
+	Config     simConfig       `json:"config"`
+	Battles    int             `json:"battles"`
+	Draws      int             `json:"draws"`
+	AvgTurns   float64         `json:"avg_turns"`
+	Archetypes []*archetypeRow `json:"archetypes"`
+	Matchups   []*matchupRow   `json:"matchups"`
+	Abilities  []*abilityRow   `json:"abilities"`
+}
+
+func main() {
+	archetypes := flag.String("archetypes", "", "Comma-separated archetypes to simulate (default: all)")
+	rarity := flag.String("rarity", "COMMON", "Rarity of every gopher: COMMON, UNCOMMON, RARE, EPIC or LEGENDARY")
+	level := flag.Int("level", 10, "Level of every gopher")
+	stage := flag.Int("stage", 0, "Evolution stage of every gopher (0-2), which unlocks more abilities")
+	dualType := flag.Bool("dual", false, "Give every gopher a random secondary type")
+	battles := flag.Int("battles", 1000, "Battles per archetype matchup")
+	strategy := flag.String("strategy", "heuristic", "AI strategy for both sides: random, greedy or heuristic")
+	seed := flag.Int64("seed", 1, "Random seed; the same seed and options give the same results")
+	format := flag.String("format", "csv", "Output format: csv or json")
+	out := flag.String("out", "", "File to write the report to (default: stdout)")
+	dataPath := flag.String("data", "./data", "Directory with abilities.json")
+	flag.Parse()
+
+	if err := game.LoadAbilities(*dataPath); err != nil {
+		log.Fatal(err)
+	}
+
+	cfg := simConfig{
+		Rarity:   strings.ToUpper(*rarity),
+		Level:    *level,
+		Stage:    *stage,
+		DualType: *dualType,
+		Battles:  *battles,
+		Strategy: *strategy,
+		Seed:     *seed,
+	}
+	var err error
+	if cfg.Archetypes, err = parseArchetypes(*archetypes); err != nil {
+		log.Fatal(err)
+	}
+	if err := cfg.validate(); err != nil {
+		log.Fatal(err)
+	}
+	if *format != "csv" && *format != "json" {
+		log.Fatalf("unknown format %q", *format)
+	}
+
+	start := time.Now()
+	rep, err := simulate(cfg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Simulated %d battles in %v", rep.Battles, time.Since(start).Round(time.Millisecond))
+
+	var w io.Writer = os.Stdout
+	if *out != "" {
+		file, err := os.Create(*out)
+		if err != nil {
+			log.Fatalf("failed to create output file: %v", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if *format == "json" {
+		err = writeJSON(w, rep)
+	} else {
+		err = writeCSV(w, rep)
+	}
+	if err != nil {
+		log.Fatalf("failed to write report: %v", err)
+	}
+}
+
+func parseArchetypes(list string) ([]game.Archetype, error) {
+	if list == "" {
+		return allArchetypes, nil
+	}
+
+	archetypes := []game.Archetype{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		found := false
+		for _, archetype := range allArchetypes {
+			if strings.EqualFold(name, string(archetype)) {
+				archetypes = append(archetypes, archetype)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown archetype %q", name)
+		}
+	}
+	return archetypes, nil
+}
+
+func (cfg simConfig) validate() error {
+	switch game.Rarity(cfg.Rarity) {
+	case game.RarityCommon, game.RarityUncommon, game.RarityRare, game.RarityEpic, game.RarityLegendary:
+	default:
+		return fmt.Errorf("unknown rarity %q", cfg.Rarity)
+	}
+	if cfg.Level < 1 {
+		return fmt.Errorf("level must be at least 1")
+	}
+	if cfg.Stage < 0 || cfg.Stage > 2 {
+		return fmt.Errorf("evolution stage must be 0, 1 or 2")
+	}
+	if cfg.Battles < 1 {
+		return fmt.Errorf("battles must be at least 1")
+	}
+	if game.StrategyNamed(cfg.Strategy) == nil {
+		return fmt.Errorf("unknown strategy %q", cfg.Strategy)
+	}
+	return nil
+}
+
+// simulate battles every pair of archetypes, including each against itself. Sides alternate
+// between battles so neither archetype always fights as the player.
+func simulate(cfg simConfig) (*report, error) {
+	strategy := game.StrategyNamed(cfg.Strategy)
+	rng := game.NewRNG(cfg.Seed)
+
+	archetypes := make(map[string]*record)
+	matchups := make(map[[2]string]*record)
+	abilities := make(map[string]*abilityRow)
+	rep := &report{Config: cfg}
+	turns := 0
+
+	for a, archetypeA := range cfg.Archetypes {
+		for _, archetypeB := range cfg.Archetypes[a:] {
+			for n := 0; n < cfg.Battles; n++ {
+				gopherA := game.NewSimGopher("a", cfg.spec(archetypeA), rng)
+				gopherB := game.NewSimGopher("b", cfg.spec(archetypeB), rng)
+
+				player, enemy, sideA := gopherA, gopherB, game.SimSidePlayer
+				if n%2 == 1 {
+					player, enemy, sideA = gopherB, gopherA, game.SimSideEnemy
+				}
+				result, err := game.SimulateBattle(player, enemy, strategy, strategy, rng.Int63())
+				if err != nil {
+					return nil, err
+				}
+
+				rep.Battles++
+				turns += result.Turns
+				draw := result.Winner == ""
+				if draw {
+					rep.Draws++
+				}
+				wonA := result.Winner == sideA
+
+				if archetypeA != archetypeB {
+					tally(archetypes, string(archetypeA)).add(wonA, draw)
+					tally(archetypes, string(archetypeB)).add(!wonA, draw)
+				}
+				typeA, typeB := typeName(gopherA), typeName(gopherB)
+				tallyMatchup(matchups, typeA, typeB).add(wonA, draw)
+				if typeA != typeB {
+					tallyMatchup(matchups, typeB, typeA).add(!wonA, draw)
+				}
+
+				for _, event := range result.Events {
+					if event.Type != game.BattleEventAbility {
+						continue
+					}
+					row := abilities[event.Ability]
+					if row == nil {
+						row = &abilityRow{Ability: event.Ability}
+						abilities[event.Ability] = row
+					}
+					row.Uses++
+					row.Damage += event.Damage
+				}
+			}
+		}
+	}
+
+	rep.AvgTurns = ratio(turns, rep.Battles)
+	rep.Archetypes = archetypeRows(archetypes)
+	rep.Matchups = matchupRows(matchups)
+	rep.Abilities = abilityRows(abilities)
+	return rep, nil
+}
+
+func (cfg simConfig) spec(archetype game.Archetype) game.SimGopherSpec {
+	return game.SimGopherSpec{
+		Archetype:      archetype,
+		Rarity:         cfg.Rarity,
+		Level:          cfg.Level,
+		EvolutionStage: cfg.Stage,
+		DualType:       cfg.DualType,
+	}
+}
+
+// typeName is a gopher's type, or both types joined with a slash for dual-type gophers
+func typeName(gopher *game.Gopher) string {
+	if gopher.SecondaryType != "" {
+		return fmt.Sprintf("%s/%s", gopher.PrimaryType, gopher.SecondaryType)
+	}
+	return string(gopher.PrimaryType)
+}
+
+func tally(records map[string]*record, key string) *record {
+	if records[key] == nil {
+		records[key] = &record{}
+	}
+	return records[key]
+}
+
+func tallyMatchup(records map[[2]string]*record, typeName, opponent string) *record {
+	key := [2]string{typeName, opponent}
+	if records[key] == nil {
+		records[key] = &record{}
+	}
+	return records[key]
+}
+
+func ratio(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) / float64(total)
+}
+
+// archetypeRows lists archetypes from the highest win rate down
+func archetypeRows(records map[string]*record) []*archetypeRow {
+	rows := []*archetypeRow{}
+	for archetype, r := range records {
+		r.WinRate = ratio(r.Wins, r.Battles)
+		rows = append(rows, &archetypeRow{Archetype: archetype, record: *r})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].WinRate != rows[j].WinRate {
+			return rows[i].WinRate > rows[j].WinRate
+		}
+		return rows[i].Archetype < rows[j].Archetype
+	})
+	return rows
+}
+
+// matchupRows lists matchups by type, then opponent
+func matchupRows(records map[[2]string]*record) []*matchupRow {
+	rows := []*matchupRow{}
+	for key, r := range records {
+		r.WinRate = ratio(r.Wins, r.Battles)
+		rows = append(rows, &matchupRow{Type: key[0], Opponent: key[1], record: *r})
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Type != rows[j].Type {
+			return rows[i].Type < rows[j].Type
+		}
+		return rows[i].Opponent < rows[j].Opponent
+	})
+	return rows
+}
+
+// abilityRows lists abilities from the largest share of damage down
+func abilityRows(records map[string]*abilityRow) []*abilityRow {
+	totalUses, totalDamage := 0, 0
+	for _, row := range records {
+		totalUses += row.Uses
+		totalDamage += row.Damage
+	}
+
+	rows := []*abilityRow{}
+	for _, row := range records {
+		row.AvgDamage = ratio(row.Damage, row.Uses)
+		row.UsageShare = ratio(row.Uses, totalUses)
+		row.DamageShare = ratio(row.Damage, totalDamage)
+		rows = append(rows, row)
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Damage != rows[j].Damage {
+			return rows[i].Damage > rows[j].Damage
+		}
+		return rows[i].Ability < rows[j].Ability
+	})
+	return rows
+}
+
+func writeJSON(w io.Writer, rep *report) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rep)
+}
+
+// writeCSV writes the report as one CSV table per section, separated by blank lines
+func writeCSV(w io.Writer, rep *report) error {
+	cw := csv.NewWriter(w)
+	rate := func(f float64) string { return strconv.FormatFloat(f, 'f', 4, 64) }
+	itoa := strconv.Itoa
+
+	cw.Write([]string{"battles", "draws", "avg_turns"})
+	cw.Write([]string{itoa(rep.Battles), itoa(rep.Draws), rate(rep.AvgTurns)})
+	cw.Write(nil)
+
+	cw.Write([]string{"archetype", "battles", "wins", "losses", "draws", "win_rate"})
+	for _, row := range rep.Archetypes {
+		cw.Write([]string{row.Archetype, itoa(row.Battles), itoa(row.Wins), itoa(row.Losses), itoa(row.Draws), rate(row.WinRate)})
+	}
+	cw.Write(nil)
+
+	cw.Write([]string{"type", "opponent", "battles", "wins", "losses", "draws", "win_rate"})
+	for _, row := range rep.Matchups {
+		cw.Write([]string{row.Type, row.Opponent, itoa(row.Battles), itoa(row.Wins), itoa(row.Losses), itoa(row.Draws), rate(row.WinRate)})
+	}
+	cw.Write(nil)
+
+	cw.Write([]string{"ability", "uses", "damage", "avg_damage", "usage_share", "damage_share"})
+	for _, row := range rep.Abilities {
+		cw.Write([]string{row.Ability, itoa(row.Uses), itoa(row.Damage), rate(row.AvgDamage), rate(row.UsageShare), rate(row.DamageShare)})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
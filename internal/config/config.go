@@ -10,11 +10,14 @@ import (
 type Config struct {
 	DiscordToken        string
 	DBPath              string
+	DataPath            string // Directory with the ability, NPC trainer and wild area data files
 	BotPrefix           string
 	EventAnnounceChannel string // Discord channel ID for event announcements
 	AutoEventsEnabled   bool    // Enable automatic event scheduling
 	AutoEventInterval   int     // Hours between auto events (default: 48)
 	AutoEventDuration   int     // Hours each auto event lasts (default: 24)
+	AutoRaidInterval    int     // Hours between auto raid bosses (default: 0, disabled)
+	PvPChannel          string  // Discord channel ID where matchmade PvP battles are played
 }
 
 func Load() (*Config, error) {
@@ -38,14 +41,22 @@ func Load() (*Config, error) {
 		autoEventDuration = 24
 	}
 
+	autoRaidInterval := parseInt(getEnv("AUTO_RAID_INTERVAL", "0")) // Raids are opt-in
+	if autoRaidInterval < 0 {
+		autoRaidInterval = 0
+	}
+
 	return &Config{
 		DiscordToken:         getEnv("DISCORD_TOKEN", ""),
 		DBPath:              getEnv("DB_PATH", "./gophermon.db"),
+		DataPath:            getEnv("DATA_PATH", "./data"),
 		BotPrefix:           getEnv("BOT_PREFIX", "!"),
 		EventAnnounceChannel: getEnv("EVENT_ANNOUNCE_CHANNEL", ""),
 		AutoEventsEnabled:   autoEventsEnabled,
 		AutoEventInterval:   autoEventInterval,
 		AutoEventDuration:   autoEventDuration,
+		AutoRaidInterval:    autoRaidInterval,
+		PvPChannel:          getEnv("PVP_CHANNEL", ""),
 	}, nil
 }
 
@@ -9,6 +9,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"gophermon-bot/internal/game"
@@ -18,15 +19,36 @@ import (
 	"github.com/google/uuid"
 )
 
+
 type Handlers struct {
-	gameService     *game.Service
-	trainerRepo     *storage.TrainerRepo
-	gopherRepo      *storage.GopherRepo
-	partyRepo       *storage.PartyRepo
-	battleRepo      *storage.BattleRepo
-	itemRepo        *storage.ItemRepo
-	battles         map[string]*game.BattleState // In-memory battle cache
-	starterSessions map[string][]string          // Session ID -> starter gopher IDs
+	gameService        *game.Service
+	trainerRepo        *storage.TrainerRepo
+	gopherRepo         *storage.GopherRepo
+	partyRepo          *storage.PartyRepo
+	battleRepo         *storage.BattleRepo
+	itemRepo           *storage.ItemRepo
+	pvpRepo            *storage.PvPRepo
+	tradeRepo          *storage.TradeRepo
+	statsRepo          *storage.StatsRepo
+	gopherdexRepo      *storage.GopherdexRepo
+	replayRepo         *storage.ReplayRepo
+	badgeRepo          *storage.BadgeRepo
+	achievementService *game.AchievementService
+	questService       *game.QuestService
+	itemService        *game.ItemService
+	battles            map[string]*game.BattleState       // In-memory battle cache
+	battlesMu          sync.Mutex                         // Guards battles
+	starterSessions    map[string][]string                // Session ID -> starter gopher IDs
+	pvpBattles         map[string]*game.PvPBattleState    // Pending challenges and active PvP battles
+	pvpMu              sync.Mutex                         // Guards pvpBattles
+	raids              map[string]*game.RaidBattleState   // Open raid lobbies and active raids
+	raidMu             sync.Mutex                         // Guards raids
+	doubles            map[string]*game.DoubleBattleState // Active double battles
+	doubleMu           sync.Mutex                         // Guards doubles
+	matchmaking        *game.MatchmakingQueue             // Trainers waiting for a ranked PvP match
+	pvpChannelID       string                             // Channel where matchmade PvP battles are played
+	guildMembers       map[string]*guildMemberCache       // Guild ID -> trainers in that guild
+	guildMembersMu     sync.Mutex                         // Guards guildMembers
 }
 
 func NewHandlers(
@@ -36,16 +58,39 @@ func NewHandlers(
 	partyRepo *storage.PartyRepo,
 	battleRepo *storage.BattleRepo,
 	itemRepo *storage.ItemRepo,
+	pvpRepo *storage.PvPRepo,
+	tradeRepo *storage.TradeRepo,
+	statsRepo *storage.StatsRepo,
+	gopherdexRepo *storage.GopherdexRepo,
+	replayRepo *storage.ReplayRepo,
+	badgeRepo *storage.BadgeRepo,
+	achievementService *game.AchievementService,
+	questService *game.QuestService,
+	itemService *game.ItemService,
 ) *Handlers {
 	return &Handlers{
-		gameService:     gameService,
-		trainerRepo:     trainerRepo,
-		gopherRepo:      gopherRepo,
-		partyRepo:       partyRepo,
-		battleRepo:      battleRepo,
-		itemRepo:        itemRepo,
-		battles:         make(map[string]*game.BattleState),
-		starterSessions: make(map[string][]string),
+		gameService:        gameService,
+		trainerRepo:        trainerRepo,
+		gopherRepo:         gopherRepo,
+		partyRepo:          partyRepo,
+		battleRepo:         battleRepo,
+		itemRepo:           itemRepo,
+		pvpRepo:            pvpRepo,
+		tradeRepo:          tradeRepo,
+		statsRepo:          statsRepo,
+		gopherdexRepo:      gopherdexRepo,
+		replayRepo:         replayRepo,
+		badgeRepo:          badgeRepo,
+		achievementService: achievementService,
+		questService:       questService,
+		itemService:        itemService,
+		battles:            make(map[string]*game.BattleState),
+		starterSessions:    make(map[string][]string),
+		pvpBattles:         make(map[string]*game.PvPBattleState),
+		raids:              make(map[string]*game.RaidBattleState),
+		doubles:            make(map[string]*game.DoubleBattleState),
+		matchmaking:        game.NewMatchmakingQueue(),
+		guildMembers:       make(map[string]*guildMemberCache),
 	}
 }
 
@@ -82,12 +127,18 @@ func (h *Handlers) handleCommand(s *discordgo.Session, i *discordgo.InteractionC
 		h.handleEvents(s, i)
 	case "shop":
 		h.handleShop(s, i)
+	case "item":
+		h.handleItem(s, i)
 	case "achievements":
 		h.handleAchievements(s, i)
 	case "quests":
 		h.handleQuests(s, i)
 	case "challenge":
 		h.handleChallenge(s, i)
+	case "pvp":
+		h.handlePvP(s, i)
+	case "raid":
+		h.handleRaid(s, i)
 	case "stats":
 		h.handleStats(s, i)
 	case "leaderboard":
@@ -96,6 +147,12 @@ func (h *Handlers) handleCommand(s *discordgo.Session, i *discordgo.InteractionC
 		h.handleGopherdex(s, i)
 	case "trade":
 		h.handleTrade(s, i)
+	case "battle":
+		h.handleBattle(s, i)
+	case "trainers":
+		h.handleTrainers(s, i)
+	case "badges":
+		h.handleBadges(s, i)
 	default:
 		respondEphemeral(s, i, "Unknown command")
 	}
@@ -109,9 +166,27 @@ func (h *Handlers) handleComponent(s *discordgo.Session, i *discordgo.Interactio
 			h.handleBattleAbility(s, i)
 		} else if strings.HasPrefix(data.CustomID, "battle_swap_") {
 			h.handleBattleSwap(s, i)
+		} else if strings.HasPrefix(data.CustomID, "battle_bag_") || strings.HasPrefix(data.CustomID, "battle_item_") {
+			h.handleBattleItem(s, i)
 		} else {
 			h.handleBattleAction(s, i)
 		}
+	} else if strings.HasPrefix(data.CustomID, "pvp_") {
+		h.handlePvPComponent(s, i)
+	} else if strings.HasPrefix(data.CustomID, "raid_") {
+		h.handleRaidComponent(s, i)
+	} else if strings.HasPrefix(data.CustomID, "double_") {
+		h.handleDoubleComponent(s, i)
+	} else if strings.HasPrefix(data.CustomID, "trade_") {
+		h.handleTradeComponent(s, i)
+	} else if strings.HasPrefix(data.CustomID, "gopherdex_") {
+		h.handleGopherdexPage(s, i)
+	} else if strings.HasPrefix(data.CustomID, "leaderboard_") {
+		h.handleLeaderboardPage(s, i)
+	} else if strings.HasPrefix(data.CustomID, "replay_") {
+		h.handleReplayPage(s, i)
+	} else if strings.HasPrefix(data.CustomID, "move_") {
+		h.handleMoveComponent(s, i)
 	} else if strings.HasPrefix(data.CustomID, "choose_") {
 		h.handleChooseStarter(s, i)
 	} else {
@@ -319,6 +394,10 @@ func (h *Handlers) handleChooseStarter(s *discordgo.Session, i *discordgo.Intera
 		respondEphemeral(s, i, fmt.Sprintf("Error assigning gopher to trainer: %v", err))
 		return
 	}
+	if err := h.gameService.StoreStartingAbilities(chosenGopher); err != nil {
+		log.Printf("Error saving starter abilities: %v", err)
+	}
+	h.markGopherdexOwned(trainer.ID, chosenGopher)
 
 	// Update trainer's party slot count
 	partySize, err := h.partyRepo.GetPartySize(trainer.ID)
@@ -668,6 +747,7 @@ func (h *Handlers) handlePC(s *discordgo.Session, i *discordgo.InteractionCreate
 }
 
 func (h *Handlers) handleWild(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
 	discordID := i.Member.User.ID
 
 	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
@@ -676,37 +756,50 @@ func (h *Handlers) handleWild(s *discordgo.Session, i *discordgo.InteractionCrea
 		return
 	}
 
-	// Get player's first party gopher
-	party, err := h.gopherRepo.GetParty(trainer.ID)
-	if err != nil || len(party) == 0 {
-		respondEphemeral(s, i, "Your party is empty! Use /start to get a starter gopher.")
+	// Pick the area to search; higher-level areas need a gym badge
+	roster := h.gameService.Roster()
+	area := roster.DefaultArea()
+	double := false
+	for _, opt := range data.Options {
+		switch opt.Name {
+		case "area":
+			areaID := strings.ToLower(strings.TrimSpace(opt.StringValue()))
+			area = roster.Area(areaID)
+			if area == nil {
+				respondEphemeral(s, i, fmt.Sprintf("Unknown area `%s`. Use /badges to see the wild areas.", areaID))
+				return
+			}
+		case "double":
+			double = opt.BoolValue()
+		}
+	}
+	if area != nil && !area.Unlocked(h.earnedBadges(trainer.ID)) {
+		message := fmt.Sprintf("🔒 %s needs the %s.", area.Name, roster.BadgeName(area.RequiredBadge))
+		if leader := roster.BadgeAwarder(area.RequiredBadge); leader != nil {
+			message += fmt.Sprintf(" Beat %s to earn it!", leader.DisplayName())
+		}
+		respondEphemeral(s, i, message)
 		return
 	}
 
-	// Check if all party members are dead - trigger blackout if so
-	blackedOut, blackoutMsg := h.gameService.CheckAndHandleBlackout(trainer.ID)
-	if blackedOut {
-		respondEphemeral(s, i, blackoutMsg)
+	// A gopher can only fight in one battle at a time, or the battles overwrite each other's XP
+	if reason := h.battleBusyReason(trainer.ID); reason != "" {
+		respondEphemeral(s, i, reason)
 		return
 	}
 
-	// Find first alive gopher in party
-	var playerGopherStorage *storage.Gopher
-	for _, gopher := range party {
-		if gopher.CurrentHP > 0 {
-			playerGopherStorage = gopher
-			break
-		}
+	if double {
+		h.handleWildDouble(s, i, trainer, area)
+		return
 	}
 
-	// If no alive gopher found (shouldn't happen after blackout check, but safety check)
-	if playerGopherStorage == nil {
-		respondEphemeral(s, i, "All your gophers are fainted! You need to rest.")
+	playerGopher, gameParty, ok := h.loadBattleParty(s, i, trainer.ID)
+	if !ok {
 		return
 	}
 
 	// Generate wild gopher
-	wildGopherStorage, err := h.gameService.GenerateWildGopher()
+	wildGopherStorage, err := h.gameService.GenerateWildGopher(area)
 	if err != nil {
 		respondEphemeral(s, i, fmt.Sprintf("Error generating wild gopher: %v", err))
 		return
@@ -719,24 +812,83 @@ func (h *Handlers) handleWild(s *discordgo.Session, i *discordgo.InteractionCrea
 		return
 	}
 
-	// Convert to game gophers
-	playerGopher, err := h.gameService.StorageGopherToGameGopher(playerGopherStorage)
+	// Log the encounter in the trainer's Gopherdex
+	if err := h.gopherdexRepo.RecordEncounter(trainer.ID, wildGopherStorage.Name, wildGopherStorage.SpeciesArchetype, wildGopherStorage.Rarity); err != nil {
+		log.Printf("Error recording gopherdex encounter: %v", err)
+	}
+
+	enemyGopher, err := h.gameService.StorageGopherToGameGopher(wildGopherStorage)
 	if err != nil {
 		respondEphemeral(s, i, fmt.Sprintf("Error: %v", err))
 		return
 	}
 
-	enemyGopher, err := h.gameService.StorageGopherToGameGopher(wildGopherStorage)
+	// Create battle state with party and event manager
+	battleState := game.NewBattleState(trainer.ID, i.ChannelID, playerGopher, enemyGopher, gameParty, h.gameService.GetEventManager(), h.gameService.NewBattleSeed())
+	battleState.ID = uuid.New().String()
+
+	// An XP Booster activated with /item use applies to this battle
+	battleState.XPMultiplier = h.itemService.ConsumeXPBoost(trainer.ID)
+	if battleState.XPMultiplier > 1.0 {
+		battleState.Log = append(battleState.Log, fmt.Sprintf("⚡ XP Booster active: %.1fx XP this battle!", battleState.XPMultiplier))
+	}
+
+	if err := h.startBattle(s, i, battleState); err != nil {
+		h.refundXPBoost(trainer.ID, battleState.XPMultiplier)
+		respondEphemeral(s, i, fmt.Sprintf("Error sending battle message: %v", err))
+		return
+	}
+
+	if area != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Wild gopher encountered in %s!", area.Name))
+		return
+	}
+	respondEphemeral(s, i, "Wild gopher encountered!")
+}
+
+// loadBattleParty returns the first party gopher that can battle and the whole party. If the
+// trainer can't battle it responds with the reason and returns false.
+func (h *Handlers) loadBattleParty(s *discordgo.Session, i *discordgo.InteractionCreate, trainerID string) (*game.Gopher, []*game.Gopher, bool) {
+	// Get player's first party gopher
+	party, err := h.battleParty(trainerID)
+	if err != nil || len(party) == 0 {
+		respondEphemeral(s, i, noBattlePartyMessage)
+		return nil, nil, false
+	}
+
+	// Check if all party members are dead - trigger blackout if so
+	blackedOut, blackoutMsg := h.gameService.CheckAndHandleBlackout(trainerID)
+	if blackedOut {
+		respondEphemeral(s, i, blackoutMsg)
+		return nil, nil, false
+	}
+
+	// Find first alive gopher in party
+	var playerGopherStorage *storage.Gopher
+	for _, gopher := range party {
+		if gopher.CurrentHP > 0 {
+			playerGopherStorage = gopher
+			break
+		}
+	}
+
+	// If no alive gopher found (shouldn't happen after blackout check, but safety check)
+	if playerGopherStorage == nil {
+		respondEphemeral(s, i, "All your gophers are fainted! You need to rest.")
+		return nil, nil, false
+	}
+
+	playerGopher, err := h.gameService.StorageGopherToGameGopher(playerGopherStorage)
 	if err != nil {
 		respondEphemeral(s, i, fmt.Sprintf("Error: %v", err))
-		return
+		return nil, nil, false
 	}
 
-	// Get full party for battle (for swapping) - reuse party variable from earlier
-	partyStorage, err := h.gopherRepo.GetParty(trainer.ID)
+	// Get full party for battle (for swapping)
+	partyStorage, err := h.battleParty(trainerID)
 	if err != nil {
 		respondEphemeral(s, i, fmt.Sprintf("Error getting party: %v", err))
-		return
+		return nil, nil, false
 	}
 
 	// Convert party to game gophers
@@ -745,15 +897,16 @@ func (h *Handlers) handleWild(s *discordgo.Session, i *discordgo.InteractionCrea
 		gameGopher, err := h.gameService.StorageGopherToGameGopher(gopherStorage)
 		if err != nil {
 			respondEphemeral(s, i, fmt.Sprintf("Error converting gopher: %v", err))
-			return
+			return nil, nil, false
 		}
 		gameParty[idx] = gameGopher
 	}
 
-	// Create battle state with party and event manager
-	battleState := game.NewBattleState(trainer.ID, i.ChannelID, playerGopher, enemyGopher, gameParty, h.gameService.GetEventManager())
-	battleState.ID = uuid.New().String()
+	return playerGopher, gameParty, true
+}
 
+// startBattle posts a new battle with its battle card, then saves it and keeps it in memory
+func (h *Handlers) startBattle(s *discordgo.Session, i *discordgo.InteractionCreate, battleState *game.BattleState) error {
 	// Create battle embed
 	embed := h.createBattleEmbed(battleState)
 
@@ -763,9 +916,11 @@ func (h *Handlers) handleWild(s *discordgo.Session, i *discordgo.InteractionCrea
 	// Generate battle card with both gophers (enemy on top, player on bottom) - returns base64
 	var battleCardFile *discordgo.File
 	var battleImageURL string
+	playerGopherStorage := h.gameGopherToStorage(battleState.PlayerGopher)
+	enemyGopherStorage := h.gameGopherToStorage(battleState.EnemyGopher)
 	if (playerGopherStorage.SpriteData != "" || playerGopherStorage.SpritePath != "") &&
-		(wildGopherStorage.SpriteData != "" || wildGopherStorage.SpritePath != "") {
-		cardBase64, err := h.gameService.GenerateBattleCard(wildGopherStorage, playerGopherStorage)
+		(enemyGopherStorage.SpriteData != "" || enemyGopherStorage.SpritePath != "") {
+		cardBase64, err := h.gameService.GenerateBattleCard(enemyGopherStorage, playerGopherStorage)
 		if err != nil {
 			log.Printf("Error generating battle card: %v", err)
 		} else if cardBase64 != "" {
@@ -799,8 +954,7 @@ func (h *Handlers) handleWild(s *discordgo.Session, i *discordgo.InteractionCrea
 
 	msg, err := s.ChannelMessageSendComplex(i.ChannelID, msgSend)
 	if err != nil {
-		respondEphemeral(s, i, fmt.Sprintf("Error sending battle message: %v", err))
-		return
+		return err
 	}
 
 	battleState.MessageID = msg.ID
@@ -811,11 +965,21 @@ func (h *Handlers) handleWild(s *discordgo.Session, i *discordgo.InteractionCrea
 		ChannelID:      battleState.ChannelID,
 		MessageID:      battleState.MessageID,
 		TrainerID:      battleState.TrainerID,
-		OpponentType:   "WILD",
-		GopherIDPlayer: &playerGopher.ID,
-		GopherIDEnemy:  &enemyGopher.ID,
+		OpponentType:   battleState.OpponentType,
+		GopherIDPlayer: &battleState.PlayerGopher.ID,
 		TurnOwner:      battleState.TurnOwner,
 		State:          battleState.State,
+		XPMultiplier:   battleState.XPMultiplier,
+		Energy:         encodeEnergy(battleState),
+		Seed:           battleState.Seed,
+		RNGDraws:       battleState.Rand().Draws(),
+		Snapshot:       encodeSnapshot(battleState),
+	}
+	// Wild gophers are stored; an NPC trainer's gophers only exist in the battle snapshot
+	if battleState.Opponent != nil {
+		battle.NPCID = &battleState.Opponent.ID
+	} else {
+		battle.GopherIDEnemy = &battleState.EnemyGopher.ID
 	}
 	_, err = h.battleRepo.Create(battle)
 	if err != nil {
@@ -823,9 +987,10 @@ func (h *Handlers) handleWild(s *discordgo.Session, i *discordgo.InteractionCrea
 	}
 
 	// Store in memory
+	h.battlesMu.Lock()
 	h.battles[battleState.ID] = battleState
-
-	respondEphemeral(s, i, "Wild gopher encountered!")
+	h.battlesMu.Unlock()
+	return nil
 }
 
 func (h *Handlers) handleGopher(s *discordgo.Session, i *discordgo.InteractionCreate) {
@@ -909,6 +1074,12 @@ func (h *Handlers) handleGopher(s *discordgo.Session, i *discordgo.InteractionCr
 			return
 		}
 
+		// Gophers in a pending trade are locked in escrow
+		if inTrade, err := h.tradeRepo.IsGopherInPendingTrade(gopherID); err != nil || inTrade {
+			respondEphemeral(s, i, "This gopher is part of a pending trade! Cancel the trade first.")
+			return
+		}
+
 		// Calculate currency reward
 		reward := gopher.Level * 10
 		switch gopher.Rarity {
@@ -927,6 +1098,14 @@ func (h *Handlers) handleGopher(s *discordgo.Session, i *discordgo.InteractionCr
 			respondEphemeral(s, i, fmt.Sprintf("Error releasing gopher: %v", err))
 			return
 		}
+		h.refreshGopherdexOwned(trainer.ID, gopher)
+
+		// The held item goes back in the bag
+		if gopher.HeldItem != "" {
+			if err := h.itemRepo.AddItem(trainer.ID, gopher.HeldItem, 1); err != nil {
+				log.Printf("Error returning held item after release: %v", err)
+			}
+		}
 
 		// Add currency reward
 		if err := h.trainerRepo.AddCurrency(trainer.ID, reward); err != nil {
@@ -987,6 +1166,11 @@ func (h *Handlers) handleGopher(s *discordgo.Session, i *discordgo.InteractionCr
 					Value:  fmt.Sprintf("**Type:** %s\n**Rarity:** %s\n**Evolution Stage:** %d", gopher.SpeciesArchetype, gopher.Rarity, gopher.EvolutionStage),
 					Inline: true,
 				},
+				{
+					Name:   "Held Item",
+					Value:  heldItemLabel(gopher.HeldItem),
+					Inline: true,
+				},
 			},
 		}
 
@@ -1004,6 +1188,15 @@ func (h *Handlers) handleGopher(s *discordgo.Session, i *discordgo.InteractionCr
 		}
 
 		respondEmbed(s, i, embed, true)
+
+	case "moves":
+		h.handleGopherMoves(s, i, trainer.ID, subCommand.Options[0].StringValue())
+
+	case "hold":
+		h.handleGopherHold(s, i, trainer.ID, subCommand.Options[0].StringValue(), subCommand.Options[1].StringValue())
+
+	case "take":
+		h.handleGopherTake(s, i, trainer.ID, subCommand.Options[0].StringValue())
 	}
 }
 
@@ -1321,10 +1514,16 @@ func (h *Handlers) handleBattleAction(s *discordgo.Session, i *discordgo.Interac
 		return
 	}
 
-	if action == "fight" {
-		// Show ability buttons instead
+	if action == "bag" {
+		// Bag action shows usable items
+		h.showBagMenu(s, i, battleState)
+		return
+	}
+
+	if action == "fight" || action == "back" {
+		// Show ability buttons instead, or return to the main actions
 		embed := h.createBattleEmbed(battleState)
-		components := h.createBattleButtons(battleState, true)
+		components := h.createBattleButtons(battleState, action == "fight")
 		h.editBattleMessage(s, i, "", embed, components)
 		return
 	}
@@ -1337,86 +1536,7 @@ func (h *Handlers) handleBattleAction(s *discordgo.Session, i *discordgo.Interac
 		return
 	}
 
-	// Update battle state in DB
-	battle, _ := h.battleRepo.GetByID(battleState.ID)
-	if battle != nil {
-		battle.State = battleState.State
-		battle.TurnOwner = battleState.TurnOwner
-		h.battleRepo.Update(battle)
-	}
-
-	// Check for evolution after level up - check all participating gophers
-	evolutionMessages := []string{}
-	if strings.Contains(strings.Join(messages, " "), "leveled up") {
-		// Check evolution for all participating gophers that may have leveled up
-		for _, gopher := range battleState.ParticipatingGophers {
-			// Check if this gopher is at an evolution threshold
-			shouldCheckEvolution := (gopher.Level >= 16 && gopher.EvolutionStage == 0) ||
-				(gopher.Level >= 32 && gopher.EvolutionStage == 1)
-
-			if shouldCheckEvolution {
-				evolved, evolutionMsg := h.gameService.CheckEvolution(gopher)
-				if evolved {
-					evolutionMessages = append(evolutionMessages, evolutionMsg)
-					// Update gopher after evolution
-					h.gopherRepo.Update(h.gameGopherToStorage(gopher))
-				}
-			}
-		}
-	}
-
-	// Update all participating gophers in DB (they may have gained XP and leveled up)
-	// IMPORTANT: Save HP changes BEFORE checking for blackout
-	for _, gopher := range battleState.ParticipatingGophers {
-		h.gopherRepo.Update(h.gameGopherToStorage(gopher))
-	}
-
-	// Update active player gopher (in case it wasn't in participating list)
-	h.gopherRepo.Update(h.gameGopherToStorage(battleState.PlayerGopher))
-
-	// Update all party members in DB to ensure HP is saved
-	partyStorage, _ := h.gopherRepo.GetParty(battleState.TrainerID)
-	for _, partyGopher := range partyStorage {
-		// Find matching gopher in battle state
-		for _, battleGopher := range battleState.PlayerParty {
-			if battleGopher.ID == partyGopher.ID {
-				partyGopher.CurrentHP = battleGopher.CurrentHP
-				partyGopher.MaxHP = battleGopher.MaxHP
-				h.gopherRepo.Update(partyGopher)
-				break
-			}
-		}
-	}
-
-	if battleState.State != "WON" && battleState.State != "ESCAPED" {
-		h.gopherRepo.Update(h.gameGopherToStorage(battleState.EnemyGopher))
-	}
-
-	// Handle battle end
-	if battleState.State != "ACTIVE" {
-		if battleState.State == "WON" {
-			// Check if captured
-			if strings.Contains(strings.Join(messages, " "), "captured") {
-				// Add to party or PC
-				partySize, _ := h.partyRepo.GetPartySize(battleState.TrainerID)
-				enemyStorage := h.gameGopherToStorage(battleState.EnemyGopher)
-				enemyStorage.TrainerID = &battleState.TrainerID
-				enemyStorage.IsInParty = partySize < 6
-				h.gopherRepo.Update(enemyStorage)
-				if enemyStorage.IsInParty {
-					h.trainerRepo.UpdatePartySlots(battleState.TrainerID, partySize+1)
-				}
-			}
-		} else if battleState.State == "LOST" {
-			// Check for blackout after battle loss (HP is already saved above)
-			blackedOut, blackoutMsg := h.gameService.CheckAndHandleBlackout(battleState.TrainerID)
-			if blackedOut {
-				messages = append(messages, "")
-				messages = append(messages, blackoutMsg)
-			}
-		}
-		delete(h.battles, battleState.ID)
-	}
+	messages = h.finishBattleTurn(s, battleState, messages)
 
 	// Update embed with battle card image (don't regenerate for regular actions)
 	embed := h.createBattleEmbed(battleState)
@@ -1480,72 +1600,110 @@ func (h *Handlers) handleBattleAbility(s *discordgo.Session, i *discordgo.Intera
 		return
 	}
 
-	// Update battle state
-	battle, _ := h.battleRepo.GetByID(battleState.ID)
-	if battle != nil {
-		battle.State = battleState.State
-		battle.TurnOwner = battleState.TurnOwner
-		h.battleRepo.Update(battle)
+	messages = h.finishBattleTurn(s, battleState, messages)
+	messageText := strings.Join(messages, "\n")
+
+	// Update embed with battle card image (don't regenerate for regular actions)
+	embed := h.createBattleEmbed(battleState)
+	h.addBattleCardToEmbed(embed, battleState, false) // false = don't regenerate, just reference existing
+
+	var components []discordgo.MessageComponent
+	if battleState.State == "ACTIVE" {
+		components = h.createBattleButtons(battleState, false)
 	}
+	h.editBattleMessage(s, i, messageText, embed, components)
+}
+
+// finishBattleTurn persists the result of a battle turn: battle state, evolutions, gopher HP/XP,
+// captures, blackouts and trainer statistics. Returns messages with any additions.
+func (h *Handlers) finishBattleTurn(s *discordgo.Session, battleState *game.BattleState, messages []string) []string {
+	// Quests to advance once everything has been saved
+	questProgress := []string{}
 
 	// Check for evolution after level up - check all participating gophers
-	evolutionMessages := []string{}
-	if strings.Contains(strings.Join(messages, " "), "leveled up") {
-		// Check evolution for all participating gophers that may have leveled up
-		for _, gopher := range battleState.ParticipatingGophers {
-			// Check if this gopher is at an evolution threshold
-			shouldCheckEvolution := (gopher.Level >= 16 && gopher.EvolutionStage == 0) ||
-				(gopher.Level >= 32 && gopher.EvolutionStage == 1)
-
-			if shouldCheckEvolution {
-				evolved, evolutionMsg := h.gameService.CheckEvolution(gopher)
-				if evolved {
-					evolutionMessages = append(evolutionMessages, evolutionMsg)
-					// Update gopher after evolution
-					h.gopherRepo.Update(h.gameGopherToStorage(gopher))
-				}
-			}
+	leveledUp := strings.Contains(strings.Join(messages, " "), "leveled up")
+	if leveledUp {
+		evolutionMsgs, evolved := h.evolveGophers(battleState.TrainerID, battleState.ParticipatingGophers)
+		messages = append(messages, evolutionMsgs...)
+		for n := 0; n < evolved; n++ {
+			questProgress = append(questProgress, game.QuestEvolveGopher)
 		}
 	}
 
 	// Update all participating gophers in DB (they may have gained XP and leveled up)
 	// IMPORTANT: Save HP changes BEFORE checking for blackout
 	for _, gopher := range battleState.ParticipatingGophers {
-		h.gopherRepo.Update(h.gameGopherToStorage(gopher))
+		h.gopherRepo.UpdateStats(h.gameGopherToStorage(gopher))
 	}
 
 	// Update active player gopher (in case it wasn't in participating list)
-	h.gopherRepo.Update(h.gameGopherToStorage(battleState.PlayerGopher))
+	h.gopherRepo.UpdateStats(h.gameGopherToStorage(battleState.PlayerGopher))
+
+	// Gophers that reached a new move milestone learn it or ask which move to forget
+	if leveledUp {
+		for _, gopher := range battleState.ParticipatingGophers {
+			messages = append(messages, h.offerNewMoves(s, battleState.ChannelID, battleState.TrainerID, gopher)...)
+		}
+	}
 
 	// Update all party members in DB to ensure HP is saved
 	partyStorage, _ := h.gopherRepo.GetParty(battleState.TrainerID)
 	for _, partyGopher := range partyStorage {
 		// Find matching gopher in battle state
 		for _, battleGopher := range battleState.PlayerParty {
+			if battleGopher.ID == battleState.PlayerGopher.ID {
+				// The active gopher's instance holds its current HP
+				battleGopher = battleState.PlayerGopher
+			}
 			if battleGopher.ID == partyGopher.ID {
 				partyGopher.CurrentHP = battleGopher.CurrentHP
 				partyGopher.MaxHP = battleGopher.MaxHP
-				h.gopherRepo.Update(partyGopher)
+				h.gopherRepo.UpdateStats(partyGopher)
 				break
 			}
 		}
 	}
 
-	if battleState.State != "WON" && battleState.State != "ESCAPED" {
-		h.gopherRepo.Update(h.gameGopherToStorage(battleState.EnemyGopher))
+	// NPC trainers' gophers are never stored
+	if battleState.OpponentType == "WILD" && battleState.State != "WON" && battleState.State != "ESCAPED" {
+		h.gopherRepo.UpdateStats(h.gameGopherToStorage(battleState.EnemyGopher))
+	}
+
+	// Update battle state in DB, after evolutions so the snapshot holds the evolved stats
+	battle, _ := h.battleRepo.GetByID(battleState.ID)
+	if battle != nil {
+		battle.State = battleState.State
+		battle.TurnOwner = battleState.TurnOwner
+		battle.GopherIDPlayer = &battleState.PlayerGopher.ID
+		battle.Energy = encodeEnergy(battleState)
+		battle.RNGDraws = battleState.Rand().Draws()
+		battle.Snapshot = encodeSnapshot(battleState)
+		if err := h.battleRepo.Update(battle); err != nil {
+			log.Printf("Error saving battle: %v", err)
+		}
 	}
 
 	// Handle battle end
 	if battleState.State != "ACTIVE" {
-		if battleState.State == "WON" {
+		if battleState.State == "WON" && battleState.Captured {
+			// Add to party or PC
 			partySize, _ := h.partyRepo.GetPartySize(battleState.TrainerID)
 			enemyStorage := h.gameGopherToStorage(battleState.EnemyGopher)
 			enemyStorage.TrainerID = &battleState.TrainerID
 			enemyStorage.IsInParty = partySize < 6
 			h.gopherRepo.Update(enemyStorage)
+			if err := h.gameService.SaveAbilities(battleState.EnemyGopher); err != nil {
+				log.Printf("Error saving caught gopher's abilities: %v", err)
+			}
 			if enemyStorage.IsInParty {
 				h.trainerRepo.UpdatePartySlots(battleState.TrainerID, partySize+1)
 			}
+			if err := h.gopherdexRepo.RecordCatch(battleState.TrainerID, enemyStorage.Name, enemyStorage.SpeciesArchetype, enemyStorage.Rarity); err != nil {
+				log.Printf("Error recording gopherdex catch: %v", err)
+			}
+		} else if battleState.State == "WON" && battleState.Opponent != nil {
+			messages = append(messages, "")
+			messages = append(messages, h.rewardTrainerBattle(battleState.TrainerID, battleState.Opponent)...)
 		} else if battleState.State == "LOST" {
 			// Check for blackout after battle loss (HP is already saved above)
 			blackedOut, blackoutMsg := h.gameService.CheckAndHandleBlackout(battleState.TrainerID)
@@ -1554,34 +1712,111 @@ func (h *Handlers) handleBattleAbility(s *discordgo.Session, i *discordgo.Intera
 				messages = append(messages, blackoutMsg)
 			}
 		}
+		h.recordBattleStats(battleState)
+		h.saveBattleReplay(battleState)
+		h.battlesMu.Lock()
 		delete(h.battles, battleState.ID)
+		h.battlesMu.Unlock()
+
+		if battleState.State == "WON" {
+			questProgress = append(questProgress, game.QuestWinBattles, game.QuestWin10Battles)
+		}
+		if battleState.Captured {
+			questProgress = append(questProgress, game.QuestCatchGophers, game.QuestCatch20Gophers)
+			if battleState.EnemyGopher.Shiny {
+				questProgress = append(questProgress, game.QuestCatchShiny)
+			}
+		}
 	}
 
-	// Combine messages
-	allMessages := append(messages, evolutionMessages...)
-	messageText := strings.Join(allMessages, "\n")
-	if messageText == "" {
-		messageText = strings.Join(messages, "\n")
+	if len(questProgress) > 0 {
+		h.progressQuests(s, battleState.ChannelID, battleState.TrainerID, questProgress...)
 	}
 
-	// Update embed with battle card image (don't regenerate for regular actions)
-	embed := h.createBattleEmbed(battleState)
-	h.addBattleCardToEmbed(embed, battleState, false) // false = don't regenerate, just reference existing
+	if leveledUp || battleState.State != "ACTIVE" {
+		h.evaluateAchievements(s, battleState.ChannelID, battleState.TrainerID)
+	}
 
-	var components []discordgo.MessageComponent
-	if battleState.State == "ACTIVE" {
-		components = h.createBattleButtons(battleState, false)
+	return messages
+}
+
+// evolveGophers evolves battle gophers that reached an evolution threshold and saves them.
+// It returns the evolution messages and how many gophers evolved.
+func (h *Handlers) evolveGophers(trainerID string, gophers []*game.Gopher) ([]string, int) {
+	messages := []string{}
+	evolvedCount := 0
+	for _, gopher := range gophers {
+		// Check if this gopher is at an evolution threshold
+		shouldCheckEvolution := (gopher.Level >= 16 && gopher.EvolutionStage == 0) ||
+			(gopher.Level >= 32 && gopher.EvolutionStage == 1)
+		if !shouldCheckEvolution {
+			continue
+		}
+
+		previousForm := h.gameGopherToStorage(gopher)
+		evolved, evolutionMsg := h.gameService.CheckEvolution(gopher)
+		if !evolved {
+			continue
+		}
+		messages = append(messages, evolutionMsg)
+		// Update gopher after evolution
+		evolvedForm := h.gameGopherToStorage(gopher)
+		h.gopherRepo.UpdateStats(evolvedForm)
+		if err := h.gameService.SaveAbilities(gopher); err != nil {
+			log.Printf("Error saving abilities after evolution: %v", err)
+		}
+		// Evolving can change rarity, which is a different Gopherdex entry
+		h.markGopherdexOwned(trainerID, evolvedForm)
+		h.refreshGopherdexOwned(trainerID, previousForm)
+		if err := h.statsRepo.IncrementEvolutions(trainerID); err != nil {
+			log.Printf("Error recording evolution stats: %v", err)
+		}
+		evolvedCount++
+	}
+	return messages, evolvedCount
+}
+
+// recordBattleStats records a finished battle in the trainer's statistics
+func (h *Handlers) recordBattleStats(battleState *game.BattleState) {
+	trainerID := battleState.TrainerID
+
+	if battleState.State == "WON" || battleState.State == "LOST" {
+		if err := h.statsRepo.IncrementBattles(trainerID, battleState.State == "WON"); err != nil {
+			log.Printf("Error recording battle stats: %v", err)
+		}
+	}
+
+	if battleState.Captured {
+		if err := h.statsRepo.IncrementGophersCaught(trainerID, battleState.EnemyGopher.Shiny); err != nil {
+			log.Printf("Error recording capture stats: %v", err)
+		}
+	}
+
+	if battleState.XPAwarded > 0 {
+		if err := h.statsRepo.AddXP(trainerID, battleState.XPAwarded); err != nil {
+			log.Printf("Error recording XP stats: %v", err)
+		}
+	}
+
+	usage := make(map[string]string)
+	for _, gopher := range battleState.ParticipatingGophers {
+		usage[gopher.ID] = gopher.SpeciesArchetype
+	}
+	if err := h.statsRepo.RecordGopherUsage(trainerID, usage); err != nil {
+		log.Printf("Error recording gopher usage: %v", err)
 	}
-	h.editBattleMessage(s, i, messageText, embed, components)
 }
 
 func (h *Handlers) findBattleByMessage(channelID, messageID string) *game.BattleState {
 	// Check in-memory cache first
+	h.battlesMu.Lock()
 	for _, battle := range h.battles {
 		if battle.ChannelID == channelID && battle.MessageID == messageID {
+			h.battlesMu.Unlock()
 			return battle
 		}
 	}
+	h.battlesMu.Unlock()
 
 	// Load from DB
 	battle, err := h.battleRepo.GetByMessageID(channelID, messageID)
@@ -1591,17 +1826,56 @@ func (h *Handlers) findBattleByMessage(channelID, messageID string) *game.Battle
 
 	// Reconstruct battle state
 	playerGopherStorage, _ := h.gopherRepo.GetByID(*battle.GopherIDPlayer)
-	enemyGopherStorage, _ := h.gopherRepo.GetByID(*battle.GopherIDEnemy)
-
-	if playerGopherStorage == nil || enemyGopherStorage == nil {
+	if playerGopherStorage == nil {
 		return nil
 	}
-
 	playerGopher, _ := h.gameService.StorageGopherToGameGopher(playerGopherStorage)
-	enemyGopher, _ := h.gameService.StorageGopherToGameGopher(enemyGopherStorage)
 
-	// Get party for battle state
-	partyStorage, _ := h.gopherRepo.GetParty(battle.TrainerID)
+	// Wild gophers are stored; an NPC trainer's party is rebuilt from the roster
+	var enemyGopher *game.Gopher
+	var opponent *game.NPCTrainer
+	var enemyParty []*game.Gopher
+	if battle.NPCID != nil {
+		opponent = h.gameService.Roster().Trainer(*battle.NPCID)
+		if opponent == nil {
+			log.Printf("Error loading battle %s: unknown NPC trainer %s", battle.ID, *battle.NPCID)
+			return nil
+		}
+		enemyParty, err = h.gameService.NPCParty(opponent)
+		if err != nil {
+			log.Printf("Error loading battle %s: %v", battle.ID, err)
+			return nil
+		}
+		enemyGopher = enemyParty[0]
+	} else {
+		if battle.GopherIDEnemy == nil {
+			return nil
+		}
+		enemyGopherStorage, _ := h.gopherRepo.GetByID(*battle.GopherIDEnemy)
+		if enemyGopherStorage == nil {
+			return nil
+		}
+		enemyGopher, _ = h.gameService.StorageGopherToGameGopher(enemyGopherStorage)
+	}
+
+	snapshot := &game.BattleSnapshot{}
+	if err := json.Unmarshal([]byte(battle.Snapshot), snapshot); err != nil {
+		log.Printf("Error reading battle snapshot: %v", err)
+		snapshot = &game.BattleSnapshot{}
+	}
+
+	// Get party for battle state: the gophers it started with, or the current party for
+	// battles saved before snapshots
+	partyStorage := []*storage.Gopher{}
+	if len(snapshot.PartyIDs) > 0 {
+		for _, gopherID := range snapshot.PartyIDs {
+			if gopherStorage, _ := h.gopherRepo.GetByID(gopherID); gopherStorage != nil {
+				partyStorage = append(partyStorage, gopherStorage)
+			}
+		}
+	} else {
+		partyStorage, _ = h.gopherRepo.GetParty(battle.TrainerID)
+	}
 	gameParty := make([]*game.Gopher, len(partyStorage))
 	for i, gopherStorage := range partyStorage {
 		gameGopher, _ := h.gameService.StorageGopherToGameGopher(gopherStorage)
@@ -1621,12 +1895,49 @@ func (h *Handlers) findBattleByMessage(channelID, messageID string) *game.Battle
 		TurnOwner:            battle.TurnOwner,
 		State:                battle.State,
 		EventManager:         h.gameService.GetEventManager(), // Add event manager
+		XPMultiplier:         battle.XPMultiplier,
+		EnergyPools:          make(map[string]int),
+		Seed:                 battle.Seed,
+		Opponent:             opponent,
+		EnemyParty:           enemyParty,
+	}
+	// Continue the battle's random sequence where the last turn left off
+	battleState.ResumeRand(battle.RNGDraws)
+	if err := json.Unmarshal([]byte(battle.Energy), &battleState.EnergyPools); err != nil {
+		// Fall back to full energy pools
+		battleState.EnergyPools = make(map[string]int)
+	}
+	battleState.Restore(snapshot)
+
+	h.battlesMu.Lock()
+	defer h.battlesMu.Unlock()
+	// Another interaction may have loaded the battle in the meantime
+	if cached, ok := h.battles[battleState.ID]; ok {
+		return cached
 	}
-
 	h.battles[battleState.ID] = battleState
 	return battleState
 }
 
+// encodeEnergy serializes a battle's energy pools for storage
+func encodeEnergy(battleState *game.BattleState) string {
+	energyBytes, err := json.Marshal(battleState.EnergyPools)
+	if err != nil || battleState.EnergyPools == nil {
+		return "{}"
+	}
+	return string(energyBytes)
+}
+
+// encodeSnapshot serializes a battle's snapshot for storage
+func encodeSnapshot(battleState *game.BattleState) string {
+	snapshotBytes, err := json.Marshal(battleState.Snapshot())
+	if err != nil {
+		log.Printf("Error encoding battle snapshot: %v", err)
+		return "{}"
+	}
+	return string(snapshotBytes)
+}
+
 func (h *Handlers) createBattleEmbed(battleState *game.BattleState) *discordgo.MessageEmbed {
 	playerHPBar := game.GetHPBar(battleState.PlayerGopher.CurrentHP, battleState.PlayerGopher.MaxHP, 12)
 	enemyHPBar := game.GetHPBar(battleState.EnemyGopher.CurrentHP, battleState.EnemyGopher.MaxHP, 12)
@@ -1650,24 +1961,35 @@ func (h *Handlers) createBattleEmbed(battleState *game.BattleState) *discordgo.M
 		color = 0xffff00
 	}
 
+	title := "Battle"
+	enemyName := fmt.Sprintf("%s (Lv.%d) - %s", battleState.EnemyGopher.Name, battleState.EnemyGopher.Level, battleState.EnemyGopher.Rarity)
+	if battleState.Opponent != nil {
+		title = fmt.Sprintf("Battle vs %s", battleState.Opponent.DisplayName())
+		enemyName += fmt.Sprintf(" • %d/%d left", battleState.EnemyGophersLeft(), len(battleState.EnemyParty))
+	}
+
 	embed := &discordgo.MessageEmbed{
-		Title:       "Battle",
+		Title:       title,
 		Description: description,
 		Color:       color,
 		Fields: []*discordgo.MessageEmbedField{
 			{
 				Name:   fmt.Sprintf("%s (Lv.%d)", battleState.PlayerGopher.Name, battleState.PlayerGopher.Level),
-				Value:  fmt.Sprintf("HP: %s", playerHPBar),
+				Value:  fmt.Sprintf("HP: %s\n⚡ Energy: %d/%d", playerHPBar, battleState.Energy(battleState.PlayerGopher), game.MaxEnergy(battleState.PlayerGopher)),
 				Inline: false,
 			},
 			{
-				Name:   fmt.Sprintf("%s (Lv.%d) - %s", battleState.EnemyGopher.Name, battleState.EnemyGopher.Level, battleState.EnemyGopher.Rarity),
-				Value:  fmt.Sprintf("HP: %s", enemyHPBar),
+				Name:   enemyName,
+				Value:  fmt.Sprintf("HP: %s\n⚡ Energy: %d/%d", enemyHPBar, battleState.Energy(battleState.EnemyGopher), game.MaxEnergy(battleState.EnemyGopher)),
 				Inline: false,
 			},
 		},
 	}
 
+	if field := fieldEmbedField(battleState.Field); field != nil && battleState.State == "ACTIVE" {
+		embed.Fields = append(embed.Fields, field)
+	}
+
 	if battleState.State != "ACTIVE" {
 		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
 			Name:   "Battle Result",
@@ -1679,6 +2001,18 @@ func (h *Handlers) createBattleEmbed(battleState *game.BattleState) *discordgo.M
 	return embed
 }
 
+// fieldEmbedField shows the battle's field condition, or returns nil when the field is clear
+func fieldEmbedField(field *game.Field) *discordgo.MessageEmbedField {
+	if !field.Active() {
+		return nil
+	}
+	return &discordgo.MessageEmbedField{
+		Name:   field.Name(),
+		Value:  field.Description(),
+		Inline: false,
+	}
+}
+
 // addBattleCardToEmbed adds the battle card image reference to the embed
 // Set forceRegen=true to force regeneration (e.g., after swap), false to preserve existing
 func (h *Handlers) addBattleCardToEmbed(embed *discordgo.MessageEmbed, battleState *game.BattleState, forceRegen bool) {
@@ -1717,20 +2051,37 @@ func (h *Handlers) createBattleButtons(battleState *game.BattleState, showAbilit
 	}
 
 	if showAbilities && battleState.TurnOwner == "PLAYER" {
-		// Show ability buttons
+		// Show ability buttons, greyed out when the gopher can't afford them
 		buttons := []discordgo.MessageComponent{}
 		for idx, ability := range battleState.PlayerGopher.Abilities {
 			if idx >= 4 {
 				break
 			}
-			buttons = append(buttons, createButton(ability.Name, discordgo.PrimaryButton, fmt.Sprintf("battle_ability_%d", idx+1)))
+			buttons = append(buttons, &ButtonWithoutEmoji{
+				Label:    fmt.Sprintf("%s (%d⚡)", ability.Name, ability.Cost),
+				Style:    discordgo.PrimaryButton,
+				Disabled: !battleState.CanAfford(battleState.PlayerGopher, ability),
+				CustomID: fmt.Sprintf("battle_ability_%d", idx+1),
+			})
 		}
+		buttons = append(buttons, createButton("Rest", discordgo.SecondaryButton, "battle_rest"))
 		return []discordgo.MessageComponent{
 			discordgo.ActionsRow{Components: buttons},
 		}
 	}
 
-	// Show main action buttons
+	// Show main action buttons. There's no running from or capturing in trainer battles.
+	if battleState.OpponentType != "WILD" {
+		return []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					createButton("Fight", discordgo.PrimaryButton, "battle_fight"),
+					createButton("Swap", discordgo.SecondaryButton, "battle_swap"),
+					createButton("Bag", discordgo.SecondaryButton, "battle_bag"),
+				},
+			},
+		}
+	}
 	return []discordgo.MessageComponent{
 		discordgo.ActionsRow{
 			Components: []discordgo.MessageComponent{
@@ -1738,6 +2089,7 @@ func (h *Handlers) createBattleButtons(battleState *game.BattleState, showAbilit
 				createButton("Swap", discordgo.SecondaryButton, "battle_swap"),
 				createButton("Run", discordgo.DangerButton, "battle_run"),
 				createButton("Throw Net", discordgo.SuccessButton, "battle_net"),
+				createButton("Bag", discordgo.SecondaryButton, "battle_bag"),
 			},
 		},
 	}
@@ -1776,6 +2128,7 @@ func (h *Handlers) gameGopherToStorage(gameGopher *game.Gopher) *storage.Gopher
 		StatusEffects:    statusEffectsJSON,
 		Shiny:            gameGopher.Shiny,
 		IsFavorite:       gameGopher.IsFavorite,
+		HeldItem:         gameGopher.HeldItem,
 		IsInParty:        gameGopher.IsInParty,
 		PCSlot:           gameGopher.PCSlot,
 	}
@@ -2001,6 +2354,18 @@ func editMessage(s *discordgo.Session, i *discordgo.InteractionCreate, content s
 	}
 }
 
+// progressBar renders progress towards a target as a text bar of the given length
+func progressBar(progress, target, length int) string {
+	filled := length
+	if target > 0 && progress < target {
+		filled = progress * length / target
+	}
+	if filled < 0 {
+		filled = 0
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", length-filled)
+}
+
 // getGopherImageData returns image bytes from base64 or file path
 func (h *Handlers) getGopherImageData(gopher *storage.Gopher) ([]byte, error) {
 	if gopher.SpriteData != "" {
@@ -2114,11 +2479,7 @@ func (h *Handlers) handleBattleSwap(s *discordgo.Session, i *discordgo.Interacti
 		return
 	}
 
-	// Update gophers in DB
-	h.gopherRepo.Update(h.gameGopherToStorage(battleState.PlayerGopher))
-	for _, gopher := range battleState.PlayerParty {
-		h.gopherRepo.Update(h.gameGopherToStorage(gopher))
-	}
+	messages = h.finishBattleTurn(s, battleState, messages)
 
 	// Update embed with battle card image (don't regenerate for regular actions)
 	embed := h.createBattleEmbed(battleState)
@@ -0,0 +1,154 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"gophermon-bot/internal/game"
+	"gophermon-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (h *Handlers) handleAchievements(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	discordID := i.Member.User.ID
+
+	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	// Catch up on anything earned before achievements were tracked
+	h.evaluateAchievements(s, i.ChannelID, trainer.ID)
+
+	achievements, err := h.achievementService.GetAchievements(trainer.ID)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error loading achievements: %v", err))
+		return
+	}
+
+	byType := make(map[string]*storage.Achievement)
+	for _, ach := range achievements {
+		byType[ach.AchievementType] = ach
+	}
+
+	var completed []string
+	var inProgress []*discordgo.MessageEmbedField
+	for _, achievementType := range game.AchievementOrder {
+		name := game.AchievementNames[achievementType]
+		ach := byType[achievementType]
+
+		if ach != nil && ach.Completed {
+			line := fmt.Sprintf("✅ **%s** — %s", name, game.AchievementDescriptions[achievementType])
+			if ach.CompletedAt != nil {
+				line += fmt.Sprintf(" (<t:%d:d>)", ach.CompletedAt.Unix())
+			}
+			completed = append(completed, line)
+			continue
+		}
+
+		progress := 0
+		if ach != nil {
+			progress = ach.Progress
+		}
+		requirement := game.AchievementRequirements[achievementType]
+		inProgress = append(inProgress, &discordgo.MessageEmbedField{
+			Name: name,
+			Value: fmt.Sprintf("%s\n`%s` %d/%d • Reward: %d GoCoins",
+				game.AchievementDescriptions[achievementType],
+				progressBar(progress, requirement, 10), progress, requirement,
+				game.AchievementRewards[achievementType]),
+			Inline: false,
+		})
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🏆 Achievements 🏆",
+		Description: fmt.Sprintf("**%d/%d** achievements unlocked", len(completed), len(game.AchievementOrder)),
+		Color:       0xffd700,
+		Fields:      inProgress,
+	}
+	if len(completed) > 0 {
+		embed.Fields = append([]*discordgo.MessageEmbedField{{
+			Name:   "Unlocked",
+			Value:  strings.Join(completed, "\n"),
+			Inline: false,
+		}}, embed.Fields...)
+	}
+
+	respondEmbed(s, i, embed, true)
+}
+
+// evaluateAchievements brings every achievement up to date for a trainer, paying rewards
+// and announcing any that unlock in the given channel
+func (h *Handlers) evaluateAchievements(s *discordgo.Session, channelID, trainerID string) {
+	stats, err := h.statsRepo.GetOrCreate(trainerID)
+	if err != nil {
+		log.Printf("Error loading stats for achievements: %v", err)
+		return
+	}
+
+	battlesWon := stats.BattlesWon
+	if pvpStats, err := h.pvpRepo.GetOrCreate(trainerID); err == nil {
+		battlesWon += pvpStats.Wins
+	}
+
+	legendaries, err := h.gopherRepo.CountByRarity(trainerID, game.RarityLegendary.String())
+	if err != nil {
+		log.Printf("Error counting legendaries for achievements: %v", err)
+	}
+
+	highestLevel, err := h.gopherRepo.GetHighestLevel(trainerID)
+	if err != nil {
+		log.Printf("Error getting highest level for achievements: %v", err)
+	}
+
+	progress := map[string]int{
+		game.AchievementFirstCatch:         stats.GophersCaught,
+		game.AchievementCatchMaster:        stats.GophersCaught,
+		game.AchievementShinyHunter:        stats.ShinyCount,
+		game.AchievementLegendaryCollector: legendaries,
+		game.AchievementBattleVeteran:      battlesWon,
+		game.AchievementEvolutionMaster:    stats.Evolutions,
+		game.AchievementLevelMaster:        highestLevel,
+	}
+
+	for _, achievementType := range game.AchievementOrder {
+		completed, reward, err := h.achievementService.CheckAndSetAchievement(trainerID, achievementType, progress[achievementType])
+		if err != nil {
+			log.Printf("Error updating achievement %s: %v", achievementType, err)
+			continue
+		}
+		if completed {
+			h.announceAchievement(s, channelID, trainerID, achievementType, reward)
+		}
+	}
+}
+
+// announceAchievement posts a celebratory embed for a newly unlocked achievement
+func (h *Handlers) announceAchievement(s *discordgo.Session, channelID, trainerID, achievementType string, reward int) {
+	trainer, err := h.trainerRepo.GetByID(trainerID)
+	if err != nil || trainer == nil || channelID == "" {
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: "🎉 Achievement Unlocked! 🎉",
+		Description: fmt.Sprintf("**%s** earned **%s**!\n%s",
+			trainer.Name, game.AchievementNames[achievementType], game.AchievementDescriptions[achievementType]),
+		Color: 0xffd700,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Reward", Value: fmt.Sprintf("💰 %d GoCoins", reward), Inline: true},
+		},
+	}
+
+	_, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content: fmt.Sprintf("<@%s>", trainer.DiscordID),
+		Embeds:  []*discordgo.MessageEmbed{embed},
+	})
+	if err != nil {
+		log.Printf("Error announcing achievement: %v", err)
+	}
+}
@@ -0,0 +1,960 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gophermon-bot/internal/game"
+	"gophermon-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+)
+
+// Double battle button custom IDs have the form double_<action>_<battleID>[_<slot>[_<index>[_<target>]]]
+
+// handleWildDouble starts a double battle against a horde of two wild gophers
+func (h *Handlers) handleWildDouble(s *discordgo.Session, i *discordgo.InteractionCreate, trainer *storage.Trainer, area *game.WildArea) {
+	_, gameParty, ok := h.loadBattleParty(s, i, trainer.ID)
+	if !ok {
+		return
+	}
+	if !game.CanBattleDouble(gameParty) {
+		respondEphemeral(s, i, fmt.Sprintf("You need at least %d gophers that can battle for a double battle!", game.DoubleSlots))
+		return
+	}
+
+	horde := []*game.Gopher{}
+	for len(horde) < game.DoubleSlots {
+		wildGopherStorage, err := h.gameService.GenerateWildGopher(area)
+		if err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("Error generating wild gopher: %v", err))
+			return
+		}
+
+		// Save wild gopher (without trainer_id)
+		wildGopherStorage, err = h.gopherRepo.Create(wildGopherStorage)
+		if err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("Error saving wild gopher: %v", err))
+			return
+		}
+
+		if err := h.gopherdexRepo.RecordEncounter(trainer.ID, wildGopherStorage.Name, wildGopherStorage.SpeciesArchetype, wildGopherStorage.Rarity); err != nil {
+			log.Printf("Error recording gopherdex encounter: %v", err)
+		}
+
+		wildGopher, err := h.gameService.StorageGopherToGameGopher(wildGopherStorage)
+		if err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("Error: %v", err))
+			return
+		}
+		horde = append(horde, wildGopher)
+	}
+
+	doubleState, err := game.NewWildDoubleBattle(i.ChannelID, trainer.ID, trainer.Name, gameParty, horde, h.gameService.GetEventManager(), h.gameService.NewBattleSeed())
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Couldn't start the double battle: %v", err))
+		return
+	}
+	h.applyDoubleXPBoost(doubleState, trainer.ID)
+
+	if err := h.startDoubleBattle(s, doubleState); err != nil {
+		h.refundXPBoost(trainer.ID, doubleState.XPMultiplier)
+		respondEphemeral(s, i, fmt.Sprintf("Couldn't start the double battle: %v", err))
+		return
+	}
+
+	if area != nil {
+		respondEphemeral(s, i, fmt.Sprintf("A wild horde appeared in %s!", area.Name))
+		return
+	}
+	respondEphemeral(s, i, "A wild horde appeared!")
+}
+
+// acceptDoubleChallenge turns an accepted double battle challenge into a double battle, to be
+// posted with postDoubleBattle as a new message below the challenge. The reply updates the
+// challenge. Caller must hold pvpMu.
+func (h *Handlers) acceptDoubleChallenge(pvpState *game.PvPBattleState, party1, party2 []*game.Gopher) (*game.DoubleBattleState, *pvpReply) {
+	delete(h.pvpBattles, pvpState.ID)
+
+	doubleState, err := game.NewPvPDoubleBattle(pvpState.ChannelID, pvpState.Trainer1ID, pvpState.Trainer1Name,
+		pvpState.Trainer2ID, pvpState.Trainer2Name, party1, party2, h.gameService.GetEventManager(), h.gameService.NewBattleSeed())
+	if err != nil {
+		return nil, &pvpReply{ephemeral: fmt.Sprintf("Couldn't start the battle: %v", err)}
+	}
+	doubleState.ID = pvpState.ID
+
+	if err := h.addDoubleBattle(doubleState); err != nil {
+		return nil, &pvpReply{ephemeral: fmt.Sprintf("Couldn't start the battle: %v", err)}
+	}
+
+	return doubleState, &pvpReply{embed: &discordgo.MessageEmbed{
+		Title:       "⚔️ PvP Challenge",
+		Description: fmt.Sprintf("%s accepted the double battle!", pvpState.Trainer2Name),
+		Color:       0xff9900,
+	}}
+}
+
+// applyDoubleXPBoost uses up an XP Booster the trainer activated with /item use
+func (h *Handlers) applyDoubleXPBoost(doubleState *game.DoubleBattleState, trainerID string) {
+	doubleState.XPMultiplier = h.itemService.ConsumeXPBoost(trainerID)
+	if doubleState.XPMultiplier > 1.0 {
+		doubleState.Log = append(doubleState.Log, fmt.Sprintf("⚡ XP Booster active: %.1fx XP this battle!", doubleState.XPMultiplier))
+	}
+}
+
+// refundXPBoost gives back an XP Booster a battle used up when the battle couldn't start
+func (h *Handlers) refundXPBoost(trainerID string, multiplier float64) {
+	if err := h.itemService.RefundXPBoost(trainerID, multiplier); err != nil {
+		log.Printf("Error refunding XP Booster: %v", err)
+	}
+}
+
+// startDoubleBattle posts a new double battle with its battle card and keeps it in memory.
+// Trainers can only be in one double battle at a time. Wild and trainer doubles check
+// battleBusyReason before they get here, so no other battle holds the same gophers.
+func (h *Handlers) startDoubleBattle(s *discordgo.Session, doubleState *game.DoubleBattleState) error {
+	if err := h.addDoubleBattle(doubleState); err != nil {
+		return err
+	}
+	return h.postDoubleBattle(s, doubleState)
+}
+
+// addDoubleBattle keeps a new double battle in memory, unless one of its trainers is already in
+// a double battle
+func (h *Handlers) addDoubleBattle(doubleState *game.DoubleBattleState) error {
+	if doubleState.ID == "" {
+		doubleState.ID = uuid.New().String()
+	}
+
+	h.doubleMu.Lock()
+	defer h.doubleMu.Unlock()
+	for _, side := range doubleState.Sides {
+		if !side.IsComputer() && h.findDoubleBattleByTrainer(side.TrainerID) != nil {
+			return fmt.Errorf("%s is already in a double battle", side.Name)
+		}
+	}
+	h.doubles[doubleState.ID] = doubleState
+	return nil
+}
+
+// postDoubleBattle sends the first message of a battle added with addDoubleBattle, removing the
+// battle again if it can't be sent. Caller must not hold doubleMu.
+func (h *Handlers) postDoubleBattle(s *discordgo.Session, doubleState *game.DoubleBattleState) error {
+	embed := h.createDoubleEmbed(doubleState)
+	message := &discordgo.MessageSend{
+		Content:    h.doubleMentions(waitingDoubleTrainers(doubleState), doubleState.Round),
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: h.createDoubleButtons(doubleState),
+	}
+	if cardFile := h.createDoubleCardFile(h.copyDoubleCard(doubleState)); cardFile != nil {
+		embed.Image = &discordgo.MessageEmbedImage{URL: fmt.Sprintf("attachment://%s", cardFile.Name)}
+		message.Files = []*discordgo.File{cardFile}
+	}
+
+	msg, err := s.ChannelMessageSendComplex(doubleState.ChannelID, message)
+
+	h.doubleMu.Lock()
+	defer h.doubleMu.Unlock()
+	if err != nil {
+		delete(h.doubles, doubleState.ID)
+		return fmt.Errorf("failed to send battle message: %w", err)
+	}
+	doubleState.MessageID = msg.ID
+	return nil
+}
+
+// doubleReply is the reply to a double battle button press. It's copied out of the battle under
+// doubleMu and sent once doubleMu is released, so database and Discord calls don't hold up
+// other battles.
+type doubleReply struct {
+	ephemeral      string // Sent privately instead of anything below
+	menu           string // The trainer's private menu; with no menu the battle message was clicked
+	menuComponents []discordgo.MessageComponent
+	newMenu        bool          // The menu is sent as a new private message instead of updating the clicked one
+	battle         *doubleUpdate // Update to the battle message, nil if it hasn't changed
+	finished       bool          // The battle ended and was removed; it's finished before the battle message is updated
+}
+
+// doubleUpdate is an update to a double battle's message
+type doubleUpdate struct {
+	channelID  string
+	messageID  string
+	messages   []string // Shown below the mentions
+	waiting    []string // Trainers to ping to choose their moves
+	round      int
+	embed      *discordgo.MessageEmbed
+	components []discordgo.MessageComponent
+	card       *doubleCard // Set when gophers were sent out and the battle card needs redrawing
+}
+
+// doubleCard is a copy of the active gophers a double battle card shows, so the card can be
+// drawn without holding doubleMu
+type doubleCard struct {
+	name        string
+	top, bottom []*storage.Gopher
+}
+
+func (h *Handlers) handleDoubleComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+	discordID := i.Member.User.ID
+
+	parts := strings.Split(data.CustomID, "_")
+	if len(parts) < 3 {
+		respondEphemeral(s, i, "Unknown action")
+		return
+	}
+	action := parts[1]
+	battleID := parts[2]
+	args := []int{}
+	for _, part := range parts[3:] {
+		parsed, err := strconv.Atoi(part)
+		if err != nil {
+			respondEphemeral(s, i, "Invalid selection")
+			return
+		}
+		args = append(args, parsed)
+	}
+	arg := func(n int) int {
+		if n < len(args) {
+			return args[n]
+		}
+		return -1
+	}
+
+	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	h.doubleMu.Lock()
+	expired := h.removeExpiredDoubles()
+	doubleState, reply := h.doubleComponentReply(battleID, trainer.ID, action, arg)
+	h.doubleMu.Unlock()
+
+	h.finishExpiredDoubles(s, expired)
+	h.sendDoubleReply(s, i, doubleState, reply)
+}
+
+// doubleComponentReply runs a button press against its battle and builds the reply.
+// Caller must hold doubleMu.
+func (h *Handlers) doubleComponentReply(battleID, trainerID, action string, arg func(int) int) (*game.DoubleBattleState, *doubleReply) {
+	doubleState := h.doubles[battleID]
+	if doubleState == nil {
+		return nil, &doubleReply{ephemeral: "Battle not found or already ended"}
+	}
+	if doubleState.SideOf(trainerID) < 0 {
+		return doubleState, &doubleReply{ephemeral: "This isn't your battle!"}
+	}
+
+	switch action {
+	case "move":
+		slot := doubleState.NextSlot(trainerID)
+		if slot < 0 {
+			return doubleState, &doubleReply{ephemeral: "You've already chosen your moves this round! Waiting for the others..."}
+		}
+		content, components := h.doubleMenu(doubleState, trainerID, slot, "")
+		return doubleState, &doubleReply{menu: content, menuComponents: components, newMenu: true}
+	case "ability":
+		slot, index := arg(0), arg(1)
+		if doubleState.NeedsTarget(trainerID, slot, index) {
+			if reason := doubleState.CheckAction(trainerID, slot, game.DoubleAction{Type: "fight", Index: index}); reason != "" {
+				return doubleState, &doubleReply{ephemeral: reason}
+			}
+			content, components := h.doubleTargetMenu(doubleState, trainerID, slot, index)
+			return doubleState, &doubleReply{menu: content, menuComponents: components}
+		}
+		return doubleState, h.executeDoubleChoice(doubleState, trainerID, slot, game.DoubleAction{Type: "fight", Index: index})
+	case "target":
+		return doubleState, h.executeDoubleChoice(doubleState, trainerID, arg(0), game.DoubleAction{Type: "fight", Index: arg(1), Target: arg(2)})
+	case "rest":
+		return doubleState, h.executeDoubleChoice(doubleState, trainerID, arg(0), game.DoubleAction{Type: "rest"})
+	case "swap", "back":
+		slot := arg(0)
+		if doubleState.NextSlot(trainerID) != slot {
+			return doubleState, &doubleReply{ephemeral: "That menu is out of date. Use Choose Moves again."}
+		}
+		content, components := h.doubleMenu(doubleState, trainerID, slot, action)
+		return doubleState, &doubleReply{menu: content, menuComponents: components}
+	case "swapto":
+		return doubleState, h.executeDoubleChoice(doubleState, trainerID, arg(0), game.DoubleAction{Type: "swap", Index: arg(1)})
+	case "run":
+		return doubleState, h.executeDoubleChoice(doubleState, trainerID, -1, game.DoubleAction{Type: "run"})
+	case "forfeit":
+		if doubleState.Format != game.DoubleFormatPvP || doubleState.IsOver() {
+			return doubleState, &doubleReply{ephemeral: "You can't forfeit this battle!"}
+		}
+		messages := doubleState.Forfeit(trainerID)
+		delete(h.doubles, doubleState.ID)
+		return doubleState, &doubleReply{battle: h.newDoubleUpdate(doubleState, messages), finished: true}
+	}
+	return doubleState, &doubleReply{ephemeral: "Unknown action"}
+}
+
+// executeDoubleChoice records the trainer's action for one of their gophers from their private
+// menu. The menu moves on to their next gopher, and once both sides have chosen the round
+// resolves and the battle message is updated. A battle that ended is removed, and finished once
+// the reply is sent. Caller must hold doubleMu.
+func (h *Handlers) executeDoubleChoice(doubleState *game.DoubleBattleState, trainerID string, slot int, action game.DoubleAction) *doubleReply {
+	if reason := doubleState.CheckAction(trainerID, slot, action); reason != "" {
+		return &doubleReply{ephemeral: reason}
+	}
+
+	chosen := describeDoubleAction(doubleState, trainerID, slot, action)
+	lineup := doubleLineup(doubleState)
+	messages := doubleState.Choose(trainerID, slot, action)
+
+	if messages == nil {
+		if next := doubleState.NextSlot(trainerID); next >= 0 {
+			content, components := h.doubleMenu(doubleState, trainerID, next, "")
+			return &doubleReply{menu: fmt.Sprintf("✅ %s\n%s", chosen, content), menuComponents: components}
+		}
+		return &doubleReply{
+			menu:   fmt.Sprintf("✅ %s Waiting for the others...", chosen),
+			battle: h.newDoubleUpdate(doubleState, nil),
+		}
+	}
+
+	reply := &doubleReply{menu: fmt.Sprintf("✅ %s", chosen), battle: h.newDoubleUpdate(doubleState, messages)}
+	if doubleState.IsOver() {
+		delete(h.doubles, doubleState.ID)
+		reply.finished = true
+	} else if doubleLineup(doubleState) != lineup {
+		// Gophers were sent out, so the battle card needs to be redrawn
+		reply.battle.card = h.copyDoubleCard(doubleState)
+	}
+	return reply
+}
+
+// sendDoubleReply sends a reply built under doubleMu, finishing the battle first if it ended.
+// Caller must not hold doubleMu.
+func (h *Handlers) sendDoubleReply(s *discordgo.Session, i *discordgo.InteractionCreate, doubleState *game.DoubleBattleState, reply *doubleReply) {
+	switch {
+	case reply.ephemeral != "":
+		respondEphemeral(s, i, reply.ephemeral)
+		return
+	case reply.newMenu:
+		respondWithComponents(s, i, reply.menu, nil, reply.menuComponents, true)
+		return
+	case reply.menu != "":
+		updatePrivateMenu(s, i, reply.menu, reply.menuComponents)
+	}
+
+	update := reply.battle
+	if update == nil {
+		return
+	}
+	if reply.finished {
+		// Nothing else can reach the battle once it's removed
+		update.messages = append(update.messages, h.finishDoubleBattle(s, doubleState)...)
+		update.embed = h.createDoubleEmbed(doubleState)
+		update.components = h.createDoubleButtons(doubleState)
+	}
+
+	if reply.menu == "" {
+		updatePvPMessage(s, i, h.doubleUpdateContent(update), update.embed, update.components)
+		return
+	}
+	if update.card != nil {
+		h.resendDoubleMessage(s, doubleState, update)
+		return
+	}
+	h.editDoubleMessage(s, update)
+}
+
+// finishDoubleBattle saves what the battle changed and hands out its rewards. PvP battles update
+// both trainers' ratings; battles against wild gophers and NPC trainers save the party and XP.
+// The battle must already be removed from doubles, and the caller must not hold doubleMu.
+func (h *Handlers) finishDoubleBattle(s *discordgo.Session, doubleState *game.DoubleBattleState) []string {
+	h.saveDoubleReplay(doubleState)
+	if doubleState.State == game.DoubleStateExpired {
+		return nil
+	}
+
+	first, second := doubleState.Sides[0], doubleState.Sides[1]
+	winner := doubleState.WinnerSide()
+
+	if doubleState.Format == game.DoubleFormatPvP {
+		result := game.PvPStateDraw
+		switch winner {
+		case 0:
+			result = game.PvPStateTrainer1Won
+		case 1:
+			result = game.PvPStateTrainer2Won
+		}
+		messages := h.updatePvPRatings(first.TrainerID, first.Name, second.TrainerID, second.Name, result)
+
+		// Announce unlocks after the battle message has been updated
+		defer h.evaluateAchievements(s, doubleState.ChannelID, first.TrainerID)
+		defer h.evaluateAchievements(s, doubleState.ChannelID, second.TrainerID)
+		if winner >= 0 {
+			defer h.progressQuests(s, doubleState.ChannelID, doubleState.Sides[winner].TrainerID, game.QuestWinBattles, game.QuestWin10Battles)
+		}
+		return messages
+	}
+
+	trainerID := first.TrainerID
+	questProgress := []string{}
+	messages := []string{}
+
+	// Gophers that leveled up may evolve or learn new moves
+	leveledUp := strings.Contains(strings.Join(doubleState.Log, " "), "leveled up")
+	if leveledUp {
+		evolutionMsgs, evolved := h.evolveGophers(trainerID, first.Participants)
+		messages = append(messages, evolutionMsgs...)
+		for n := 0; n < evolved; n++ {
+			questProgress = append(questProgress, game.QuestEvolveGopher)
+		}
+	}
+
+	// Save HP and XP before checking for blackout
+	for _, gopher := range first.Party {
+		h.gopherRepo.UpdateStats(h.gameGopherToStorage(gopher))
+	}
+	if leveledUp {
+		for _, gopher := range first.Participants {
+			messages = append(messages, h.offerNewMoves(s, doubleState.ChannelID, trainerID, gopher)...)
+		}
+	}
+
+	// Wild gophers that weren't beaten keep their wounds; NPC trainers' gophers are never stored
+	if doubleState.Format == game.DoubleFormatWild && winner != 0 {
+		for _, gopher := range second.Party {
+			h.gopherRepo.UpdateStats(h.gameGopherToStorage(gopher))
+		}
+	}
+
+	switch doubleState.State {
+	case game.DoubleStateWon:
+		if doubleState.Opponent != nil {
+			messages = append(messages, "")
+			messages = append(messages, h.rewardTrainerBattle(trainerID, doubleState.Opponent)...)
+		}
+		questProgress = append(questProgress, game.QuestWinBattles, game.QuestWin10Battles)
+	case game.DoubleStateLost:
+		if blackedOut, blackoutMsg := h.gameService.CheckAndHandleBlackout(trainerID); blackedOut {
+			messages = append(messages, "", blackoutMsg)
+		}
+	}
+
+	if doubleState.State == game.DoubleStateWon || doubleState.State == game.DoubleStateLost {
+		if err := h.statsRepo.IncrementBattles(trainerID, doubleState.State == game.DoubleStateWon); err != nil {
+			log.Printf("Error recording battle stats: %v", err)
+		}
+	}
+	if doubleState.XPAwarded > 0 {
+		if err := h.statsRepo.AddXP(trainerID, doubleState.XPAwarded); err != nil {
+			log.Printf("Error recording XP stats: %v", err)
+		}
+	}
+	usage := make(map[string]string)
+	for _, gopher := range first.Participants {
+		usage[gopher.ID] = gopher.SpeciesArchetype
+	}
+	if err := h.statsRepo.RecordGopherUsage(trainerID, usage); err != nil {
+		log.Printf("Error recording gopher usage: %v", err)
+	}
+
+	if len(questProgress) > 0 {
+		h.progressQuests(s, doubleState.ChannelID, trainerID, questProgress...)
+	}
+	h.evaluateAchievements(s, doubleState.ChannelID, trainerID)
+
+	return messages
+}
+
+// saveDoubleReplay stores a finished double battle so its trainers can replay it
+func (h *Handlers) saveDoubleReplay(doubleState *game.DoubleBattleState) {
+	first, second := doubleState.Sides[0], doubleState.Sides[1]
+
+	result := "Draw"
+	switch doubleState.State {
+	case game.DoubleStateWon, game.DoubleStateLost:
+		result = fmt.Sprintf("%s won", doubleState.Sides[doubleState.WinnerSide()].Name)
+		if doubleState.Format != game.DoubleFormatPvP {
+			result = "Won"
+			if doubleState.State == game.DoubleStateLost {
+				result = "Lost"
+			}
+		}
+	case game.DoubleStateEscaped:
+		result = "Escaped"
+	case game.DoubleStateExpired:
+		result = "Abandoned"
+	}
+
+	replay := &storage.BattleReplay{
+		ID:         doubleState.ID,
+		BattleType: storage.ReplayTypeWild,
+		Trainer1ID: first.TrainerID,
+		Result:     result,
+		Turns:      doubleState.Round,
+		Seed:       doubleState.Seed,
+	}
+	switch doubleState.Format {
+	case game.DoubleFormatWild:
+		names := []string{}
+		for _, gopher := range second.Party {
+			names = append(names, gopher.Name)
+		}
+		replay.Title = fmt.Sprintf("%s vs wild %s", first.Name, strings.Join(names, " and "))
+	case game.DoubleFormatTrainer:
+		replay.BattleType = storage.ReplayTypeTrainer
+		replay.Title = fmt.Sprintf("%s vs %s (double)", first.Name, doubleState.Opponent.DisplayName())
+	case game.DoubleFormatPvP:
+		trainer2ID := second.TrainerID
+		replay.BattleType = storage.ReplayTypePvP
+		replay.Trainer2ID = &trainer2ID
+		replay.Title = fmt.Sprintf("%s vs %s (double)", first.Name, second.Name)
+	}
+
+	h.saveReplay(replay, doubleState.Events)
+}
+
+// findDoubleBattleByTrainer returns the double battle a trainer is fighting in.
+// Caller must hold doubleMu.
+func (h *Handlers) findDoubleBattleByTrainer(trainerID string) *game.DoubleBattleState {
+	for _, doubleState := range h.doubles {
+		if doubleState.SideOf(trainerID) >= 0 {
+			return doubleState
+		}
+	}
+	return nil
+}
+
+// inDoubleBattle reports whether a trainer is fighting in a double battle
+func (h *Handlers) inDoubleBattle(trainerID string) bool {
+	h.doubleMu.Lock()
+	defer h.doubleMu.Unlock()
+	return h.findDoubleBattleByTrainer(trainerID) != nil
+}
+
+// removeExpiredDoubles calls off and removes battles whose round nobody finished choosing in
+// time, returning them for finishExpiredDoubles. Caller must hold doubleMu.
+func (h *Handlers) removeExpiredDoubles() []*game.DoubleBattleState {
+	expired := []*game.DoubleBattleState{}
+	for id, doubleState := range h.doubles {
+		if doubleState.IsExpired() {
+			doubleState.Expire()
+			delete(h.doubles, id)
+			expired = append(expired, doubleState)
+		}
+	}
+	return expired
+}
+
+// finishExpiredDoubles finishes the battles removeExpiredDoubles called off.
+// Caller must not hold doubleMu.
+func (h *Handlers) finishExpiredDoubles(s *discordgo.Session, expired []*game.DoubleBattleState) {
+	for _, doubleState := range expired {
+		h.finishDoubleBattle(s, doubleState)
+		h.editDoubleMessage(s, h.newDoubleUpdate(doubleState, nil))
+	}
+}
+
+// waitingDoubleTrainers returns the trainers who still need to choose moves this round.
+// Caller must hold doubleMu while the battle is shared.
+func waitingDoubleTrainers(doubleState *game.DoubleBattleState) []string {
+	if doubleState.IsOver() {
+		return nil
+	}
+
+	trainerIDs := []string{}
+	for _, side := range doubleState.Sides {
+		if !side.IsComputer() && !side.HasChosen() {
+			trainerIDs = append(trainerIDs, side.TrainerID)
+		}
+	}
+	return trainerIDs
+}
+
+// doubleMentions pings the trainers who still need to choose moves for the round
+func (h *Handlers) doubleMentions(trainerIDs []string, round int) string {
+	mentions := []string{}
+	for _, trainerID := range trainerIDs {
+		trainer, err := h.trainerRepo.GetByID(trainerID)
+		if err != nil || trainer == nil {
+			continue
+		}
+		mentions = append(mentions, fmt.Sprintf("<@%s>", trainer.DiscordID))
+	}
+	if len(mentions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s, choose your moves for round %d!", strings.Join(mentions, " "), round)
+}
+
+// doubleMenu builds a trainer's private menu for one of their gophers. view is "swap" for the
+// party list, or "" for its abilities.
+func (h *Handlers) doubleMenu(doubleState *game.DoubleBattleState, trainerID string, slot int, view string) (string, []discordgo.MessageComponent) {
+	side := doubleState.Sides[doubleState.SideOf(trainerID)]
+	gopher := side.Active[slot]
+	content := fmt.Sprintf("Round %d: what will %s do? (HP %d/%d • ⚡ %d/%d)", doubleState.Round, gopher.Name,
+		gopher.CurrentHP, gopher.MaxHP, doubleState.Energy(gopher), game.MaxEnergy(gopher))
+
+	if view == "swap" {
+		buttons := []discordgo.MessageComponent{}
+		for idx, member := range side.Party {
+			if member.CurrentHP <= 0 || member == side.Active[0] || member == side.Active[1] {
+				continue
+			}
+
+			label := fmt.Sprintf("%s (%d/%d)", member.Name, member.CurrentHP, member.MaxHP)
+			if len(label) > 80 {
+				label = label[:77] + "..."
+			}
+			buttons = append(buttons, createButton(label, discordgo.SecondaryButton, fmt.Sprintf("double_swapto_%s_%d_%d", doubleState.ID, slot, idx)))
+
+			if len(buttons) >= 5 {
+				break // Discord limit
+			}
+		}
+
+		rows := []discordgo.MessageComponent{}
+		if len(buttons) > 0 {
+			rows = append(rows, discordgo.ActionsRow{Components: buttons})
+		} else {
+			content += "\nNo other gophers can battle!"
+		}
+		rows = append(rows, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				createButton("Back", discordgo.SecondaryButton, fmt.Sprintf("double_back_%s_%d", doubleState.ID, slot)),
+			},
+		})
+		return content, rows
+	}
+
+	buttons := []discordgo.MessageComponent{}
+	for idx, ability := range gopher.Abilities {
+		if idx >= 5 {
+			break // Discord limit
+		}
+		label := fmt.Sprintf("%s (%d⚡)", ability.Name, ability.Cost)
+		if ability.Targeting == game.TargetingBoth {
+			label += " 💥"
+		}
+		buttons = append(buttons, &ButtonWithoutEmoji{
+			Label:    label,
+			Style:    discordgo.PrimaryButton,
+			Disabled: !doubleState.CanAfford(gopher, ability),
+			CustomID: fmt.Sprintf("double_ability_%s_%d_%d", doubleState.ID, slot, idx),
+		})
+	}
+
+	actions := []discordgo.MessageComponent{
+		createButton("Rest", discordgo.SuccessButton, fmt.Sprintf("double_rest_%s_%d", doubleState.ID, slot)),
+		createButton("Swap", discordgo.SecondaryButton, fmt.Sprintf("double_swap_%s_%d", doubleState.ID, slot)),
+	}
+	if doubleState.Format == game.DoubleFormatWild {
+		actions = append(actions, createButton("Run", discordgo.DangerButton, fmt.Sprintf("double_run_%s", doubleState.ID)))
+	}
+
+	rows := []discordgo.MessageComponent{}
+	if len(buttons) > 0 {
+		rows = append(rows, discordgo.ActionsRow{Components: buttons})
+	}
+	rows = append(rows, discordgo.ActionsRow{Components: actions})
+	return content, rows
+}
+
+// doubleTargetMenu asks which foe a single-target ability should hit
+func (h *Handlers) doubleTargetMenu(doubleState *game.DoubleBattleState, trainerID string, slot, index int) (string, []discordgo.MessageComponent) {
+	sideIdx := doubleState.SideOf(trainerID)
+	gopher := doubleState.Sides[sideIdx].Active[slot]
+	foes := doubleState.Sides[1-sideIdx]
+
+	buttons := []discordgo.MessageComponent{}
+	for foeSlot, foe := range foes.Active {
+		if foe == nil || foe.CurrentHP <= 0 {
+			continue
+		}
+		label := fmt.Sprintf("%s (%d/%d)", foe.Name, foe.CurrentHP, foe.MaxHP)
+		buttons = append(buttons, createButton(label, discordgo.DangerButton, fmt.Sprintf("double_target_%s_%d_%d_%d", doubleState.ID, slot, index, foeSlot)))
+	}
+	buttons = append(buttons, createButton("Back", discordgo.SecondaryButton, fmt.Sprintf("double_back_%s_%d", doubleState.ID, slot)))
+
+	content := fmt.Sprintf("Who should %s use %s on?", gopher.Name, gopher.Abilities[index].Name)
+	return content, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: buttons},
+	}
+}
+
+// describeDoubleAction describes a chosen action for the trainer's private menu
+func describeDoubleAction(doubleState *game.DoubleBattleState, trainerID string, slot int, action game.DoubleAction) string {
+	sideIdx := doubleState.SideOf(trainerID)
+	side := doubleState.Sides[sideIdx]
+
+	switch action.Type {
+	case "run":
+		return "You'll try to run away."
+	case "rest":
+		return fmt.Sprintf("%s will rest.", side.Active[slot].Name)
+	case "swap":
+		return fmt.Sprintf("%s will switch out for %s.", side.Active[slot].Name, side.Party[action.Index].Name)
+	}
+
+	gopher := side.Active[slot]
+	ability := gopher.Abilities[action.Index]
+	switch {
+	case ability.Targeting == game.TargetingBoth:
+		return fmt.Sprintf("%s will use %s on both foes.", gopher.Name, ability.Name)
+	case ability.Targeting == game.TargetingSelf:
+		return fmt.Sprintf("%s will use %s.", gopher.Name, ability.Name)
+	}
+	if foe := doubleState.Sides[1-sideIdx].Active[action.Target]; foe != nil && doubleState.NeedsTarget(trainerID, slot, action.Index) {
+		return fmt.Sprintf("%s will use %s on %s.", gopher.Name, ability.Name, foe.Name)
+	}
+	return fmt.Sprintf("%s will use %s.", gopher.Name, ability.Name)
+}
+
+// doubleLineup identifies the gophers in battle, to tell when the battle card is out of date
+func doubleLineup(doubleState *game.DoubleBattleState) string {
+	ids := []string{}
+	for _, side := range doubleState.Sides {
+		for _, gopher := range side.Active {
+			if gopher != nil {
+				ids = append(ids, gopher.ID)
+			} else {
+				ids = append(ids, "-")
+			}
+		}
+	}
+	return strings.Join(ids, ",")
+}
+
+func (h *Handlers) createDoubleEmbed(doubleState *game.DoubleBattleState) *discordgo.MessageEmbed {
+	// Show last 8 log entries
+	start := len(doubleState.Log) - 8
+	if start < 0 {
+		start = 0
+	}
+
+	color := 0xff9900
+	switch doubleState.State {
+	case game.DoubleStateWon, game.DoubleStateLost:
+		color = 0xffd700
+	case game.DoubleStateDraw, game.DoubleStateEscaped, game.DoubleStateExpired:
+		color = 0x808080
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("⚔️ Double Battle: %s vs %s", doubleState.Sides[0].Name, doubleState.Sides[1].Name),
+		Description: strings.Join(doubleState.Log[start:], "\n"),
+		Color:       color,
+		Fields:      []*discordgo.MessageEmbedField{},
+	}
+
+	// The second side is drawn on top of the battle card, so list it first
+	for _, sideIdx := range []int{1, 0} {
+		side := doubleState.Sides[sideIdx]
+		lines := []string{}
+		for _, gopher := range side.Active {
+			if gopher == nil {
+				continue
+			}
+			line := fmt.Sprintf("**%s** Lv.%d [%s]", gopher.Name, gopher.Level, gopher.PrimaryType)
+			if !side.IsComputer() && doubleState.State == game.DoubleStateActive && side.HasChosen() {
+				line += " ✅"
+			}
+			line += fmt.Sprintf("\nHP: %s %d/%d • ⚡ %d", game.GetHPBar(gopher.CurrentHP, gopher.MaxHP, 10), gopher.CurrentHP, gopher.MaxHP, doubleState.Energy(gopher))
+			lines = append(lines, line)
+		}
+		if len(lines) == 0 {
+			lines = append(lines, "No gophers left 💀")
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s (gophers left: %d/%d)", side.Name, side.GophersLeft(), len(side.Party)),
+			Value:  strings.Join(lines, "\n"),
+			Inline: false,
+		})
+	}
+
+	if field := fieldEmbedField(doubleState.Field); field != nil && !doubleState.IsOver() {
+		embed.Fields = append(embed.Fields, field)
+	}
+
+	if doubleState.IsOver() {
+		result := "Draw"
+		switch doubleState.State {
+		case game.DoubleStateWon, game.DoubleStateLost:
+			result = fmt.Sprintf("%s wins!", doubleState.Sides[doubleState.WinnerSide()].Name)
+		case game.DoubleStateEscaped:
+			result = "Got away safely"
+		case game.DoubleStateExpired:
+			result = "Called off"
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Battle Result",
+			Value:  result,
+			Inline: false,
+		})
+	} else {
+		embed.Footer = &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Round %d/%d • 💥 moves hit both foes at %d%% power", doubleState.Round, game.DoubleMaxRounds, int(game.SpreadMultiplier*100)),
+		}
+	}
+
+	return embed
+}
+
+func (h *Handlers) createDoubleButtons(doubleState *game.DoubleBattleState) []discordgo.MessageComponent {
+	if doubleState.IsOver() {
+		return nil
+	}
+
+	buttons := []discordgo.MessageComponent{
+		createButton("Choose Moves", discordgo.PrimaryButton, fmt.Sprintf("double_move_%s", doubleState.ID)),
+	}
+	if doubleState.Format == game.DoubleFormatPvP {
+		buttons = append(buttons, createButton("Forfeit", discordgo.DangerButton, fmt.Sprintf("double_forfeit_%s", doubleState.ID)))
+	}
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: buttons},
+	}
+}
+
+// copyDoubleCard copies the gophers in battle for the battle card. Caller must hold doubleMu
+// while the battle is shared.
+func (h *Handlers) copyDoubleCard(doubleState *game.DoubleBattleState) *doubleCard {
+	sideStorage := func(side *game.DoubleSide) []*storage.Gopher {
+		gophers := []*storage.Gopher{}
+		for _, gopher := range side.Active {
+			if gopher != nil {
+				gophers = append(gophers, h.gameGopherToStorage(gopher))
+			}
+		}
+		return gophers
+	}
+
+	return &doubleCard{
+		name:   fmt.Sprintf("double_%s_%d.png", doubleState.ID[:8], doubleState.Round),
+		top:    sideStorage(doubleState.Sides[1]),
+		bottom: sideStorage(doubleState.Sides[0]),
+	}
+}
+
+// createDoubleCardFile renders the battle card for the gophers in battle
+func (h *Handlers) createDoubleCardFile(card *doubleCard) *discordgo.File {
+	cardBase64, err := h.gameService.GenerateDoubleBattleCard(card.top, card.bottom)
+	if err != nil || cardBase64 == "" {
+		if err != nil {
+			log.Printf("Error generating double battle card: %v", err)
+		}
+		return nil
+	}
+
+	fileData, err := base64.StdEncoding.DecodeString(cardBase64)
+	if err != nil {
+		log.Printf("Error decoding double battle card base64: %v", err)
+		return nil
+	}
+
+	return &discordgo.File{
+		Name:        card.name,
+		ContentType: "image/png",
+		Reader:      bytes.NewReader(fileData),
+	}
+}
+
+// editDoubleMessage updates the battle's message in its channel. The battle card stays attached.
+func (h *Handlers) editDoubleMessage(s *discordgo.Session, update *doubleUpdate) {
+	components := update.components
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+
+	content := h.doubleUpdateContent(update)
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    update.channelID,
+		ID:         update.messageID,
+		Content:    &content,
+		Embeds:     []*discordgo.MessageEmbed{update.embed},
+		Components: components,
+	})
+	if err != nil {
+		log.Printf("Error editing double battle message: %v", err)
+	}
+}
+
+// resendDoubleMessage replaces the battle message with a new one carrying an updated battle card.
+// Caller must not hold doubleMu.
+func (h *Handlers) resendDoubleMessage(s *discordgo.Session, doubleState *game.DoubleBattleState, update *doubleUpdate) {
+	cardFile := h.createDoubleCardFile(update.card)
+	if cardFile == nil {
+		h.editDoubleMessage(s, update)
+		return
+	}
+
+	update.embed.Image = &discordgo.MessageEmbedImage{URL: fmt.Sprintf("attachment://%s", cardFile.Name)}
+	msg, err := s.ChannelMessageSendComplex(update.channelID, &discordgo.MessageSend{
+		Content:    h.doubleUpdateContent(update),
+		Embeds:     []*discordgo.MessageEmbed{update.embed},
+		Components: update.components,
+		Files:      []*discordgo.File{cardFile},
+	})
+	if err != nil {
+		log.Printf("Error sending updated double battle message: %v", err)
+		update.embed.Image = nil
+		h.editDoubleMessage(s, update)
+		return
+	}
+
+	h.doubleMu.Lock()
+	doubleState.MessageID = msg.ID
+	h.doubleMu.Unlock()
+	if err := s.ChannelMessageDelete(update.channelID, update.messageID); err != nil {
+		log.Printf("Error deleting old double battle message: %v", err)
+	}
+}
+
+// newDoubleUpdate copies the battle message out of the battle. Caller must hold doubleMu while
+// the battle is shared.
+func (h *Handlers) newDoubleUpdate(doubleState *game.DoubleBattleState, messages []string) *doubleUpdate {
+	return &doubleUpdate{
+		channelID:  doubleState.ChannelID,
+		messageID:  doubleState.MessageID,
+		messages:   messages,
+		waiting:    waitingDoubleTrainers(doubleState),
+		round:      doubleState.Round,
+		embed:      h.createDoubleEmbed(doubleState),
+		components: h.createDoubleButtons(doubleState),
+	}
+}
+
+// doubleUpdateContent returns the update's message text, below pings for the trainers who still
+// need to choose
+func (h *Handlers) doubleUpdateContent(update *doubleUpdate) string {
+	content := strings.Join(update.messages, "\n")
+	mentions := h.doubleMentions(update.waiting, update.round)
+	switch {
+	case mentions == "":
+		return content
+	case content == "":
+		return mentions
+	}
+	return mentions + "\n" + content
+}
+
+// updatePrivateMenu updates the private menu message the component was clicked on
+func updatePrivateMenu(s *discordgo.Session, i *discordgo.InteractionCreate, content string, components []discordgo.MessageComponent) {
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: components,
+		},
+	})
+	if err != nil {
+		log.Printf("Error updating double battle menu: %v", err)
+	}
+}
@@ -0,0 +1,321 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gophermon-bot/internal/game"
+	"gophermon-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	gopherdexCardName      = "gopherdex.png"
+	gopherdexNamesPerField = 15
+)
+
+// Gopherdex pages: page 0 is the overview, then one page per archetype.
+// Button custom IDs have the form gopherdex_<page>
+var (
+	gopherdexArchetypes = []game.Archetype{
+		game.ArchetypeHacker, game.ArchetypeTank, game.ArchetypeSpeedy, game.ArchetypeSupport, game.ArchetypeMage,
+	}
+	gopherdexRarities = []game.Rarity{
+		game.RarityCommon, game.RarityUncommon, game.RarityRare, game.RarityEpic, game.RarityLegendary,
+	}
+)
+
+func (h *Handlers) handleGopherdex(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	discordID := i.Member.User.ID
+
+	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	// Building the sprite grid can take a moment
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	entries, err := h.gopherdexRepo.GetEntries(trainer.ID)
+	if err != nil {
+		content := fmt.Sprintf("Error loading Gopherdex: %v", err)
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+		return
+	}
+
+	embed := h.createGopherdexEmbed(trainer, entries, 0)
+	components := createGopherdexButtons(0)
+	edit := &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
+	}
+
+	if cardFile := h.createGopherdexCardFile(trainer.ID); cardFile != nil {
+		embed.Image = &discordgo.MessageEmbedImage{URL: fmt.Sprintf("attachment://%s", cardFile.Name)}
+		edit.Files = []*discordgo.File{cardFile}
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		log.Printf("Error sending Gopherdex: %v", err)
+	}
+}
+
+func (h *Handlers) handleGopherdexPage(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+
+	page, err := strconv.Atoi(strings.TrimPrefix(data.CustomID, "gopherdex_"))
+	if err != nil || page < 0 || page > len(gopherdexArchetypes) {
+		respondEphemeral(s, i, "Invalid page")
+		return
+	}
+
+	trainer, err := h.trainerRepo.GetByDiscordID(i.Member.User.ID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	entries, err := h.gopherdexRepo.GetEntries(trainer.ID)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error loading Gopherdex: %v", err))
+		return
+	}
+
+	embed := h.createGopherdexEmbed(trainer, entries, page)
+
+	// The sprite grid stays attached to the message, so every page can keep showing it
+	if i.Message != nil {
+		for _, attachment := range i.Message.Attachments {
+			if attachment.Filename == gopherdexCardName {
+				embed.Image = &discordgo.MessageEmbedImage{URL: fmt.Sprintf("attachment://%s", gopherdexCardName)}
+				break
+			}
+		}
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: createGopherdexButtons(page),
+		},
+	})
+	if err != nil {
+		log.Printf("Error updating Gopherdex: %v", err)
+	}
+}
+
+func (h *Handlers) createGopherdexEmbed(trainer *storage.Trainer, entries []*storage.GopherdexEntry, page int) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:  fmt.Sprintf("📖 %s's Gopherdex", trainer.Name),
+		Color:  0x9966ff,
+		Fields: []*discordgo.MessageEmbedField{},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("✅ caught • 👁️ seen • ❔ unknown • Page %d/%d", page+1, len(gopherdexArchetypes)+1),
+		},
+	}
+
+	// Group entries by archetype and rarity
+	grouped := make(map[string]map[string][]*storage.GopherdexEntry)
+	for _, entry := range entries {
+		if grouped[entry.Archetype] == nil {
+			grouped[entry.Archetype] = make(map[string][]*storage.GopherdexEntry)
+		}
+		grouped[entry.Archetype][entry.Rarity] = append(grouped[entry.Archetype][entry.Rarity], entry)
+	}
+
+	if page == 0 {
+		totalSpecies := len(gopherdexArchetypes) * len(gopherdexRarities)
+		seenSpecies, caughtSpecies := 0, 0
+		for _, archetype := range gopherdexArchetypes {
+			markers := []string{}
+			for _, rarity := range gopherdexRarities {
+				group := grouped[string(archetype)][rarity.String()]
+				marker := gopherdexGroupMarker(group)
+				if len(group) > 0 {
+					seenSpecies++
+				}
+				if marker == "✅" {
+					caughtSpecies++
+				}
+				markers = append(markers, fmt.Sprintf("%s %s", marker, rarity))
+			}
+			embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+				Name:   string(archetype),
+				Value:  strings.Join(markers, " · "),
+				Inline: false,
+			})
+		}
+
+		owned, discovered, err := h.gopherdexRepo.GetCompletion(trainer.ID)
+		if err != nil {
+			log.Printf("Error getting gopherdex completion: %v", err)
+		}
+
+		embed.Description = fmt.Sprintf("**Completion:** %d/%d species caught (%d%%)\n**Seen:** %d/%d species\n**Variants:** %d discovered, %d currently owned",
+			caughtSpecies, totalSpecies, caughtSpecies*100/totalSpecies,
+			seenSpecies, totalSpecies, discovered, owned)
+		return embed
+	}
+
+	archetype := gopherdexArchetypes[page-1]
+	embed.Title = fmt.Sprintf("📖 %s's Gopherdex — %s", trainer.Name, archetype)
+
+	for _, rarity := range gopherdexRarities {
+		group := grouped[string(archetype)][rarity.String()]
+
+		lines := []string{}
+		for idx, entry := range group {
+			if idx >= gopherdexNamesPerField {
+				lines = append(lines, fmt.Sprintf("...and %d more", len(group)-gopherdexNamesPerField))
+				break
+			}
+			line := fmt.Sprintf("%s **%s** — seen %d, caught %d", gopherdexEntryMarker(entry), entry.GopherName, entry.TimesEncountered, entry.TimesCaught)
+			if entry.Owned {
+				line += " (owned)"
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) == 0 {
+			lines = append(lines, "Not yet encountered")
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s %s", gopherdexGroupMarker(group), rarity),
+			Value:  strings.Join(lines, "\n"),
+			Inline: false,
+		})
+	}
+
+	return embed
+}
+
+// createGopherdexCardFile renders one owned gopher per caught archetype/rarity as a sprite grid
+func (h *Handlers) createGopherdexCardFile(trainerID string) *discordgo.File {
+	gophers, err := h.gopherRepo.GetByTrainerID(trainerID)
+	if err != nil || len(gophers) == 0 {
+		return nil
+	}
+
+	bySpecies := make(map[string]*storage.Gopher)
+	for _, gopher := range gophers {
+		if gopher.SpriteData == "" && gopher.SpritePath == "" {
+			continue
+		}
+		key := gopher.SpeciesArchetype + "|" + gopher.Rarity
+		// Prefer showing off a shiny if there is one
+		if existing, ok := bySpecies[key]; !ok || (gopher.Shiny && !existing.Shiny) {
+			bySpecies[key] = gopher
+		}
+	}
+
+	var grid []*storage.Gopher
+	for _, archetype := range gopherdexArchetypes {
+		for _, rarity := range gopherdexRarities {
+			if gopher, ok := bySpecies[string(archetype)+"|"+rarity.String()]; ok {
+				grid = append(grid, gopher)
+			}
+		}
+	}
+	if len(grid) == 0 {
+		return nil
+	}
+
+	cols := len(gopherdexRarities)
+	if len(grid) < cols {
+		cols = len(grid)
+	}
+
+	cardBase64, err := h.gameService.GenerateGopherCard(grid, cols)
+	if err != nil {
+		log.Printf("Error generating Gopherdex card: %v", err)
+		return nil
+	}
+
+	fileData, err := base64.StdEncoding.DecodeString(cardBase64)
+	if err != nil {
+		log.Printf("Error decoding Gopherdex card: %v", err)
+		return nil
+	}
+
+	return &discordgo.File{
+		Name:        gopherdexCardName,
+		ContentType: "image/png",
+		Reader:      bytes.NewReader(fileData),
+	}
+}
+
+func createGopherdexButtons(page int) []discordgo.MessageComponent {
+	buttons := []discordgo.MessageComponent{}
+	for p := 0; p <= len(gopherdexArchetypes); p++ {
+		label := "Overview"
+		if p > 0 {
+			label = string(gopherdexArchetypes[p-1])
+		}
+		style := discordgo.SecondaryButton
+		if p == page {
+			style = discordgo.PrimaryButton
+		}
+		buttons = append(buttons, &ButtonWithoutEmoji{
+			Label:    label,
+			Style:    style,
+			CustomID: fmt.Sprintf("gopherdex_%d", p),
+			Disabled: p == page,
+		})
+	}
+
+	// Discord allows at most 5 buttons per row
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: buttons[:3]},
+		discordgo.ActionsRow{Components: buttons[3:]},
+	}
+}
+
+// markGopherdexOwned records that a trainer now owns a gopher of this kind
+func (h *Handlers) markGopherdexOwned(trainerID string, gopher *storage.Gopher) {
+	if err := h.gopherdexRepo.SetOwned(trainerID, gopher.Name, gopher.SpeciesArchetype, gopher.Rarity, true); err != nil {
+		log.Printf("Error updating gopherdex ownership: %v", err)
+	}
+}
+
+// refreshGopherdexOwned clears the owned flag once a trainer has no gophers of this kind left
+func (h *Handlers) refreshGopherdexOwned(trainerID string, gopher *storage.Gopher) {
+	if err := h.gopherdexRepo.RefreshOwned(trainerID, gopher.Name, gopher.SpeciesArchetype, gopher.Rarity); err != nil {
+		log.Printf("Error updating gopherdex ownership: %v", err)
+	}
+}
+
+func gopherdexEntryMarker(entry *storage.GopherdexEntry) string {
+	if entry.TimesCaught > 0 || entry.Owned {
+		return "✅"
+	}
+	return "👁️"
+}
+
+// gopherdexGroupMarker marks an archetype/rarity group as caught, seen or unknown
+func gopherdexGroupMarker(group []*storage.GopherdexEntry) string {
+	if len(group) == 0 {
+		return "❔"
+	}
+	for _, entry := range group {
+		if gopherdexEntryMarker(entry) == "✅" {
+			return "✅"
+		}
+	}
+	return "👁️"
+}
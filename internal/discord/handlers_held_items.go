@@ -0,0 +1,103 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+
+	"gophermon-bot/internal/game"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleGopherHold gives a gopher a held item from the trainer's bag
+func (h *Handlers) handleGopherHold(s *discordgo.Session, i *discordgo.InteractionCreate, trainerID, gopherID, itemType string) {
+	gopher := h.heldItemGopher(s, i, trainerID, gopherID)
+	if gopher == nil {
+		return
+	}
+
+	previous := gopher.HeldItem
+	message, err := h.itemService.GiveHeldItem(trainerID, gopher, itemType)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Can't do that: %v", err))
+		return
+	}
+	if err := h.gopherRepo.Update(h.gameGopherToStorage(gopher)); err != nil {
+		h.revertHeldItem(trainerID, gopher, previous)
+		respondEphemeral(s, i, fmt.Sprintf("Error saving gopher: %v", err))
+		return
+	}
+
+	respondEphemeral(s, i, fmt.Sprintf("%s %s\n*%s*", itemEmojis[itemType], message, game.HeldItems[itemType].Description))
+}
+
+// handleGopherTake puts a gopher's held item back in the trainer's bag
+func (h *Handlers) handleGopherTake(s *discordgo.Session, i *discordgo.InteractionCreate, trainerID, gopherID string) {
+	gopher := h.heldItemGopher(s, i, trainerID, gopherID)
+	if gopher == nil {
+		return
+	}
+
+	itemType := gopher.HeldItem
+	message, err := h.itemService.TakeHeldItem(trainerID, gopher)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Can't do that: %v", err))
+		return
+	}
+	if err := h.gopherRepo.Update(h.gameGopherToStorage(gopher)); err != nil {
+		h.revertHeldItem(trainerID, gopher, itemType)
+		respondEphemeral(s, i, fmt.Sprintf("Error saving gopher: %v", err))
+		return
+	}
+
+	respondEphemeral(s, i, fmt.Sprintf("%s %s", itemEmojis[itemType], message))
+}
+
+// revertHeldItem puts the bag back the way it was when a held item change couldn't be saved
+func (h *Handlers) revertHeldItem(trainerID string, gopher *game.Gopher, previous string) {
+	if err := h.itemService.RevertHeldItem(trainerID, gopher, previous); err != nil {
+		log.Printf("Error reverting held item for gopher %s: %v", gopher.ID, err)
+	}
+}
+
+// heldItemGopher loads a gopher whose held item the trainer wants to change, responding and
+// returning nil if it can't be changed right now
+func (h *Handlers) heldItemGopher(s *discordgo.Session, i *discordgo.InteractionCreate, trainerID, gopherID string) *game.Gopher {
+	gopher, err := h.gopherRepo.GetByID(gopherID)
+	if err != nil || gopher == nil {
+		respondEphemeral(s, i, "Gopher not found")
+		return nil
+	}
+
+	if gopher.TrainerID == nil || *gopher.TrainerID != trainerID {
+		respondEphemeral(s, i, "This gopher doesn't belong to you")
+		return nil
+	}
+
+	// Battles keep their own copy of the party and save it when they end
+	if h.battleBusyReason(trainerID) != "" {
+		respondEphemeral(s, i, "You can't change held items during a battle!")
+		return nil
+	}
+
+	// Gophers in a pending trade are locked in escrow
+	if inTrade, err := h.tradeRepo.IsGopherInPendingTrade(gopherID); err != nil || inTrade {
+		respondEphemeral(s, i, "This gopher is part of a pending trade! Cancel the trade first.")
+		return nil
+	}
+
+	gameGopher, err := h.gameService.StorageGopherToGameGopher(gopher)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error: %v", err))
+		return nil
+	}
+	return gameGopher
+}
+
+// heldItemLabel describes a gopher's held item for embeds
+func heldItemLabel(itemType string) string {
+	if itemType == "" {
+		return "None"
+	}
+	return fmt.Sprintf("%s %s", itemEmojis[itemType], game.ItemNames[itemType])
+}
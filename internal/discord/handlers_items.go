@@ -0,0 +1,347 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gophermon-bot/internal/game"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Items that can be used from the Bag during a battle.
+// Button custom IDs have the form battle_bag_<item> (choose a target) and battle_item_<item>_<party index>
+var bagItems = []string{game.ItemTypePotion, game.ItemTypeRevive}
+
+var itemEmojis = map[string]string{
+	game.ItemTypePotion:         "💊",
+	game.ItemTypeRevive:         "💉",
+	game.ItemTypeXPBooster:      "⚡",
+	game.ItemTypeEvolutionStone: "💎",
+	game.ItemTypeShinyCharm:     "✨",
+
+	game.ItemTypeMechanicalKeyboard: "⌨️",
+	game.ItemTypeTitaniumCase:       "🛡️",
+	game.ItemTypeFiberCable:         "🔌",
+	game.ItemTypeRubberDuck:         "🦆",
+	game.ItemTypeCrystalCompiler:    "🔮",
+	game.ItemTypeCoffeeMug:          "☕",
+	game.ItemTypeRecoverCharm:       "🍀",
+	game.ItemTypeTurboScarf:         "🧣",
+}
+
+func (h *Handlers) handleItem(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	discordID := i.Member.User.ID
+
+	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	if len(data.Options) == 0 || data.Options[0].Name != "use" {
+		respondEphemeral(s, i, "Use /item use <item> <gopher_id>")
+		return
+	}
+
+	subCommand := data.Options[0]
+	itemType := ""
+	gopherID := ""
+	for _, opt := range subCommand.Options {
+		switch opt.Name {
+		case "item":
+			itemType = opt.StringValue()
+		case "gopher_id":
+			gopherID = opt.StringValue()
+		}
+	}
+
+	if itemType == game.ItemTypeXPBooster {
+		if err := h.itemService.ActivateXPBooster(trainer.ID); err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("Can't use that: %v", err))
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("⚡ XP Booster activated! Your next battle awards %.1fx XP.", game.XPBoosterMultiplier))
+		return
+	}
+
+	if gopherID == "" {
+		respondEphemeral(s, i, fmt.Sprintf("Choose a gopher to use the %s on with the gopher_id option.", game.ItemNames[itemType]))
+		return
+	}
+
+	gopher, err := h.gopherRepo.GetByID(gopherID)
+	if err != nil || gopher == nil {
+		respondEphemeral(s, i, "Gopher not found")
+		return
+	}
+
+	if gopher.TrainerID == nil || *gopher.TrainerID != trainer.ID {
+		respondEphemeral(s, i, "This gopher doesn't belong to you")
+		return
+	}
+
+	// The battle keeps its own copy of the party, so items go through the Bag instead
+	if h.findBattleByTrainer(trainer.ID) != nil {
+		respondEphemeral(s, i, "You're in a battle! Use the Bag button there instead.")
+		return
+	}
+	if h.inDoubleBattle(trainer.ID) {
+		respondEphemeral(s, i, "You're in a double battle! Items can't be used until it's over.")
+		return
+	}
+
+	gameGopher, err := h.gameService.StorageGopherToGameGopher(gopher)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	switch itemType {
+	case game.ItemTypePotion, game.ItemTypeRevive:
+		message, err := h.itemService.UseItemOn(trainer.ID, itemType, gameGopher)
+		if err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("Can't use that: %v", err))
+			return
+		}
+		if err := h.gopherRepo.Update(h.gameGopherToStorage(gameGopher)); err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("Error saving gopher: %v", err))
+			return
+		}
+		respondEphemeral(s, i, fmt.Sprintf("%s %s\nHP: %s", itemEmojis[itemType], message,
+			game.GetHPBar(gameGopher.CurrentHP, gameGopher.MaxHP, 12)))
+
+	case game.ItemTypeEvolutionStone:
+		// Generating the evolved sprite can take a moment
+		err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Flags: discordgo.MessageFlagsEphemeral,
+			},
+		})
+		if err != nil {
+			log.Printf("Error deferring interaction: %v", err)
+			return
+		}
+
+		content := h.useEvolutionStone(s, i.ChannelID, trainer.ID, gameGopher)
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content})
+
+	default:
+		respondEphemeral(s, i, fmt.Sprintf("%s can't be used on a gopher.", game.ItemNames[itemType]))
+	}
+}
+
+// useEvolutionStone evolves a gopher with an Evolution Stone and records the evolution
+// like one earned in battle. Returns the message to show the trainer.
+func (h *Handlers) useEvolutionStone(s *discordgo.Session, channelID, trainerID string, gopher *game.Gopher) string {
+	previousForm := h.gameGopherToStorage(gopher)
+
+	message, err := h.itemService.UseEvolutionStone(trainerID, gopher)
+	if err != nil {
+		return fmt.Sprintf("Can't use that: %v", err)
+	}
+
+	evolvedForm := h.gameGopherToStorage(gopher)
+	if err := h.gopherRepo.Update(evolvedForm); err != nil {
+		return fmt.Sprintf("Error saving gopher: %v", err)
+	}
+	if err := h.gameService.SaveAbilities(gopher); err != nil {
+		log.Printf("Error saving abilities after evolution: %v", err)
+	}
+
+	// Evolving can change rarity, which is a different Gopherdex entry
+	h.markGopherdexOwned(trainerID, evolvedForm)
+	h.refreshGopherdexOwned(trainerID, previousForm)
+	if err := h.statsRepo.IncrementEvolutions(trainerID); err != nil {
+		log.Printf("Error recording evolution stats: %v", err)
+	}
+	h.progressQuests(s, channelID, trainerID, game.QuestEvolveGopher)
+	h.evaluateAchievements(s, channelID, trainerID)
+
+	for _, learned := range h.offerNewMoves(s, channelID, trainerID, gopher) {
+		message += "\n" + learned
+	}
+
+	return fmt.Sprintf("%s Used an Evolution Stone!\n%s", itemEmojis[game.ItemTypeEvolutionStone], message)
+}
+
+// findBattleByTrainer returns the trainer's active wild battle, if any. Takes battlesMu.
+func (h *Handlers) findBattleByTrainer(trainerID string) *game.BattleState {
+	h.battlesMu.Lock()
+	defer h.battlesMu.Unlock()
+	for _, battle := range h.battles {
+		if battle.TrainerID == trainerID && battle.State == "ACTIVE" {
+			return battle
+		}
+	}
+	return nil
+}
+
+// showBagMenu displays the battle items the trainer has
+func (h *Handlers) showBagMenu(s *discordgo.Session, i *discordgo.InteractionCreate, battleState *game.BattleState) {
+	if battleState.State != "ACTIVE" || battleState.TurnOwner != "PLAYER" {
+		// Note: interaction already acknowledged in handleBattleAction, so we need to edit the message
+		h.editBattleMessage(s, i, "You can't use items right now!", h.createBattleEmbed(battleState), h.createBattleButtons(battleState, false))
+		return
+	}
+
+	buttons := []discordgo.MessageComponent{}
+	for _, itemType := range bagItems {
+		qty, err := h.itemRepo.GetItemQuantity(battleState.TrainerID, itemType)
+		if err != nil {
+			log.Printf("Error getting item quantity: %v", err)
+			continue
+		}
+		if qty <= 0 {
+			continue
+		}
+
+		label := fmt.Sprintf("%s %s x%d", itemEmojis[itemType], game.ItemNames[itemType], qty)
+		buttons = append(buttons, createButton(label, discordgo.SecondaryButton, fmt.Sprintf("battle_bag_%s", itemType)))
+	}
+
+	if len(buttons) == 0 {
+		h.editBattleMessage(s, i, "Your bag is empty! Buy Potions and Revives with /shop buy.", h.createBattleEmbed(battleState), h.createBattleButtons(battleState, false))
+		return
+	}
+
+	buttons = append(buttons, createButton("Back", discordgo.DangerButton, "battle_back"))
+
+	embed := h.createBattleEmbed(battleState)
+	embed.Description = "Choose an item to use:"
+	h.addBattleCardToEmbed(embed, battleState, false)
+
+	h.editBattleMessage(s, i, "", embed, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: buttons},
+	})
+}
+
+// handleBattleItem handles the Bag menus: picking an item shows the party members it can be
+// used on, and picking a party member uses the item, which takes the player's turn
+func (h *Handlers) handleBattleItem(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+	discordID := i.Member.User.ID
+
+	// Find battle
+	battleState := h.findBattleByMessage(i.ChannelID, i.Message.ID)
+	if battleState == nil {
+		respondEphemeral(s, i, "Battle not found")
+		return
+	}
+
+	// Verify ownership
+	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found")
+		return
+	}
+	if battleState.TrainerID != trainer.ID {
+		respondEphemeral(s, i, "This isn't your battle!")
+		return
+	}
+
+	// Acknowledge interaction
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("Error acknowledging item use: %v", err)
+		return
+	}
+
+	if itemType, ok := strings.CutPrefix(data.CustomID, "battle_bag_"); ok {
+		h.showItemTargetMenu(s, i, battleState, itemType)
+		return
+	}
+
+	// Parse item type and party member index
+	rest := strings.TrimPrefix(data.CustomID, "battle_item_")
+	sep := strings.LastIndex(rest, "_")
+	if sep < 0 {
+		h.editBattleMessage(s, i, "Invalid item selection", h.createBattleEmbed(battleState), h.createBattleButtons(battleState, false))
+		return
+	}
+
+	itemType := rest[:sep]
+	partyIndex, err := strconv.Atoi(rest[sep+1:])
+	if err != nil || partyIndex < 0 || partyIndex >= len(battleState.PlayerParty) {
+		h.editBattleMessage(s, i, "Invalid party member", h.createBattleEmbed(battleState), h.createBattleButtons(battleState, false))
+		return
+	}
+
+	// Use the item
+	messages, err := battleState.UseItem(h.itemService, itemType, partyIndex)
+	if err != nil {
+		h.editBattleMessage(s, i, fmt.Sprintf("Error: %v", err), h.createBattleEmbed(battleState), h.createBattleButtons(battleState, false))
+		return
+	}
+
+	messages = h.finishBattleTurn(s, battleState, messages)
+
+	// Update embed with battle card image (don't regenerate for regular actions)
+	embed := h.createBattleEmbed(battleState)
+	h.addBattleCardToEmbed(embed, battleState, false)
+
+	var components []discordgo.MessageComponent
+	if battleState.State == "ACTIVE" {
+		components = h.createBattleButtons(battleState, false)
+	}
+	h.editBattleMessage(s, i, strings.Join(messages, "\n"), embed, components)
+}
+
+// showItemTargetMenu displays the party members an item can be used on
+func (h *Handlers) showItemTargetMenu(s *discordgo.Session, i *discordgo.InteractionCreate, battleState *game.BattleState, itemType string) {
+	if battleState.State != "ACTIVE" || battleState.TurnOwner != "PLAYER" {
+		h.editBattleMessage(s, i, "You can't use items right now!", h.createBattleEmbed(battleState), h.createBattleButtons(battleState, false))
+		return
+	}
+
+	buttons := []discordgo.MessageComponent{}
+	for idx, gopher := range battleState.PlayerParty {
+		if gopher.ID == battleState.PlayerGopher.ID {
+			gopher = battleState.PlayerGopher
+		}
+
+		// Potions heal conscious gophers, Revives bring back fainted ones
+		fainted := gopher.CurrentHP <= 0
+		if itemType == game.ItemTypeRevive && !fainted {
+			continue
+		}
+		if itemType == game.ItemTypePotion && (fainted || gopher.CurrentHP >= gopher.MaxHP) {
+			continue
+		}
+
+		label := fmt.Sprintf("%s (HP %d/%d)", gopher.Name, gopher.CurrentHP, gopher.MaxHP)
+		if len(label) > 80 {
+			label = label[:77] + "..."
+		}
+		buttons = append(buttons, createButton(label, discordgo.SecondaryButton, fmt.Sprintf("battle_item_%s_%d", itemType, idx)))
+	}
+
+	if len(buttons) == 0 {
+		h.editBattleMessage(s, i, fmt.Sprintf("None of your gophers need a %s right now!", game.ItemNames[itemType]), h.createBattleEmbed(battleState), h.createBattleButtons(battleState, false))
+		return
+	}
+
+	buttons = append(buttons, createButton("Back", discordgo.DangerButton, "battle_back"))
+
+	// Discord allows 5 buttons per row
+	rows := []discordgo.MessageComponent{}
+	for start := 0; start < len(buttons); start += 5 {
+		end := start + 5
+		if end > len(buttons) {
+			end = len(buttons)
+		}
+		rows = append(rows, discordgo.ActionsRow{Components: buttons[start:end]})
+	}
+
+	embed := h.createBattleEmbed(battleState)
+	embed.Description = fmt.Sprintf("Choose a gopher to use the %s on:", game.ItemNames[itemType])
+	h.addBattleCardToEmbed(embed, battleState, false)
+
+	h.editBattleMessage(s, i, "", embed, rows)
+}
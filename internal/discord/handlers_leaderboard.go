@@ -0,0 +1,322 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"gophermon-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	leaderboardPageSize = 10
+	guildMemberCacheTTL = 10 * time.Minute
+)
+
+// guildMemberCache remembers which trainers belong to a guild
+type guildMemberCache struct {
+	trainerIDs []string
+	fetchedAt  time.Time
+}
+
+// leaderboardRow is a single rendered leaderboard entry
+type leaderboardRow struct {
+	trainerID string
+	value     string
+}
+
+// Leaderboard button custom IDs have the form leaderboard_<type>_<scope>_<page>
+
+func (h *Handlers) handleLeaderboard(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+
+	leaderboardType := storage.StatWins
+	scope := "global"
+	for _, option := range data.Options {
+		switch option.Name {
+		case "type":
+			leaderboardType = option.StringValue()
+		case "server_only":
+			if option.BoolValue() && i.GuildID != "" {
+				scope = "server"
+			}
+		}
+	}
+
+	embed, components, err := h.buildLeaderboard(s, i, leaderboardType, scope, 0)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error loading leaderboard: %v", err))
+		return
+	}
+
+	respondWithComponents(s, i, "", embed, components, true)
+}
+
+func (h *Handlers) handleLeaderboardPage(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+
+	parts := strings.Split(data.CustomID, "_")
+	if len(parts) != 4 {
+		respondEphemeral(s, i, "Unknown action")
+		return
+	}
+	page, err := strconv.Atoi(parts[3])
+	if err != nil || page < 0 {
+		respondEphemeral(s, i, "Invalid page")
+		return
+	}
+
+	embed, components, err := h.buildLeaderboard(s, i, parts[1], parts[2], page)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error loading leaderboard: %v", err))
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+	if err != nil {
+		log.Printf("Error updating leaderboard: %v", err)
+	}
+}
+
+// buildLeaderboard renders one page of a leaderboard along with the caller's own rank
+func (h *Handlers) buildLeaderboard(s *discordgo.Session, i *discordgo.InteractionCreate, leaderboardType, scope string, page int) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	var trainerIDs []string
+	if scope == "server" {
+		ids, err := h.guildTrainerIDs(s, i.GuildID)
+		if err != nil {
+			return nil, nil, err
+		}
+		trainerIDs = ids
+	}
+
+	var total int
+	var rows []leaderboardRow
+	var err error
+	if leaderboardType == "pvp" {
+		total, rows, err = h.pvpLeaderboardRows(trainerIDs, page)
+	} else {
+		total, rows, err = h.statsLeaderboardRows(leaderboardType, trainerIDs, page)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totalPages := (total + leaderboardPageSize - 1) / leaderboardPageSize
+	if totalPages == 0 {
+		totalPages = 1
+	}
+
+	title := fmt.Sprintf("🏆 %s Leaderboard", leaderboardTitle(leaderboardType))
+	if scope == "server" {
+		title += " (This Server)"
+	}
+
+	lines := []string{}
+	for idx, row := range rows {
+		rank := page*leaderboardPageSize + idx + 1
+		name := "Unknown"
+		if trainer, err := h.trainerRepo.GetByID(row.trainerID); err == nil && trainer != nil {
+			name = trainer.Name
+		}
+		lines = append(lines, fmt.Sprintf("%s **%s** — %s", rankMedal(rank), name, row.value))
+	}
+	description := strings.Join(lines, "\n")
+	if description == "" {
+		description = "No trainers on this leaderboard yet."
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       title,
+		Description: description,
+		Color:       0xffd700,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Page %d/%d • %s", page+1, totalPages, h.callerRankText(i.Member.User.ID, leaderboardType, trainerIDs)),
+		},
+	}
+
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				&ButtonWithoutEmoji{
+					Label:    "◀ Previous",
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("leaderboard_%s_%s_%d", leaderboardType, scope, page-1),
+					Disabled: page == 0,
+				},
+				&ButtonWithoutEmoji{
+					Label:    "Next ▶",
+					Style:    discordgo.SecondaryButton,
+					CustomID: fmt.Sprintf("leaderboard_%s_%s_%d", leaderboardType, scope, page+1),
+					Disabled: page+1 >= totalPages,
+				},
+			},
+		},
+	}
+
+	return embed, components, nil
+}
+
+func (h *Handlers) statsLeaderboardRows(statType string, trainerIDs []string, page int) (int, []leaderboardRow, error) {
+	total, err := h.statsRepo.CountLeaderboard(trainerIDs)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	stats, err := h.statsRepo.GetLeaderboardPage(statType, trainerIDs, leaderboardPageSize, page*leaderboardPageSize)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rows := make([]leaderboardRow, 0, len(stats))
+	for _, stat := range stats {
+		rows = append(rows, leaderboardRow{
+			trainerID: stat.TrainerID,
+			value:     fmt.Sprintf("%d %s", stat.StatValue(statType), leaderboardUnit(statType)),
+		})
+	}
+	return total, rows, nil
+}
+
+func (h *Handlers) pvpLeaderboardRows(trainerIDs []string, page int) (int, []leaderboardRow, error) {
+	total, err := h.pvpRepo.CountLeaderboard(trainerIDs)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	stats, err := h.pvpRepo.GetLeaderboardPage(trainerIDs, leaderboardPageSize, page*leaderboardPageSize)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rows := make([]leaderboardRow, 0, len(stats))
+	for _, stat := range stats {
+		rows = append(rows, leaderboardRow{
+			trainerID: stat.TrainerID,
+			value:     fmt.Sprintf("%d rating (%d W / %d L / %d D)", stat.Rating, stat.Wins, stat.Losses, stat.Draws),
+		})
+	}
+	return total, rows, nil
+}
+
+// callerRankText describes where the calling user sits on the leaderboard
+func (h *Handlers) callerRankText(discordID, leaderboardType string, trainerIDs []string) string {
+	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
+	if err != nil || trainer == nil {
+		return "You're not a trainer yet"
+	}
+
+	var rank int
+	var value string
+	if leaderboardType == "pvp" {
+		rank, err = h.pvpRepo.GetRank(trainer.ID, trainerIDs)
+		if err == nil && rank > 0 {
+			if stats, err := h.pvpRepo.GetOrCreate(trainer.ID); err == nil {
+				value = fmt.Sprintf("%d rating", stats.Rating)
+			}
+		}
+	} else {
+		rank, err = h.statsRepo.GetRank(leaderboardType, trainer.ID, trainerIDs)
+		if err == nil && rank > 0 {
+			if stats, err := h.statsRepo.GetOrCreate(trainer.ID); err == nil {
+				value = fmt.Sprintf("%d %s", stats.StatValue(leaderboardType), leaderboardUnit(leaderboardType))
+			}
+		}
+	}
+
+	if err != nil {
+		log.Printf("Error getting leaderboard rank: %v", err)
+		return "Your rank is unavailable"
+	}
+	if rank == 0 {
+		return "You're not ranked yet"
+	}
+	return fmt.Sprintf("Your rank: #%d (%s)", rank, value)
+}
+
+// guildTrainerIDs returns the IDs of trainers who are members of the guild, cached for a few minutes
+func (h *Handlers) guildTrainerIDs(s *discordgo.Session, guildID string) ([]string, error) {
+	h.guildMembersMu.Lock()
+	cached := h.guildMembers[guildID]
+	h.guildMembersMu.Unlock()
+	if cached != nil && time.Since(cached.fetchedAt) < guildMemberCacheTTL {
+		return cached.trainerIDs, nil
+	}
+
+	trainers, err := h.trainerRepo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	trainerIDs := []string{}
+	for _, trainer := range trainers {
+		if _, err := s.State.Member(guildID, trainer.DiscordID); err == nil {
+			trainerIDs = append(trainerIDs, trainer.ID)
+			continue
+		}
+		// Fetching a single member doesn't need the privileged members intent
+		if _, err := s.GuildMember(guildID, trainer.DiscordID); err == nil {
+			trainerIDs = append(trainerIDs, trainer.ID)
+		}
+	}
+
+	h.guildMembersMu.Lock()
+	h.guildMembers[guildID] = &guildMemberCache{trainerIDs: trainerIDs, fetchedAt: time.Now()}
+	h.guildMembersMu.Unlock()
+
+	return trainerIDs, nil
+}
+
+func leaderboardTitle(leaderboardType string) string {
+	switch leaderboardType {
+	case "pvp":
+		return "PvP Rating"
+	case storage.StatCaught:
+		return "Gophers Caught"
+	case storage.StatShinies:
+		return "Shinies"
+	case storage.StatEvolutions:
+		return "Evolutions"
+	case storage.StatXP:
+		return "Total XP"
+	default:
+		return "Battles Won"
+	}
+}
+
+func leaderboardUnit(statType string) string {
+	switch statType {
+	case storage.StatCaught:
+		return "caught"
+	case storage.StatShinies:
+		return "shinies"
+	case storage.StatEvolutions:
+		return "evolutions"
+	case storage.StatXP:
+		return "XP"
+	default:
+		return "wins"
+	}
+}
+
+func rankMedal(rank int) string {
+	switch rank {
+	case 1:
+		return "🥇"
+	case 2:
+		return "🥈"
+	case 3:
+		return "🥉"
+	default:
+		return fmt.Sprintf("`#%d`", rank)
+	}
+}
@@ -0,0 +1,242 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"gophermon-bot/internal/game"
+	"gophermon-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+)
+
+// SetPvPChannel sets the channel where matchmade PvP battles are played. When it's empty,
+// a battle is played where the trainer who waited longer queued.
+func (h *Handlers) SetPvPChannel(channelID string) {
+	h.pvpChannelID = channelID
+}
+
+func (h *Handlers) handlePvP(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+
+	trainer, err := h.trainerRepo.GetByDiscordID(i.Member.User.ID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	if len(data.Options) == 0 {
+		respondEphemeral(s, i, "Use /pvp queue or /pvp leave")
+		return
+	}
+
+	switch data.Options[0].Name {
+	case "queue":
+		h.handlePvPQueue(s, i, trainer)
+	case "leave":
+		h.handlePvPLeave(s, i, trainer)
+	default:
+		respondEphemeral(s, i, "Unknown subcommand")
+	}
+}
+
+// handlePvPQueue puts the trainer in the matchmaking queue at their current rating
+func (h *Handlers) handlePvPQueue(s *discordgo.Session, i *discordgo.InteractionCreate, trainer *storage.Trainer) {
+	if entry := h.matchmaking.Entry(trainer.ID); entry != nil {
+		now := time.Now()
+		respondEphemeral(s, i, fmt.Sprintf("You're already in the queue! Waiting %s for an opponent rated %d ± %d. Use /pvp leave to stop looking.",
+			now.Sub(entry.JoinedAt).Round(time.Second), entry.Rating, entry.Window(now)))
+		return
+	}
+
+	if reason := h.battleBusyReason(trainer.ID); reason != "" {
+		respondEphemeral(s, i, reason)
+		return
+	}
+
+	party, err := h.battleParty(trainer.ID)
+	if err != nil || len(party) == 0 {
+		respondEphemeral(s, i, noBattlePartyMessage)
+		return
+	}
+
+	stats, err := h.pvpRepo.GetOrCreate(trainer.ID)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error loading your rating: %v", err))
+		return
+	}
+
+	err = h.matchmaking.Join(&game.QueueEntry{
+		TrainerID:   trainer.ID,
+		TrainerName: trainer.Name,
+		ChannelID:   i.ChannelID,
+		Rating:      stats.Rating,
+		JoinedAt:    time.Now(),
+	})
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Couldn't join the queue: %v", err))
+		return
+	}
+
+	respondEphemeral(s, i, fmt.Sprintf("🔎 Looking for an opponent rated %d ± %d. The range widens the longer you wait; after %d minutes you'll leave the queue. Use /pvp leave to stop looking.",
+		stats.Rating, game.MatchmakingBaseWindow, int(game.MatchmakingTimeout.Minutes())))
+
+	h.RunMatchmaking(s)
+}
+
+// handlePvPLeave takes the trainer out of the matchmaking queue
+func (h *Handlers) handlePvPLeave(s *discordgo.Session, i *discordgo.InteractionCreate, trainer *storage.Trainer) {
+	if !h.matchmaking.Leave(trainer.ID) {
+		respondEphemeral(s, i, "You're not in the queue. Use /pvp queue to look for an opponent.")
+		return
+	}
+	respondEphemeral(s, i, "You left the PvP queue.")
+}
+
+// RunMatchmaking starts battles for queued trainers who can be paired up, and takes trainers
+// who waited too long out of the queue
+func (h *Handlers) RunMatchmaking(s *discordgo.Session) {
+	now := time.Now()
+
+	for _, entry := range h.matchmaking.Expire(now) {
+		content := fmt.Sprintf("%s, no opponent was found in %d minutes, so you left the PvP queue. Use /pvp queue to try again.",
+			h.trainerMention(entry.TrainerID, entry.TrainerName), int(game.MatchmakingTimeout.Minutes()))
+		if _, err := s.ChannelMessageSend(entry.ChannelID, content); err != nil {
+			log.Printf("Error announcing PvP queue timeout: %v", err)
+		}
+	}
+
+	// Trainers who started another battle while queued wait until it's over
+	matches := h.matchmaking.Match(now, func(trainerID string) bool {
+		return h.battleBusyReason(trainerID) == ""
+	})
+	for _, match := range matches {
+		if err := h.startMatchedBattle(s, match); err != nil {
+			log.Printf("Error starting matchmade PvP battle: %v", err)
+		}
+	}
+}
+
+// startMatchedBattle starts a PvP battle between two matched trainers. Trainer 1, who waited
+// longer, moves first. Parties are loaded and the message is sent without holding pvpMu, so
+// PvP buttons don't wait on them.
+func (h *Handlers) startMatchedBattle(s *discordgo.Session, match *game.Match) error {
+	channelID := h.pvpChannelID
+	if channelID == "" {
+		channelID = match.Trainer1.ChannelID
+	}
+	mention1 := h.trainerMention(match.Trainer1.TrainerID, match.Trainer1.TrainerName)
+	mention2 := h.trainerMention(match.Trainer2.TrainerID, match.Trainer2.TrainerName)
+
+	pvpState := game.NewPvPChallenge(channelID, match.Trainer1.TrainerID, match.Trainer2.TrainerID,
+		match.Trainer1.TrainerName, match.Trainer2.TrainerName, h.gameService.GetEventManager(), h.gameService.NewBattleSeed())
+	pvpState.ID = uuid.New().String()
+
+	if err := h.acceptMatchedBattle(pvpState); err != nil {
+		content := fmt.Sprintf("%s and %s were matched, but the battle couldn't start: %v", mention1, mention2, err)
+		if _, sendErr := s.ChannelMessageSend(channelID, content); sendErr != nil {
+			log.Printf("Error announcing failed PvP match: %v", sendErr)
+		}
+		return err
+	}
+
+	embed := h.createPvPEmbed(pvpState)
+	message := &discordgo.MessageSend{
+		Content: fmt.Sprintf("🎯 Ranked match found! %s (%d) vs %s (%d)\n%s",
+			mention1, match.Trainer1.Rating, mention2, match.Trainer2.Rating, h.pvpTurnMention(currentPvPTurn(pvpState))),
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: h.createPvPButtons(pvpState, ""),
+	}
+	if cardFile := h.createPvPCardFile(h.copyPvPCard(pvpState)); cardFile != nil {
+		embed.Image = &discordgo.MessageEmbedImage{URL: fmt.Sprintf("attachment://%s", cardFile.Name)}
+		message.Files = []*discordgo.File{cardFile}
+	}
+
+	// Either trainer may have been challenged since they were matched; the other one waits on.
+	// The queue is joined after pvpMu is released, since matching takes pvpMu under the queue's lock.
+	h.pvpMu.Lock()
+	requeue := []*game.QueueEntry{}
+	for _, entry := range []*game.QueueEntry{match.Trainer1, match.Trainer2} {
+		if h.findPvPBattleByTrainer(entry.TrainerID) == nil {
+			requeue = append(requeue, entry)
+		}
+	}
+	if len(requeue) == 2 {
+		h.pvpBattles[pvpState.ID] = pvpState
+	}
+	h.pvpMu.Unlock()
+	if len(requeue) < 2 {
+		for _, entry := range requeue {
+			h.matchmaking.Join(entry)
+		}
+		return nil
+	}
+
+	msg, err := s.ChannelMessageSendComplex(channelID, message)
+
+	h.pvpMu.Lock()
+	defer h.pvpMu.Unlock()
+	if err != nil {
+		delete(h.pvpBattles, pvpState.ID)
+		return fmt.Errorf("failed to send battle message: %w", err)
+	}
+	pvpState.MessageID = msg.ID
+	return nil
+}
+
+// acceptMatchedBattle loads both trainers' parties and starts the battle
+func (h *Handlers) acceptMatchedBattle(pvpState *game.PvPBattleState) error {
+	party1, err := h.loadPvPParty(pvpState.Trainer1ID)
+	if err != nil {
+		return fmt.Errorf("failed to load %s's party: %w", pvpState.Trainer1Name, err)
+	}
+	party2, err := h.loadPvPParty(pvpState.Trainer2ID)
+	if err != nil {
+		return fmt.Errorf("failed to load %s's party: %w", pvpState.Trainer2Name, err)
+	}
+	return pvpState.Accept(party1, party2)
+}
+
+// battleBusyReason returns why a trainer can't start a new battle right now, or "" if they can
+func (h *Handlers) battleBusyReason(trainerID string) string {
+	if reason := h.nonRaidBusyReason(trainerID); reason != "" {
+		return reason
+	}
+
+	h.raidMu.Lock()
+	inRaid := h.findRaidByTrainer(trainerID) != nil
+	h.raidMu.Unlock()
+	if inRaid {
+		return "You're already in a raid!"
+	}
+	return ""
+}
+
+// nonRaidBusyReason is battleBusyReason without the raid check, for callers that hold raidMu
+func (h *Handlers) nonRaidBusyReason(trainerID string) string {
+	if h.findBattleByTrainer(trainerID) != nil {
+		return "Finish your current battle first!"
+	}
+	if h.inDoubleBattle(trainerID) {
+		return "You're already in a double battle!"
+	}
+
+	h.pvpMu.Lock()
+	inPvP := h.findPvPBattleByTrainer(trainerID) != nil
+	h.pvpMu.Unlock()
+	if inPvP {
+		return "You're already in a PvP battle or have a pending challenge!"
+	}
+	return ""
+}
+
+// trainerMention pings a trainer, falling back to their name if they can't be looked up
+func (h *Handlers) trainerMention(trainerID, name string) string {
+	trainer, err := h.trainerRepo.GetByID(trainerID)
+	if err != nil || trainer == nil {
+		return name
+	}
+	return fmt.Sprintf("<@%s>", trainer.DiscordID)
+}
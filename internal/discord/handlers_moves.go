@@ -0,0 +1,277 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gophermon-bot/internal/game"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// handleGopherMoves shows a gopher's learned moves and what it can still learn
+func (h *Handlers) handleGopherMoves(s *discordgo.Session, i *discordgo.InteractionCreate, trainerID, gopherID string) {
+	gopher, err := h.gopherRepo.GetByID(gopherID)
+	if err != nil || gopher == nil {
+		respondEphemeral(s, i, "Gopher not found")
+		return
+	}
+
+	if gopher.TrainerID == nil || *gopher.TrainerID != trainerID {
+		respondEphemeral(s, i, "This gopher doesn't belong to you")
+		return
+	}
+
+	gameGopher, err := h.gameService.StorageGopherToGameGopher(gopher)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:  fmt.Sprintf("📘 %s's Moves", gameGopher.Name),
+		Color:  0x3399ff,
+		Fields: []*discordgo.MessageEmbedField{},
+	}
+
+	learned := ""
+	for idx, ability := range gameGopher.Abilities {
+		learned += fmt.Sprintf("%d. %s\n", idx+1, describeAbility(ability.Name, ability.Description, ability.Power, ability.Cost))
+	}
+	if learned == "" {
+		learned = "None"
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:   fmt.Sprintf("Learned (%d/%d)", len(gameGopher.Abilities), game.MaxActiveAbilities),
+		Value:  learned,
+		Inline: false,
+	})
+
+	next := game.NextLearnableAbility(gameGopher)
+	learnable := ""
+	for _, templateID := range game.LearnableAbilities(gameGopher) {
+		template := game.AbilityTemplates[templateID]
+		marker := "🔒"
+		if templateID == next {
+			marker = "✨"
+		}
+		learnable += fmt.Sprintf("%s %s\n", marker, describeAbility(template.Name, template.Description, template.Power, template.Cost))
+	}
+	if learnable == "" {
+		learnable = "Nothing left to learn"
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:   "Learnable",
+		Value:  learnable,
+		Inline: false,
+	})
+
+	if len(gameGopher.ForgottenAbilities) > 0 {
+		names := []string{}
+		for _, ability := range gameGopher.ForgottenAbilities {
+			names = append(names, ability.Name)
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Forgotten",
+			Value:  strings.Join(names, ", "),
+			Inline: false,
+		})
+	}
+
+	embed.Footer = &discordgo.MessageEmbedFooter{
+		Text: "✨ ready to learn • 🔒 not eligible yet",
+	}
+
+	// A move that is waiting on the trainer's choice can be learned from here too
+	if next != "" {
+		prompt, components := createMoveLearnPrompt(gameGopher, next)
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   prompt.Title,
+			Value:  prompt.Description,
+			Inline: false,
+		})
+		respondWithComponents(s, i, "", embed, components, true)
+		return
+	}
+
+	respondEmbed(s, i, embed, true)
+}
+
+func describeAbility(name, description string, power, cost int) string {
+	return fmt.Sprintf("**%s** - %s (Power: %d, Cost: %d)", name, description, power, cost)
+}
+
+// offerNewMoves teaches a gopher the moves it has become eligible for. Moves that fit in a free
+// slot are learned straight away and returned as messages; if the gopher already knows
+// MaxActiveAbilities moves, its trainer is asked in the channel which move to forget.
+func (h *Handlers) offerNewMoves(s *discordgo.Session, channelID, trainerID string, gopher *game.Gopher) []string {
+	messages := []string{}
+
+	learned := game.LearnFreeAbilities(gopher)
+	if len(learned) > 0 {
+		if err := h.gameService.SaveAbilities(gopher); err != nil {
+			log.Printf("Error saving learned abilities: %v", err)
+			return messages
+		}
+		for _, ability := range learned {
+			messages = append(messages, fmt.Sprintf("📘 %s learned %s!", gopher.Name, ability.Name))
+		}
+	}
+
+	next := game.NextLearnableAbility(gopher)
+	if next == "" || channelID == "" {
+		return messages
+	}
+
+	trainer, err := h.trainerRepo.GetByID(trainerID)
+	if err != nil || trainer == nil {
+		return messages
+	}
+
+	embed, components := createMoveLearnPrompt(gopher, next)
+	_, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:    fmt.Sprintf("<@%s>", trainer.DiscordID),
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+	})
+	if err != nil {
+		log.Printf("Error sending move prompt: %v", err)
+	}
+	return messages
+}
+
+// createMoveLearnPrompt asks which move a gopher should forget to make room for templateID.
+// Button IDs are move_forget_<slot>_<gopherID>_<templateID> and move_skip_<gopherID>_<templateID>.
+func createMoveLearnPrompt(gopher *game.Gopher, templateID string) (*discordgo.MessageEmbed, []discordgo.MessageComponent) {
+	template := game.AbilityTemplates[templateID]
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("📘 %s wants to learn %s!", gopher.Name, template.Name),
+		Description: fmt.Sprintf("%s\n\nBut %s already knows %d moves. Should a move be forgotten to make room for %s?",
+			describeAbility(template.Name, template.Description, template.Power, template.Cost),
+			gopher.Name, len(gopher.Abilities), template.Name),
+		Color: 0x3399ff,
+	}
+
+	forgetButtons := []discordgo.MessageComponent{}
+	for idx, ability := range gopher.Abilities {
+		forgetButtons = append(forgetButtons, createButton(fmt.Sprintf("Forget %s", ability.Name), discordgo.DangerButton,
+			fmt.Sprintf("move_forget_%d_%s_%s", idx, gopher.ID, templateID)))
+	}
+
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: forgetButtons},
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			createButton(fmt.Sprintf("Don't learn %s", template.Name), discordgo.SecondaryButton,
+				fmt.Sprintf("move_skip_%s_%s", gopher.ID, templateID)),
+		}},
+	}
+	return embed, components
+}
+
+// handleMoveComponent handles the forget/skip buttons of a move prompt
+func (h *Handlers) handleMoveComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+
+	slot := -1
+	var gopherID, templateID string
+	if strings.HasPrefix(data.CustomID, "move_forget_") {
+		parts := strings.SplitN(strings.TrimPrefix(data.CustomID, "move_forget_"), "_", 3)
+		if len(parts) != 3 {
+			respondEphemeral(s, i, "Invalid move")
+			return
+		}
+		var err error
+		slot, err = strconv.Atoi(parts[0])
+		if err != nil {
+			respondEphemeral(s, i, "Invalid move")
+			return
+		}
+		gopherID, templateID = parts[1], parts[2]
+	} else {
+		parts := strings.SplitN(strings.TrimPrefix(data.CustomID, "move_skip_"), "_", 2)
+		if len(parts) != 2 {
+			respondEphemeral(s, i, "Invalid move")
+			return
+		}
+		gopherID, templateID = parts[0], parts[1]
+	}
+
+	trainer, err := h.trainerRepo.GetByDiscordID(i.Member.User.ID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	gopher, err := h.gopherRepo.GetByID(gopherID)
+	if err != nil || gopher == nil {
+		respondEphemeral(s, i, "Gopher not found")
+		return
+	}
+	if gopher.TrainerID == nil || *gopher.TrainerID != trainer.ID {
+		respondEphemeral(s, i, "This gopher doesn't belong to you")
+		return
+	}
+
+	// The battle has its own copy of the gopher, which would overwrite the change
+	if h.findBattleByTrainer(trainer.ID) != nil || h.inDoubleBattle(trainer.ID) {
+		respondEphemeral(s, i, "Finish your battle before changing moves!")
+		return
+	}
+
+	gameGopher, err := h.gameService.StorageGopherToGameGopher(gopher)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	template := game.AbilityTemplates[templateID]
+	var result string
+	if slot >= 0 {
+		forgotten, learned, err := game.ReplaceAbility(gameGopher, slot, templateID)
+		if err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("Can't do that: %v", err))
+			return
+		}
+		result = fmt.Sprintf("1, 2 and... Poof! %s forgot %s and learned %s!", gameGopher.Name, forgotten.Name, learned.Name)
+	} else {
+		if err := game.SkipAbility(gameGopher, templateID); err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("Can't do that: %v", err))
+			return
+		}
+		result = fmt.Sprintf("%s did not learn %s.", gameGopher.Name, template.Name)
+	}
+
+	if err := h.gameService.SaveAbilities(gameGopher); err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error saving moves: %v", err))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "📘 Moves updated",
+		Description: result,
+		Color:       0x3399ff,
+	}
+	components := []discordgo.MessageComponent{}
+
+	// Gophers that became eligible for several moves at once are asked about the next one
+	if next := game.NextLearnableAbility(gameGopher); next != "" {
+		var prompt *discordgo.MessageEmbed
+		prompt, components = createMoveLearnPrompt(gameGopher, next)
+		embed.Title = prompt.Title
+		embed.Description = fmt.Sprintf("%s\n\n%s", result, prompt.Description)
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+	if err != nil {
+		log.Printf("Error updating move prompt: %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 
+	"gophermon-bot/internal/game"
 	"gophermon-bot/internal/storage"
 
 	"github.com/bwmarrin/discordgo"
@@ -41,6 +42,10 @@ func (h *Handlers) handleShop(s *discordgo.Session, i *discordgo.InteractionCrea
 		if len(subCommand.Options) > 1 {
 			quantity = int(subCommand.Options[1].IntValue())
 		}
+		if quantity < 1 {
+			respondEphemeral(s, i, "Quantity must be at least 1")
+			return
+		}
 
 		var price int
 		switch itemType {
@@ -55,8 +60,12 @@ func (h *Handlers) handleShop(s *discordgo.Session, i *discordgo.InteractionCrea
 		case "SHINY_CHARM":
 			price = 1000
 		default:
-			respondEphemeral(s, i, "Invalid item type")
-			return
+			heldItem, ok := game.HeldItems[itemType]
+			if !ok {
+				respondEphemeral(s, i, "Invalid item type")
+				return
+			}
+			price = heldItem.Price
 		}
 
 		totalCost := price * quantity
@@ -83,7 +92,7 @@ func (h *Handlers) handleShop(s *discordgo.Session, i *discordgo.InteractionCrea
 			return
 		}
 
-		respondEphemeral(s, i, fmt.Sprintf("Purchased %d %s for %d GoCoins!", quantity, itemType, totalCost))
+		respondEphemeral(s, i, fmt.Sprintf("Purchased %d %s for %d GoCoins!", quantity, game.ItemNames[itemType], totalCost))
 	}
 }
 
@@ -101,89 +110,18 @@ func (h *Handlers) showShopView(s *discordgo.Session, i *discordgo.InteractionCr
 			{Name: "✨ Shiny Charm", Value: "Doubles shiny rate\n**Price:** 1000 GoCoins", Inline: true},
 		},
 	}
-	respondEmbed(s, i, embed, true)
-}
-
-func (h *Handlers) handleAchievements(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	discordID := i.Member.User.ID
-
-	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
-	if err != nil || trainer == nil {
-		respondEphemeral(s, i, "Trainer not found. Use /start first.")
-		return
-	}
-
-	embed := &discordgo.MessageEmbed{
-		Title:       "🏆 Achievements 🏆",
-		Description: "Your achievement progress will be displayed here.",
-		Color:       0xffd700,
-		Fields:      []*discordgo.MessageEmbedField{},
+	// Held items are given to a gopher with /gopher hold
+	for _, itemType := range game.HeldItemTypes {
+		item := game.HeldItems[itemType]
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s %s (held)", itemEmojis[itemType], item.Name),
+			Value:  fmt.Sprintf("%s\n**Price:** %d GoCoins", item.Description, item.Price),
+			Inline: true,
+		})
 	}
-
 	respondEmbed(s, i, embed, true)
 }
 
-func (h *Handlers) handleQuests(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	discordID := i.Member.User.ID
-
-	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
-	if err != nil || trainer == nil {
-		respondEphemeral(s, i, "Trainer not found. Use /start first.")
-		return
-	}
-
-	embed := &discordgo.MessageEmbed{
-		Title:       "📋 Quests 📋",
-		Description: "Your active quests will be displayed here.",
-		Color:       0x00ff00,
-		Fields:      []*discordgo.MessageEmbedField{},
-	}
-
-	respondEmbed(s, i, embed, true)
-}
-
-func (h *Handlers) handleChallenge(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	data := i.ApplicationCommandData()
-	discordID := i.Member.User.ID
-
-	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
-	if err != nil || trainer == nil {
-		respondEphemeral(s, i, "Trainer not found. Use /start first.")
-		return
-	}
-
-	opponentUser := data.Options[0].UserValue(s)
-	if opponentUser == nil {
-		respondEphemeral(s, i, "Invalid user")
-		return
-	}
-
-	if opponentUser.ID == discordID {
-		respondEphemeral(s, i, "You can't challenge yourself!")
-		return
-	}
-
-	opponentTrainer, err := h.trainerRepo.GetByDiscordID(opponentUser.ID)
-	if err != nil || opponentTrainer == nil {
-		respondEphemeral(s, i, "That user is not a trainer yet!")
-		return
-	}
-
-	party1, _ := h.gopherRepo.GetParty(trainer.ID)
-	party2, _ := h.gopherRepo.GetParty(opponentTrainer.ID)
-
-	if len(party1) == 0 {
-		respondEphemeral(s, i, "Your party is empty!")
-		return
-	}
-	if len(party2) == 0 {
-		respondEphemeral(s, i, "Opponent's party is empty!")
-		return
-	}
-
-	respondEphemeral(s, i, fmt.Sprintf("PvP battle challenge sent to %s! (Feature in development)", opponentUser.Username))
-}
-
 func (h *Handlers) handleStats(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	data := i.ApplicationCommandData()
 
@@ -201,79 +139,53 @@ func (h *Handlers) handleStats(s *discordgo.Session, i *discordgo.InteractionCre
 		return
 	}
 
-	embed := &discordgo.MessageEmbed{
-		Title:       fmt.Sprintf("📊 %s's Statistics", trainer.Name),
-		Description: "Statistics will be displayed here.",
-		Color:       0x0099ff,
-		Fields:      []*discordgo.MessageEmbedField{},
+	stats, err := h.statsRepo.GetOrCreate(trainer.ID)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error loading statistics: %v", err))
+		return
 	}
 
-	respondEmbed(s, i, embed, true)
-}
-
-func (h *Handlers) handleLeaderboard(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	data := i.ApplicationCommandData()
-	leaderboardType := data.Options[0].StringValue()
-
-	embed := &discordgo.MessageEmbed{
-		Title:       "🏆 Leaderboard",
-		Description: fmt.Sprintf("Top players by %s", leaderboardType),
-		Color:       0xffd700,
-		Fields:      []*discordgo.MessageEmbedField{},
+	winRate := 0.0
+	if stats.BattlesWon+stats.BattlesLost > 0 {
+		winRate = float64(stats.BattlesWon) / float64(stats.BattlesWon+stats.BattlesLost) * 100
 	}
 
-	respondEmbed(s, i, embed, true)
-}
-
-func (h *Handlers) handleGopherdex(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	discordID := i.Member.User.ID
+	favoriteArchetype := "None yet"
+	if stats.FavoriteArchetype != nil {
+		favoriteArchetype = *stats.FavoriteArchetype
+	}
 
-	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
-	if err != nil || trainer == nil {
-		respondEphemeral(s, i, "Trainer not found. Use /start first.")
-		return
+	mostUsed := "None yet"
+	if stats.MostUsedGopherID != nil {
+		if gopher, err := h.gopherRepo.GetByID(*stats.MostUsedGopherID); err == nil && gopher != nil {
+			mostUsed = fmt.Sprintf("%s (Lv.%d)", gopher.Name, gopher.Level)
+		}
 	}
 
 	embed := &discordgo.MessageEmbed{
-		Title:       "📖 Gopherdex",
-		Description: "Your collection will be displayed here.",
-		Color:       0x9966ff,
-		Fields:      []*discordgo.MessageEmbedField{},
+		Title: fmt.Sprintf("📊 %s's Statistics", trainer.Name),
+		Color: 0x0099ff,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "⚔️ Battles", Value: fmt.Sprintf("%d", stats.TotalBattles), Inline: true},
+			{Name: "🏆 Won / Lost", Value: fmt.Sprintf("%d / %d", stats.BattlesWon, stats.BattlesLost), Inline: true},
+			{Name: "📈 Win Rate", Value: fmt.Sprintf("%.1f%%", winRate), Inline: true},
+			{Name: "🎯 Gophers Caught", Value: fmt.Sprintf("%d", stats.GophersCaught), Inline: true},
+			{Name: "✨ Shinies Caught", Value: fmt.Sprintf("%d", stats.ShinyCount), Inline: true},
+			{Name: "🔄 Evolutions", Value: fmt.Sprintf("%d", stats.Evolutions), Inline: true},
+			{Name: "⭐ Total XP Earned", Value: fmt.Sprintf("%d", stats.TotalXPEarned), Inline: true},
+			{Name: "❤️ Favorite Archetype", Value: favoriteArchetype, Inline: true},
+			{Name: "🥇 Most Used Gopher", Value: mostUsed, Inline: true},
+		},
 	}
 
-	respondEmbed(s, i, embed, true)
-}
-
-func (h *Handlers) handleTrade(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	data := i.ApplicationCommandData()
-	discordID := i.Member.User.ID
-
-	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
-	if err != nil || trainer == nil {
-		respondEphemeral(s, i, "Trainer not found. Use /start first.")
-		return
+	if pvpStats, err := h.pvpRepo.GetOrCreate(trainer.ID); err == nil && pvpStats.TotalBattles > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: "🤺 PvP",
+			Value: fmt.Sprintf("Rating: %d (best %d)\nRecord: %d W / %d L / %d D",
+				pvpStats.Rating, pvpStats.HighestRating, pvpStats.Wins, pvpStats.Losses, pvpStats.Draws),
+			Inline: false,
+		})
 	}
 
-	subCommand := data.Options[0]
-	switch subCommand.Name {
-	case "offer":
-		opponentUser := subCommand.Options[0].UserValue(s)
-		if opponentUser == nil {
-			respondEphemeral(s, i, "Invalid user")
-			return
-		}
-
-		respondEphemeral(s, i, fmt.Sprintf("Trade offer sent to %s!", opponentUser.Username))
-	case "accept":
-		tradeID := subCommand.Options[0].StringValue()
-		respondEphemeral(s, i, fmt.Sprintf("Trade %s accepted!", tradeID))
-	case "list":
-		embed := &discordgo.MessageEmbed{
-			Title:       "💼 Pending Trades",
-			Description: "Your pending trades will be listed here.",
-			Color:       0x00ff00,
-		}
-		respondEmbed(s, i, embed, true)
-	}
+	respondEmbed(s, i, embed, true)
 }
-
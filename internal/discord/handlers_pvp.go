@@ -0,0 +1,778 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gophermon-bot/internal/game"
+	"gophermon-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+)
+
+// PvP button custom IDs have the form pvp_<action>_<battleID>[_<index>]
+
+func (h *Handlers) handleChallenge(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	discordID := i.Member.User.ID
+
+	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	var opponentUser *discordgo.User
+	double := false
+	for _, opt := range data.Options {
+		switch opt.Name {
+		case "user":
+			opponentUser = opt.UserValue(s)
+		case "double":
+			double = opt.BoolValue()
+		}
+	}
+	if opponentUser == nil {
+		respondEphemeral(s, i, "Invalid user")
+		return
+	}
+
+	if opponentUser.ID == discordID {
+		respondEphemeral(s, i, "You can't challenge yourself!")
+		return
+	}
+
+	opponentTrainer, err := h.trainerRepo.GetByDiscordID(opponentUser.ID)
+	if err != nil || opponentTrainer == nil {
+		respondEphemeral(s, i, "That user is not a trainer yet!")
+		return
+	}
+
+	party1, _ := h.battleParty(trainer.ID)
+	party2, _ := h.battleParty(opponentTrainer.ID)
+
+	if len(party1) == 0 {
+		respondEphemeral(s, i, "Your party is empty!")
+		return
+	}
+	if len(party2) == 0 {
+		respondEphemeral(s, i, "Opponent's party is empty!")
+		return
+	}
+	if double && (len(party1) < game.DoubleSlots || len(party2) < game.DoubleSlots) {
+		respondEphemeral(s, i, fmt.Sprintf("Both trainers need at least %d gophers in their party for a double battle!", game.DoubleSlots))
+		return
+	}
+
+	pvpState := game.NewPvPChallenge(i.ChannelID, trainer.ID, opponentTrainer.ID, trainer.Name, opponentTrainer.Name, h.gameService.GetEventManager(), h.gameService.NewBattleSeed())
+	pvpState.ID = uuid.New().String()
+	pvpState.Double = double
+	if reason := h.addPvPChallenge(pvpState); reason != "" {
+		respondEphemeral(s, i, reason)
+		return
+	}
+
+	battleKind := "a battle"
+	if double {
+		battleKind = "a double battle"
+	}
+	embed := &discordgo.MessageEmbed{
+		Title:       "⚔️ PvP Challenge!",
+		Description: fmt.Sprintf("<@%s> has challenged <@%s> to %s!\n\nThe challenge expires in %d minutes.", discordID, opponentUser.ID, battleKind, int(game.PvPChallengeTimeout.Minutes())),
+		Color:       0xff9900,
+	}
+
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				createButton("Accept", discordgo.SuccessButton, fmt.Sprintf("pvp_accept_%s", pvpState.ID)),
+				createButton("Decline", discordgo.DangerButton, fmt.Sprintf("pvp_decline_%s", pvpState.ID)),
+			},
+		},
+	}
+
+	respondWithComponents(s, i, fmt.Sprintf("<@%s>", opponentUser.ID), embed, components, false)
+}
+
+// addPvPChallenge keeps a new challenge in memory, or returns why it can't be made
+func (h *Handlers) addPvPChallenge(pvpState *game.PvPBattleState) string {
+	h.pvpMu.Lock()
+	defer h.pvpMu.Unlock()
+
+	h.cleanupExpiredChallenges()
+	switch {
+	case h.findPvPBattleByTrainer(pvpState.Trainer1ID) != nil:
+		return "You're already in a PvP battle or have a pending challenge!"
+	case h.findPvPBattleByTrainer(pvpState.Trainer2ID) != nil:
+		return fmt.Sprintf("%s is already in a PvP battle!", pvpState.Trainer2Name)
+	case h.inDoubleBattle(pvpState.Trainer1ID):
+		return "You're already in a double battle!"
+	case h.inDoubleBattle(pvpState.Trainer2ID):
+		return fmt.Sprintf("%s is already in a double battle!", pvpState.Trainer2Name)
+	}
+	h.pvpBattles[pvpState.ID] = pvpState
+	return ""
+}
+
+// pvpReply is the reply to a PvP button press. It's copied out of the battle under pvpMu and
+// sent once pvpMu is released, so database and Discord calls don't hold up other battles or
+// matchmaking.
+type pvpReply struct {
+	ephemeral  string   // Sent privately instead of updating the battle message
+	turn       *pvpTurn // Trainer to ping above the content, nil when the battle isn't active
+	content    string
+	embed      *discordgo.MessageEmbed
+	components []discordgo.MessageComponent
+	card       *pvpCard // Set when the active gophers changed and the battle card needs redrawing
+	finished   bool     // The battle ended and was removed; its result is recorded before sending
+}
+
+// pvpTurn is whose turn it is in a PvP battle
+type pvpTurn struct {
+	trainerID   string
+	trainerName string
+	forcedSwap  bool
+}
+
+// pvpCard is a copy of the active gophers a PvP battle card shows, so the card can be drawn
+// without holding pvpMu
+type pvpCard struct {
+	name               string
+	trainer1, trainer2 *storage.Gopher
+}
+
+func (h *Handlers) handlePvPComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+	discordID := i.Member.User.ID
+
+	parts := strings.Split(data.CustomID, "_")
+	if len(parts) < 3 {
+		respondEphemeral(s, i, "Unknown action")
+		return
+	}
+	action := parts[1]
+	battleID := parts[2]
+	index := -1
+	if len(parts) > 3 {
+		parsed, err := strconv.Atoi(parts[3])
+		if err != nil {
+			respondEphemeral(s, i, "Invalid selection")
+			return
+		}
+		index = parsed
+	}
+
+	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found")
+		return
+	}
+
+	if action == "accept" {
+		h.handlePvPAccept(s, i, battleID, trainer.ID)
+		return
+	}
+
+	h.pvpMu.Lock()
+	pvpState, reply := h.pvpComponentReply(battleID, trainer.ID, action, index)
+	h.pvpMu.Unlock()
+
+	h.sendPvPReply(s, i, pvpState, reply)
+}
+
+// pvpComponentReply runs a button press against its battle and builds the reply.
+// Caller must hold pvpMu.
+func (h *Handlers) pvpComponentReply(battleID, trainerID, action string, index int) (*game.PvPBattleState, *pvpReply) {
+	pvpState := h.pvpBattles[battleID]
+	if pvpState == nil {
+		return nil, &pvpReply{ephemeral: "Battle not found or already ended"}
+	}
+
+	side := pvpState.SideOf(trainerID)
+	if side == "" {
+		return pvpState, &pvpReply{ephemeral: "This isn't your battle!"}
+	}
+
+	switch action {
+	case "fight", "swap", "back", "ability", "rest", "pick":
+		if reason := pvpTurnReason(pvpState, side); reason != "" {
+			return pvpState, &pvpReply{ephemeral: reason}
+		}
+	}
+
+	switch action {
+	case "decline":
+		return pvpState, h.handlePvPDecline(pvpState, side)
+	case "fight", "swap", "back":
+		return pvpState, h.pvpBattleReply(pvpState, nil, action)
+	case "ability":
+		return pvpState, h.executePvPAction(pvpState, trainerID, "fight", index)
+	case "rest":
+		return pvpState, h.executePvPAction(pvpState, trainerID, "rest", -1)
+	case "pick":
+		return pvpState, h.executePvPAction(pvpState, trainerID, "swap", index)
+	case "forfeit":
+		if pvpState.State != game.PvPStateActive {
+			return pvpState, &pvpReply{ephemeral: "This battle isn't active!"}
+		}
+		return pvpState, h.executePvPAction(pvpState, trainerID, "forfeit", -1)
+	}
+	return pvpState, &pvpReply{ephemeral: "Unknown action"}
+}
+
+// handlePvPAccept starts a challenge the challenged trainer accepted. Both parties are loaded
+// and the battle message is sent without holding pvpMu.
+func (h *Handlers) handlePvPAccept(s *discordgo.Session, i *discordgo.InteractionCreate, battleID, trainerID string) {
+	h.pvpMu.Lock()
+	pvpState, reply := h.checkPvPAccept(battleID, trainerID)
+	h.pvpMu.Unlock()
+	if reply != nil {
+		h.sendPvPReply(s, i, pvpState, reply)
+		return
+	}
+
+	party1, err := h.loadPvPParty(pvpState.Trainer1ID)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error loading %s's party: %v", pvpState.Trainer1Name, err))
+		return
+	}
+	party2, err := h.loadPvPParty(pvpState.Trainer2ID)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error loading your party: %v", err))
+		return
+	}
+
+	h.pvpMu.Lock()
+	var doubleState *game.DoubleBattleState
+	switch {
+	case h.pvpBattles[pvpState.ID] != pvpState || pvpState.State != game.PvPStatePending:
+		// The challenge was withdrawn while the parties loaded
+		reply = &pvpReply{ephemeral: "This challenge has already been answered!"}
+	case pvpState.Double:
+		doubleState, reply = h.acceptDoubleChallenge(pvpState, party1, party2)
+	default:
+		reply = h.acceptPvPChallenge(pvpState, i.Message.ID, party1, party2)
+	}
+	h.pvpMu.Unlock()
+
+	if doubleState != nil {
+		if err := h.postDoubleBattle(s, doubleState); err != nil {
+			reply = &pvpReply{ephemeral: fmt.Sprintf("Couldn't start the battle: %v", err)}
+		}
+	}
+	if reply.ephemeral != "" || reply.card == nil {
+		h.sendPvPReply(s, i, pvpState, reply)
+		return
+	}
+
+	var files []*discordgo.File
+	if cardFile := h.createPvPCardFile(reply.card); cardFile != nil {
+		reply.embed.Image = &discordgo.MessageEmbedImage{URL: fmt.Sprintf("attachment://%s", cardFile.Name)}
+		files = []*discordgo.File{cardFile}
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    h.pvpReplyContent(reply),
+			Embeds:     []*discordgo.MessageEmbed{reply.embed},
+			Components: reply.components,
+			Files:      files,
+		},
+	})
+	if err != nil {
+		log.Printf("Error starting PvP battle: %v", err)
+	}
+}
+
+// checkPvPAccept returns the challenge the trainer is accepting, or the reply refusing it.
+// Expired challenges are removed. Caller must hold pvpMu.
+func (h *Handlers) checkPvPAccept(battleID, trainerID string) (*game.PvPBattleState, *pvpReply) {
+	pvpState := h.pvpBattles[battleID]
+	switch {
+	case pvpState == nil:
+		return nil, &pvpReply{ephemeral: "Battle not found or already ended"}
+	case pvpState.SideOf(trainerID) == "":
+		return pvpState, &pvpReply{ephemeral: "This isn't your battle!"}
+	case pvpState.State != game.PvPStatePending:
+		return pvpState, &pvpReply{ephemeral: "This challenge has already been answered!"}
+	case pvpState.SideOf(trainerID) != game.PvPSideTrainer2:
+		return pvpState, &pvpReply{ephemeral: "Only the challenged trainer can accept!"}
+	case pvpState.IsExpired():
+		delete(h.pvpBattles, pvpState.ID)
+		return pvpState, &pvpReply{embed: &discordgo.MessageEmbed{
+			Title:       "⚔️ PvP Challenge",
+			Description: "This challenge has expired.",
+			Color:       0x808080,
+		}}
+	}
+	return pvpState, nil
+}
+
+// acceptPvPChallenge starts the battle for an accepted challenge and builds its first battle
+// message. Caller must hold pvpMu.
+func (h *Handlers) acceptPvPChallenge(pvpState *game.PvPBattleState, messageID string, party1, party2 []*game.Gopher) *pvpReply {
+	if err := pvpState.Accept(party1, party2); err != nil {
+		delete(h.pvpBattles, pvpState.ID)
+		return &pvpReply{ephemeral: fmt.Sprintf("Couldn't start the battle: %v", err)}
+	}
+	pvpState.MessageID = messageID
+
+	reply := h.pvpBattleReply(pvpState, nil, "")
+	reply.card = h.copyPvPCard(pvpState)
+	return reply
+}
+
+// handlePvPDecline removes a challenge the challenged trainer declined or the challenger
+// withdrew. Caller must hold pvpMu.
+func (h *Handlers) handlePvPDecline(pvpState *game.PvPBattleState, side string) *pvpReply {
+	if pvpState.State != game.PvPStatePending {
+		return &pvpReply{ephemeral: "This challenge has already been answered!"}
+	}
+
+	delete(h.pvpBattles, pvpState.ID)
+
+	description := fmt.Sprintf("%s declined the challenge.", pvpState.Trainer2Name)
+	if side == game.PvPSideTrainer1 {
+		description = fmt.Sprintf("%s withdrew the challenge.", pvpState.Trainer1Name)
+	}
+
+	return &pvpReply{embed: &discordgo.MessageEmbed{
+		Title:       "⚔️ PvP Challenge",
+		Description: description,
+		Color:       0x808080,
+	}}
+}
+
+// pvpTurnReason returns why the given side can't act, or "" if the battle is active and it's
+// their turn
+func pvpTurnReason(pvpState *game.PvPBattleState, side string) string {
+	if pvpState.State != game.PvPStateActive {
+		return "This battle isn't active!"
+	}
+	if pvpState.TurnOwner != side {
+		return "It's not your turn!"
+	}
+	return ""
+}
+
+// executePvPAction runs an action through the PvP engine and builds the reply that updates the
+// battle message. A battle that ended is removed, and recorded once the reply is sent.
+// Caller must hold pvpMu.
+func (h *Handlers) executePvPAction(pvpState *game.PvPBattleState, trainerID, action string, index int) *pvpReply {
+	trainer1Gopher := pvpState.Trainer1Gopher.ID
+	trainer2Gopher := pvpState.Trainer2Gopher.ID
+
+	messages, err := pvpState.Action(trainerID, action, index)
+	if err != nil {
+		return &pvpReply{ephemeral: fmt.Sprintf("Error: %v", err)}
+	}
+
+	reply := h.pvpBattleReply(pvpState, messages, "")
+	if pvpState.IsOver() {
+		delete(h.pvpBattles, pvpState.ID)
+		reply.finished = true
+	}
+
+	// Active gophers changed, so the battle card needs to be redrawn
+	if pvpState.Trainer1Gopher.ID != trainer1Gopher || pvpState.Trainer2Gopher.ID != trainer2Gopher {
+		reply.card = h.copyPvPCard(pvpState)
+	}
+	return reply
+}
+
+// pvpBattleReply builds the battle message with the given menu open. Caller must hold pvpMu
+// while the battle is shared.
+func (h *Handlers) pvpBattleReply(pvpState *game.PvPBattleState, messages []string, menu string) *pvpReply {
+	reply := &pvpReply{
+		content:    strings.Join(messages, "\n"),
+		embed:      h.createPvPEmbed(pvpState),
+		components: h.createPvPButtons(pvpState, menu),
+	}
+	if pvpState.State == game.PvPStateActive {
+		reply.turn = currentPvPTurn(pvpState)
+	}
+	return reply
+}
+
+// sendPvPReply sends a reply built under pvpMu, recording the battle's result first if it
+// ended. Caller must not hold pvpMu.
+func (h *Handlers) sendPvPReply(s *discordgo.Session, i *discordgo.InteractionCreate, pvpState *game.PvPBattleState, reply *pvpReply) {
+	if reply.ephemeral != "" {
+		respondEphemeral(s, i, reply.ephemeral)
+		return
+	}
+
+	content := h.pvpReplyContent(reply)
+	if reply.finished {
+		// Nothing else can reach the battle once it's removed
+		content = strings.Join(append([]string{content}, h.recordPvPResult(pvpState)...), "\n")
+		h.savePvPReplay(pvpState)
+
+		// Announce unlocks after the battle message has been updated
+		defer h.evaluateAchievements(s, i.ChannelID, pvpState.Trainer1ID)
+		defer h.evaluateAchievements(s, i.ChannelID, pvpState.Trainer2ID)
+		if winner := pvpState.WinnerSide(); winner != "" {
+			defer h.progressQuests(s, i.ChannelID, pvpState.TrainerIDFor(winner), game.QuestWinBattles, game.QuestWin10Battles)
+		}
+	}
+
+	if reply.card != nil {
+		if cardFile := h.createPvPCardFile(reply.card); cardFile != nil {
+			h.resendPvPMessage(s, i, pvpState, content, reply.embed, reply.components, cardFile)
+			return
+		}
+	}
+
+	updatePvPMessage(s, i, content, reply.embed, reply.components)
+}
+
+// pvpReplyContent returns the reply's message text, below a ping for whoever's turn it is
+func (h *Handlers) pvpReplyContent(reply *pvpReply) string {
+	if reply.turn == nil {
+		return reply.content
+	}
+	mention := h.pvpTurnMention(reply.turn)
+	if reply.content == "" {
+		return mention
+	}
+	return mention + "\n" + reply.content
+}
+
+// resendPvPMessage replaces the battle message with a new one carrying an updated battle card.
+// Caller must not hold pvpMu.
+func (h *Handlers) resendPvPMessage(s *discordgo.Session, i *discordgo.InteractionCreate, pvpState *game.PvPBattleState, content string, embed *discordgo.MessageEmbed, components []discordgo.MessageComponent, cardFile *discordgo.File) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("Error acknowledging PvP action: %v", err)
+		return
+	}
+
+	embed.Image = &discordgo.MessageEmbedImage{URL: fmt.Sprintf("attachment://%s", cardFile.Name)}
+	msg, err := s.ChannelMessageSendComplex(i.ChannelID, &discordgo.MessageSend{
+		Content:    content,
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: components,
+		Files:      []*discordgo.File{cardFile},
+	})
+	if err != nil {
+		log.Printf("Error sending updated PvP message: %v", err)
+		embed.Image = nil
+		h.editBattleMessageSimple(s, i, content, embed, components)
+		return
+	}
+
+	h.pvpMu.Lock()
+	pvpState.MessageID = msg.ID
+	h.pvpMu.Unlock()
+	if err := s.ChannelMessageDelete(i.ChannelID, i.Message.ID); err != nil {
+		log.Printf("Error deleting old PvP message: %v", err)
+	}
+}
+
+// recordPvPResult updates both trainers' ELO ratings and returns messages describing the change
+func (h *Handlers) recordPvPResult(pvpState *game.PvPBattleState) []string {
+	return h.updatePvPRatings(pvpState.Trainer1ID, pvpState.Trainer1Name, pvpState.Trainer2ID, pvpState.Trainer2Name, pvpState.State)
+}
+
+// updatePvPRatings updates two trainers' ELO ratings for a finished battle and returns messages
+// describing the change. result is the PvP state the battle ended in.
+func (h *Handlers) updatePvPRatings(trainer1ID, trainer1Name, trainer2ID, trainer2Name, result string) []string {
+	stats1, err := h.pvpRepo.GetOrCreate(trainer1ID)
+	if err != nil {
+		log.Printf("Error loading PvP stats: %v", err)
+		return nil
+	}
+	stats2, err := h.pvpRepo.GetOrCreate(trainer2ID)
+	if err != nil {
+		log.Printf("Error loading PvP stats: %v", err)
+		return nil
+	}
+
+	var newRating1, newRating2 int
+	switch result {
+	case game.PvPStateDraw:
+		newRating1, newRating2 = game.CalculateELODraw(stats1.Rating, stats2.Rating)
+		if err := h.pvpRepo.UpdateRatingDraw(trainer1ID, newRating1); err != nil {
+			log.Printf("Error updating PvP rating: %v", err)
+		}
+		if err := h.pvpRepo.UpdateRatingDraw(trainer2ID, newRating2); err != nil {
+			log.Printf("Error updating PvP rating: %v", err)
+		}
+	default:
+		trainer1Won := result == game.PvPStateTrainer1Won
+		newRating1, newRating2 = game.CalculateELO(stats1.Rating, stats2.Rating, trainer1Won)
+		if err := h.pvpRepo.UpdateRating(trainer1ID, newRating1, trainer1Won); err != nil {
+			log.Printf("Error updating PvP rating: %v", err)
+		}
+		if err := h.pvpRepo.UpdateRating(trainer2ID, newRating2, !trainer1Won); err != nil {
+			log.Printf("Error updating PvP rating: %v", err)
+		}
+	}
+
+	return []string{
+		"",
+		fmt.Sprintf("%s: %d → %d (%+d)", trainer1Name, stats1.Rating, newRating1, newRating1-stats1.Rating),
+		fmt.Sprintf("%s: %d → %d (%+d)", trainer2Name, stats2.Rating, newRating2, newRating2-stats2.Rating),
+	}
+}
+
+// loadPvPParty loads a trainer's party as game gophers for a PvP battle
+func (h *Handlers) loadPvPParty(trainerID string) ([]*game.Gopher, error) {
+	partyStorage, err := h.battleParty(trainerID)
+	if err != nil {
+		return nil, err
+	}
+
+	party := make([]*game.Gopher, 0, len(partyStorage))
+	for _, gopherStorage := range partyStorage {
+		gameGopher, err := h.gameService.StorageGopherToGameGopher(gopherStorage)
+		if err != nil {
+			return nil, err
+		}
+		party = append(party, gameGopher)
+	}
+	return party, nil
+}
+
+// findPvPBattleByTrainer returns the pending or active PvP battle a trainer is part of.
+// Caller must hold pvpMu.
+func (h *Handlers) findPvPBattleByTrainer(trainerID string) *game.PvPBattleState {
+	for _, pvpState := range h.pvpBattles {
+		if pvpState.SideOf(trainerID) != "" {
+			return pvpState
+		}
+	}
+	return nil
+}
+
+// cleanupExpiredChallenges removes challenges nobody answered in time. Caller must hold pvpMu.
+func (h *Handlers) cleanupExpiredChallenges() {
+	for id, pvpState := range h.pvpBattles {
+		if pvpState.IsExpired() {
+			delete(h.pvpBattles, id)
+		}
+	}
+}
+
+// currentPvPTurn returns whose turn it is. Caller must hold pvpMu while the battle is shared.
+func currentPvPTurn(pvpState *game.PvPBattleState) *pvpTurn {
+	return &pvpTurn{
+		trainerID:   pvpState.TrainerIDFor(pvpState.TurnOwner),
+		trainerName: pvpState.TrainerNameFor(pvpState.TurnOwner),
+		forcedSwap:  pvpState.NeedsForcedSwap(),
+	}
+}
+
+func (h *Handlers) pvpTurnMention(turn *pvpTurn) string {
+	trainer, err := h.trainerRepo.GetByID(turn.trainerID)
+	if err != nil || trainer == nil {
+		return fmt.Sprintf("%s's turn!", turn.trainerName)
+	}
+	if turn.forcedSwap {
+		return fmt.Sprintf("<@%s>, choose your next gopher!", trainer.DiscordID)
+	}
+	return fmt.Sprintf("<@%s>, it's your turn!", trainer.DiscordID)
+}
+
+func (h *Handlers) createPvPEmbed(pvpState *game.PvPBattleState) *discordgo.MessageEmbed {
+	description := ""
+	if len(pvpState.Log) > 0 {
+		// Show last 4 log entries
+		start := len(pvpState.Log) - 4
+		if start < 0 {
+			start = 0
+		}
+		description = strings.Join(pvpState.Log[start:], "\n")
+	}
+
+	color := 0xff9900
+	if pvpState.IsOver() {
+		color = 0xffd700
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("⚔️ %s vs %s", pvpState.Trainer1Name, pvpState.Trainer2Name),
+		Description: description,
+		Color:       color,
+		Fields:      []*discordgo.MessageEmbedField{},
+	}
+
+	// Trainer 2 is drawn on top of the battle card, so list them first
+	for _, side := range []string{game.PvPSideTrainer2, game.PvPSideTrainer1} {
+		gopher := pvpState.ActiveGopher(side)
+		remaining := 0
+		party := pvpState.Party(side)
+		for _, member := range party {
+			if member.CurrentHP > 0 {
+				remaining++
+			}
+		}
+
+		value := fmt.Sprintf("HP: %s\n⚡ Energy: %d/%d\nGophers left: %d/%d", game.GetHPBar(gopher.CurrentHP, gopher.MaxHP, 12),
+			pvpState.Energy(gopher), game.MaxEnergy(gopher), remaining, len(party))
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s: %s (Lv.%d)", pvpState.TrainerNameFor(side), gopher.Name, gopher.Level),
+			Value:  value,
+			Inline: false,
+		})
+	}
+
+	if field := fieldEmbedField(pvpState.Field); field != nil && !pvpState.IsOver() {
+		embed.Fields = append(embed.Fields, field)
+	}
+
+	if pvpState.IsOver() {
+		result := "Draw"
+		if winner := pvpState.WinnerSide(); winner != "" {
+			result = fmt.Sprintf("%s wins!", pvpState.TrainerNameFor(winner))
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Battle Result",
+			Value:  result,
+			Inline: false,
+		})
+	}
+
+	return embed
+}
+
+// createPvPButtons builds the components for the current turn owner. menu is "fight" for the
+// ability list, "swap" for the party list, or "" for the main actions.
+func (h *Handlers) createPvPButtons(pvpState *game.PvPBattleState, menu string) []discordgo.MessageComponent {
+	if pvpState.State != game.PvPStateActive {
+		return nil
+	}
+
+	// A fainted gopher must be replaced before anything else
+	if pvpState.NeedsForcedSwap() {
+		menu = "swap"
+	}
+
+	gopher := pvpState.ActiveGopher(pvpState.TurnOwner)
+
+	switch menu {
+	case "fight":
+		// Abilities are greyed out when the gopher can't afford them
+		buttons := []discordgo.MessageComponent{}
+		for idx, ability := range gopher.Abilities {
+			if idx >= 4 {
+				break
+			}
+			buttons = append(buttons, &ButtonWithoutEmoji{
+				Label:    fmt.Sprintf("%s (%d⚡)", ability.Name, ability.Cost),
+				Style:    discordgo.PrimaryButton,
+				Disabled: !pvpState.CanAfford(gopher, ability),
+				CustomID: fmt.Sprintf("pvp_ability_%s_%d", pvpState.ID, idx),
+			})
+		}
+		buttons = append(buttons, createButton("Rest", discordgo.SecondaryButton, fmt.Sprintf("pvp_rest_%s", pvpState.ID)))
+		return []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: buttons},
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					createButton("Back", discordgo.SecondaryButton, fmt.Sprintf("pvp_back_%s", pvpState.ID)),
+				},
+			},
+		}
+	case "swap":
+		buttons := []discordgo.MessageComponent{}
+		for idx, member := range pvpState.Party(pvpState.TurnOwner) {
+			if member.ID == gopher.ID || member.CurrentHP <= 0 {
+				continue
+			}
+
+			label := fmt.Sprintf("%s (%d/%d)", member.Name, member.CurrentHP, member.MaxHP)
+			if len(label) > 80 {
+				label = label[:77] + "..."
+			}
+			buttons = append(buttons, createButton(label, discordgo.SecondaryButton, fmt.Sprintf("pvp_pick_%s_%d", pvpState.ID, idx)))
+
+			if len(buttons) >= 5 {
+				break // Discord limit
+			}
+		}
+
+		rows := []discordgo.MessageComponent{}
+		if len(buttons) > 0 {
+			rows = append(rows, discordgo.ActionsRow{Components: buttons})
+		}
+		if !pvpState.NeedsForcedSwap() {
+			rows = append(rows, discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					createButton("Back", discordgo.SecondaryButton, fmt.Sprintf("pvp_back_%s", pvpState.ID)),
+				},
+			})
+		}
+		return rows
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				createButton("Fight", discordgo.PrimaryButton, fmt.Sprintf("pvp_fight_%s", pvpState.ID)),
+				createButton("Swap", discordgo.SecondaryButton, fmt.Sprintf("pvp_swap_%s", pvpState.ID)),
+				createButton("Forfeit", discordgo.DangerButton, fmt.Sprintf("pvp_forfeit_%s", pvpState.ID)),
+			},
+		},
+	}
+}
+
+// copyPvPCard copies the two active gophers for the battle card. Caller must hold pvpMu while
+// the battle is shared.
+func (h *Handlers) copyPvPCard(pvpState *game.PvPBattleState) *pvpCard {
+	return &pvpCard{
+		name:     fmt.Sprintf("pvp_%s_%d.png", pvpState.ID[:8], pvpState.Turn),
+		trainer1: h.gameGopherToStorage(pvpState.Trainer1Gopher),
+		trainer2: h.gameGopherToStorage(pvpState.Trainer2Gopher),
+	}
+}
+
+// createPvPCardFile renders the battle card for the two active gophers
+func (h *Handlers) createPvPCardFile(card *pvpCard) *discordgo.File {
+	cardBase64, err := h.gameService.GenerateBattleCard(card.trainer2, card.trainer1)
+	if err != nil || cardBase64 == "" {
+		if err != nil {
+			log.Printf("Error generating PvP battle card: %v", err)
+		}
+		return nil
+	}
+
+	fileData, err := base64.StdEncoding.DecodeString(cardBase64)
+	if err != nil {
+		log.Printf("Error decoding PvP battle card base64: %v", err)
+		return nil
+	}
+
+	return &discordgo.File{
+		Name:        card.name,
+		ContentType: "image/png",
+		Reader:      bytes.NewReader(fileData),
+	}
+}
+
+// updatePvPMessage updates the message the component was clicked on
+func updatePvPMessage(s *discordgo.Session, i *discordgo.InteractionCreate, content string, embed *discordgo.MessageEmbed, components []discordgo.MessageComponent) {
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: components,
+		},
+	})
+	if err != nil {
+		log.Printf("Error updating PvP message: %v", err)
+	}
+}
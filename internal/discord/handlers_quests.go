@@ -0,0 +1,178 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"gophermon-bot/internal/game"
+	"gophermon-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func (h *Handlers) handleQuests(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	discordID := i.Member.User.ID
+
+	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	if err := h.questService.EnsureQuests(trainer.ID); err != nil {
+		log.Printf("Error generating quests: %v", err)
+	}
+
+	quests, err := h.questService.GetCurrentQuests(trainer.ID)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error loading quests: %v", err))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:  "📋 Quests 📋",
+		Color:  0x00ff00,
+		Fields: []*discordgo.MessageEmbedField{},
+	}
+
+	for _, questType := range []string{game.QuestTypeDaily, game.QuestTypeWeekly} {
+		lines := []string{}
+		var expiresAt int64
+		for _, quest := range quests {
+			if quest.QuestType != questType {
+				continue
+			}
+			expiresAt = quest.ExpiresAt.Unix()
+			lines = append(lines, describeQuest(quest))
+		}
+		if len(lines) == 0 {
+			continue
+		}
+
+		name := "☀️ Daily Quests"
+		if questType == game.QuestTypeWeekly {
+			name = "📅 Weekly Quests"
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  fmt.Sprintf("%s\nResets <t:%d:R>", strings.Join(lines, "\n"), expiresAt),
+			Inline: false,
+		})
+	}
+
+	if len(embed.Fields) == 0 {
+		embed.Description = "You have no quests right now."
+	}
+
+	respondEmbed(s, i, embed, true)
+}
+
+// progressQuests adds one point of progress to each named quest, generating the trainer's
+// daily and weekly quests first if needed. Completed quests pay out and are announced.
+func (h *Handlers) progressQuests(s *discordgo.Session, channelID, trainerID string, questNames ...string) {
+	if err := h.questService.EnsureQuests(trainerID); err != nil {
+		log.Printf("Error generating quests: %v", err)
+		return
+	}
+
+	for _, questName := range questNames {
+		quest, err := h.questService.UpdateQuestProgress(trainerID, questName, 1)
+		if err != nil {
+			log.Printf("Error updating quest %s: %v", questName, err)
+		}
+		if quest == nil || !quest.Completed {
+			continue
+		}
+
+		xpMessage := h.awardQuestXP(s, channelID, trainerID, quest.RewardXP)
+		h.announceQuest(s, channelID, trainerID, quest, xpMessage)
+	}
+}
+
+// awardQuestXP gives a quest's XP reward to the trainer's lead gopher and describes the result
+func (h *Handlers) awardQuestXP(s *discordgo.Session, channelID, trainerID string, xp int) string {
+	if xp <= 0 {
+		return ""
+	}
+
+	party, err := h.gopherRepo.GetParty(trainerID)
+	if err != nil || len(party) == 0 {
+		return ""
+	}
+
+	lead, err := h.gameService.StorageGopherToGameGopher(party[0])
+	if err != nil {
+		log.Printf("Error loading lead gopher for quest XP: %v", err)
+		return ""
+	}
+
+	leveledUp, newLevel := lead.AddXP(xp, h.gameService.Rand())
+	if err := h.gopherRepo.Update(h.gameGopherToStorage(lead)); err != nil {
+		log.Printf("Error saving quest XP: %v", err)
+		return ""
+	}
+	if err := h.statsRepo.AddXP(trainerID, xp); err != nil {
+		log.Printf("Error recording XP stats: %v", err)
+	}
+
+	message := fmt.Sprintf("⭐ %d XP for %s", xp, lead.Name)
+	if leveledUp {
+		message += fmt.Sprintf(" (now level %d!)", newLevel)
+		for _, learned := range h.offerNewMoves(s, channelID, trainerID, lead) {
+			message += "\n" + learned
+		}
+	}
+	return message
+}
+
+// announceQuest posts an embed for a completed quest
+func (h *Handlers) announceQuest(s *discordgo.Session, channelID, trainerID string, quest *storage.Quest, xpMessage string) {
+	trainer, err := h.trainerRepo.GetByID(trainerID)
+	if err != nil || trainer == nil || channelID == "" {
+		return
+	}
+
+	rewards := []string{}
+	if quest.RewardCurrency > 0 {
+		rewards = append(rewards, fmt.Sprintf("💰 %d GoCoins", quest.RewardCurrency))
+	}
+	if xpMessage != "" {
+		rewards = append(rewards, xpMessage)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "📋 Quest Complete!",
+		Description: fmt.Sprintf("**%s** completed **%s**!\n%s", trainer.Name, quest.QuestName, quest.Description),
+		Color:       0x00ff00,
+	}
+	if len(rewards) > 0 {
+		embed.Fields = []*discordgo.MessageEmbedField{
+			{Name: "Rewards", Value: strings.Join(rewards, "\n"), Inline: false},
+		}
+	}
+
+	_, err = s.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content: fmt.Sprintf("<@%s>", trainer.DiscordID),
+		Embeds:  []*discordgo.MessageEmbed{embed},
+	})
+	if err != nil {
+		log.Printf("Error announcing quest: %v", err)
+	}
+}
+
+func describeQuest(quest *storage.Quest) string {
+	marker := "⬜"
+	if quest.Completed {
+		marker = "✅"
+	}
+
+	progress := quest.CurrentProgress
+	if progress > quest.TargetValue {
+		progress = quest.TargetValue
+	}
+
+	return fmt.Sprintf("%s **%s** `%s` %d/%d • %d GoCoins, %d XP",
+		marker, quest.QuestName, progressBar(progress, quest.TargetValue, 10), progress, quest.TargetValue,
+		quest.RewardCurrency, quest.RewardXP)
+}
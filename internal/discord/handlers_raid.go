@@ -0,0 +1,532 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gophermon-bot/internal/game"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+)
+
+// Raid button custom IDs have the form raid_<action>_<raidID>[_<index>]
+
+func (h *Handlers) handleRaid(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 || data.Options[0].Name != "start" {
+		respondEphemeral(s, i, "Unknown raid subcommand")
+		return
+	}
+
+	if !h.isAdmin(s, i) {
+		respondEphemeral(s, i, "❌ You need Administrator permissions to start raids!")
+		return
+	}
+
+	level := game.RaidDefaultLevel
+	for _, opt := range data.Options[0].Options {
+		if opt.Name == "level" {
+			level = int(opt.IntValue())
+			if level < 1 {
+				level = 1
+			}
+			if level > 100 {
+				level = 100
+			}
+		}
+	}
+
+	// Drawing the boss can take a moment
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error deferring raid start: %v", err)
+		return
+	}
+
+	content := "👹 The raid boss has appeared!"
+	if err := h.SpawnRaid(s, i.ChannelID, level); err != nil {
+		content = fmt.Sprintf("Error starting raid: %v", err)
+	}
+	if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+		log.Printf("Error responding to raid start: %v", err)
+	}
+}
+
+// SpawnRaid posts a raid boss lobby in a channel for trainers to join
+func (h *Handlers) SpawnRaid(s *discordgo.Session, channelID string, level int) error {
+	raidID := uuid.New().String()
+	boss, err := h.gameService.GenerateRaidBoss("raid_"+raidID, level)
+	if err != nil {
+		return fmt.Errorf("failed to generate raid boss: %w", err)
+	}
+
+	raid := game.NewRaidBattleState(channelID, boss, h.gameService.GetEventManager(), h.gameService.NewBattleSeed())
+	raid.ID = raidID
+
+	embed := h.createRaidEmbed(raid)
+	message := &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: h.createRaidButtons(raid),
+	}
+	if spriteData, err := base64.StdEncoding.DecodeString(boss.SpriteData); err == nil && len(spriteData) > 0 {
+		fileName := fmt.Sprintf("raid_%s.png", raidID[:8])
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: fmt.Sprintf("attachment://%s", fileName)}
+		message.Files = []*discordgo.File{{
+			Name:        fileName,
+			ContentType: "image/png",
+			Reader:      bytes.NewReader(spriteData),
+		}}
+	}
+
+	msg, err := s.ChannelMessageSendComplex(channelID, message)
+	if err != nil {
+		return fmt.Errorf("failed to send raid message: %w", err)
+	}
+	raid.MessageID = msg.ID
+
+	h.raidMu.Lock()
+	defer h.raidMu.Unlock()
+	h.raids[raid.ID] = raid
+	return nil
+}
+
+func (h *Handlers) handleRaidComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+	discordID := i.Member.User.ID
+
+	parts := strings.Split(data.CustomID, "_")
+	if len(parts) < 3 {
+		respondEphemeral(s, i, "Unknown action")
+		return
+	}
+	action := parts[1]
+	raidID := parts[2]
+	index := -1
+	if len(parts) > 3 {
+		parsed, err := strconv.Atoi(parts[3])
+		if err != nil {
+			respondEphemeral(s, i, "Invalid selection")
+			return
+		}
+		index = parsed
+	}
+
+	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	h.raidMu.Lock()
+	defer h.raidMu.Unlock()
+
+	h.cleanupExpiredRaids(s)
+	raid := h.raids[raidID]
+	if raid == nil {
+		respondEphemeral(s, i, "Raid not found or already ended")
+		return
+	}
+
+	switch action {
+	case "join":
+		h.handleRaidJoin(s, i, raid, trainer.ID, trainer.Name)
+	case "start":
+		if raid.Participant(trainer.ID) == nil {
+			respondEphemeral(s, i, "Only trainers who joined the raid can start it!")
+			return
+		}
+		if err := raid.Start(); err != nil {
+			respondEphemeral(s, i, fmt.Sprintf("Couldn't start the raid: %v", err))
+			return
+		}
+		updatePvPMessage(s, i, h.raidMentions(raid), h.createRaidEmbed(raid), h.createRaidButtons(raid))
+	case "move":
+		h.showRaidMoves(s, i, raid, trainer.ID)
+	case "ability":
+		h.executeRaidChoice(s, i, raid, trainer.ID, index)
+	case "rest":
+		h.executeRaidChoice(s, i, raid, trainer.ID, game.RaidChoiceRest)
+	default:
+		respondEphemeral(s, i, "Unknown action")
+	}
+}
+
+// handleRaidJoin adds the trainer's first healthy party gopher to the raid, starting it once
+// the raid is full. Caller must hold raidMu.
+func (h *Handlers) handleRaidJoin(s *discordgo.Session, i *discordgo.InteractionCreate, raid *game.RaidBattleState, trainerID, trainerName string) {
+	if raid.Participant(trainerID) != nil {
+		respondEphemeral(s, i, "You've already joined this raid!")
+		return
+	}
+	if h.findRaidByTrainer(trainerID) != nil {
+		respondEphemeral(s, i, "You're already in another raid!")
+		return
+	}
+	// The raid fights with a party gopher, so it can't also be in another battle
+	if reason := h.nonRaidBusyReason(trainerID); reason != "" {
+		respondEphemeral(s, i, reason)
+		return
+	}
+
+	party, err := h.battleParty(trainerID)
+	if err != nil || len(party) == 0 {
+		respondEphemeral(s, i, noBattlePartyMessage)
+		return
+	}
+	var gopher *game.Gopher
+	for _, member := range party {
+		if member.CurrentHP > 0 {
+			gopher, err = h.gameService.StorageGopherToGameGopher(member)
+			if err != nil {
+				respondEphemeral(s, i, fmt.Sprintf("Error: %v", err))
+				return
+			}
+			break
+		}
+	}
+	if gopher == nil {
+		respondEphemeral(s, i, "All your gophers are fainted! You need to rest.")
+		return
+	}
+
+	if err := raid.Join(trainerID, trainerName, gopher); err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Couldn't join the raid: %v", err))
+		return
+	}
+
+	content := ""
+	if raid.IsFull() {
+		if err := raid.Start(); err != nil {
+			log.Printf("Error starting full raid: %v", err)
+		} else {
+			content = h.raidMentions(raid)
+		}
+	}
+	updatePvPMessage(s, i, content, h.createRaidEmbed(raid), h.createRaidButtons(raid))
+}
+
+// showRaidMoves sends the participant their gopher's abilities to choose from
+func (h *Handlers) showRaidMoves(s *discordgo.Session, i *discordgo.InteractionCreate, raid *game.RaidBattleState, trainerID string) {
+	if reason := raid.CheckChoice(trainerID, game.RaidChoiceRest); reason != "" {
+		respondEphemeral(s, i, reason)
+		return
+	}
+
+	// Abilities are greyed out when the gopher can't afford them
+	gopher := raid.Participant(trainerID).Gopher
+	buttons := []discordgo.MessageComponent{}
+	for idx, ability := range gopher.Abilities {
+		if idx >= 5 {
+			break // Discord limit
+		}
+		buttons = append(buttons, &ButtonWithoutEmoji{
+			Label:    fmt.Sprintf("%s (%d⚡)", ability.Name, ability.Cost),
+			Style:    discordgo.PrimaryButton,
+			Disabled: !raid.CanAfford(gopher, ability),
+			CustomID: fmt.Sprintf("raid_ability_%s_%d", raid.ID, idx),
+		})
+	}
+
+	content := fmt.Sprintf("Round %d: choose a move for %s (HP %d/%d • ⚡ %d/%d)", raid.Round, gopher.Name,
+		gopher.CurrentHP, gopher.MaxHP, raid.Energy(gopher), game.MaxEnergy(gopher))
+	respondWithComponents(s, i, content, nil, []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: buttons},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				createButton("Rest", discordgo.SecondaryButton, fmt.Sprintf("raid_rest_%s", raid.ID)),
+			},
+		},
+	}, true)
+}
+
+// executeRaidChoice records a participant's move, resolving the round once everyone has
+// chosen, and updates the raid message
+func (h *Handlers) executeRaidChoice(s *discordgo.Session, i *discordgo.InteractionCreate, raid *game.RaidBattleState, trainerID string, index int) {
+	if reason := raid.CheckChoice(trainerID, index); reason != "" {
+		respondEphemeral(s, i, reason)
+		return
+	}
+
+	participant := raid.Participant(trainerID)
+	chosen := index
+	if participant.Choice != game.RaidChoiceNone {
+		chosen = participant.Choice
+	}
+	reply := "You chose to rest!"
+	if chosen >= 0 {
+		reply = fmt.Sprintf("You chose %s!", participant.Gopher.Abilities[chosen].Name)
+	}
+
+	// The choice was made from the participant's private move list
+	messages := raid.Choose(trainerID, index)
+	if messages == nil {
+		reply += " Waiting for the others..."
+	}
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    reply,
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		log.Printf("Error updating raid move message: %v", err)
+	}
+
+	content := ""
+	if raid.IsOver() {
+		messages = append(messages, h.finishRaid(s, raid)...)
+		content = strings.Join(messages, "\n")
+	} else if messages != nil {
+		content = h.raidMentions(raid)
+	}
+	h.editRaidMessage(s, raid, content)
+}
+
+// finishRaid pays out a won raid's rewards, records the battle for every participant and
+// removes the raid. Caller must hold raidMu.
+func (h *Handlers) finishRaid(s *discordgo.Session, raid *game.RaidBattleState) []string {
+	delete(h.raids, raid.ID)
+	won := raid.State == game.RaidStateWon
+
+	messages := []string{}
+	for _, reward := range raid.Rewards() {
+		participant := raid.Participant(reward.TrainerID)
+		lines := []string{fmt.Sprintf("%d damage", reward.Damage)}
+
+		if err := h.trainerRepo.AddCurrency(reward.TrainerID, reward.Currency); err != nil {
+			log.Printf("Error paying raid reward: %v", err)
+		} else {
+			lines = append(lines, fmt.Sprintf("💰 %d GoCoins", reward.Currency))
+		}
+		if xpMessage := h.awardRaidXP(s, raid.ChannelID, reward); xpMessage != "" {
+			lines = append(lines, xpMessage)
+		}
+		if reward.Item != "" {
+			if err := h.itemRepo.AddItem(reward.TrainerID, reward.Item, 1); err != nil {
+				log.Printf("Error awarding raid MVP item: %v", err)
+			} else {
+				lines = append(lines, fmt.Sprintf("🏅 MVP: %s", game.ItemNames[reward.Item]))
+			}
+		}
+		messages = append(messages, fmt.Sprintf("**%s**: %s", participant.TrainerName, strings.Join(lines, " • ")))
+	}
+
+	for _, participant := range raid.Participants {
+		if err := h.statsRepo.IncrementBattles(participant.TrainerID, won); err != nil {
+			log.Printf("Error recording raid stats: %v", err)
+		}
+		if won {
+			h.progressQuests(s, raid.ChannelID, participant.TrainerID, game.QuestWinBattles, game.QuestWin10Battles)
+		}
+		h.evaluateAchievements(s, raid.ChannelID, participant.TrainerID)
+	}
+
+	return messages
+}
+
+// awardRaidXP gives a participant's gopher its share of the raid XP and returns a message
+// describing it
+func (h *Handlers) awardRaidXP(s *discordgo.Session, channelID string, reward *game.RaidReward) string {
+	if reward.XP <= 0 {
+		return ""
+	}
+
+	gopherStorage, err := h.gopherRepo.GetByID(reward.GopherID)
+	if err != nil || gopherStorage == nil {
+		log.Printf("Error loading gopher for raid XP: %v", err)
+		return ""
+	}
+	gopher, err := h.gameService.StorageGopherToGameGopher(gopherStorage)
+	if err != nil {
+		log.Printf("Error loading gopher for raid XP: %v", err)
+		return ""
+	}
+
+	leveledUp, newLevel := gopher.AddXP(reward.XP, h.gameService.Rand())
+	if err := h.gopherRepo.Update(h.gameGopherToStorage(gopher)); err != nil {
+		log.Printf("Error saving raid XP: %v", err)
+		return ""
+	}
+	if err := h.statsRepo.AddXP(reward.TrainerID, reward.XP); err != nil {
+		log.Printf("Error recording XP stats: %v", err)
+	}
+
+	message := fmt.Sprintf("⭐ %d XP for %s", reward.XP, gopher.Name)
+	if leveledUp {
+		message += fmt.Sprintf(" (now level %d!)", newLevel)
+		for _, learned := range h.offerNewMoves(s, channelID, reward.TrainerID, gopher) {
+			message += "\n" + learned
+		}
+	}
+	return message
+}
+
+// findRaidByTrainer returns the raid a trainer has joined. Caller must hold raidMu.
+func (h *Handlers) findRaidByTrainer(trainerID string) *game.RaidBattleState {
+	for _, raid := range h.raids {
+		if raid.Participant(trainerID) != nil {
+			return raid
+		}
+	}
+	return nil
+}
+
+// cleanupExpiredRaids closes lobbies nobody started and raids everyone walked away from.
+// Caller must hold raidMu.
+func (h *Handlers) cleanupExpiredRaids(s *discordgo.Session) {
+	for id, raid := range h.raids {
+		if raid.IsExpired() {
+			raid.Expire()
+			delete(h.raids, id)
+			h.editRaidMessage(s, raid, "")
+		}
+	}
+}
+
+// raidMentions pings the participants who still need to choose a move this round
+func (h *Handlers) raidMentions(raid *game.RaidBattleState) string {
+	mentions := []string{}
+	for _, participant := range raid.Participants {
+		if participant.Gopher.CurrentHP <= 0 || participant.Choice != game.RaidChoiceNone {
+			continue
+		}
+		trainer, err := h.trainerRepo.GetByID(participant.TrainerID)
+		if err != nil || trainer == nil {
+			continue
+		}
+		mentions = append(mentions, fmt.Sprintf("<@%s>", trainer.DiscordID))
+	}
+	if len(mentions) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%s, choose your moves for round %d!", strings.Join(mentions, " "), raid.Round)
+}
+
+func (h *Handlers) createRaidEmbed(raid *game.RaidBattleState) *discordgo.MessageEmbed {
+	boss := raid.Boss
+
+	// Show last 6 log entries
+	start := len(raid.Log) - 6
+	if start < 0 {
+		start = 0
+	}
+
+	color := 0x8b0000
+	switch raid.State {
+	case game.RaidStateWon:
+		color = 0xffd700
+	case game.RaidStateLost, game.RaidStateExpired:
+		color = 0x808080
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("👹 Raid: %s (Lv.%d)", boss.Name, boss.Level),
+		Description: strings.Join(raid.Log[start:], "\n"),
+		Color:       color,
+		Fields:      []*discordgo.MessageEmbedField{},
+	}
+
+	if raid.State != game.RaidStateOpen {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s [%s]", boss.Name, boss.PrimaryType),
+			Value:  fmt.Sprintf("HP: %s %d/%d", game.GetHPBar(boss.CurrentHP, boss.MaxHP, 12), boss.CurrentHP, boss.MaxHP),
+			Inline: false,
+		})
+	}
+	if field := fieldEmbedField(raid.Field); field != nil && raid.State == game.RaidStateActive {
+		embed.Fields = append(embed.Fields, field)
+	}
+
+	participants := []string{}
+	for _, participant := range raid.Participants {
+		gopher := participant.Gopher
+		line := fmt.Sprintf("**%s**: %s (Lv.%d)", participant.TrainerName, gopher.Name, gopher.Level)
+		if raid.State != game.RaidStateOpen {
+			marker := ""
+			switch {
+			case gopher.CurrentHP <= 0:
+				marker = " 💀"
+			case raid.State == game.RaidStateActive && participant.Choice != game.RaidChoiceNone:
+				marker = " ✅"
+			}
+			line += fmt.Sprintf("%s\nHP %d/%d • ⚡ %d • %d damage", marker, gopher.CurrentHP, gopher.MaxHP, raid.Energy(gopher), participant.Damage)
+		}
+		participants = append(participants, line)
+	}
+	if len(participants) == 0 {
+		participants = append(participants, "Nobody yet!")
+	}
+	embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+		Name:   fmt.Sprintf("Trainers (%d/%d)", len(raid.Participants), game.RaidMaxParticipants),
+		Value:  strings.Join(participants, "\n"),
+		Inline: false,
+	})
+
+	switch raid.State {
+	case game.RaidStateOpen:
+		embed.Footer = &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Join with your first healthy party gopher • The lobby closes after %d minutes", int(game.RaidJoinWindow.Minutes())),
+		}
+	case game.RaidStateActive:
+		embed.Footer = &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Round %d/%d • The round resolves once everyone has chosen, or after %d seconds", raid.Round, game.RaidMaxRounds, int(game.RaidRoundTimeout.Seconds())),
+		}
+	case game.RaidStateExpired:
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: "This raid has ended"}
+	}
+
+	return embed
+}
+
+func (h *Handlers) createRaidButtons(raid *game.RaidBattleState) []discordgo.MessageComponent {
+	switch raid.State {
+	case game.RaidStateOpen:
+		return []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					createButton("Join Raid", discordgo.SuccessButton, fmt.Sprintf("raid_join_%s", raid.ID)),
+					createButton("Start", discordgo.PrimaryButton, fmt.Sprintf("raid_start_%s", raid.ID)),
+				},
+			},
+		}
+	case game.RaidStateActive:
+		return []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					createButton("Choose Move", discordgo.PrimaryButton, fmt.Sprintf("raid_move_%s", raid.ID)),
+				},
+			},
+		}
+	}
+	return nil
+}
+
+// editRaidMessage updates the raid's message in its channel. The boss sprite stays attached.
+func (h *Handlers) editRaidMessage(s *discordgo.Session, raid *game.RaidBattleState, content string) {
+	components := h.createRaidButtons(raid)
+	if components == nil {
+		components = []discordgo.MessageComponent{}
+	}
+
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    raid.ChannelID,
+		ID:         raid.MessageID,
+		Content:    &content,
+		Embeds:     []*discordgo.MessageEmbed{h.createRaidEmbed(raid)},
+		Components: components,
+	})
+	if err != nil {
+		log.Printf("Error editing raid message: %v", err)
+	}
+}
@@ -0,0 +1,376 @@
+package discord
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"gophermon-bot/internal/game"
+	"gophermon-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// replayHistoryLimit is how many recent battles /battle history lists
+const replayHistoryLimit = 10
+
+func (h *Handlers) handleBattle(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+
+	trainer, err := h.trainerRepo.GetByDiscordID(i.Member.User.ID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	if len(data.Options) == 0 {
+		respondEphemeral(s, i, "Use /battle history or /battle replay <battle_id>")
+		return
+	}
+
+	subCommand := data.Options[0]
+	switch subCommand.Name {
+	case "history":
+		h.handleBattleHistory(s, i, trainer)
+	case "replay":
+		h.handleBattleReplay(s, i, subCommand.Options[0].StringValue())
+	default:
+		respondEphemeral(s, i, "Unknown subcommand")
+	}
+}
+
+// handleBattleHistory lists the trainer's most recent battles
+func (h *Handlers) handleBattleHistory(s *discordgo.Session, i *discordgo.InteractionCreate, trainer *storage.Trainer) {
+	replays, err := h.replayRepo.GetByTrainerID(trainer.ID, replayHistoryLimit)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error loading battles: %v", err))
+		return
+	}
+	if len(replays) == 0 {
+		respondEphemeral(s, i, "No battles recorded yet. Go find a wild gopher with /wild!")
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:  fmt.Sprintf("📼 %s's Recent Battles", trainer.Name),
+		Color:  0x3399ff,
+		Fields: []*discordgo.MessageEmbedField{},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Use /battle replay <battle_id> to watch a battle",
+		},
+	}
+
+	for _, replay := range replays {
+		icon := "🌿"
+		switch replay.BattleType {
+		case storage.ReplayTypePvP:
+			icon = "⚔️"
+		case storage.ReplayTypeTrainer:
+			icon = "🏆"
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s %s", icon, replay.Title),
+			Value:  fmt.Sprintf("%s • %d turns • %s\n`%s`", replay.Result, replay.Turns, replay.CreatedAt.Format("Jan 2 15:04"), replay.ID),
+			Inline: false,
+		})
+	}
+
+	respondEmbed(s, i, embed, true)
+}
+
+// handleBattleReplay posts a replay viewer for a finished battle, starting at its first turn
+func (h *Handlers) handleBattleReplay(s *discordgo.Session, i *discordgo.InteractionCreate, replayID string) {
+	replay, turns, err := h.loadReplay(replayID)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Can't show that replay: %v", err))
+		return
+	}
+
+	// Rendering the battle card can take a moment
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		log.Printf("Error deferring interaction: %v", err)
+		return
+	}
+
+	embed := createReplayEmbed(replay, turns, 0)
+	components := createReplayButtons(replay.ID, 0, len(turns))
+	edit := &discordgo.WebhookEdit{
+		Embeds:     &[]*discordgo.MessageEmbed{embed},
+		Components: &components,
+	}
+	if cardFile := h.createReplayCardFile(replay.ID, turns[0]); cardFile != nil {
+		embed.Image = &discordgo.MessageEmbedImage{URL: fmt.Sprintf("attachment://%s", cardFile.Name)}
+		edit.Files = []*discordgo.File{cardFile}
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		log.Printf("Error sending replay: %v", err)
+	}
+}
+
+// handleReplayPage moves a replay viewer to another turn.
+// Button custom IDs have the form replay_<button>_<page>_<battleID>
+func (h *Handlers) handleReplayPage(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+
+	parts := strings.SplitN(strings.TrimPrefix(data.CustomID, "replay_"), "_", 3)
+	if len(parts) != 3 {
+		respondEphemeral(s, i, "Unknown action")
+		return
+	}
+	page, err := strconv.Atoi(parts[1])
+	if err != nil {
+		respondEphemeral(s, i, "Invalid page")
+		return
+	}
+
+	replay, turns, err := h.loadReplay(parts[2])
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Can't show that replay: %v", err))
+		return
+	}
+	if page < 0 || page >= len(turns) {
+		respondEphemeral(s, i, "Invalid page")
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		log.Printf("Error acknowledging replay page: %v", err)
+		return
+	}
+
+	embed := createReplayEmbed(replay, turns, page)
+	edit := &discordgo.MessageEdit{
+		Channel:    i.ChannelID,
+		ID:         i.Message.ID,
+		Embeds:     []*discordgo.MessageEmbed{embed},
+		Components: createReplayButtons(replay.ID, page, len(turns)),
+		// Replace the previous turn's battle card rather than adding to it
+		Attachments: &[]*discordgo.MessageAttachment{},
+	}
+	if cardFile := h.createReplayCardFile(replay.ID, turns[page]); cardFile != nil {
+		embed.Image = &discordgo.MessageEmbedImage{URL: fmt.Sprintf("attachment://%s", cardFile.Name)}
+		edit.Files = []*discordgo.File{cardFile}
+	}
+
+	if _, err := s.ChannelMessageEditComplex(edit); err != nil {
+		log.Printf("Error updating replay: %v", err)
+	}
+}
+
+// loadReplay loads a replay and its events grouped by turn
+func (h *Handlers) loadReplay(replayID string) (*storage.BattleReplay, [][]game.BattleEvent, error) {
+	replay, err := h.replayRepo.GetByID(replayID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if replay == nil {
+		return nil, nil, fmt.Errorf("replay not found, use /battle history to find a battle ID")
+	}
+
+	var events []game.BattleEvent
+	if err := json.Unmarshal([]byte(replay.Events), &events); err != nil {
+		return nil, nil, fmt.Errorf("failed to read replay: %w", err)
+	}
+
+	turns := game.ReplayTurns(events)
+	if len(turns) == 0 {
+		return nil, nil, fmt.Errorf("this replay has no recorded turns")
+	}
+	return replay, turns, nil
+}
+
+func createReplayEmbed(replay *storage.BattleReplay, turns [][]game.BattleEvent, page int) *discordgo.MessageEmbed {
+	events := turns[page]
+
+	lines := []string{}
+	for _, event := range events {
+		lines = append(lines, event.Describe())
+	}
+	description := strings.Join(lines, "\n")
+	if runes := []rune(description); len(runes) > 4000 {
+		description = string(runes[:3997]) + "..."
+	}
+
+	turnLabel := "Start"
+	if events[0].Turn > 0 {
+		turnLabel = fmt.Sprintf("Turn %d", events[0].Turn)
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("📼 %s — %s", replay.Title, turnLabel),
+		Description: description,
+		Color:       0x3399ff,
+		Fields:      []*discordgo.MessageEmbedField{},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Result: %s • Step %d/%d", replay.Result, page+1, len(turns)),
+		},
+	}
+
+	// The top gopher is drawn on top of the battle card, so list it first
+	last := events[len(events)-1]
+	for _, gopher := range []game.ReplayGopher{last.Top, last.Bottom} {
+		if gopher.ID == "" {
+			continue
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   fmt.Sprintf("%s (Lv.%d)", gopher.Name, gopher.Level),
+			Value:  fmt.Sprintf("HP: %s", game.GetHPBar(gopher.HP, gopher.MaxHP, 12)),
+			Inline: false,
+		})
+	}
+
+	return embed
+}
+
+func createReplayButtons(replayID string, page, pages int) []discordgo.MessageComponent {
+	// Custom IDs must be unique within a message, so each button is named as well
+	button := func(name, label string, target int, disabled bool) discordgo.MessageComponent {
+		return &ButtonWithoutEmoji{
+			Label:    label,
+			Style:    discordgo.SecondaryButton,
+			CustomID: fmt.Sprintf("replay_%s_%d_%s", name, target, replayID),
+			Disabled: disabled,
+		}
+	}
+
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			button("first", "⏮ First", 0, page == 0),
+			button("prev", "◀ Previous", page-1, page == 0),
+			button("next", "Next ▶", page+1, page >= pages-1),
+			button("last", "Last ⏭", pages-1, page >= pages-1),
+		}},
+	}
+}
+
+// createReplayCardFile renders the battle card for the gophers that were out at the end of a turn
+func (h *Handlers) createReplayCardFile(replayID string, events []game.BattleEvent) *discordgo.File {
+	last := events[len(events)-1]
+	if last.Top.ID == "" || last.Bottom.ID == "" {
+		return nil
+	}
+
+	top := h.replayGopher(last.Top.ID)
+	bottom := h.replayGopher(last.Bottom.ID)
+	if top == nil || bottom == nil {
+		return nil
+	}
+
+	// Show the gophers as they were during the battle
+	top.Name, top.Level = last.Top.Name, last.Top.Level
+	bottom.Name, bottom.Level = last.Bottom.Name, last.Bottom.Level
+
+	cardBase64, err := h.gameService.GenerateBattleCard(top, bottom)
+	if err != nil || cardBase64 == "" {
+		if err != nil {
+			log.Printf("Error generating replay battle card: %v", err)
+		}
+		return nil
+	}
+
+	fileData, err := base64.StdEncoding.DecodeString(cardBase64)
+	if err != nil {
+		log.Printf("Error decoding replay battle card base64: %v", err)
+		return nil
+	}
+
+	return &discordgo.File{
+		Name:        fmt.Sprintf("replay_%s_%d.png", replayID[:8], last.Turn),
+		ContentType: "image/png",
+		Reader:      bytes.NewReader(fileData),
+	}
+}
+
+// replayGopher looks up a gopher shown in a replay. NPC trainers' gophers aren't stored, so
+// they're rebuilt from the roster.
+func (h *Handlers) replayGopher(gopherID string) *storage.Gopher {
+	if gopher, err := h.gopherRepo.GetByID(gopherID); err == nil && gopher != nil {
+		return gopher
+	}
+	npcGopher, err := h.gameService.NPCGopher(gopherID)
+	if err != nil || npcGopher == nil {
+		return nil
+	}
+	return h.gameGopherToStorage(npcGopher)
+}
+
+// saveBattleReplay stores a finished wild or trainer battle so it can be replayed
+func (h *Handlers) saveBattleReplay(battleState *game.BattleState) {
+	trainerName := "Trainer"
+	if trainer, err := h.trainerRepo.GetByID(battleState.TrainerID); err == nil && trainer != nil {
+		trainerName = trainer.Name
+	}
+
+	result := battleState.State
+	switch {
+	case battleState.Captured:
+		result = "Captured"
+	case battleState.State == "WON":
+		result = "Won"
+	case battleState.State == "LOST":
+		result = "Lost"
+	case battleState.State == "ESCAPED":
+		result = "Escaped"
+	}
+
+	battleType := storage.ReplayTypeWild
+	title := fmt.Sprintf("%s vs wild %s", trainerName, battleState.EnemyGopher.Name)
+	if battleState.Opponent != nil {
+		battleType = storage.ReplayTypeTrainer
+		title = fmt.Sprintf("%s vs %s", trainerName, battleState.Opponent.DisplayName())
+	}
+
+	h.saveReplay(&storage.BattleReplay{
+		ID:         battleState.ID,
+		BattleType: battleType,
+		Trainer1ID: battleState.TrainerID,
+		Title:      title,
+		Result:     result,
+		Turns:      battleState.Turn,
+		Seed:       battleState.Seed,
+	}, battleState.Events)
+}
+
+// savePvPReplay stores a finished PvP battle so either trainer can replay it
+func (h *Handlers) savePvPReplay(pvpState *game.PvPBattleState) {
+	result := "Draw"
+	if winner := pvpState.WinnerSide(); winner != "" {
+		result = fmt.Sprintf("%s won", pvpState.TrainerNameFor(winner))
+	}
+
+	trainer2ID := pvpState.Trainer2ID
+	h.saveReplay(&storage.BattleReplay{
+		ID:         pvpState.ID,
+		BattleType: storage.ReplayTypePvP,
+		Trainer1ID: pvpState.Trainer1ID,
+		Trainer2ID: &trainer2ID,
+		Title:      fmt.Sprintf("%s vs %s", pvpState.Trainer1Name, pvpState.Trainer2Name),
+		Result:     result,
+		Turns:      pvpState.Turn,
+		Seed:       pvpState.Seed,
+	}, pvpState.Events)
+}
+
+func (h *Handlers) saveReplay(replay *storage.BattleReplay, events []game.BattleEvent) {
+	eventBytes, err := json.Marshal(events)
+	if err != nil {
+		log.Printf("Error encoding battle replay: %v", err)
+		return
+	}
+	replay.Events = string(eventBytes)
+
+	if err := h.replayRepo.Create(replay); err != nil {
+		log.Printf("Error saving battle replay: %v", err)
+	}
+}
@@ -0,0 +1,535 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"gophermon-bot/internal/game"
+	"gophermon-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Trade button custom IDs have the form trade_<action>_<tradeID>
+
+func (h *Handlers) handleTrade(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	discordID := i.Member.User.ID
+
+	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	subCommand := data.Options[0]
+	switch subCommand.Name {
+	case "offer":
+		h.handleTradeOffer(s, i, trainer, subCommand)
+	case "accept":
+		tradeID := subCommand.Options[0].StringValue()
+		trade, err := h.tradeRepo.GetByID(tradeID)
+		if err != nil || trade == nil {
+			respondEphemeral(s, i, "Trade not found")
+			return
+		}
+		if trade.Trainer2ID != trainer.ID {
+			respondEphemeral(s, i, "This trade wasn't offered to you!")
+			return
+		}
+		respondEphemeral(s, i, h.acceptTrade(s, i.ChannelID, trade))
+	case "cancel":
+		tradeID := subCommand.Options[0].StringValue()
+		trade, err := h.tradeRepo.GetByID(tradeID)
+		if err != nil || trade == nil {
+			respondEphemeral(s, i, "Trade not found")
+			return
+		}
+		if trade.Trainer1ID != trainer.ID {
+			respondEphemeral(s, i, "Only the trainer who made the offer can cancel it!")
+			return
+		}
+		respondEphemeral(s, i, h.closeTrade(trade, storage.TradeStatusCancelled))
+	case "list":
+		h.handleTradeList(s, i, trainer)
+	}
+}
+
+func (h *Handlers) handleTradeOffer(s *discordgo.Session, i *discordgo.InteractionCreate, trainer *storage.Trainer, subCommand *discordgo.ApplicationCommandInteractionDataOption) {
+	var opponentUser *discordgo.User
+	var gopherID, wantGopherID string
+	var currency, wantCurrency int
+	for _, option := range subCommand.Options {
+		switch option.Name {
+		case "user":
+			opponentUser = option.UserValue(s)
+		case "gopher_id":
+			gopherID = option.StringValue()
+		case "currency":
+			currency = int(option.IntValue())
+		case "want_gopher_id":
+			wantGopherID = option.StringValue()
+		case "want_currency":
+			wantCurrency = int(option.IntValue())
+		}
+	}
+
+	if opponentUser == nil {
+		respondEphemeral(s, i, "Invalid user")
+		return
+	}
+	if opponentUser.ID == i.Member.User.ID {
+		respondEphemeral(s, i, "You can't trade with yourself!")
+		return
+	}
+	if currency < 0 || wantCurrency < 0 {
+		respondEphemeral(s, i, "Currency amounts can't be negative!")
+		return
+	}
+	if gopherID == "" && wantGopherID == "" && currency == 0 && wantCurrency == 0 {
+		respondEphemeral(s, i, "A trade needs at least one gopher or some GoCoins!")
+		return
+	}
+
+	opponentTrainer, err := h.trainerRepo.GetByDiscordID(opponentUser.ID)
+	if err != nil || opponentTrainer == nil {
+		respondEphemeral(s, i, "That user is not a trainer yet!")
+		return
+	}
+
+	trade := &storage.Trade{
+		Trainer1ID: trainer.ID,
+		Trainer2ID: opponentTrainer.ID,
+		Currency1:  currency,
+		Currency2:  wantCurrency,
+	}
+
+	var offeredGopher, wantedGopher *storage.Gopher
+	if gopherID != "" {
+		offeredGopher, err = h.gopherRepo.GetByID(gopherID)
+		if err != nil || offeredGopher == nil {
+			respondEphemeral(s, i, "Gopher not found")
+			return
+		}
+		if offeredGopher.TrainerID == nil || *offeredGopher.TrainerID != trainer.ID {
+			respondEphemeral(s, i, "This gopher doesn't belong to you")
+			return
+		}
+		if wantGopherID == "" && h.isLastPartyGopher(trainer.ID, offeredGopher) {
+			respondEphemeral(s, i, "You can't trade away your last party gopher!")
+			return
+		}
+		trade.Gopher1ID = &offeredGopher.ID
+	}
+
+	if wantGopherID != "" {
+		wantedGopher, err = h.gopherRepo.GetByID(wantGopherID)
+		if err != nil || wantedGopher == nil {
+			respondEphemeral(s, i, "Requested gopher not found")
+			return
+		}
+		if wantedGopher.TrainerID == nil || *wantedGopher.TrainerID != opponentTrainer.ID {
+			respondEphemeral(s, i, fmt.Sprintf("That gopher doesn't belong to %s", opponentTrainer.Name))
+			return
+		}
+		trade.Gopher2ID = &wantedGopher.ID
+	}
+
+	if reason := h.tradeBusyReason(trade, trainer.ID); reason != "" {
+		respondEphemeral(s, i, reason)
+		return
+	}
+
+	if err := h.tradeRepo.CreateOffer(trade); err != nil {
+		switch {
+		case errors.Is(err, storage.ErrInsufficientCurrency):
+			respondEphemeral(s, i, fmt.Sprintf("Insufficient currency! You only have %d GoCoins.", trainer.Currency))
+		case errors.Is(err, storage.ErrGopherInTrade):
+			respondEphemeral(s, i, "That gopher is already part of a pending trade!")
+		case errors.Is(err, storage.ErrTradeGopherMoved):
+			respondEphemeral(s, i, "This gopher doesn't belong to you")
+		default:
+			respondEphemeral(s, i, fmt.Sprintf("Error creating trade: %v", err))
+		}
+		return
+	}
+
+	// Reload to pick up the expiry set by the database
+	if created, err := h.tradeRepo.GetByID(trade.ID); err == nil && created != nil {
+		trade = created
+	}
+
+	embed := h.createTradeEmbed(trade, trainer.Name, opponentTrainer.Name, offeredGopher, wantedGopher)
+	embed.Description = fmt.Sprintf("<@%s> offered a trade to <@%s>!", i.Member.User.ID, opponentUser.ID)
+
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				createButton("Accept", discordgo.SuccessButton, fmt.Sprintf("trade_accept_%s", trade.ID)),
+				createButton("Reject", discordgo.DangerButton, fmt.Sprintf("trade_reject_%s", trade.ID)),
+				createButton("Cancel", discordgo.SecondaryButton, fmt.Sprintf("trade_cancel_%s", trade.ID)),
+			},
+		},
+	}
+
+	respondWithComponents(s, i, fmt.Sprintf("<@%s>", opponentUser.ID), embed, components, false)
+}
+
+func (h *Handlers) handleTradeList(s *discordgo.Session, i *discordgo.InteractionCreate, trainer *storage.Trainer) {
+	trades, err := h.tradeRepo.GetPendingTrades(trainer.ID)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error: %v", err))
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:  "💼 Pending Trades",
+		Color:  0x00ff00,
+		Fields: []*discordgo.MessageEmbedField{},
+	}
+
+	if len(trades) == 0 {
+		embed.Description = "You have no pending trades."
+		respondEmbed(s, i, embed, true)
+		return
+	}
+
+	for idx, trade := range trades {
+		if idx >= 10 {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("...and %d more", len(trades)-10)}
+			break
+		}
+
+		direction := "Sent to"
+		otherID := trade.Trainer2ID
+		if trade.Trainer2ID == trainer.ID {
+			direction = "Received from"
+			otherID = trade.Trainer1ID
+		}
+		otherName := "Unknown"
+		if other, err := h.trainerRepo.GetByID(otherID); err == nil && other != nil {
+			otherName = other.Name
+		}
+
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name: fmt.Sprintf("%s %s", direction, otherName),
+			Value: fmt.Sprintf("**Gives:** %s\n**Wants:** %s\nExpires <t:%d:R>\nID: `%s`",
+				h.describeTradeSide(trade.Gopher1ID, trade.Currency1),
+				h.describeTradeSide(trade.Gopher2ID, trade.Currency2),
+				trade.ExpiresAt.Unix(), trade.ID),
+			Inline: false,
+		})
+	}
+
+	respondEmbed(s, i, embed, true)
+}
+
+func (h *Handlers) handleTradeComponent(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
+
+	parts := strings.SplitN(data.CustomID, "_", 3)
+	if len(parts) < 3 {
+		respondEphemeral(s, i, "Unknown action")
+		return
+	}
+	action := parts[1]
+	tradeID := parts[2]
+
+	trainer, err := h.trainerRepo.GetByDiscordID(i.Member.User.ID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found")
+		return
+	}
+
+	trade, err := h.tradeRepo.GetByID(tradeID)
+	if err != nil || trade == nil {
+		respondEphemeral(s, i, "Trade not found")
+		return
+	}
+
+	var result string
+	switch action {
+	case "accept", "reject":
+		if trade.Trainer2ID != trainer.ID {
+			respondEphemeral(s, i, "This trade wasn't offered to you!")
+			return
+		}
+		if action == "accept" {
+			result = h.acceptTrade(s, i.ChannelID, trade)
+		} else {
+			result = h.closeTrade(trade, storage.TradeStatusRejected)
+		}
+	case "cancel":
+		if trade.Trainer1ID != trainer.ID {
+			respondEphemeral(s, i, "Only the trainer who made the offer can cancel it!")
+			return
+		}
+		result = h.closeTrade(trade, storage.TradeStatusCancelled)
+	default:
+		respondEphemeral(s, i, "Unknown action")
+		return
+	}
+
+	// Leave the buttons in place if the trade is still open (e.g. not enough GoCoins yet)
+	updated, err := h.tradeRepo.GetByID(tradeID)
+	if err != nil || updated == nil || updated.Status == storage.TradeStatusPending {
+		respondEphemeral(s, i, result)
+		return
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "💼 Trade Offer",
+		Description: result,
+		Color:       tradeStatusColor(updated.Status),
+	}
+	if len(i.Message.Embeds) > 0 {
+		embed.Fields = i.Message.Embeds[0].Fields
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:     []*discordgo.MessageEmbed{embed},
+			Components: []discordgo.MessageComponent{},
+		},
+	})
+	if err != nil {
+		log.Printf("Error updating trade message: %v", err)
+	}
+}
+
+// acceptTrade runs the escrow swap and returns a message describing the outcome
+func (h *Handlers) acceptTrade(s *discordgo.Session, channelID string, trade *storage.Trade) string {
+	if trade.Status != storage.TradeStatusPending {
+		return fmt.Sprintf("This trade is already %s.", strings.ToLower(trade.Status))
+	}
+
+	if reason := h.tradeBusyReason(trade, trade.Trainer2ID); reason != "" {
+		return reason
+	}
+
+	if trade.Gopher1ID == nil && trade.Gopher2ID != nil {
+		if gopher, err := h.gopherRepo.GetByID(*trade.Gopher2ID); err == nil && gopher != nil &&
+			h.isLastPartyGopher(trade.Trainer2ID, gopher) {
+			return "You can't trade away your last party gopher!"
+		}
+	}
+
+	err := h.tradeRepo.Accept(trade.ID)
+	switch {
+	case err == nil:
+		h.syncTradeGopherdex(trade.Gopher1ID, trade.Trainer1ID, trade.Trainer2ID)
+		h.syncTradeGopherdex(trade.Gopher2ID, trade.Trainer2ID, trade.Trainer1ID)
+		// Receiving a gopher can complete collection achievements
+		h.evaluateAchievements(s, channelID, trade.Trainer1ID)
+		h.evaluateAchievements(s, channelID, trade.Trainer2ID)
+		return "✅ Trade complete! Gophers and GoCoins have been exchanged."
+	case errors.Is(err, storage.ErrInsufficientCurrency):
+		return fmt.Sprintf("You need %d GoCoins to accept this trade!", trade.Currency2)
+	case errors.Is(err, storage.ErrTradeExpired):
+		return "⌛ This trade has expired. Escrowed GoCoins were returned."
+	case errors.Is(err, storage.ErrTradeGopherMoved):
+		return "❌ A gopher in this trade changed hands, so the trade was cancelled. Escrowed GoCoins were returned."
+	case errors.Is(err, storage.ErrTradeNotPending):
+		return "This trade is no longer pending."
+	default:
+		log.Printf("Error accepting trade %s: %v", trade.ID, err)
+		return fmt.Sprintf("Error accepting trade: %v", err)
+	}
+}
+
+// closeTrade rejects or cancels a trade and returns a message describing the outcome
+func (h *Handlers) closeTrade(trade *storage.Trade, status string) string {
+	err := h.tradeRepo.Close(trade.ID, status)
+	switch {
+	case err == nil:
+		if status == storage.TradeStatusRejected {
+			return "Trade rejected. Escrowed GoCoins were returned."
+		}
+		return "Trade cancelled. Escrowed GoCoins were returned."
+	case errors.Is(err, storage.ErrTradeNotPending):
+		return "This trade is no longer pending."
+	default:
+		log.Printf("Error closing trade %s: %v", trade.ID, err)
+		return fmt.Sprintf("Error: %v", err)
+	}
+}
+
+func (h *Handlers) createTradeEmbed(trade *storage.Trade, trainer1Name, trainer2Name string, offeredGopher, wantedGopher *storage.Gopher) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title: "💼 Trade Offer",
+		Color: tradeStatusColor(trade.Status),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: fmt.Sprintf("%s gives", trainer1Name), Value: describeTradeGopher(offeredGopher, trade.Currency1), Inline: true},
+			{Name: fmt.Sprintf("%s gives", trainer2Name), Value: describeTradeGopher(wantedGopher, trade.Currency2), Inline: true},
+			{Name: "Expires", Value: fmt.Sprintf("<t:%d:R>", trade.ExpiresAt.Unix()), Inline: false},
+			{Name: "Trade ID", Value: fmt.Sprintf("`%s`", trade.ID), Inline: false},
+		},
+	}
+}
+
+// describeTradeSide loads a gopher by ID and describes one side of a trade
+func (h *Handlers) describeTradeSide(gopherID *string, currency int) string {
+	var gopher *storage.Gopher
+	if gopherID != nil {
+		gopher, _ = h.gopherRepo.GetByID(*gopherID)
+	}
+	return describeTradeGopher(gopher, currency)
+}
+
+func describeTradeGopher(gopher *storage.Gopher, currency int) string {
+	parts := []string{}
+	if gopher != nil {
+		shiny := ""
+		if gopher.Shiny {
+			shiny = "✨ "
+		}
+		parts = append(parts, fmt.Sprintf("%s%s (Lv.%d %s)", shiny, gopher.Name, gopher.Level, gopher.Rarity))
+		// Held items go with the gopher
+		if gopher.HeldItem != "" {
+			parts = append(parts, fmt.Sprintf("Holding: %s", heldItemLabel(gopher.HeldItem)))
+		}
+	}
+	if currency > 0 {
+		parts = append(parts, fmt.Sprintf("%d GoCoins", currency))
+	}
+	if len(parts) == 0 {
+		return "Nothing"
+	}
+	return strings.Join(parts, "\n")
+}
+
+// isLastPartyGopher reports whether giving away this gopher would leave the trainer's party empty
+func (h *Handlers) isLastPartyGopher(trainerID string, gopher *storage.Gopher) bool {
+	if !gopher.IsInParty {
+		return false
+	}
+	party, err := h.gopherRepo.GetParty(trainerID)
+	return err == nil && len(party) <= 1
+}
+
+// tradeBusyReason returns why a trade can't be offered or accepted right now, or "" if it can.
+// Battles keep their own copy of each gopher, so a gopher can't change hands mid-battle.
+func (h *Handlers) tradeBusyReason(trade *storage.Trade, actingTrainerID string) string {
+	for _, trainerID := range []string{trade.Trainer1ID, trade.Trainer2ID} {
+		reason := h.battleBusyReason(trainerID)
+		if reason == "" {
+			continue
+		}
+		if trainerID == actingTrainerID {
+			return reason
+		}
+		return "The other trainer is in a battle right now. Try again once it's over."
+	}
+
+	for _, gopherID := range []*string{trade.Gopher1ID, trade.Gopher2ID} {
+		if gopherID != nil && h.gopherInBattle(*gopherID) {
+			return "A gopher in this trade is in a battle right now. Try again once it's over."
+		}
+	}
+	return ""
+}
+
+// gopherInBattle reports whether a gopher is fighting in any running battle or raid
+func (h *Handlers) gopherInBattle(gopherID string) bool {
+	h.battlesMu.Lock()
+	for _, battleState := range h.battles {
+		if battleState.State != "ACTIVE" {
+			continue
+		}
+		if battleState.PlayerGopher.ID == gopherID || containsGopher(battleState.PlayerParty, gopherID) {
+			h.battlesMu.Unlock()
+			return true
+		}
+	}
+	h.battlesMu.Unlock()
+
+	h.pvpMu.Lock()
+	for _, pvpState := range h.pvpBattles {
+		if containsGopher(pvpState.Trainer1Party, gopherID) || containsGopher(pvpState.Trainer2Party, gopherID) {
+			h.pvpMu.Unlock()
+			return true
+		}
+	}
+	h.pvpMu.Unlock()
+
+	h.raidMu.Lock()
+	for _, raid := range h.raids {
+		for _, participant := range raid.Participants {
+			if participant.Gopher != nil && participant.Gopher.ID == gopherID {
+				h.raidMu.Unlock()
+				return true
+			}
+		}
+	}
+	h.raidMu.Unlock()
+
+	h.doubleMu.Lock()
+	defer h.doubleMu.Unlock()
+	for _, doubleState := range h.doubles {
+		for _, side := range doubleState.Sides {
+			if containsGopher(side.Party, gopherID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// noBattlePartyMessage is shown when a trainer has no party gophers that can be sent into battle
+const noBattlePartyMessage = "Your party is empty! Use /start to get a starter gopher. Gophers in a pending trade can't battle until the trade is resolved."
+
+// battleParty returns the trainer's party without the gophers locked in a pending trade
+func (h *Handlers) battleParty(trainerID string) ([]*storage.Gopher, error) {
+	party, err := h.gopherRepo.GetParty(trainerID)
+	if err != nil {
+		return nil, err
+	}
+	escrowed, err := h.tradeRepo.GetEscrowedGopherIDs(trainerID)
+	if err != nil {
+		return nil, err
+	}
+
+	available := make([]*storage.Gopher, 0, len(party))
+	for _, gopher := range party {
+		if !escrowed[gopher.ID] {
+			available = append(available, gopher)
+		}
+	}
+	return available, nil
+}
+
+func containsGopher(gophers []*game.Gopher, gopherID string) bool {
+	for _, gopher := range gophers {
+		if gopher != nil && gopher.ID == gopherID {
+			return true
+		}
+	}
+	return false
+}
+
+// syncTradeGopherdex updates both trainers' Gopherdex ownership after a gopher changes hands
+func (h *Handlers) syncTradeGopherdex(gopherID *string, fromTrainerID, toTrainerID string) {
+	if gopherID == nil {
+		return
+	}
+	gopher, err := h.gopherRepo.GetByID(*gopherID)
+	if err != nil || gopher == nil {
+		return
+	}
+	h.markGopherdexOwned(toTrainerID, gopher)
+	h.refreshGopherdexOwned(fromTrainerID, gopher)
+}
+
+func tradeStatusColor(status string) int {
+	switch status {
+	case storage.TradeStatusAccepted:
+		return 0x00ff00
+	case storage.TradeStatusPending:
+		return 0x0099ff
+	default:
+		return 0x808080
+	}
+}
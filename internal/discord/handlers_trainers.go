@@ -0,0 +1,322 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"gophermon-bot/internal/game"
+	"gophermon-bot/internal/storage"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/google/uuid"
+)
+
+func (h *Handlers) handleTrainers(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	discordID := i.Member.User.ID
+
+	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	if len(data.Options) == 0 {
+		respondEphemeral(s, i, "Use /trainers list or /trainers battle <trainer_id>.")
+		return
+	}
+
+	subcommand := data.Options[0]
+	switch subcommand.Name {
+	case "list":
+		h.handleTrainersList(s, i, trainer)
+	case "battle":
+		npcID := ""
+		double := false
+		for _, opt := range subcommand.Options {
+			switch opt.Name {
+			case "trainer_id":
+				npcID = opt.StringValue()
+			case "double":
+				double = opt.BoolValue()
+			}
+		}
+		if npcID == "" {
+			respondEphemeral(s, i, "Please name a trainer to battle. Use /trainers list to see who's around.")
+			return
+		}
+		h.handleTrainerBattle(s, i, trainer, npcID, double)
+	}
+}
+
+// handleTrainersList shows the NPC trainers that can be challenged
+func (h *Handlers) handleTrainersList(s *discordgo.Session, i *discordgo.InteractionCreate, trainer *storage.Trainer) {
+	roster := h.gameService.Roster()
+	if len(roster.Trainers) == 0 {
+		respondEphemeral(s, i, "There are no trainers around right now.")
+		return
+	}
+
+	earned := h.earnedBadges(trainer.ID)
+	fields := []*discordgo.MessageEmbedField{}
+	for _, npc := range roster.Trainers {
+		// Discord allows at most 25 fields per embed
+		if len(fields) == 25 {
+			break
+		}
+
+		icon := "🧢"
+		if npc.IsGymLeader() {
+			icon = "🏟️"
+		}
+		missing := npc.MissingBadges(earned)
+		if len(missing) > 0 {
+			icon = "🔒"
+		}
+		name := fmt.Sprintf("%s %s", icon, npc.DisplayName())
+		if npc.IsGymLeader() && earned[npc.Badge] {
+			name += " ✅"
+		}
+
+		minLevel, maxLevel := npc.LevelRange()
+		levels := fmt.Sprintf("Lv.%d", minLevel)
+		if maxLevel != minLevel {
+			levels = fmt.Sprintf("Lv.%d-%d", minLevel, maxLevel)
+		}
+		lines := []string{fmt.Sprintf("%s • %d gophers • 💰 %d GoCoins", levels, len(npc.Party), npc.PrizeMoney)}
+		if npc.IsGymLeader() {
+			lines = append(lines, fmt.Sprintf("Awards the %s", roster.BadgeName(npc.Badge)))
+		}
+		if len(missing) > 0 {
+			lines = append(lines, fmt.Sprintf("Needs the %s", badgeNames(roster, missing)))
+		}
+		lines = append(lines, fmt.Sprintf("`%s`", npc.ID))
+
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  strings.Join(lines, "\n"),
+			Inline: true,
+		})
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       "🧢 Trainers",
+		Description: "Beat gym leaders to earn badges and unlock new wild areas.",
+		Color:       0x3498db,
+		Fields:      fields,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Use /trainers battle <trainer_id> to challenge a trainer",
+		},
+	}
+
+	respondEmbed(s, i, embed, true)
+}
+
+// handleTrainerBattle starts a battle against an NPC trainer, or a double battle if double is set
+func (h *Handlers) handleTrainerBattle(s *discordgo.Session, i *discordgo.InteractionCreate, trainer *storage.Trainer, npcID string, double bool) {
+	roster := h.gameService.Roster()
+	npc := roster.Trainer(strings.ToLower(strings.TrimSpace(npcID)))
+	if npc == nil {
+		respondEphemeral(s, i, "Unknown trainer. Use /trainers list to see who you can challenge.")
+		return
+	}
+
+	if missing := npc.MissingBadges(h.earnedBadges(trainer.ID)); len(missing) > 0 {
+		respondEphemeral(s, i, fmt.Sprintf("🔒 %s only battles trainers with the %s.", npc.DisplayName(), badgeNames(roster, missing)))
+		return
+	}
+	if reason := h.battleBusyReason(trainer.ID); reason != "" {
+		respondEphemeral(s, i, reason)
+		return
+	}
+
+	playerGopher, gameParty, ok := h.loadBattleParty(s, i, trainer.ID)
+	if !ok {
+		return
+	}
+	if double && !game.CanBattleDouble(gameParty) {
+		respondEphemeral(s, i, fmt.Sprintf("You need at least %d gophers that can battle for a double battle!", game.DoubleSlots))
+		return
+	}
+
+	// Drawing the trainer's whole party can take a moment
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		log.Printf("Error deferring trainer battle: %v", err)
+		return
+	}
+	reply := func(content string) {
+		if _, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{Content: &content}); err != nil {
+			log.Printf("Error responding to trainer battle: %v", err)
+		}
+	}
+
+	npcParty, err := h.gameService.NPCParty(npc)
+	if err != nil {
+		reply(fmt.Sprintf("Error preparing trainer battle: %v", err))
+		return
+	}
+
+	if double {
+		doubleState, err := game.NewTrainerDoubleBattle(i.ChannelID, trainer.ID, trainer.Name, gameParty, npc, npcParty, h.gameService.GetEventManager(), h.gameService.NewBattleSeed())
+		if err != nil {
+			reply(fmt.Sprintf("Error preparing trainer battle: %v", err))
+			return
+		}
+		h.applyDoubleXPBoost(doubleState, trainer.ID)
+		if err := h.startDoubleBattle(s, doubleState); err != nil {
+			h.refundXPBoost(trainer.ID, doubleState.XPMultiplier)
+			reply(fmt.Sprintf("Couldn't start the double battle: %v", err))
+			return
+		}
+		reply(fmt.Sprintf("%s accepted your double battle challenge!", npc.DisplayName()))
+		return
+	}
+
+	battleState := game.NewTrainerBattleState(trainer.ID, i.ChannelID, playerGopher, gameParty, npc, npcParty, h.gameService.GetEventManager(), h.gameService.NewBattleSeed())
+	battleState.ID = uuid.New().String()
+
+	// An XP Booster activated with /item use applies to this battle
+	battleState.XPMultiplier = h.itemService.ConsumeXPBoost(trainer.ID)
+	if battleState.XPMultiplier > 1.0 {
+		battleState.Log = append(battleState.Log, fmt.Sprintf("⚡ XP Booster active: %.1fx XP this battle!", battleState.XPMultiplier))
+	}
+
+	if err := h.startBattle(s, i, battleState); err != nil {
+		h.refundXPBoost(trainer.ID, battleState.XPMultiplier)
+		reply(fmt.Sprintf("Error sending battle message: %v", err))
+		return
+	}
+
+	reply(fmt.Sprintf("%s accepted your challenge!", npc.DisplayName()))
+}
+
+func (h *Handlers) handleBadges(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	discordID := i.Member.User.ID
+
+	trainer, err := h.trainerRepo.GetByDiscordID(discordID)
+	if err != nil || trainer == nil {
+		respondEphemeral(s, i, "Trainer not found. Use /start first.")
+		return
+	}
+
+	badges, err := h.badgeRepo.GetByTrainerID(trainer.ID)
+	if err != nil {
+		respondEphemeral(s, i, fmt.Sprintf("Error loading badges: %v", err))
+		return
+	}
+	earned := make(map[string]*storage.Badge)
+	earnedIDs := make(map[string]bool)
+	for _, badge := range badges {
+		earned[badge.BadgeID] = badge
+		earnedIDs[badge.BadgeID] = true
+	}
+
+	roster := h.gameService.Roster()
+	gyms := []string{}
+	for _, leader := range roster.GymLeaders() {
+		if badge := earned[leader.Badge]; badge != nil {
+			gyms = append(gyms, fmt.Sprintf("✅ **%s** — %s (<t:%d:d>)", roster.BadgeName(leader.Badge), leader.DisplayName(), badge.EarnedAt.Unix()))
+		} else {
+			gyms = append(gyms, fmt.Sprintf("⬜ %s — %s (`%s`)", roster.BadgeName(leader.Badge), leader.DisplayName(), leader.ID))
+		}
+	}
+
+	areas := []string{}
+	for _, area := range roster.Areas {
+		line := fmt.Sprintf("**%s** (`%s`) • Lv.%d-%d", area.Name, area.ID, area.MinLevel, area.MaxLevel)
+		if area.Unlocked(earnedIDs) {
+			areas = append(areas, "🌿 "+line)
+		} else {
+			areas = append(areas, fmt.Sprintf("🔒 %s • needs the %s", line, roster.BadgeName(area.RequiredBadge)))
+		}
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("🏅 %s's Badges", trainer.Name),
+		Description: fmt.Sprintf("**%d/%d** gym badges earned", len(badges), len(roster.GymLeaders())),
+		Color:       0xffd700,
+		Fields:      []*discordgo.MessageEmbedField{},
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Use /wild area:<area> to explore an unlocked area",
+		},
+	}
+	if len(gyms) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Gym Badges",
+			Value:  strings.Join(gyms, "\n"),
+			Inline: false,
+		})
+	}
+	if len(areas) > 0 {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:   "Wild Areas",
+			Value:  strings.Join(areas, "\n"),
+			Inline: false,
+		})
+	}
+
+	respondEmbed(s, i, embed, false)
+}
+
+// rewardTrainerBattle pays out a beaten NPC trainer's prize money and, the first time a gym
+// leader is beaten, their badge
+func (h *Handlers) rewardTrainerBattle(trainerID string, npc *game.NPCTrainer) []string {
+	messages := []string{}
+
+	if npc.PrizeMoney > 0 {
+		if err := h.trainerRepo.AddCurrency(trainerID, npc.PrizeMoney); err != nil {
+			log.Printf("Error paying trainer battle prize: %v", err)
+		} else {
+			messages = append(messages, fmt.Sprintf("💰 %s paid out %d GoCoins!", npc.DisplayName(), npc.PrizeMoney))
+		}
+	}
+
+	if !npc.IsGymLeader() {
+		return messages
+	}
+	newBadge, err := h.badgeRepo.Award(trainerID, npc.Badge, npc.ID)
+	if err != nil {
+		log.Printf("Error awarding badge: %v", err)
+		return messages
+	}
+	if newBadge {
+		roster := h.gameService.Roster()
+		messages = append(messages, fmt.Sprintf("🏅 You earned the %s!", roster.BadgeName(npc.Badge)))
+		for _, area := range roster.Areas {
+			if area.RequiredBadge == npc.Badge {
+				messages = append(messages, fmt.Sprintf("🗺️ New wild area unlocked: %s! Use /wild area:%s", area.Name, area.ID))
+			}
+		}
+	}
+	return messages
+}
+
+// earnedBadges returns the IDs of the badges a trainer has earned
+func (h *Handlers) earnedBadges(trainerID string) map[string]bool {
+	earned := make(map[string]bool)
+	badges, err := h.badgeRepo.GetByTrainerID(trainerID)
+	if err != nil {
+		log.Printf("Error loading badges: %v", err)
+		return earned
+	}
+	for _, badge := range badges {
+		earned[badge.BadgeID] = true
+	}
+	return earned
+}
+
+// badgeNames joins badge IDs into their display names
+func badgeNames(roster *game.Roster, badges []string) string {
+	names := make([]string, len(badges))
+	for idx, badge := range badges {
+		names[idx] = roster.BadgeName(badge)
+	}
+	return strings.Join(names, " and ")
+}
@@ -83,6 +83,78 @@ func RegisterCommands(s *discordgo.Session, guildID string) error {
 		{
 			Name:        "wild",
 			Description: "Encounter a wild gopher",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "area",
+					Description: "Where to look, from /badges (higher-level areas need gym badges)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "double",
+					Description: "Take on a horde of two wild gophers with two of your own",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "trainers",
+			Description: "Battle NPC trainers and gym leaders",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "list",
+					Description: "List the trainers and gym leaders you can challenge",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "battle",
+					Description: "Challenge a trainer or gym leader",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "trainer_id",
+							Description: "The trainer ID from /trainers list",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionBoolean,
+							Name:        "double",
+							Description: "Battle two gophers at a time",
+							Required:    false,
+						},
+					},
+				},
+			},
+		},
+		{
+			Name:        "badges",
+			Description: "View your gym badges and the wild areas they unlock",
+		},
+		{
+			Name:        "battle",
+			Description: "Look back at your past battles",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "history",
+					Description: "List your recent battles",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "replay",
+					Description: "Replay a finished battle turn by turn",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "battle_id",
+							Description: "The battle ID from /battle history",
+							Required:    true,
+						},
+					},
+				},
+			},
 		},
 		{
 			Name:        "gopher",
@@ -133,6 +205,9 @@ func RegisterCommands(s *discordgo.Session, guildID string) error {
 								{Name: "Lucky Day", Value: "LUCKY_DAY"},
 								{Name: "Stat Boost", Value: "STAT_BOOST"},
 								{Name: "Evolution Festival", Value: "EVOLUTION_FEST"},
+								{Name: "Compile Storm", Value: "COMPILE_STORM"},
+								{Name: "Garbage Day", Value: "GARBAGE_DAY"},
+								{Name: "Lag Spike", Value: "LAG_SPIKE"},
 							},
 						},
 						{
@@ -160,6 +235,9 @@ func RegisterCommands(s *discordgo.Session, guildID string) error {
 								{Name: "Lucky Day", Value: "LUCKY_DAY"},
 								{Name: "Stat Boost", Value: "STAT_BOOST"},
 								{Name: "Evolution Festival", Value: "EVOLUTION_FEST"},
+								{Name: "Compile Storm", Value: "COMPILE_STORM"},
+								{Name: "Garbage Day", Value: "GARBAGE_DAY"},
+								{Name: "Lag Spike", Value: "LAG_SPIKE"},
 							},
 						},
 					},
@@ -191,6 +269,14 @@ func RegisterCommands(s *discordgo.Session, guildID string) error {
 								{Name: "XP Booster", Value: "XP_BOOSTER"},
 								{Name: "Evolution Stone", Value: "EVOLUTION_STONE"},
 								{Name: "Shiny Charm", Value: "SHINY_CHARM"},
+								{Name: "Mechanical Keyboard (held)", Value: "MECHANICAL_KEYBOARD"},
+								{Name: "Titanium Case (held)", Value: "TITANIUM_CASE"},
+								{Name: "Fiber Cable (held)", Value: "FIBER_CABLE"},
+								{Name: "Rubber Duck (held)", Value: "RUBBER_DUCK"},
+								{Name: "Crystal Compiler (held)", Value: "CRYSTAL_COMPILER"},
+								{Name: "Coffee Mug (held)", Value: "COFFEE_MUG"},
+								{Name: "Recover Charm (held)", Value: "RECOVER_CHARM"},
+								{Name: "Turbo Scarf (held)", Value: "TURBO_SCARF"},
 							},
 						},
 						{
@@ -203,6 +289,37 @@ func RegisterCommands(s *discordgo.Session, guildID string) error {
 				},
 			},
 		},
+		{
+			Name:        "item",
+			Description: "Use items from your bag",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "use",
+					Description: "Use an item outside of battle",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "item",
+							Description: "Item to use",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Potion", Value: "POTION"},
+								{Name: "Revive", Value: "REVIVE"},
+								{Name: "XP Booster", Value: "XP_BOOSTER"},
+								{Name: "Evolution Stone", Value: "EVOLUTION_STONE"},
+							},
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "gopher_id",
+							Description: "Gopher to use the item on (not needed for XP Booster)",
+							Required:    false,
+						},
+					},
+				},
+			},
+		},
 		{
 			Name:        "achievements",
 			Description: "View your achievements",
@@ -221,6 +338,47 @@ func RegisterCommands(s *discordgo.Session, guildID string) error {
 					Description: "The user to challenge",
 					Required:    true,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "double",
+					Description: "Battle two gophers at a time",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:        "pvp",
+			Description: "Ranked PvP matchmaking",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "queue",
+					Description: "Look for an opponent with a similar rating",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "leave",
+					Description: "Stop looking for an opponent",
+				},
+			},
+		},
+		{
+			Name:        "raid",
+			Description: "Cooperative raid boss battles",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "start",
+					Description: "Spawn a raid boss in this channel (admin only)",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "level",
+							Description: "Boss level (default: 25)",
+							Required:    false,
+						},
+					},
+				},
 			},
 		},
 		{
@@ -249,8 +407,16 @@ func RegisterCommands(s *discordgo.Session, guildID string) error {
 						{Name: "Battles Won", Value: "wins"},
 						{Name: "Shinies", Value: "shinies"},
 						{Name: "Gophers Caught", Value: "caught"},
+						{Name: "Evolutions", Value: "evolutions"},
+						{Name: "Total XP", Value: "xp"},
 					},
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "server_only",
+					Description: "Only show trainers in this server",
+					Required:    false,
+				},
 			},
 		},
 		{
@@ -284,6 +450,18 @@ func RegisterCommands(s *discordgo.Session, guildID string) error {
 							Description: "Currency to offer (optional)",
 							Required:    false,
 						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "want_gopher_id",
+							Description: "Gopher you want in return (optional)",
+							Required:    false,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionInteger,
+							Name:        "want_currency",
+							Description: "Currency you want in return (optional)",
+							Required:    false,
+						},
 					},
 				},
 				{
@@ -299,6 +477,19 @@ func RegisterCommands(s *discordgo.Session, guildID string) error {
 						},
 					},
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "cancel",
+					Description: "Cancel a trade you offered",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "trade_id",
+							Description: "Trade ID to cancel",
+							Required:    true,
+						},
+					},
+				},
 				{
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Name:        "list",
@@ -323,6 +514,19 @@ func RegisterCommands(s *discordgo.Session, guildID string) error {
 						},
 					},
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "moves",
+					Description: "View a gopher's moves and the moves it can still learn",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "gopher_id",
+							Description: "The ID of the gopher",
+							Required:    true,
+						},
+					},
+				},
 				{
 					Type:        discordgo.ApplicationCommandOptionSubCommand,
 					Name:        "rename",
@@ -368,6 +572,48 @@ func RegisterCommands(s *discordgo.Session, guildID string) error {
 						},
 					},
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "hold",
+					Description: "Give a gopher a held item from your bag",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "gopher_id",
+							Description: "The ID of the gopher",
+							Required:    true,
+						},
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "item",
+							Description: "Item to hold",
+							Required:    true,
+							Choices: []*discordgo.ApplicationCommandOptionChoice{
+								{Name: "Mechanical Keyboard", Value: "MECHANICAL_KEYBOARD"},
+								{Name: "Titanium Case", Value: "TITANIUM_CASE"},
+								{Name: "Fiber Cable", Value: "FIBER_CABLE"},
+								{Name: "Rubber Duck", Value: "RUBBER_DUCK"},
+								{Name: "Crystal Compiler", Value: "CRYSTAL_COMPILER"},
+								{Name: "Coffee Mug", Value: "COFFEE_MUG"},
+								{Name: "Recover Charm", Value: "RECOVER_CHARM"},
+								{Name: "Turbo Scarf", Value: "TURBO_SCARF"},
+							},
+						},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionSubCommand,
+					Name:        "take",
+					Description: "Take a gopher's held item back to your bag",
+					Options: []*discordgo.ApplicationCommandOption{
+						{
+							Type:        discordgo.ApplicationCommandOptionString,
+							Name:        "gopher_id",
+							Description: "The ID of the gopher",
+							Required:    true,
+						},
+					},
+				},
 			},
 		},
 		{
@@ -0,0 +1,64 @@
+package game
+
+import (
+	"math"
+	"testing"
+)
+
+func approxEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestCriticalChance(t *testing.T) {
+	tests := []struct {
+		name     string
+		speed    int
+		field    *Field
+		highCrit bool
+		want     float64
+	}{
+		{"no speed", 0, nil, false, baseCriticalChance},
+		{"speed adds 1% per 20", 100, nil, false, 0.09},
+		{"lag spike halves speed", 100, &Field{Condition: FieldLagSpike, Turns: 3}, false, 0.065},
+		{"other conditions don't", 100, &Field{Condition: FieldCompileStorm, Turns: 3}, false, 0.09},
+		{"high crit triples", 100, nil, true, 0.27},
+		{"capped", 2000, nil, true, maxCriticalChance},
+	}
+	for _, tt := range tests {
+		attacker := &Gopher{Speed: tt.speed}
+		if got := criticalChance(attacker, tt.field, tt.highCrit); !approxEqual(got, tt.want) {
+			t.Errorf("%s: criticalChance = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHitChance(t *testing.T) {
+	status := func(effectType StatusEffectType, intensity int) *StatusEffect {
+		return &StatusEffect{Type: effectType, Duration: 3, Intensity: intensity}
+	}
+
+	tests := []struct {
+		name       string
+		accuracy   int
+		userStatus []*StatusEffect
+		targStatus []*StatusEffect
+		want       float64
+	}{
+		{"sure hit", 100, nil, nil, 1},
+		{"accuracy", 85, nil, nil, 0.85},
+		{"confused user", 100, []*StatusEffect{status(StatusConfusion, 0)}, nil, 0.75},
+		{"one evasion stage", 100, nil, []*StatusEffect{status(StatusEvasionUp, 1)}, 0.75},
+		{"two evasion stages", 90, nil, []*StatusEffect{status(StatusEvasionUp, 2)}, 0.54},
+		{"paralyzed targets can't dodge", 100, nil, []*StatusEffect{status(StatusEvasionUp, 2), status(StatusParalysis, 0)}, 1},
+		{"evasion down", 60, nil, []*StatusEffect{status(StatusEvasionDown, 1)}, 0.8},
+		{"capped at a sure hit", 100, nil, []*StatusEffect{status(StatusEvasionDown, 3)}, 1},
+		{"stages cancel out", 80, nil, []*StatusEffect{status(StatusEvasionUp, 1), status(StatusEvasionDown, 1)}, 0.8},
+	}
+	for _, tt := range tests {
+		user := &Gopher{StatusEffects: tt.userStatus}
+		target := &Gopher{StatusEffects: tt.targStatus}
+		if got := hitChance(tt.accuracy, user, target); !approxEqual(got, tt.want) {
+			t.Errorf("%s: hitChance = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package game
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Abilities and the moves each archetype can learn are defined in abilities.json in the data
+// directory, so new moves don't need a code change.
+const abilitiesFile = "abilities.json"
+
+// AbilityData is the contents of abilities.json
+type AbilityData struct {
+	Abilities []*AbilityTemplate     `json:"abilities"`
+	MovePools map[Archetype]MovePool `json:"move_pools"`
+	Legendary LegendaryMovePool      `json:"legendary"`
+}
+
+// MovePool lists the abilities an archetype learns, in the order it becomes eligible for them.
+// Evolved gophers learn their stage's abilities after the ones before it.
+type MovePool struct {
+	Base   []string `json:"base"`
+	Stage1 []string `json:"stage1"`
+	Stage2 []string `json:"stage2"`
+}
+
+// LegendaryMovePool lists the extra abilities legendary gophers can learn. Every legendary
+// gets the first one or two signature abilities, and with UltimateChance one ultimate ability.
+type LegendaryMovePool struct {
+	Signature      []string `json:"signature"`
+	Ultimate       []string `json:"ultimate"`
+	UltimateChance float64  `json:"ultimate_chance"`
+}
+
+var (
+	movePools     = map[Archetype]MovePool{}
+	legendaryPool LegendaryMovePool
+)
+
+// LoadAbilities reads and validates the abilities and move pools in dir and makes them the
+// ones gophers learn and use. Call it once at startup, before any gophers are created.
+func LoadAbilities(dir string) error {
+	data := &AbilityData{}
+	if err := readDataFile(filepath.Join(dir, abilitiesFile), data); err != nil {
+		return err
+	}
+	if err := data.Validate(); err != nil {
+		return fmt.Errorf("invalid %s: %w", abilitiesFile, err)
+	}
+
+	templates := make(map[string]AbilityTemplate, len(data.Abilities))
+	for _, template := range data.Abilities {
+		if template.Accuracy == 0 {
+			template.Accuracy = 100
+		}
+		for _, effect := range template.Effects {
+			if effect.Type == EffectDamage || effect.Type == EffectMultiHit {
+				template.Power = effect.Power
+				break
+			}
+		}
+		templates[template.ID] = *template
+	}
+
+	AbilityTemplates = templates
+	movePools = data.MovePools
+	legendaryPool = data.Legendary
+	return nil
+}
+
+// Validate checks that ability IDs are unique, every ability and effect makes sense and the
+// move pools only refer to abilities that exist
+func (d *AbilityData) Validate() error {
+	ids := make(map[string]bool)
+	for _, ability := range d.Abilities {
+		if ability.ID == "" || ability.Name == "" {
+			return fmt.Errorf("every ability needs an id and a name")
+		}
+		if ids[ability.ID] {
+			return fmt.Errorf("duplicate ability id %q", ability.ID)
+		}
+		ids[ability.ID] = true
+
+		switch ability.Targeting {
+		case TargetingSelf, TargetingEnemy, TargetingBoth:
+		default:
+			return fmt.Errorf("ability %q: unknown targeting %q", ability.ID, ability.Targeting)
+		}
+		if ability.Accuracy < 0 || ability.Accuracy > 100 {
+			return fmt.Errorf("ability %q: accuracy must be between 1 and 100", ability.ID)
+		}
+		if ability.Cost < 0 {
+			return fmt.Errorf("ability %q: cost can't be negative", ability.ID)
+		}
+		if len(ability.Effects) == 0 {
+			return fmt.Errorf("ability %q: needs at least one effect", ability.ID)
+		}
+		damaging := 0
+		for n, effect := range ability.Effects {
+			if err := effect.validate(); err != nil {
+				return fmt.Errorf("ability %q effect %d: %w", ability.ID, n+1, err)
+			}
+			if effect.Type == EffectDamage || effect.Type == EffectMultiHit {
+				damaging++
+			}
+		}
+		if damaging > 1 {
+			return fmt.Errorf("ability %q: at most one damage or multi_hit effect", ability.ID)
+		}
+		if damaging > 0 && ability.Targeting == TargetingSelf {
+			return fmt.Errorf("ability %q: a damaging ability can't target only its user", ability.ID)
+		}
+	}
+
+	checkPool := func(pool string, abilities []string) error {
+		for _, id := range abilities {
+			if !ids[id] {
+				return fmt.Errorf("%s: unknown ability %q", pool, id)
+			}
+		}
+		return nil
+	}
+
+	for archetype := range archetypeEnergy {
+		pool, ok := d.MovePools[archetype]
+		if !ok || len(pool.Base) == 0 {
+			return fmt.Errorf("archetype %q needs a move pool with base abilities", archetype)
+		}
+	}
+	for archetype, pool := range d.MovePools {
+		if _, ok := archetypeEnergy[archetype]; !ok {
+			return fmt.Errorf("move pool for unknown archetype %q", archetype)
+		}
+		if err := checkPool(fmt.Sprintf("%s base move pool", archetype), pool.Base); err != nil {
+			return err
+		}
+		if err := checkPool(fmt.Sprintf("%s stage 1 move pool", archetype), pool.Stage1); err != nil {
+			return err
+		}
+		if err := checkPool(fmt.Sprintf("%s stage 2 move pool", archetype), pool.Stage2); err != nil {
+			return err
+		}
+	}
+
+	if len(d.Legendary.Signature) == 0 {
+		return fmt.Errorf("legendary move pool needs signature abilities")
+	}
+	if d.Legendary.UltimateChance < 0 || d.Legendary.UltimateChance > 1 {
+		return fmt.Errorf("legendary ultimate chance must be between 0 and 1")
+	}
+	if d.Legendary.UltimateChance > 0 && len(d.Legendary.Ultimate) == 0 {
+		return fmt.Errorf("legendary move pool needs ultimate abilities when ultimate chance is set")
+	}
+	if err := checkPool("legendary signature pool", d.Legendary.Signature); err != nil {
+		return err
+	}
+	if err := checkPool("legendary ultimate pool", d.Legendary.Ultimate); err != nil {
+		return err
+	}
+
+	// Raid bosses have a fixed move set
+	if err := checkPool("raid bosses", RaidBossAbilities); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package game
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testDataDir is the bot's data directory, relative to this package
+const testDataDir = "../../data"
+
+func TestMain(m *testing.M) {
+	// Gophers learn abilities from the shipped data
+	if err := LoadAbilities(testDataDir); err != nil {
+		panic(err)
+	}
+	os.Exit(m.Run())
+}
+
+// readTestAbilityData reads the shipped abilities.json
+func readTestAbilityData(t *testing.T) *AbilityData {
+	t.Helper()
+	data := &AbilityData{}
+	if err := readDataFile(filepath.Join(testDataDir, abilitiesFile), data); err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// writeTestAbilityData writes data as abilities.json in a new directory and returns it
+func writeTestAbilityData(t *testing.T, data interface{}) string {
+	t.Helper()
+	contents, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, abilitiesFile), contents, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestLoadAbilities(t *testing.T) {
+	if len(AbilityTemplates) == 0 {
+		t.Fatal("no abilities loaded")
+	}
+	for _, template := range AbilityTemplates {
+		if template.Accuracy < 1 || template.Accuracy > 100 {
+			t.Errorf("%s: accuracy %d, want a default of 100", template.ID, template.Accuracy)
+		}
+	}
+	if quickHit := AbilityTemplates["quick_hit"]; quickHit.Power != 20 {
+		t.Errorf("quick_hit power = %d, want the damage effect's 20", quickHit.Power)
+	}
+	for archetype := range archetypeEnergy {
+		if len(movePools[archetype].Base) == 0 {
+			t.Errorf("%s has no base move pool", archetype)
+		}
+	}
+}
+
+func TestLoadAbilitiesRejectsInvalidData(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(data *AbilityData)
+		want   string
+	}{
+		{"duplicate id", func(data *AbilityData) {
+			data.Abilities = append(data.Abilities, data.Abilities[0])
+		}, "duplicate ability id"},
+		{"unknown targeting", func(data *AbilityData) {
+			data.Abilities[0].Targeting = "EVERYONE"
+		}, "unknown targeting"},
+		{"accuracy over 100", func(data *AbilityData) {
+			data.Abilities[0].Accuracy = 150
+		}, "accuracy"},
+		{"no effects", func(data *AbilityData) {
+			data.Abilities[0].Effects = nil
+		}, "at least one effect"},
+		{"damage without power", func(data *AbilityData) {
+			data.Abilities[0].Effects = []AbilityEffect{{Type: EffectDamage}}
+		}, "positive power"},
+		{"self-targeted damage", func(data *AbilityData) {
+			data.Abilities[0].Targeting = TargetingSelf
+		}, "can't target only its user"},
+		{"heal without an amount", func(data *AbilityData) {
+			data.Abilities[0].Effects = []AbilityEffect{{Type: EffectHeal}}
+			data.Abilities[0].Targeting = TargetingSelf
+		}, "heal needs"},
+		{"unknown ability in move pool", func(data *AbilityData) {
+			pool := data.MovePools[ArchetypeHacker]
+			pool.Base = append(pool.Base, "no_such_move")
+			data.MovePools[ArchetypeHacker] = pool
+		}, "unknown ability \"no_such_move\""},
+		{"missing move pool", func(data *AbilityData) {
+			delete(data.MovePools, ArchetypeTank)
+		}, "needs a move pool"},
+		{"ultimate chance over 1", func(data *AbilityData) {
+			data.Legendary.UltimateChance = 2
+		}, "ultimate chance"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := readTestAbilityData(t)
+			tt.modify(data)
+			err := LoadAbilities(writeTestAbilityData(t, data))
+			if err == nil {
+				t.Fatal("invalid data loaded")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q doesn't mention %q", err, tt.want)
+			}
+			if _, ok := AbilityTemplates["quick_hit"]; !ok {
+				t.Error("a failed load replaced the loaded abilities")
+			}
+		})
+	}
+}
+
+func TestLoadAbilitiesRejectsUnknownFields(t *testing.T) {
+	dir := t.TempDir()
+	contents := `{"abilities": [{"id": "x", "name": "X", "targeting": "ENEMY", "powr": 10, "effects": []}]}`
+	if err := os.WriteFile(filepath.Join(dir, abilitiesFile), []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadAbilities(dir); err == nil {
+		t.Error("loaded a file with a misspelled field")
+	}
+}
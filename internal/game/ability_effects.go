@@ -0,0 +1,396 @@
+package game
+
+import (
+	"fmt"
+	"math"
+)
+
+// EffectType names an effect primitive. An ability applies its effects in order.
+type EffectType string
+
+const (
+	EffectDamage    EffectType = "damage"     // Hit the target once with Power
+	EffectMultiHit  EffectType = "multi_hit"  // Hit the target MinHits to MaxHits times with Power
+	EffectHeal      EffectType = "heal"       // Restore Percent of the user's max HP plus Amount and PerLevel HP
+	EffectStatus    EffectType = "status"     // Inflict Status with Chance for Duration turns
+	EffectStatStage EffectType = "stat_stage" // Raise or lower Stat by Stages for Duration turns
+	EffectStatBoost EffectType = "stat_boost" // Raise Stat by Amount and PerLevel points for the rest of the battle
+	EffectProtect   EffectType = "protect"    // Block the next attack on the user
+	EffectCleanse   EffectType = "cleanse"    // Remove the user's status effects
+	EffectField     EffectType = "field"      // Change the field condition to Condition for Duration rounds
+)
+
+// MaxStatStages is how far a single stat_stage effect can raise or lower a stat
+const MaxStatStages = 3
+
+// Effect targets. Damage always hits the target; heal, protect and cleanse always affect the user.
+const (
+	EffectTargetSelf   = "self"
+	EffectTargetTarget = "target"
+	EffectTargetBoth   = "both"
+)
+
+// AbilityEffect is one effect primitive of an ability. Which fields apply depends on Type.
+type AbilityEffect struct {
+	Type   EffectType `json:"type"`
+	Target string     `json:"target,omitempty"` // For status and stat_stage; defaults to "target"
+
+	Power          int     `json:"power,omitempty"`           // damage, multi_hit
+	MinHits        int     `json:"min_hits,omitempty"`        // multi_hit
+	MaxHits        int     `json:"max_hits,omitempty"`        // multi_hit
+	RecoilPercent  int     `json:"recoil_percent,omitempty"`  // damage: percent of the damage dealt the user takes too
+	BackfireChance float64 `json:"backfire_chance,omitempty"` // damage: chance the user takes half the damage instead
+	Percent        int     `json:"percent,omitempty"`         // heal
+	Amount         int     `json:"amount,omitempty"`          // heal: flat HP; stat_boost: flat points
+	PerLevel       float64 `json:"per_level,omitempty"`       // heal, stat_boost: this much more for each level of the user
+
+	Status StatusEffectType `json:"status,omitempty"` // status
+	Stat   string           `json:"stat,omitempty"`   // stat_stage: "attack", "defense", "speed" or "evasion"; stat_boost: not "evasion"
+	Stages int              `json:"stages,omitempty"` // stat_stage: positive raises, negative lowers
+	Chance float64          `json:"chance,omitempty"` // status, stat_stage: 0 means always
+
+	Duration      int `json:"duration,omitempty"`        // status, stat_stage: turns the effect lasts
+	ExtraDuration int `json:"extra_duration,omitempty"`  // Up to this many more turns at random
+	LevelsPerTurn int `json:"levels_per_turn,omitempty"` // One more turn for every this many levels of the user
+
+	Intensity          int `json:"intensity,omitempty"`            // status: e.g. poison damage per turn
+	LevelsPerIntensity int `json:"levels_per_intensity,omitempty"` // One more intensity for every this many levels of the user
+
+	NegativeOnly bool `json:"negative_only,omitempty"` // cleanse: keep stat boosts
+
+	Condition FieldCondition `json:"condition,omitempty"` // field
+}
+
+// statStatuses maps stats to the status effects that raise and lower them
+var statStatuses = map[string][2]StatusEffectType{
+	"attack":  {StatusAttackUp, StatusAttackDown},
+	"defense": {StatusDefenseUp, StatusDefenseDown},
+	"speed":   {StatusSpeedUp, StatusSpeedDown},
+	"evasion": {StatusEvasionUp, StatusEvasionDown},
+}
+
+// inflictableStatuses are the statuses a status effect can inflict, with the message shown when it lands
+var inflictableStatuses = map[StatusEffectType]string{
+	StatusBurn:      "%s was burned!",
+	StatusPoison:    "%s was poisoned!",
+	StatusParalysis: "%s was paralyzed!",
+	StatusSleep:     "%s fell asleep!",
+	StatusConfusion: "%s became confused!",
+}
+
+// applyEffects applies an ability's effects in order and returns the battle log, starting with
+// "<user> used <ability>!". Abilities aimed at the target can miss, and damaging abilities can
+// land a critical hit. An ability whose effects all missed fails.
+func applyEffects(state *BattleState, template AbilityTemplate, user, target *Gopher) []string {
+	headline := fmt.Sprintf("%s used %s!", user.Name, template.Name)
+	if template.Targeting != TargetingSelf {
+		if chance := hitChance(template.Accuracy, user, target); chance < 1 && state.Rand().Float64() >= chance {
+			return []string{headline + " It missed!"}
+		}
+	}
+	critical := template.Power > 0 && state.Rand().Float64() < criticalChance(user, state.Field, template.HighCrit)
+
+	messages := []string{}
+	for _, effect := range template.Effects {
+		messages = append(messages, effect.apply(state, user, target, critical)...)
+	}
+
+	if len(messages) == 0 {
+		return []string{headline + " But it failed!"}
+	}
+	messages[0] = headline + " " + messages[0]
+	return messages
+}
+
+// apply applies the effect. A critical hit multiplies the damage of every hit.
+func (e AbilityEffect) apply(state *BattleState, user, target *Gopher, critical bool) []string {
+	rng := state.Rand()
+
+	switch e.Type {
+	case EffectDamage:
+		damage := state.spreadDamage(calculateDamage(rng, state.Field, user, target, e.Power))
+		if critical {
+			damage = int(float64(damage) * CriticalMultiplier)
+		}
+		if e.BackfireChance > 0 && rng.Float64() < e.BackfireChance {
+			takeDamage(user, damage/2)
+			return []string{fmt.Sprintf("But it backfired! %s took %d damage!", user.Name, damage/2)}
+		}
+
+		held := takeHit(rng, target, damage)
+		messages := []string{fmt.Sprintf("Dealt %d damage!", damage)}
+		if critical {
+			messages = append(messages, "A critical hit!")
+		}
+		if msg := GetTypeEffectivenessMessage(typeEffectiveness(user, target)); msg != "" {
+			messages = append(messages, msg)
+		}
+		messages = append(messages, held...)
+		if recoil := damage * e.RecoilPercent / 100; recoil > 0 {
+			takeDamage(user, recoil)
+			messages = append(messages, fmt.Sprintf("%s took %d recoil damage!", user.Name, recoil))
+		}
+		return messages
+
+	case EffectMultiHit:
+		hits := e.MinHits + rng.Intn(e.MaxHits-e.MinHits+1)
+		totalDamage := 0
+		var held []string
+		for i := 0; i < hits; i++ {
+			damage := state.spreadDamage(calculateDamage(rng, state.Field, user, target, e.Power))
+			if critical {
+				damage = int(float64(damage) * CriticalMultiplier)
+			}
+			held = append(held, takeHit(rng, target, damage)...)
+			totalDamage += damage
+		}
+		messages := []string{fmt.Sprintf("Hit %d times for %d total damage!", hits, totalDamage)}
+		if critical {
+			messages = append(messages, "A critical hit!")
+		}
+		if msg := GetTypeEffectivenessMessage(typeEffectiveness(user, target)); msg != "" {
+			messages = append(messages, msg)
+		}
+		messages = append(messages, held...)
+		return messages
+
+	case EffectHeal:
+		heal := user.MaxHP*e.Percent/100 + e.levelScaled(user)
+		if heal > user.MaxHP-user.CurrentHP {
+			heal = user.MaxHP - user.CurrentHP
+		}
+		user.CurrentHP += heal
+		if user.CurrentHP == user.MaxHP && e.Percent >= 100 {
+			return []string{"Fully restored HP!"}
+		}
+		return []string{fmt.Sprintf("Healed %d HP!", heal)}
+
+	case EffectStatus:
+		if e.Chance > 0 && rng.Float64() >= e.Chance {
+			return nil
+		}
+		duration := e.duration(rng, user)
+		intensity := e.Intensity
+		if e.LevelsPerIntensity > 0 {
+			intensity += user.Level / e.LevelsPerIntensity
+		}
+
+		messages := []string{}
+		for _, gopher := range e.targets(user, target) {
+			gopher.AddStatusEffect(e.Status, duration, intensity)
+			messages = append(messages, fmt.Sprintf(inflictableStatuses[e.Status], gopher.Name))
+		}
+		return messages
+
+	case EffectStatStage:
+		if e.Chance > 0 && rng.Float64() >= e.Chance {
+			return nil
+		}
+		duration := e.duration(rng, user)
+		statuses := statStatuses[e.Stat]
+		status, stages, change := statuses[0], e.Stages, "increased"
+		if stages < 0 {
+			status, stages, change = statuses[1], -stages, "decreased"
+		}
+		if stages > 1 {
+			change = "sharply " + change
+		}
+
+		messages := []string{}
+		for _, gopher := range e.targets(user, target) {
+			gopher.AddStatusEffect(status, duration, stages)
+			gopher.RecalculateStats()
+			messages = append(messages, fmt.Sprintf("%s's %s %s!", gopher.Name, e.Stat, change))
+		}
+		return messages
+
+	case EffectStatBoost:
+		boost := e.levelScaled(user)
+		messages := []string{}
+		for _, gopher := range e.targets(user, target) {
+			raiseBaseStat(gopher, e.Stat, boost)
+			messages = append(messages, fmt.Sprintf("%s's %s increased by %d!", gopher.Name, e.Stat, boost))
+		}
+		return messages
+
+	case EffectProtect:
+		user.AddStatusEffect(StatusProtect, 1, 0)
+		return []string{fmt.Sprintf("%s is protected from the next attack!", user.Name)}
+
+	case EffectCleanse:
+		kept := []*StatusEffect{}
+		for _, effect := range user.StatusEffects {
+			if e.NegativeOnly && isStatBoost(effect.Type) {
+				kept = append(kept, effect)
+			}
+		}
+		if len(kept) == len(user.StatusEffects) {
+			return nil
+		}
+		user.StatusEffects = kept
+		user.RecalculateStats()
+		return []string{fmt.Sprintf("%s's status effects were cleared!", user.Name)}
+
+	case EffectField:
+		turns := e.Duration
+		if turns == 0 {
+			turns = FieldDuration
+		}
+		return state.Field.set(e.Condition, turns)
+	}
+
+	return nil
+}
+
+// duration returns how many turns a status or stat stage effect lasts when the user applies it
+func (e AbilityEffect) duration(rng *RNG, user *Gopher) int {
+	duration := e.Duration
+	if e.LevelsPerTurn > 0 {
+		duration += user.Level / e.LevelsPerTurn
+	}
+	if e.ExtraDuration > 0 {
+		duration += rng.Intn(e.ExtraDuration + 1)
+	}
+	return duration
+}
+
+// levelScaled returns Amount plus PerLevel for each of the user's levels
+func (e AbilityEffect) levelScaled(user *Gopher) int {
+	return e.Amount + int(e.PerLevel*float64(user.Level))
+}
+
+// raiseBaseStat raises a gopher's stat for the rest of the battle. Stat stages apply on top.
+func raiseBaseStat(gopher *Gopher, stat string, amount int) {
+	gopher.RecalculateStats() // Sets the base stats if they aren't yet
+	switch stat {
+	case "attack":
+		gopher.BaseAttack += amount
+	case "defense":
+		gopher.BaseDefense += amount
+	case "speed":
+		gopher.BaseSpeed += amount
+	}
+	gopher.RecalculateStats()
+}
+
+// targets returns the gophers a status, stat stage or stat boost effect applies to
+func (e AbilityEffect) targets(user, target *Gopher) []*Gopher {
+	switch e.Target {
+	case EffectTargetSelf:
+		return []*Gopher{user}
+	case EffectTargetBoth:
+		return []*Gopher{user, target}
+	}
+	return []*Gopher{target}
+}
+
+// validate checks that the effect's type is known and its fields make sense for that type
+func (e AbilityEffect) validate() error {
+	if e.Chance < 0 || e.Chance > 1 || e.BackfireChance < 0 || e.BackfireChance > 1 {
+		return fmt.Errorf("chances must be between 0 and 1")
+	}
+	if e.Duration < 0 || e.ExtraDuration < 0 || e.LevelsPerTurn < 0 || e.Intensity < 0 || e.LevelsPerIntensity < 0 {
+		return fmt.Errorf("durations and intensities can't be negative")
+	}
+
+	switch e.Type {
+	case EffectDamage, EffectMultiHit:
+		if e.Target != "" && e.Target != EffectTargetTarget {
+			return fmt.Errorf("%s always hits the target", e.Type)
+		}
+		if e.Power <= 0 {
+			return fmt.Errorf("%s needs a positive power", e.Type)
+		}
+		if e.RecoilPercent < 0 || e.RecoilPercent > 100 {
+			return fmt.Errorf("recoil percent must be between 0 and 100")
+		}
+		if e.Type == EffectMultiHit && (e.MinHits < 1 || e.MaxHits < e.MinHits) {
+			return fmt.Errorf("multi_hit needs 1 <= min_hits <= max_hits")
+		}
+	case EffectHeal:
+		if e.Percent < 0 || e.Percent > 100 {
+			return fmt.Errorf("heal percent must be between 0 and 100")
+		}
+		if e.Amount < 0 || e.PerLevel < 0 {
+			return fmt.Errorf("heal amounts can't be negative")
+		}
+		if e.Percent == 0 && e.Amount == 0 && e.PerLevel == 0 {
+			return fmt.Errorf("heal needs a percent, amount or per_level")
+		}
+	case EffectStatus:
+		if _, ok := inflictableStatuses[e.Status]; !ok {
+			return fmt.Errorf("unknown status %q", e.Status)
+		}
+		if e.Duration+e.LevelsPerTurn+e.ExtraDuration == 0 {
+			return fmt.Errorf("status needs a duration")
+		}
+	case EffectStatStage:
+		if _, ok := statStatuses[e.Stat]; !ok {
+			return fmt.Errorf("unknown stat %q", e.Stat)
+		}
+		if e.Stages == 0 || e.Stages < -MaxStatStages || e.Stages > MaxStatStages {
+			return fmt.Errorf("stages must be between -%d and %d and not 0", MaxStatStages, MaxStatStages)
+		}
+		if e.Duration+e.LevelsPerTurn+e.ExtraDuration == 0 {
+			return fmt.Errorf("stat_stage needs a duration")
+		}
+	case EffectStatBoost:
+		if _, ok := statStatuses[e.Stat]; !ok || e.Stat == "evasion" {
+			return fmt.Errorf("stat_boost needs attack, defense or speed, not %q", e.Stat)
+		}
+		if e.Amount < 0 || e.PerLevel < 0 || e.Amount == 0 && e.PerLevel == 0 {
+			return fmt.Errorf("stat_boost needs a positive amount or per_level")
+		}
+	case EffectField:
+		if _, ok := fieldConditions[e.Condition]; !ok {
+			return fmt.Errorf("unknown field condition %q", e.Condition)
+		}
+		if e.Target != "" {
+			return fmt.Errorf("field affects the whole battle and has no target")
+		}
+	case EffectProtect, EffectCleanse:
+	default:
+		return fmt.Errorf("unknown effect type %q", e.Type)
+	}
+
+	switch e.Target {
+	case "", EffectTargetSelf, EffectTargetTarget, EffectTargetBoth:
+	default:
+		return fmt.Errorf("unknown target %q", e.Target)
+	}
+	if e.Target != "" && (e.Type == EffectHeal || e.Type == EffectProtect || e.Type == EffectCleanse) && e.Target != EffectTargetSelf {
+		return fmt.Errorf("%s always affects the user", e.Type)
+	}
+	return nil
+}
+
+// takeDamage lowers a gopher's HP, stopping at 0
+func takeDamage(gopher *Gopher, damage int) {
+	gopher.CurrentHP -= damage
+	if gopher.CurrentHP < 0 {
+		gopher.CurrentHP = 0
+	}
+}
+
+// isStatBoost reports whether a status effect raises a stat
+func isStatBoost(status StatusEffectType) bool {
+	return status == StatusAttackUp || status == StatusDefenseUp || status == StatusSpeedUp || status == StatusEvasionUp
+}
+
+// effectStages returns the number of stages of a stat status. Stat statuses from before stages
+// existed have an intensity of 0 and count as one.
+func effectStages(status *StatusEffect) int {
+	if status.Intensity < 1 {
+		return 1
+	}
+	return status.Intensity
+}
+
+// statStageMultiplier is how much a stat changes for a stat status
+func statStageMultiplier(status *StatusEffect) float64 {
+	stages := effectStages(status)
+	if isStatBoost(status.Type) {
+		return math.Pow(1.5, float64(stages))
+	}
+	return math.Pow(0.75, float64(stages))
+}
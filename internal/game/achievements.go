@@ -1,5 +1,9 @@
 package game
 
+import (
+	"gophermon-bot/internal/storage"
+)
+
 // Achievement types
 const (
 	AchievementFirstCatch      = "FIRST_CATCH"
@@ -33,6 +37,39 @@ var AchievementRewards = map[string]int{
 	AchievementLevelMaster:     1000,
 }
 
+// AchievementOrder is the order achievements are listed in
+var AchievementOrder = []string{
+	AchievementFirstCatch,
+	AchievementCatchMaster,
+	AchievementShinyHunter,
+	AchievementLegendaryCollector,
+	AchievementBattleVeteran,
+	AchievementEvolutionMaster,
+	AchievementLevelMaster,
+}
+
+// Achievement display names
+var AchievementNames = map[string]string{
+	AchievementFirstCatch:      "First Catch",
+	AchievementShinyHunter:     "Shiny Hunter",
+	AchievementEvolutionMaster:  "Evolution Master",
+	AchievementBattleVeteran:   "Battle Veteran",
+	AchievementLegendaryCollector: "Legendary Collector",
+	AchievementCatchMaster:     "Catch Master",
+	AchievementLevelMaster:     "Level Master",
+}
+
+// Achievement descriptions
+var AchievementDescriptions = map[string]string{
+	AchievementFirstCatch:      "Catch your first gopher",
+	AchievementShinyHunter:     "Catch 10 shiny gophers",
+	AchievementEvolutionMaster:  "Evolve 50 gophers",
+	AchievementBattleVeteran:   "Win 100 battles",
+	AchievementLegendaryCollector: "Own 5 legendary gophers",
+	AchievementCatchMaster:     "Catch 100 gophers",
+	AchievementLevelMaster:     "Reach level 50 with a gopher",
+}
+
 type AchievementService struct {
 	achievementRepo AchievementRepoInterface
 	trainerRepo     TrainerRepoInterface
@@ -60,8 +97,32 @@ func (s *AchievementService) CheckAndUpdateAchievement(trainerID, achievementTyp
 		return false, 0, err
 	}
 
+	return s.checkCompletion(trainerID, achievementType)
+}
+
+// CheckAndSetAchievement records an absolute progress value (e.g. a running total or the highest level reached)
+// rather than an increment. Progress never goes down.
+func (s *AchievementService) CheckAndSetAchievement(trainerID, achievementType string, progress int) (completed bool, reward int, err error) {
+	ach, err := s.achievementRepo.GetOrCreate(trainerID, achievementType)
+	if err != nil {
+		return false, 0, err
+	}
+
+	if ach.Completed {
+		return false, 0, nil // Already completed
+	}
+
+	if err := s.achievementRepo.SetProgress(trainerID, achievementType, progress); err != nil {
+		return false, 0, err
+	}
+
+	return s.checkCompletion(trainerID, achievementType)
+}
+
+// checkCompletion completes the achievement if its progress has reached the requirement
+func (s *AchievementService) checkCompletion(trainerID, achievementType string) (completed bool, reward int, err error) {
 	// Get updated achievement to check if completed
-	ach, err = s.achievementRepo.GetOrCreate(trainerID, achievementType)
+	ach, err := s.achievementRepo.GetOrCreate(trainerID, achievementType)
 	if err != nil {
 		return false, 0, err
 	}
@@ -96,3 +157,6 @@ func (s *AchievementService) CompleteAchievement(trainerID, achievementType stri
 	return nil
 }
 
+func (s *AchievementService) GetAchievements(trainerID string) ([]*storage.Achievement, error) {
+	return s.achievementRepo.GetAchievements(trainerID)
+}
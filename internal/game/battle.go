@@ -2,7 +2,6 @@ package game
 
 import (
 	"fmt"
-	"math/rand"
 	"strings"
 )
 
@@ -21,61 +20,133 @@ type BattleState struct {
 	State             string      // "ACTIVE", "WON", "LOST", "ESCAPED"
 	Log               []string
 	EventManager      *EventManager // Event manager for event bonuses
+	Captured          bool          // Whether the enemy was captured (rather than defeated)
+	XPAwarded         int           // Total XP granted to participating gophers
+	XPMultiplier      float64       // XP Booster multiplier for this battle (0 or 1 = none)
+	EnergyPools       map[string]int // Energy left per gopher ID; gophers not listed have a full pool
+	EnemyStrategy     EnemyStrategy  // How the enemy picks moves; chosen by StrategyFor when nil
+	Seed              int64          // Seed of the battle's random source, recorded so the battle can be replayed
+	Turn              int            // Number of rounds played so far
+	Events            []BattleEvent  // Everything that happened, for replays
+	Opponent          *NPCTrainer    // NPC trainer being battled; nil in wild battles
+	EnemyParty        []*Gopher      // The NPC trainer's party, sent out in order
+	Field             *Field         // Battlefield condition such as a Compile Storm
+	rng               *RNG
+	spread            bool // Set while a move hits both foes in a double battle
 }
 
 // NewBattleState creates a new battle state
-func NewBattleState(trainerID, channelID string, playerGopher, enemyGopher *Gopher, playerParty []*Gopher, eventManager *EventManager) *BattleState {
+func NewBattleState(trainerID, channelID string, playerGopher, enemyGopher *Gopher, playerParty []*Gopher, eventManager *EventManager, seed int64) *BattleState {
+	bs := newBattleState(trainerID, channelID, playerGopher, enemyGopher, playerParty, nil, eventManager, seed)
+	bs.Log = []string{fmt.Sprintf("A wild %s appeared!", enemyGopher.Name)}
+	bs.Field = startingField(eventManager, bs.Rand(), true)
+	if message := bs.Field.StartMessage(); message != "" {
+		bs.Log = append(bs.Log, message)
+	}
+	bs.record(BattleEvent{Type: BattleEventStart, Side: "ENEMY", Gopher: enemyGopher.Name, Messages: bs.Log})
+	return bs
+}
+
+// NewTrainerBattleState creates a battle against an NPC trainer, who sends out their party in order.
+// Their gophers can't be captured and the player can't run.
+func NewTrainerBattleState(trainerID, channelID string, playerGopher *Gopher, playerParty []*Gopher, opponent *NPCTrainer, opponentParty []*Gopher, eventManager *EventManager, seed int64) *BattleState {
+	bs := newBattleState(trainerID, channelID, playerGopher, opponentParty[0], playerParty, opponentParty, eventManager, seed)
+	bs.OpponentType = "TRAINER"
+	bs.Opponent = opponent
+	bs.EnemyParty = opponentParty
+
+	bs.Log = []string{fmt.Sprintf("%s wants to battle!", opponent.DisplayName())}
+	if opponent.Intro != "" {
+		bs.Log = append(bs.Log, fmt.Sprintf("%s: \"%s\"", opponent.Name, opponent.Intro))
+	}
+	bs.Log = append(bs.Log, fmt.Sprintf("%s sent out %s!", opponent.Name, bs.EnemyGopher.Name))
+	bs.Field = startingField(eventManager, bs.Rand(), false)
+	if message := bs.Field.StartMessage(); message != "" {
+		bs.Log = append(bs.Log, message)
+	}
+	bs.record(BattleEvent{Type: BattleEventStart, Side: "ENEMY", Gopher: bs.EnemyGopher.Name, Messages: bs.Log})
+	return bs
+}
+
+func newBattleState(trainerID, channelID string, playerGopher, enemyGopher *Gopher, playerParty, enemyParty []*Gopher, eventManager *EventManager, seed int64) *BattleState {
 	// Initialize participating gophers with the active gopher
 	participating := []*Gopher{playerGopher}
 	
 	// Apply stat boost event to all gophers in battle
-	if eventManager != nil {
-		statBoost := eventManager.GetStatBoostMultiplier()
-		if statBoost > 1.0 {
-			// Ensure base stats are initialized, then apply boost
-			applyStatBoost := func(gopher *Gopher) {
-				// Initialize base stats if needed
-				if gopher.BaseAttack == 0 {
-					gopher.BaseAttack = gopher.Attack
-				}
-				if gopher.BaseDefense == 0 {
-					gopher.BaseDefense = gopher.Defense
-				}
-				if gopher.BaseSpeed == 0 {
-					gopher.BaseSpeed = gopher.Speed
-				}
-				// Recalculate stats first (applies status effects)
-				gopher.RecalculateStats()
-				// Then apply event stat boost on top (multiply current stats)
-				gopher.Attack = int(float64(gopher.Attack) * statBoost)
-				gopher.Defense = int(float64(gopher.Defense) * statBoost)
-				gopher.Speed = int(float64(gopher.Speed) * statBoost)
-			}
-			
-			applyStatBoost(playerGopher)
-			applyStatBoost(enemyGopher)
-			for _, gopher := range playerParty {
-				if gopher.ID != playerGopher.ID {
-					applyStatBoost(gopher)
-				}
-			}
+	gophers := []*Gopher{playerGopher, enemyGopher}
+	for _, gopher := range playerParty {
+		if gopher.ID != playerGopher.ID {
+			gophers = append(gophers, gopher)
+		}
+	}
+	for _, gopher := range enemyParty {
+		if gopher.ID != enemyGopher.ID {
+			gophers = append(gophers, gopher)
 		}
 	}
+	applyEventStatBoost(eventManager, gophers...)
 	
 	return &BattleState{
 		TrainerID:          trainerID,
 		ChannelID:          channelID,
+		OpponentType:       "WILD",
 		PlayerGopher:       playerGopher,
 		EnemyGopher:        enemyGopher,
 		PlayerParty:        playerParty,
 		ParticipatingGophers: participating,
 		TurnOwner:          "PLAYER",
 		State:              "ACTIVE",
-		Log:                []string{fmt.Sprintf("A wild %s appeared!", enemyGopher.Name)},
 		EventManager:       eventManager,
+		EnergyPools:        make(map[string]int),
+		Seed:               seed,
+		rng:                NewRNG(seed),
 	}
 }
 
+// applyEventStatBoost raises the gophers' stats while a stat boost event is running
+func applyEventStatBoost(eventManager *EventManager, gophers ...*Gopher) {
+	if eventManager == nil {
+		return
+	}
+	statBoost := eventManager.GetStatBoostMultiplier()
+	if statBoost <= 1.0 {
+		return
+	}
+
+	for _, gopher := range gophers {
+		// Initialize base stats if needed
+		if gopher.BaseAttack == 0 {
+			gopher.BaseAttack = gopher.Attack
+		}
+		if gopher.BaseDefense == 0 {
+			gopher.BaseDefense = gopher.Defense
+		}
+		if gopher.BaseSpeed == 0 {
+			gopher.BaseSpeed = gopher.Speed
+		}
+		// Recalculate stats first (applies status effects)
+		gopher.RecalculateStats()
+		// Then apply event stat boost on top (multiply current stats)
+		gopher.Attack = int(float64(gopher.Attack) * statBoost)
+		gopher.Defense = int(float64(gopher.Defense) * statBoost)
+		gopher.Speed = int(float64(gopher.Speed) * statBoost)
+	}
+}
+
+// Rand returns the battle's random source, creating it from Seed on first use
+func (bs *BattleState) Rand() *RNG {
+	if bs.rng == nil {
+		bs.rng = NewRNG(bs.Seed)
+	}
+	return bs.rng
+}
+
+// ResumeRand restores the battle's random source after draws values were taken from it,
+// so a battle loaded from storage continues the same sequence
+func (bs *BattleState) ResumeRand(draws int64) {
+	bs.rng = ResumeRNG(bs.Seed, draws)
+}
+
 // PlayerAction executes a player action
 func (bs *BattleState) PlayerAction(action string, abilityIndex int) ([]string, error) {
 	if bs.State != "ACTIVE" {
@@ -86,10 +157,38 @@ func (bs *BattleState) PlayerAction(action string, abilityIndex int) ([]string,
 		return []string{"It's not your turn!"}, nil
 	}
 
+	// Validate the requested action before any turn effects are applied
+	switch action {
+	case "fight":
+		if abilityIndex < 0 || abilityIndex >= len(bs.PlayerGopher.Abilities) {
+			return []string{"Invalid ability!"}, nil
+		}
+		ability := bs.PlayerGopher.Abilities[abilityIndex]
+		if !bs.CanAfford(bs.PlayerGopher, ability) {
+			return []string{fmt.Sprintf("Not enough energy! %s needs %d ⚡ but %s only has %d.",
+				ability.Name, ability.Cost, bs.PlayerGopher.Name, bs.Energy(bs.PlayerGopher))}, nil
+		}
+	case "swap":
+		if msg := bs.validateSwap(abilityIndex); msg != "" {
+			return []string{msg}, nil
+		}
+	}
+
+	if bs.OpponentType != "WILD" {
+		switch action {
+		case "throw_net":
+			return []string{"You can't capture another trainer's gopher!"}, nil
+		case "run":
+			return []string{"There's no running from a trainer battle!"}, nil
+		}
+	}
+
 	messages := []string{}
+	bs.Turn++
+	defer bs.recordResult()
 	
 	// Process status effects at start of turn
-	statusMsgs := bs.PlayerGopher.ProcessStatusEffects()
+	statusMsgs := bs.processStatusEffects("PLAYER", bs.PlayerGopher)
 	messages = append(messages, statusMsgs...)
 	
 	// Check if player is asleep or paralyzed
@@ -97,11 +196,13 @@ func (bs *BattleState) PlayerAction(action string, abilityIndex int) ([]string,
 		// Check if sleep wears off
 		for _, effect := range bs.PlayerGopher.StatusEffects {
 			if effect.Type == StatusSleep {
-				if rand.Float64() < 0.3 { // 30% chance to wake up
+				if bs.Rand().Float64() < 0.3 { // 30% chance to wake up
 					bs.PlayerGopher.RemoveStatusEffect(StatusSleep)
 					messages = append(messages, fmt.Sprintf("%s woke up!", bs.PlayerGopher.Name))
+					bs.recordStatus("PLAYER", bs.PlayerGopher, 0, messages[len(messages)-1])
 				} else {
 					messages = append(messages, fmt.Sprintf("%s is fast asleep!", bs.PlayerGopher.Name))
+					bs.recordStatus("PLAYER", bs.PlayerGopher, 0, messages[len(messages)-1])
 					bs.TurnOwner = "ENEMY"
 					enemyMsgs := bs.enemyTurn()
 					messages = append(messages, enemyMsgs...)
@@ -114,8 +215,9 @@ func (bs *BattleState) PlayerAction(action string, abilityIndex int) ([]string,
 	
 	// Check paralysis
 	if bs.PlayerGopher.HasStatusEffect(StatusParalysis) {
-		if rand.Float64() < 0.25 { // 25% chance to be paralyzed
+		if bs.Rand().Float64() < 0.25 { // 25% chance to be paralyzed
 			messages = append(messages, fmt.Sprintf("%s is paralyzed! It can't move!", bs.PlayerGopher.Name))
+			bs.recordStatus("PLAYER", bs.PlayerGopher, 0, messages[len(messages)-1])
 			bs.TurnOwner = "ENEMY"
 			enemyMsgs := bs.enemyTurn()
 			messages = append(messages, enemyMsgs...)
@@ -127,25 +229,50 @@ func (bs *BattleState) PlayerAction(action string, abilityIndex int) ([]string,
 	// Check confusion
 	confused := bs.PlayerGopher.HasStatusEffect(StatusConfusion)
 	if confused && action == "fight" {
-		if rand.Float64() < 0.33 { // 33% chance to hurt self
+		if bs.Rand().Float64() < 0.33 { // 33% chance to hurt self
 			damage := bs.PlayerGopher.MaxHP / 8
 			bs.PlayerGopher.CurrentHP -= damage
 			if bs.PlayerGopher.CurrentHP < 0 {
 				bs.PlayerGopher.CurrentHP = 0
 			}
 			messages = append(messages, fmt.Sprintf("%s is confused! It hurt itself in confusion for %d damage!", bs.PlayerGopher.Name, damage))
+			bs.recordStatus("PLAYER", bs.PlayerGopher, damage, messages[len(messages)-1])
 			bs.TurnOwner = "ENEMY"
 			enemyMsgs := bs.enemyTurn()
 			messages = append(messages, enemyMsgs...)
 			if bs.PlayerGopher.CurrentHP <= 0 {
 				bs.State = "LOST"
 				messages = append(messages, fmt.Sprintf("%s was defeated!", bs.PlayerGopher.Name))
+				bs.recordFaint("PLAYER", bs.PlayerGopher)
 			}
 			bs.Log = append(bs.Log, messages...)
 			return messages, nil
 		}
 	}
 	
+	// Work out who moves first this round. Swapping, items and running always go before
+	// moves; between moves the higher priority goes first, then the faster gopher.
+	enemyAbility := bs.chooseEnemyAbility()
+	enemyActed := false
+	if !bs.playerActsFirst(action, abilityIndex, enemyAbility) {
+		bs.TurnOwner = "ENEMY"
+		messages = append(messages, bs.enemyAction(enemyAbility)...)
+		enemyActed = true
+
+		// Fainting before it gets to move ends the player's part of the round
+		if bs.PlayerGopher.CurrentHP <= 0 {
+			bs.regenerateEnergy()
+			messages = append(messages, bs.processField()...)
+			swapped, swapMsgs := bs.tryAutoSwap()
+			messages = append(messages, swapMsgs...)
+			if !swapped {
+				bs.State = "LOST"
+			}
+			bs.Log = append(bs.Log, messages...)
+			return messages, nil
+		}
+	}
+
 	// Check protect status
 	if bs.PlayerGopher.HasStatusEffect(StatusProtect) && action == "fight" {
 		// Protection is consumed when used, so remove it
@@ -154,46 +281,38 @@ func (bs *BattleState) PlayerAction(action string, abilityIndex int) ([]string,
 
 	switch action {
 	case "fight":
-		if abilityIndex < 0 || abilityIndex >= len(bs.PlayerGopher.Abilities) {
-			return []string{"Invalid ability!"}, nil
-		}
-
 		ability := bs.PlayerGopher.Abilities[abilityIndex]
+		bs.setEnergy(bs.PlayerGopher, bs.Energy(bs.PlayerGopher)-ability.Cost)
 		
-		// Check if enemy has protect status
-		if bs.EnemyGopher.HasStatusEffect(StatusProtect) {
-			bs.EnemyGopher.RemoveStatusEffect(StatusProtect)
-			messages = append(messages, fmt.Sprintf("%s was protected from the attack!", bs.EnemyGopher.Name))
-		} else {
-			msgs, err := ability.EffectFunc(bs, bs.PlayerGopher, bs.EnemyGopher)
-			if err != nil {
-				return nil, err
-			}
-			messages = append(messages, msgs...)
+		msgs, err := bs.useAbility("PLAYER", bs.PlayerGopher, bs.EnemyGopher, ability)
+		if err != nil {
+			return nil, err
 		}
+		messages = append(messages, msgs...)
 
 		// Check if enemy is defeated
 		if bs.EnemyGopher.CurrentHP <= 0 {
-			bs.State = "WON"
-			xpGain := bs.calculateXPGain()
-			
-			// Give XP to all participating gophers (including fainted ones)
-			for _, gopher := range bs.ParticipatingGophers {
-				leveledUp, newLevel := gopher.AddXP(xpGain)
-				xpBar := GetXPBar(gopher.XP, gopher.Level, 10)
-				messages = append(messages, fmt.Sprintf("%s gained %d XP! %s", gopher.Name, xpGain, xpBar))
-				if leveledUp {
-					messages = append(messages, fmt.Sprintf("%s leveled up to level %d! 🎉", gopher.Name, newLevel))
-				}
-			}
+			bs.recordFaint("ENEMY", bs.EnemyGopher)
+			messages = append(messages, bs.awardXP()...)
 			
 			messages = append(messages, fmt.Sprintf("%s was defeated!", bs.EnemyGopher.Name))
+
+			// A trainer sends out their next gopher, which gets to move from the next round
+			if sentOut, sendMsgs := bs.sendOutNextEnemy(); sentOut {
+				messages = append(messages, sendMsgs...)
+				bs.regenerateEnergy()
+				messages = append(messages, bs.processField()...)
+				bs.TurnOwner = "PLAYER"
+				bs.Log = append(bs.Log, messages...)
+				return messages, nil
+			}
+
+			bs.State = "WON"
 			return messages, nil
 		}
 
 		// Enemy turn
-		bs.TurnOwner = "ENEMY"
-		enemyMsgs := bs.enemyTurn()
+		enemyMsgs := bs.endRound(enemyAbility, enemyActed)
 		messages = append(messages, enemyMsgs...)
 
 		// Check if player gopher fainted and try auto-swap
@@ -207,20 +326,9 @@ func (bs *BattleState) PlayerAction(action string, abilityIndex int) ([]string,
 		}
 
 	case "swap":
-		// Party swap - abilityIndex contains the party member index to swap to
-		if abilityIndex < 0 || abilityIndex >= len(bs.PlayerParty) {
-			return []string{"Invalid party member!"}, nil
-		}
-		
+		// Party swap - abilityIndex contains the party member index to swap to, already validated
 		newGopher := bs.PlayerParty[abilityIndex]
-		if newGopher.CurrentHP <= 0 {
-			return []string{fmt.Sprintf("%s is fainted and can't battle!", newGopher.Name)}, nil
-		}
-		
-		if newGopher.ID == bs.PlayerGopher.ID {
-			return []string{"That gopher is already in battle!"}, nil
-		}
-		
+
 		// Swap gophers
 		oldGopher := bs.PlayerGopher
 		bs.PlayerGopher = newGopher
@@ -239,52 +347,67 @@ func (bs *BattleState) PlayerAction(action string, abilityIndex int) ([]string,
 		
 		messages = append(messages, fmt.Sprintf("%s, come back!", oldGopher.Name))
 		messages = append(messages, fmt.Sprintf("Go, %s!", newGopher.Name))
+		bs.record(BattleEvent{Type: BattleEventSwap, Side: "PLAYER", Gopher: newGopher.Name, Messages: messages[len(messages)-2:]})
 		
 		// Enemy gets a free turn after swap (like Pokemon)
-		bs.TurnOwner = "ENEMY"
-		enemyMsgs := bs.enemyTurn()
+		enemyMsgs := bs.endRound(enemyAbility, enemyActed)
 		messages = append(messages, enemyMsgs...)
 		
 		if bs.PlayerGopher.CurrentHP <= 0 {
 			bs.State = "LOST"
 			messages = append(messages, fmt.Sprintf("%s was defeated!", bs.PlayerGopher.Name))
+			bs.recordFaint("PLAYER", bs.PlayerGopher)
+		}
+
+	case "rest":
+		// Skip the attack to recover energy on top of the usual regeneration
+		recovered := EnergyRegen(bs.PlayerGopher)
+		bs.setEnergy(bs.PlayerGopher, bs.Energy(bs.PlayerGopher)+recovered)
+		messages = append(messages, fmt.Sprintf("%s is resting and recovered %d ⚡!", bs.PlayerGopher.Name, recovered))
+		bs.record(BattleEvent{Type: BattleEventRest, Side: "PLAYER", Gopher: bs.PlayerGopher.Name, Messages: messages[len(messages)-1:]})
+
+		// Enemy turn
+		enemyMsgs := bs.endRound(enemyAbility, enemyActed)
+		messages = append(messages, enemyMsgs...)
+
+		// Check if player gopher fainted and try auto-swap
+		if bs.PlayerGopher.CurrentHP <= 0 {
+			swapped, swapMsgs := bs.tryAutoSwap()
+			messages = append(messages, swapMsgs...)
+			if !swapped {
+				bs.State = "LOST"
+			}
 		}
 
 	case "run":
 		// 70% chance to escape
-		if rand.Float64() < 0.7 {
+		if bs.Rand().Float64() < 0.7 {
 			bs.State = "ESCAPED"
 			messages = append(messages, "Got away safely!")
+			bs.record(BattleEvent{Type: BattleEventRun, Side: "PLAYER", Gopher: bs.PlayerGopher.Name, Success: true, Messages: messages[len(messages)-1:]})
 		} else {
 			messages = append(messages, "Couldn't escape!")
+			bs.record(BattleEvent{Type: BattleEventRun, Side: "PLAYER", Gopher: bs.PlayerGopher.Name, Messages: messages[len(messages)-1:]})
 			// Enemy turn
-			bs.TurnOwner = "ENEMY"
-			enemyMsgs := bs.enemyTurn()
+			enemyMsgs := bs.endRound(enemyAbility, enemyActed)
 			messages = append(messages, enemyMsgs...)
 		}
 
 	case "throw_net":
 		captureChance := bs.calculateCaptureChance()
-		if rand.Float64() < captureChance {
+		if bs.Rand().Float64() < captureChance {
 			bs.State = "WON"
-			xpGain := bs.calculateXPGain()
-			
-			// Give XP to all participating gophers on capture (including fainted ones)
-			for _, gopher := range bs.ParticipatingGophers {
-				leveledUp, newLevel := gopher.AddXP(xpGain)
-				xpBar := GetXPBar(gopher.XP, gopher.Level, 10)
-				messages = append(messages, fmt.Sprintf("%s gained %d XP! %s", gopher.Name, xpGain, xpBar))
-				if leveledUp {
-					messages = append(messages, fmt.Sprintf("%s leveled up to level %d! 🎉", gopher.Name, newLevel))
-				}
-			}
+			bs.Captured = true
+			bs.record(BattleEvent{Type: BattleEventCapture, Side: "PLAYER", Target: bs.EnemyGopher.Name, Success: true,
+				Messages: []string{fmt.Sprintf("Successfully captured %s!", bs.EnemyGopher.Name)}})
+			messages = append(messages, bs.awardXP()...)
 			
 			messages = append(messages, fmt.Sprintf("Successfully captured %s!", bs.EnemyGopher.Name))
 		} else {
 			messages = append(messages, "The gopher broke free!")
+			bs.record(BattleEvent{Type: BattleEventCapture, Side: "PLAYER", Target: bs.EnemyGopher.Name, Messages: messages[len(messages)-1:]})
 			// Enemy turn
-			bs.TurnOwner = "ENEMY"
-			enemyMsgs := bs.enemyTurn()
+			enemyMsgs := bs.endRound(enemyAbility, enemyActed)
 			messages = append(messages, enemyMsgs...)
 			
 			// Check if player gopher fainted after enemy turn and try auto-swap
@@ -306,6 +429,75 @@ func (bs *BattleState) PlayerAction(action string, abilityIndex int) ([]string,
 	return messages, nil
 }
 
+// validateSwap returns an error message if the player can't swap to the given party member
+func (bs *BattleState) validateSwap(index int) string {
+	if index < 0 || index >= len(bs.PlayerParty) {
+		return "Invalid party member!"
+	}
+	newGopher := bs.PlayerParty[index]
+	if newGopher.CurrentHP <= 0 {
+		return fmt.Sprintf("%s is fainted and can't battle!", newGopher.Name)
+	}
+	if newGopher.ID == bs.PlayerGopher.ID {
+		return "That gopher is already in battle!"
+	}
+	return ""
+}
+
+// UseItem uses a Potion or Revive from the bag on a party member. Like swapping,
+// this takes the player's turn and the enemy acts next.
+func (bs *BattleState) UseItem(itemService *ItemService, itemType string, partyIndex int) ([]string, error) {
+	if bs.State != "ACTIVE" {
+		return []string{"Battle is already over!"}, nil
+	}
+
+	if bs.TurnOwner != "PLAYER" {
+		return []string{"It's not your turn!"}, nil
+	}
+
+	if partyIndex < 0 || partyIndex >= len(bs.PlayerParty) {
+		return []string{"Invalid party member!"}, nil
+	}
+
+	target := bs.PlayerParty[partyIndex]
+	if target.ID == bs.PlayerGopher.ID {
+		// The active gopher may be a different instance than the party entry
+		target = bs.PlayerGopher
+	}
+
+	itemMsg, err := itemService.UseItemOn(bs.TrainerID, itemType, target)
+	if err != nil {
+		return []string{fmt.Sprintf("Can't use that: %v", err)}, nil
+	}
+
+	messages := []string{}
+	bs.Turn++
+	defer bs.recordResult()
+
+	// Process status effects at start of turn
+	statusMsgs := bs.processStatusEffects("PLAYER", bs.PlayerGopher)
+	messages = append(messages, statusMsgs...)
+	messages = append(messages, itemMsg)
+	bs.record(BattleEvent{Type: BattleEventItem, Side: "PLAYER", Target: target.Name, Messages: []string{itemMsg}})
+
+	// Enemy turn
+	bs.TurnOwner = "ENEMY"
+	enemyMsgs := bs.enemyTurn()
+	messages = append(messages, enemyMsgs...)
+
+	// Check if player gopher fainted and try auto-swap
+	if bs.PlayerGopher.CurrentHP <= 0 {
+		swapped, swapMsgs := bs.tryAutoSwap()
+		messages = append(messages, swapMsgs...)
+		if !swapped {
+			bs.State = "LOST"
+		}
+	}
+
+	bs.Log = append(bs.Log, messages...)
+	return messages, nil
+}
+
 // tryAutoSwap attempts to swap to another party member if current gopher fainted
 // Returns true if swap occurred, false otherwise
 func (bs *BattleState) tryAutoSwap() (swapped bool, messages []string) {
@@ -314,6 +506,7 @@ func (bs *BattleState) tryAutoSwap() (swapped bool, messages []string) {
 	}
 	
 	messages = append(messages, fmt.Sprintf("%s was defeated!", bs.PlayerGopher.Name))
+	bs.recordFaint("PLAYER", bs.PlayerGopher)
 	
 	// Find next available gopher
 	var nextGopher *Gopher
@@ -343,6 +536,7 @@ func (bs *BattleState) tryAutoSwap() (swapped bool, messages []string) {
 		
 		messages = append(messages, fmt.Sprintf("%s, come back!", oldGopher.Name))
 		messages = append(messages, fmt.Sprintf("Go, %s!", nextGopher.Name))
+		bs.record(BattleEvent{Type: BattleEventSwap, Side: "PLAYER", Gopher: nextGopher.Name, Messages: messages[len(messages)-2:]})
 		bs.TurnOwner = "PLAYER" // Player gets to act with new gopher
 		return true, messages
 	} else {
@@ -352,12 +546,118 @@ func (bs *BattleState) tryAutoSwap() (swapped bool, messages []string) {
 	}
 }
 
-// enemyTurn executes the enemy's turn
+// sendOutNextEnemy has an NPC trainer send out the next gopher in their party that can still
+// battle. Returns false when they have none left.
+func (bs *BattleState) sendOutNextEnemy() (bool, []string) {
+	for _, gopher := range bs.EnemyParty {
+		if gopher.CurrentHP > 0 {
+			bs.EnemyGopher = gopher
+			message := fmt.Sprintf("%s sent out %s!", bs.Opponent.Name, gopher.Name)
+			bs.record(BattleEvent{Type: BattleEventSwap, Side: "ENEMY", Gopher: gopher.Name, Messages: []string{message}})
+			return true, []string{message}
+		}
+	}
+	return false, nil
+}
+
+// EnemyGophersLeft returns how many of the NPC trainer's gophers can still battle
+func (bs *BattleState) EnemyGophersLeft() int {
+	left := 0
+	for _, gopher := range bs.EnemyParty {
+		if gopher.CurrentHP > 0 {
+			left++
+		}
+	}
+	return left
+}
+
+// Turn order priorities. Moves use their ability's priority (0 unless the template sets one);
+// running, swapping and using items always happen before any move.
+const (
+	PriorityRun  = 8
+	PrioritySwap = 7
+	PriorityItem = 6
+)
+
+// actionPriority returns the priority of a player action
+func (bs *BattleState) actionPriority(action string, abilityIndex int) int {
+	switch action {
+	case "run":
+		return PriorityRun
+	case "swap":
+		return PrioritySwap
+	case "throw_net":
+		return PriorityItem
+	case "fight":
+		if abilityIndex >= 0 && abilityIndex < len(bs.PlayerGopher.Abilities) {
+			return bs.PlayerGopher.Abilities[abilityIndex].Priority
+		}
+	}
+	return 0
+}
+
+// playerActsFirst decides whether the player's action goes before the enemy's move: higher
+// priority first, then the faster gopher, with speed ties broken randomly. Speed already
+// includes stat changes such as Agility or Slow Down.
+func (bs *BattleState) playerActsFirst(action string, abilityIndex int, enemyAbility *Ability) bool {
+	playerPriority := bs.actionPriority(action, abilityIndex)
+	enemyPriority := 0
+	if enemyAbility != nil {
+		enemyPriority = enemyAbility.Priority
+	}
+	if playerPriority != enemyPriority {
+		return playerPriority > enemyPriority
+	}
+
+	playerSpeed, enemySpeed := TurnSpeed(bs.PlayerGopher, bs.Field), TurnSpeed(bs.EnemyGopher, bs.Field)
+	if playerSpeed != enemySpeed {
+		return playerSpeed > enemySpeed
+	}
+	return bs.Rand().Intn(2) == 0
+}
+
+// enemyTurn executes the enemy's turn, which ends the round
 func (bs *BattleState) enemyTurn() []string {
+	bs.TurnOwner = "ENEMY"
+	return bs.endRound(bs.chooseEnemyAbility(), false)
+}
+
+// endRound lets the enemy use its move if it hasn't already moved first, then refills energy
+// and applies the field condition
+func (bs *BattleState) endRound(enemyAbility *Ability, enemyActed bool) []string {
+	messages := []string{}
+	if !enemyActed {
+		bs.TurnOwner = "ENEMY"
+		messages = bs.enemyAction(enemyAbility)
+	}
+	bs.regenerateEnergy()
+	return append(messages, bs.processField()...)
+}
+
+// chooseEnemyAbility picks the enemy's move for the round from the abilities it has the
+// energy for, or nil if it has to rest
+func (bs *BattleState) chooseEnemyAbility() *Ability {
+	affordable := bs.affordableAbilities(bs.EnemyGopher)
+	if len(affordable) == 0 {
+		return nil
+	}
+
+	if bs.EnemyStrategy == nil {
+		if bs.Opponent != nil {
+			bs.EnemyStrategy = bs.Opponent.EnemyStrategy()
+		} else {
+			bs.EnemyStrategy = StrategyFor(bs.OpponentType, bs.EnemyGopher.Rarity)
+		}
+	}
+	return bs.EnemyStrategy.ChooseAbility(bs, affordable)
+}
+
+// enemyAction uses the enemy's chosen move, resting if there is none
+func (bs *BattleState) enemyAction(ability *Ability) []string {
 	messages := []string{}
 	
 	// Process status effects at start of turn
-	statusMsgs := bs.EnemyGopher.ProcessStatusEffects()
+	statusMsgs := bs.processStatusEffects("ENEMY", bs.EnemyGopher)
 	messages = append(messages, statusMsgs...)
 	
 	// Check if enemy is asleep or paralyzed
@@ -365,11 +665,13 @@ func (bs *BattleState) enemyTurn() []string {
 		// Check if sleep wears off
 		for _, effect := range bs.EnemyGopher.StatusEffects {
 			if effect.Type == StatusSleep {
-				if rand.Float64() < 0.3 { // 30% chance to wake up
+				if bs.Rand().Float64() < 0.3 { // 30% chance to wake up
 					bs.EnemyGopher.RemoveStatusEffect(StatusSleep)
 					messages = append(messages, fmt.Sprintf("%s woke up!", bs.EnemyGopher.Name))
+					bs.recordStatus("ENEMY", bs.EnemyGopher, 0, messages[len(messages)-1])
 				} else {
 					messages = append(messages, fmt.Sprintf("%s is fast asleep!", bs.EnemyGopher.Name))
+					bs.recordStatus("ENEMY", bs.EnemyGopher, 0, messages[len(messages)-1])
 					bs.TurnOwner = "PLAYER"
 					return messages
 				}
@@ -379,8 +681,9 @@ func (bs *BattleState) enemyTurn() []string {
 	
 	// Check paralysis
 	if bs.EnemyGopher.HasStatusEffect(StatusParalysis) {
-		if rand.Float64() < 0.25 { // 25% chance to be paralyzed
+		if bs.Rand().Float64() < 0.25 { // 25% chance to be paralyzed
 			messages = append(messages, fmt.Sprintf("%s is paralyzed! It can't move!", bs.EnemyGopher.Name))
+			bs.recordStatus("ENEMY", bs.EnemyGopher, 0, messages[len(messages)-1])
 			bs.TurnOwner = "PLAYER"
 			return messages
 		}
@@ -389,13 +692,14 @@ func (bs *BattleState) enemyTurn() []string {
 	// Check confusion
 	confused := bs.EnemyGopher.HasStatusEffect(StatusConfusion)
 	if confused {
-		if rand.Float64() < 0.33 { // 33% chance to hurt self
+		if bs.Rand().Float64() < 0.33 { // 33% chance to hurt self
 			damage := bs.EnemyGopher.MaxHP / 8
 			bs.EnemyGopher.CurrentHP -= damage
 			if bs.EnemyGopher.CurrentHP < 0 {
 				bs.EnemyGopher.CurrentHP = 0
 			}
 			messages = append(messages, fmt.Sprintf("%s is confused! It hurt itself in confusion for %d damage!", bs.EnemyGopher.Name, damage))
+			bs.recordStatus("ENEMY", bs.EnemyGopher, damage, messages[len(messages)-1])
 			bs.TurnOwner = "PLAYER"
 			return messages
 		}
@@ -405,25 +709,95 @@ func (bs *BattleState) enemyTurn() []string {
 		return []string{fmt.Sprintf("%s has no abilities!", bs.EnemyGopher.Name)}
 	}
 
-	// Simple AI: random ability
-	abilityIndex := rand.Intn(len(bs.EnemyGopher.Abilities))
-	ability := bs.EnemyGopher.Abilities[abilityIndex]
+	if ability == nil {
+		recovered := EnergyRegen(bs.EnemyGopher)
+		bs.setEnergy(bs.EnemyGopher, bs.Energy(bs.EnemyGopher)+recovered)
+		messages = append(messages, fmt.Sprintf("%s is resting and recovered %d ⚡!", bs.EnemyGopher.Name, recovered))
+		bs.record(BattleEvent{Type: BattleEventRest, Side: "ENEMY", Gopher: bs.EnemyGopher.Name, Messages: messages[len(messages)-1:]})
+		bs.TurnOwner = "PLAYER"
+		return messages
+	}
+	bs.setEnergy(bs.EnemyGopher, bs.Energy(bs.EnemyGopher)-ability.Cost)
 
-	// Check if player has protect status
-	if bs.PlayerGopher.HasStatusEffect(StatusProtect) {
-		bs.PlayerGopher.RemoveStatusEffect(StatusProtect)
-		messages = append(messages, fmt.Sprintf("%s was protected from the attack!", bs.PlayerGopher.Name))
-	} else {
-		msgs, err := ability.EffectFunc(bs, bs.EnemyGopher, bs.PlayerGopher)
-		if err == nil {
-			messages = append(messages, msgs...)
-		}
+	msgs, err := bs.useAbility("ENEMY", bs.EnemyGopher, bs.PlayerGopher, ability)
+	if err == nil {
+		messages = append(messages, msgs...)
 	}
 
 	bs.TurnOwner = "PLAYER"
 	return messages
 }
 
+// processStatusEffects applies a gopher's status effects at the start of its turn
+func (bs *BattleState) processStatusEffects(side string, gopher *Gopher) []string {
+	hpBefore := gopher.CurrentHP
+	messages := gopher.ProcessStatusEffects()
+	bs.recordStatus(side, gopher, hpBefore-gopher.CurrentHP, messages...)
+	return messages
+}
+
+// processField applies the field condition to both active gophers at the end of a round, and
+// records what it did
+func (bs *BattleState) processField() []string {
+	messages := bs.Field.endRound(bs.PlayerGopher, bs.EnemyGopher)
+	if len(messages) > 0 {
+		bs.record(BattleEvent{Type: BattleEventField, Messages: messages})
+	}
+	return messages
+}
+
+// useAbility uses an ability on the target unless the target is protected, and records it
+func (bs *BattleState) useAbility(side string, user, target *Gopher, ability *Ability) ([]string, error) {
+	event := BattleEvent{Type: BattleEventAbility, Side: side, Gopher: user.Name, Target: target.Name, Ability: ability.Name}
+
+	if target.HasStatusEffect(StatusProtect) {
+		target.RemoveStatusEffect(StatusProtect)
+		event.Messages = []string{fmt.Sprintf("%s was protected from the attack!", target.Name)}
+		bs.record(event)
+		return event.Messages, nil
+	}
+
+	hpBefore := target.CurrentHP
+	messages, err := ability.EffectFunc(bs, user, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if hpBefore > target.CurrentHP {
+		event.Damage = hpBefore - target.CurrentHP
+	}
+	if ability.Targeting != TargetingSelf && ability.Power > 0 {
+		event.Effectiveness = typeEffectiveness(user, target)
+	}
+	event.Messages = messages
+	bs.record(event)
+	return messages, nil
+}
+
+// recordFaint records that a gopher fainted
+func (bs *BattleState) recordFaint(side string, gopher *Gopher) {
+	bs.record(BattleEvent{Type: BattleEventFaint, Side: side, Gopher: gopher.Name,
+		Messages: []string{fmt.Sprintf("%s was defeated!", gopher.Name)}})
+}
+
+// awardXP gives XP to all participating gophers (including fainted ones)
+func (bs *BattleState) awardXP() []string {
+	messages := []string{}
+	xpGain := bs.calculateXPGain()
+
+	for _, gopher := range bs.ParticipatingGophers {
+		leveledUp, newLevel := gopher.AddXP(xpGain, bs.Rand())
+		bs.XPAwarded += xpGain
+		xpBar := GetXPBar(gopher.XP, gopher.Level, 10)
+		messages = append(messages, fmt.Sprintf("%s gained %d XP! %s", gopher.Name, xpGain, xpBar))
+		if leveledUp {
+			messages = append(messages, fmt.Sprintf("%s leveled up to level %d! 🎉", gopher.Name, newLevel))
+		}
+	}
+
+	return messages
+}
+
 // calculateXPGain calculates XP gained from defeating enemy
 func (bs *BattleState) calculateXPGain() int {
 	baseXP := bs.EnemyGopher.Level * 10
@@ -449,7 +823,13 @@ func (bs *BattleState) calculateXPGain() int {
 		eventMultiplier = bs.EventManager.GetXPMultiplier()
 	}
 	
-	return int(float64(baseXP) * rarityMultiplier * eventMultiplier)
+	// Apply XP Booster
+	boosterMultiplier := 1.0
+	if bs.XPMultiplier > 0 {
+		boosterMultiplier = bs.XPMultiplier
+	}
+	
+	return int(float64(baseXP) * rarityMultiplier * eventMultiplier * boosterMultiplier)
 }
 
 // calculateCaptureChance calculates the chance to capture a gopher
@@ -0,0 +1,129 @@
+package game
+
+import "testing"
+
+func TestRejectedSwapTakesNoTurn(t *testing.T) {
+	bs := newTestBattle(7)
+	bs.PlayerGopher.AddStatusEffect(StatusPoison, 3, 0)
+	bs.PlayerParty[1].CurrentHP = 0
+
+	tests := []struct {
+		name  string
+		index int
+	}{
+		{"out of range", 5},
+		{"fainted", 1},
+		{"already in battle", 0},
+	}
+	for _, tt := range tests {
+		hp, draws := bs.PlayerGopher.CurrentHP, bs.Rand().Draws()
+		messages, err := bs.PlayerAction("swap", tt.index)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(messages) != 1 {
+			t.Errorf("%s: got %v, want just the reason", tt.name, messages)
+		}
+		if bs.Turn != 0 || bs.PlayerGopher.CurrentHP != hp || bs.Rand().Draws() != draws {
+			t.Errorf("%s: rejected swap played a turn: turn %d, HP %d -> %d, draws %d -> %d",
+				tt.name, bs.Turn, hp, bs.PlayerGopher.CurrentHP, draws, bs.Rand().Draws())
+		}
+	}
+}
+
+func TestPlayerActsFirst(t *testing.T) {
+	quickHit, err := CreateAbilityFromTemplate("quick_hit", "enemy_quick_hit")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		playerSpeed  int
+		enemySpeed   int
+		setup        func(bs *BattleState)
+		action       string
+		ability      string // Player ability used with "fight"
+		enemyAbility *Ability
+		want         bool
+	}{
+		{name: "faster enemy", playerSpeed: 80, enemySpeed: 120, action: "fight", ability: "Go Panic()", want: false},
+		{name: "faster player", playerSpeed: 120, enemySpeed: 80, action: "fight", ability: "Go Panic()", want: true},
+		{name: "quick_hit beats speed", playerSpeed: 80, enemySpeed: 120, action: "fight", ability: "Quick Hit", want: true},
+		{name: "enemy quick_hit beats speed", playerSpeed: 120, enemySpeed: 80, action: "fight", ability: "Go Panic()", enemyAbility: quickHit, want: false},
+		{name: "both quick_hit", playerSpeed: 80, enemySpeed: 120, action: "fight", ability: "Quick Hit", enemyAbility: quickHit, want: false},
+		{name: "swap beats quick_hit", playerSpeed: 10, enemySpeed: 120, action: "swap", enemyAbility: quickHit, want: true},
+		{name: "net beats quick_hit", playerSpeed: 10, enemySpeed: 120, action: "throw_net", enemyAbility: quickHit, want: true},
+		{
+			name: "speed stage up", playerSpeed: 100, enemySpeed: 120, action: "fight", ability: "Go Panic()", want: true,
+			setup: func(bs *BattleState) {
+				bs.PlayerGopher.AddStatusEffect(StatusSpeedUp, 3, 1)
+				bs.PlayerGopher.RecalculateStats()
+			},
+		},
+		{
+			name: "speed stage down", playerSpeed: 100, enemySpeed: 120, action: "fight", ability: "Go Panic()", want: true,
+			setup: func(bs *BattleState) {
+				bs.EnemyGopher.AddStatusEffect(StatusSpeedDown, 3, 1)
+				bs.EnemyGopher.RecalculateStats()
+			},
+		},
+		{
+			name: "Turbo Scarf", playerSpeed: 100, enemySpeed: 120, action: "fight", ability: "Go Panic()", want: true,
+			setup: func(bs *BattleState) { bs.PlayerGopher.HeldItem = ItemTypeTurboScarf },
+		},
+		{
+			name: "enemy Turbo Scarf", playerSpeed: 120, enemySpeed: 100, action: "fight", ability: "Go Panic()", want: false,
+			setup: func(bs *BattleState) { bs.EnemyGopher.HeldItem = ItemTypeTurboScarf },
+		},
+		{
+			name: "Lag Spike slows both", playerSpeed: 90, enemySpeed: 100, action: "fight", ability: "Go Panic()", want: false,
+			setup: func(bs *BattleState) { bs.Field = &Field{Condition: FieldLagSpike, Turns: -1} },
+		},
+		{
+			name: "Turbo Scarf in a Lag Spike", playerSpeed: 100, enemySpeed: 120, action: "fight", ability: "Go Panic()", want: true,
+			setup: func(bs *BattleState) {
+				bs.Field = &Field{Condition: FieldLagSpike, Turns: -1}
+				bs.PlayerGopher.HeldItem = ItemTypeTurboScarf
+			},
+		},
+	}
+	for _, tt := range tests {
+		bs := newTestBattle(8)
+		bs.Field = &Field{}
+		bs.PlayerGopher.Speed, bs.PlayerGopher.BaseSpeed = tt.playerSpeed, tt.playerSpeed
+		bs.EnemyGopher.Speed, bs.EnemyGopher.BaseSpeed = tt.enemySpeed, tt.enemySpeed
+		if tt.setup != nil {
+			tt.setup(bs)
+		}
+
+		index := -1
+		if tt.ability != "" {
+			index = indexOfAbility(t, bs.PlayerGopher, tt.ability)
+		}
+		if got := bs.playerActsFirst(tt.action, index, tt.enemyAbility); got != tt.want {
+			t.Errorf("%s: playerActsFirst = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestPlayerActsFirstSpeedTie(t *testing.T) {
+	actsFirst := func(seed int64) bool {
+		bs := newTestBattle(seed)
+		bs.Field = &Field{}
+		bs.PlayerGopher.Speed, bs.EnemyGopher.Speed = 100, 100
+		return bs.playerActsFirst("rest", -1, nil)
+	}
+
+	seen := map[bool]bool{}
+	for seed := int64(1); seed <= 20; seed++ {
+		first := actsFirst(seed)
+		if actsFirst(seed) != first {
+			t.Fatalf("seed %d broke the same speed tie differently", seed)
+		}
+		seen[first] = true
+	}
+	if !seen[true] || !seen[false] {
+		t.Error("speed ties always went the same way")
+	}
+}
@@ -0,0 +1,992 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Double battle formats
+const (
+	DoubleFormatWild    = "WILD"    // A trainer against a horde of two wild gophers
+	DoubleFormatTrainer = "TRAINER" // A trainer against an NPC trainer
+	DoubleFormatPvP     = "PVP"     // Two trainers against each other
+)
+
+// Double battle states. Won and lost are from the first side's point of view.
+const (
+	DoubleStateActive  = "ACTIVE"
+	DoubleStateWon     = "WON"
+	DoubleStateLost    = "LOST"
+	DoubleStateDraw    = "DRAW"
+	DoubleStateEscaped = "ESCAPED"
+	DoubleStateExpired = "EXPIRED" // Nobody chose a move for too long
+)
+
+const (
+	// DoubleSlots is how many gophers each side has in battle at once
+	DoubleSlots = 2
+
+	// SpreadMultiplier scales the damage of a move that hits both foes
+	SpreadMultiplier = 0.75
+
+	// DoubleMaxRounds is the number of rounds after which a double battle is declared a draw
+	DoubleMaxRounds = 50
+
+	// DoubleAbandonTimeout is how long a round can wait for moves before the battle is given up on
+	DoubleAbandonTimeout = 10 * time.Minute
+)
+
+// DoubleAction is what one active gopher does in a round
+type DoubleAction struct {
+	Type   string // "fight", "rest", "swap" or "run"
+	Index  int    // Ability for "fight", party member for "swap"
+	Target int    // Slot of the foe a single-target ability is aimed at
+}
+
+// DoubleSide is one side of a double battle: a trainer, an NPC trainer or a wild horde
+type DoubleSide struct {
+	TrainerID    string // Empty for a wild horde or an NPC trainer, whose moves are chosen automatically
+	Name         string
+	Party        []*Gopher
+	Active       [DoubleSlots]*Gopher // nil when the slot's gopher fainted and nobody is left to send out
+	Actions      [DoubleSlots]*DoubleAction
+	Strategy     EnemyStrategy // How a computer-controlled side picks moves; nil picks by rarity
+	Participants []*Gopher     // Gophers that have been sent out, for XP
+}
+
+// DoubleBattleState is a 2v2 battle. Each round both sides choose an action for each of their
+// active gophers, then every action resolves in priority and speed order. Fainted gophers are
+// replaced from the party at the end of the round.
+type DoubleBattleState struct {
+	ID           string
+	ChannelID    string
+	MessageID    string
+	Format       string         // "WILD", "TRAINER" or "PVP"
+	Sides        [2]*DoubleSide // The first side is the trainer who started the battle
+	Opponent     *NPCTrainer    // NPC trainer being battled; nil otherwise
+	State        string         // "ACTIVE", "WON", "LOST", "DRAW", "ESCAPED", "EXPIRED"
+	Round        int
+	RoundStarted time.Time
+	Log          []string
+	Events       []BattleEvent // Everything that happened, for replays
+	EventManager *EventManager
+	Field        *Field         // Battlefield condition, set when the battle starts
+	EnergyPools  map[string]int // Energy left per gopher ID; gophers not listed have a full pool
+	XPMultiplier float64        // XP Booster multiplier for this battle (0 or 1 = none)
+	XPAwarded    int            // Total XP granted to the first side's gophers
+	Seed         int64          // Seed of the battle's random source
+	CreatedAt    time.Time
+	rng          *RNG
+}
+
+// CanBattleDouble reports whether a trainer's party has the two gophers a double battle needs
+func CanBattleDouble(party []*Gopher) bool {
+	healthy := 0
+	for _, gopher := range party {
+		if gopher != nil && gopher.CurrentHP > 0 {
+			healthy++
+		}
+	}
+	return healthy >= DoubleSlots
+}
+
+// NewWildDoubleBattle starts a double battle against a horde of wild gophers. Nets can't be
+// thrown in a double battle.
+func NewWildDoubleBattle(channelID, trainerID, trainerName string, party, horde []*Gopher, eventManager *EventManager, seed int64) (*DoubleBattleState, error) {
+	if !CanBattleDouble(party) {
+		return nil, fmt.Errorf("you need at least %d gophers that can battle", DoubleSlots)
+	}
+	if len(horde) == 0 {
+		return nil, fmt.Errorf("no wild gophers appeared")
+	}
+
+	d := newDoubleBattle(channelID, DoubleFormatWild, eventManager, seed)
+	d.Sides[0] = &DoubleSide{TrainerID: trainerID, Name: trainerName, Party: party}
+	d.Sides[1] = &DoubleSide{Name: "Wild gophers", Party: horde}
+	applyEventStatBoost(eventManager, append(append([]*Gopher{}, party...), horde...)...)
+	d.sendOutLeads()
+
+	for _, gopher := range d.Sides[1].Active {
+		if gopher != nil {
+			d.Log = append(d.Log, fmt.Sprintf("A wild %s appeared!", gopher.Name))
+		}
+	}
+	d.Log = append(d.Log, fmt.Sprintf("Go, %s!", d.leadNames(0)))
+	d.Field = startingField(eventManager, d.Rand(), true)
+	d.start()
+	return d, nil
+}
+
+// NewTrainerDoubleBattle starts a double battle against an NPC trainer, who sends out their
+// party two at a time. The player can't run.
+func NewTrainerDoubleBattle(channelID, trainerID, trainerName string, party []*Gopher, opponent *NPCTrainer, opponentParty []*Gopher, eventManager *EventManager, seed int64) (*DoubleBattleState, error) {
+	if !CanBattleDouble(party) {
+		return nil, fmt.Errorf("you need at least %d gophers that can battle", DoubleSlots)
+	}
+	if len(opponentParty) == 0 {
+		return nil, fmt.Errorf("%s has no gophers", opponent.DisplayName())
+	}
+
+	d := newDoubleBattle(channelID, DoubleFormatTrainer, eventManager, seed)
+	d.Opponent = opponent
+	d.Sides[0] = &DoubleSide{TrainerID: trainerID, Name: trainerName, Party: party}
+	d.Sides[1] = &DoubleSide{Name: opponent.Name, Party: opponentParty, Strategy: opponent.EnemyStrategy()}
+	applyEventStatBoost(eventManager, append(append([]*Gopher{}, party...), opponentParty...)...)
+	d.sendOutLeads()
+
+	d.Log = append(d.Log, fmt.Sprintf("%s wants to battle!", opponent.DisplayName()))
+	if opponent.Intro != "" {
+		d.Log = append(d.Log, fmt.Sprintf("%s: \"%s\"", opponent.Name, opponent.Intro))
+	}
+	d.Log = append(d.Log,
+		fmt.Sprintf("%s sent out %s!", opponent.Name, d.leadNames(1)),
+		fmt.Sprintf("Go, %s!", d.leadNames(0)),
+	)
+	d.Field = startingField(eventManager, d.Rand(), false)
+	d.start()
+	return d, nil
+}
+
+// NewPvPDoubleBattle starts a double battle between two trainers. Like other PvP battles it
+// is fought on copies at full health, so gophers in storage are never affected.
+func NewPvPDoubleBattle(channelID, trainer1ID, trainer1Name, trainer2ID, trainer2Name string, party1, party2 []*Gopher, eventManager *EventManager, seed int64) (*DoubleBattleState, error) {
+	party1 = preparePvPParty(party1)
+	party2 = preparePvPParty(party2)
+	if !CanBattleDouble(party1) || !CanBattleDouble(party2) {
+		return nil, fmt.Errorf("both trainers need at least %d gophers", DoubleSlots)
+	}
+
+	d := newDoubleBattle(channelID, DoubleFormatPvP, eventManager, seed)
+	d.Sides[0] = &DoubleSide{TrainerID: trainer1ID, Name: trainer1Name, Party: party1}
+	d.Sides[1] = &DoubleSide{TrainerID: trainer2ID, Name: trainer2Name, Party: party2}
+	d.sendOutLeads()
+
+	d.Log = append(d.Log,
+		fmt.Sprintf("%s accepted the double battle!", trainer2Name),
+		fmt.Sprintf("%s sent out %s!", trainer1Name, d.leadNames(0)),
+		fmt.Sprintf("%s sent out %s!", trainer2Name, d.leadNames(1)),
+	)
+	d.Field = startingField(eventManager, d.Rand(), false)
+	d.start()
+	return d, nil
+}
+
+func newDoubleBattle(channelID, format string, eventManager *EventManager, seed int64) *DoubleBattleState {
+	return &DoubleBattleState{
+		ChannelID:    channelID,
+		Format:       format,
+		State:        DoubleStateActive,
+		Round:        1,
+		RoundStarted: time.Now(),
+		Log:          []string{},
+		EventManager: eventManager,
+		EnergyPools:  make(map[string]int),
+		Seed:         seed,
+		CreatedAt:    time.Now(),
+	}
+}
+
+// sendOutLeads puts the first gophers of each party that can battle into the slots
+func (d *DoubleBattleState) sendOutLeads() {
+	for _, side := range d.Sides {
+		for slot := range side.Active {
+			if next := side.nextGopher(); next != nil {
+				side.Active[slot] = next
+				side.Participants = append(side.Participants, next)
+			}
+		}
+	}
+}
+
+// start announces the field condition and records the start of the battle
+func (d *DoubleBattleState) start() {
+	if message := d.Field.StartMessage(); message != "" {
+		d.Log = append(d.Log, message)
+	}
+	d.record(BattleEvent{Type: BattleEventStart, Side: d.sideName(1), Gopher: d.leadNames(1), Messages: d.Log})
+}
+
+// leadNames joins the names of a side's active gophers
+func (d *DoubleBattleState) leadNames(side int) string {
+	names := []string{}
+	for _, gopher := range d.Sides[side].Active {
+		if gopher != nil {
+			names = append(names, gopher.Name)
+		}
+	}
+	if len(names) == 2 {
+		return names[0] + " and " + names[1]
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return "nobody"
+}
+
+// nextGopher returns the first party gopher that can battle and isn't already active
+func (side *DoubleSide) nextGopher() *Gopher {
+	for _, gopher := range side.Party {
+		if gopher.CurrentHP > 0 && !side.isActive(gopher) {
+			return gopher
+		}
+	}
+	return nil
+}
+
+func (side *DoubleSide) isActive(gopher *Gopher) bool {
+	for _, active := range side.Active {
+		if active != nil && active.ID == gopher.ID {
+			return true
+		}
+	}
+	return false
+}
+
+func (side *DoubleSide) addParticipant(gopher *Gopher) {
+	for _, participant := range side.Participants {
+		if participant.ID == gopher.ID {
+			return
+		}
+	}
+	side.Participants = append(side.Participants, gopher)
+}
+
+// IsComputer reports whether the side's moves are chosen automatically
+func (side *DoubleSide) IsComputer() bool {
+	return side.TrainerID == ""
+}
+
+// Alive returns the side's active gophers that can still fight, in slot order
+func (side *DoubleSide) Alive() []*Gopher {
+	alive := []*Gopher{}
+	for _, gopher := range side.Active {
+		if gopher != nil && gopher.CurrentHP > 0 {
+			alive = append(alive, gopher)
+		}
+	}
+	return alive
+}
+
+// GophersLeft returns how many of the side's gophers can still battle
+func (side *DoubleSide) GophersLeft() int {
+	left := 0
+	for _, gopher := range side.Party {
+		if gopher.CurrentHP > 0 {
+			left++
+		}
+	}
+	return left
+}
+
+// HasChosen reports whether the side has chosen an action for every gopher that can fight
+func (side *DoubleSide) HasChosen() bool {
+	for slot, gopher := range side.Active {
+		if gopher != nil && gopher.CurrentHP > 0 && side.Actions[slot] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// SideOf returns the index of the side a trainer is fighting on, or -1 if they are not part
+// of the battle
+func (d *DoubleBattleState) SideOf(trainerID string) int {
+	for idx, side := range d.Sides {
+		if trainerID != "" && side.TrainerID == trainerID {
+			return idx
+		}
+	}
+	return -1
+}
+
+// NextSlot returns the trainer's first active gopher that still needs an action this round,
+// or -1 if there is none
+func (d *DoubleBattleState) NextSlot(trainerID string) int {
+	sideIdx := d.SideOf(trainerID)
+	if sideIdx < 0 || d.State != DoubleStateActive {
+		return -1
+	}
+	side := d.Sides[sideIdx]
+	for slot, gopher := range side.Active {
+		if gopher != nil && gopher.CurrentHP > 0 && side.Actions[slot] == nil {
+			return slot
+		}
+	}
+	return -1
+}
+
+// NeedsTarget reports whether the ability a slot's gopher wants to use hits a single foe that
+// has to be chosen, because both foes are still standing
+func (d *DoubleBattleState) NeedsTarget(trainerID string, slot, index int) bool {
+	sideIdx := d.SideOf(trainerID)
+	if sideIdx < 0 || slot < 0 || slot >= DoubleSlots {
+		return false
+	}
+	user := d.Sides[sideIdx].Active[slot]
+	if user == nil || index < 0 || index >= len(user.Abilities) {
+		return false
+	}
+	targeting := user.Abilities[index].Targeting
+	return targeting != TargetingSelf && targeting != TargetingBoth && len(d.Sides[1-sideIdx].Alive()) > 1
+}
+
+// CheckAction returns why the trainer can't choose the action for a slot this round, or ""
+// if they can
+func (d *DoubleBattleState) CheckAction(trainerID string, slot int, action DoubleAction) string {
+	if d.State != DoubleStateActive {
+		return "This battle isn't active!"
+	}
+	sideIdx := d.SideOf(trainerID)
+	if sideIdx < 0 {
+		return "You're not part of this battle!"
+	}
+	side := d.Sides[sideIdx]
+
+	if action.Type == "run" {
+		if d.Format != DoubleFormatWild {
+			return "There's no running from a trainer battle!"
+		}
+		if side.HasChosen() {
+			return "You've already chosen your moves this round! Waiting for the others..."
+		}
+		return ""
+	}
+
+	if slot < 0 || slot >= DoubleSlots || side.Active[slot] == nil || side.Active[slot].CurrentHP <= 0 {
+		return "That gopher can't fight!"
+	}
+	if side.Actions[slot] != nil {
+		return fmt.Sprintf("You've already chosen a move for %s this round!", side.Active[slot].Name)
+	}
+	user := side.Active[slot]
+
+	switch action.Type {
+	case "fight":
+		if action.Index < 0 || action.Index >= len(user.Abilities) {
+			return "Invalid ability!"
+		}
+		ability := user.Abilities[action.Index]
+		if !d.CanAfford(user, ability) {
+			return fmt.Sprintf("Not enough energy! %s needs %d ⚡ but %s only has %d.",
+				ability.Name, ability.Cost, user.Name, d.Energy(user))
+		}
+		if action.Target < 0 || action.Target >= DoubleSlots {
+			return "Invalid target!"
+		}
+	case "rest":
+	case "swap":
+		if msg := d.validateSwap(sideIdx, action.Index); msg != "" {
+			return msg
+		}
+		for other, chosen := range side.Actions {
+			if other != slot && chosen != nil && chosen.Type == "swap" && chosen.Index == action.Index {
+				return fmt.Sprintf("%s is already being sent in!", side.Party[action.Index].Name)
+			}
+		}
+	default:
+		return "Unknown action!"
+	}
+	return ""
+}
+
+// Choose records a trainer's action for one of their active gophers. Running is chosen once
+// for the whole side. Once every trainer has chosen for all their gophers, computer-controlled
+// sides choose and the round resolves, and its messages are returned; until then it returns
+// nil. CheckAction must pass first.
+func (d *DoubleBattleState) Choose(trainerID string, slot int, action DoubleAction) []string {
+	side := d.Sides[d.SideOf(trainerID)]
+	if action.Type == "run" {
+		for idx, gopher := range side.Active {
+			if gopher != nil && gopher.CurrentHP > 0 {
+				side.Actions[idx] = &DoubleAction{Type: "run"}
+			}
+		}
+	} else {
+		side.Actions[slot] = &action
+	}
+
+	for _, other := range d.Sides {
+		if !other.IsComputer() && !other.HasChosen() {
+			return nil
+		}
+	}
+	return d.resolveRound()
+}
+
+// Forfeit ends a PvP double battle with the other trainer as the winner
+func (d *DoubleBattleState) Forfeit(trainerID string) []string {
+	sideIdx := d.SideOf(trainerID)
+	if sideIdx < 0 || d.State != DoubleStateActive {
+		return nil
+	}
+
+	messages := []string{fmt.Sprintf("%s forfeited the battle!", d.Sides[sideIdx].Name)}
+	messages = append(messages, d.finish(1-sideIdx, fmt.Sprintf("%s wins the battle! 🏆", d.Sides[1-sideIdx].Name)))
+	d.Log = append(d.Log, messages...)
+	return messages
+}
+
+// IsOver reports whether the battle has finished
+func (d *DoubleBattleState) IsOver() bool {
+	return d.State != DoubleStateActive
+}
+
+// IsExpired reports whether the current round has waited too long for moves
+func (d *DoubleBattleState) IsExpired() bool {
+	return d.State == DoubleStateActive && time.Since(d.RoundStarted) > DoubleAbandonTimeout
+}
+
+// Expire ends a battle that IsExpired
+func (d *DoubleBattleState) Expire() {
+	d.State = DoubleStateExpired
+	d.Log = append(d.Log, "Nobody moved for too long. The battle was called off.")
+}
+
+// WinnerSide returns the index of the winning side, or -1 for a draw or unfinished battle
+func (d *DoubleBattleState) WinnerSide() int {
+	switch d.State {
+	case DoubleStateWon:
+		return 0
+	case DoubleStateLost:
+		return 1
+	}
+	return -1
+}
+
+// Energy returns a gopher's current energy in this battle
+func (d *DoubleBattleState) Energy(gopher *Gopher) int {
+	return d.effectState(0, gopher, nil).Energy(gopher)
+}
+
+// CanAfford returns whether the gopher has enough energy to use the ability
+func (d *DoubleBattleState) CanAfford(gopher *Gopher, ability *Ability) bool {
+	return d.Energy(gopher) >= ability.Cost
+}
+
+// doubleTurn is one gopher's action in the round's turn order
+type doubleTurn struct {
+	side     int
+	slot     int
+	gopher   *Gopher
+	action   *DoubleAction
+	priority int
+	speed    int
+}
+
+// resolveRound plays out every action of the round in turn order, then refills energy,
+// applies the field condition and sends in replacements for fainted gophers
+func (d *DoubleBattleState) resolveRound() []string {
+	for idx, side := range d.Sides {
+		if side.IsComputer() {
+			d.chooseComputerActions(idx)
+		}
+	}
+
+	messages := []string{fmt.Sprintf("⚔️ Round %d", d.Round)}
+	for _, turn := range d.turnOrder() {
+		if d.State != DoubleStateActive {
+			break
+		}
+		messages = append(messages, d.takeTurn(turn)...)
+		messages = append(messages, d.checkFaints()...)
+		messages = append(messages, d.checkOutcome()...)
+	}
+
+	if d.State == DoubleStateActive {
+		active := []*Gopher{}
+		for _, side := range d.Sides {
+			for _, gopher := range side.Alive() {
+				d.effectState(0, gopher, nil).setEnergy(gopher, d.Energy(gopher)+EnergyRegen(gopher))
+				active = append(active, gopher)
+			}
+		}
+		if fieldMsgs := d.Field.endRound(active...); len(fieldMsgs) > 0 {
+			messages = append(messages, fieldMsgs...)
+			d.record(BattleEvent{Type: BattleEventField, Messages: fieldMsgs})
+		}
+		messages = append(messages, d.checkFaints()...)
+		messages = append(messages, d.checkOutcome()...)
+	}
+
+	if d.State == DoubleStateActive && d.Round >= DoubleMaxRounds {
+		messages = append(messages, d.finish(-1, fmt.Sprintf("The battle reached %d rounds and ended in a draw!", DoubleMaxRounds)))
+	}
+
+	if d.State == DoubleStateActive {
+		messages = append(messages, d.fillSlots(0)...)
+		messages = append(messages, d.fillSlots(1)...)
+		d.Round++
+		d.RoundStarted = time.Now()
+	}
+
+	for _, side := range d.Sides {
+		side.Actions = [DoubleSlots]*DoubleAction{}
+	}
+	d.Log = append(d.Log, messages...)
+	return messages
+}
+
+// turnOrder sorts the round's actions: running and swapping first, then moves by priority,
+// then the faster gopher. Ties are broken randomly. A side only tries to run once.
+func (d *DoubleBattleState) turnOrder() []doubleTurn {
+	turns := []doubleTurn{}
+	for sideIdx, side := range d.Sides {
+		ran := false
+		for slot, gopher := range side.Active {
+			action := side.Actions[slot]
+			if gopher == nil || gopher.CurrentHP <= 0 || action == nil {
+				continue
+			}
+
+			turn := doubleTurn{side: sideIdx, slot: slot, gopher: gopher, action: action, speed: TurnSpeed(gopher, d.Field)}
+			switch action.Type {
+			case "run":
+				if ran {
+					continue
+				}
+				ran = true
+				turn.priority = PriorityRun
+			case "swap":
+				turn.priority = PrioritySwap
+			case "fight":
+				turn.priority = gopher.Abilities[action.Index].Priority
+			}
+			turns = append(turns, turn)
+		}
+	}
+
+	d.Rand().Shuffle(len(turns), func(a, b int) { turns[a], turns[b] = turns[b], turns[a] })
+	sort.SliceStable(turns, func(a, b int) bool {
+		if turns[a].priority != turns[b].priority {
+			return turns[a].priority > turns[b].priority
+		}
+		return turns[a].speed > turns[b].speed
+	})
+	return turns
+}
+
+// takeTurn runs a gopher's status effects and its action for the round
+func (d *DoubleBattleState) takeTurn(turn doubleTurn) []string {
+	user, action := turn.gopher, turn.action
+	if user.CurrentHP <= 0 || d.Sides[turn.side].Active[turn.slot] != user {
+		return nil
+	}
+
+	switch action.Type {
+	case "run":
+		return d.run()
+	case "swap":
+		return d.swap(turn.side, turn.slot, action.Index)
+	}
+
+	name := d.sideName(turn.side)
+	hpBefore := user.CurrentHP
+	messages := user.ProcessStatusEffects()
+	d.recordStatus(name, user, hpBefore-user.CurrentHP, messages)
+	if user.CurrentHP <= 0 {
+		return messages
+	}
+
+	hpBefore = user.CurrentHP
+	acted, turnMsgs := checkCanAct(d.Rand(), user, action.Type)
+	messages = append(messages, turnMsgs...)
+	d.recordStatus(name, user, hpBefore-user.CurrentHP, turnMsgs)
+	if !acted || user.CurrentHP <= 0 {
+		return messages
+	}
+
+	if action.Type == "rest" {
+		state := d.effectState(turn.side, user, nil)
+		recovered := EnergyRegen(user)
+		state.setEnergy(user, state.Energy(user)+recovered)
+		message := fmt.Sprintf("%s is resting and recovered %d ⚡!", user.Name, recovered)
+		d.record(BattleEvent{Type: BattleEventRest, Side: name, Gopher: user.Name, Messages: []string{message}})
+		return append(messages, message)
+	}
+	return append(messages, d.useAbility(turn.side, user, action)...)
+}
+
+// useAbility uses the gopher's chosen ability. Spread moves hit both foes at reduced power;
+// a single-target move aimed at a foe that has fainted hits the other foe instead.
+func (d *DoubleBattleState) useAbility(sideIdx int, user *Gopher, action *DoubleAction) []string {
+	ability := user.Abilities[action.Index]
+	name := d.sideName(sideIdx)
+
+	energy := d.effectState(sideIdx, user, nil)
+	if !energy.CanAfford(user, ability) {
+		message := fmt.Sprintf("%s doesn't have the energy for %s!", user.Name, ability.Name)
+		d.record(BattleEvent{Type: BattleEventAbility, Side: name, Gopher: user.Name, Ability: ability.Name, Messages: []string{message}})
+		return []string{message}
+	}
+	energy.setEnergy(user, energy.Energy(user)-ability.Cost)
+
+	// Protection is consumed when the protected gopher attacks
+	if user.HasStatusEffect(StatusProtect) {
+		user.RemoveStatusEffect(StatusProtect)
+	}
+
+	targets := d.abilityTargets(sideIdx, ability, action.Target)
+	if len(targets) == 0 {
+		return nil
+	}
+	spread := ability.Targeting == TargetingBoth && len(targets) > 1
+
+	messages := []string{}
+	for _, target := range targets {
+		event := BattleEvent{Type: BattleEventAbility, Side: name, Gopher: user.Name, Target: target.Name, Ability: ability.Name}
+
+		if ability.Targeting != TargetingSelf && target.HasStatusEffect(StatusProtect) {
+			target.RemoveStatusEffect(StatusProtect)
+			event.Messages = []string{fmt.Sprintf("%s was protected from %s's %s!", target.Name, user.Name, ability.Name)}
+		} else {
+			state := d.effectState(sideIdx, user, target)
+			state.spread = spread
+			hpBefore := target.CurrentHP
+			msgs, err := ability.EffectFunc(state, user, target)
+			if err != nil {
+				msgs = []string{fmt.Sprintf("%s's %s failed!", user.Name, ability.Name)}
+			}
+			if target.CurrentHP < 0 {
+				target.CurrentHP = 0
+			}
+
+			if hpBefore > target.CurrentHP {
+				event.Damage = hpBefore - target.CurrentHP
+			}
+			if ability.Targeting != TargetingSelf && ability.Power > 0 {
+				event.Effectiveness = typeEffectiveness(user, target)
+			}
+			if ability.Targeting != TargetingSelf && len(msgs) > 0 {
+				msgs[0] = fmt.Sprintf("[→ %s] %s", target.Name, msgs[0])
+			}
+			event.Messages = msgs
+		}
+
+		d.record(event)
+		messages = append(messages, event.Messages...)
+		if user.CurrentHP <= 0 {
+			break
+		}
+	}
+	return messages
+}
+
+// abilityTargets returns the foes an ability hits: both foes for a spread move, otherwise the
+// chosen foe or, if it has fainted, the other one. Abilities aimed at the user are given a
+// foe too, like in single battles.
+func (d *DoubleBattleState) abilityTargets(sideIdx int, ability *Ability, targetSlot int) []*Gopher {
+	foes := d.Sides[1-sideIdx]
+	alive := foes.Alive()
+	if len(alive) <= 1 || ability.Targeting == TargetingBoth {
+		return alive
+	}
+	if targetSlot >= 0 && targetSlot < DoubleSlots {
+		if target := foes.Active[targetSlot]; target != nil && target.CurrentHP > 0 {
+			return []*Gopher{target}
+		}
+	}
+	return alive[:1]
+}
+
+// run tries to escape a wild horde
+func (d *DoubleBattleState) run() []string {
+	lead := d.Sides[0].Alive()
+	gopherName := ""
+	if len(lead) > 0 {
+		gopherName = lead[0].Name
+	}
+
+	// 70% chance to escape, like in single battles
+	if d.Rand().Float64() < 0.7 {
+		d.State = DoubleStateEscaped
+		message := "Got away safely!"
+		d.record(BattleEvent{Type: BattleEventRun, Side: d.sideName(0), Gopher: gopherName, Success: true, Messages: []string{message}})
+		d.record(BattleEvent{Type: BattleEventResult, Side: d.sideName(0), Messages: []string{message}})
+		return []string{message}
+	}
+
+	message := "Couldn't escape!"
+	d.record(BattleEvent{Type: BattleEventRun, Side: d.sideName(0), Gopher: gopherName, Messages: []string{message}})
+	return []string{message}
+}
+
+// validateSwap returns an error message if the side can't send in the given party member
+func (d *DoubleBattleState) validateSwap(sideIdx, index int) string {
+	side := d.Sides[sideIdx]
+	if index < 0 || index >= len(side.Party) {
+		return "Invalid party member!"
+	}
+	gopher := side.Party[index]
+	if gopher.CurrentHP <= 0 {
+		return fmt.Sprintf("%s is fainted and can't battle!", gopher.Name)
+	}
+	if side.isActive(gopher) {
+		return "That gopher is already in battle!"
+	}
+	return ""
+}
+
+// swap switches a slot's gopher for a party member
+func (d *DoubleBattleState) swap(sideIdx, slot, index int) []string {
+	side := d.Sides[sideIdx]
+	if msg := d.validateSwap(sideIdx, index); msg != "" {
+		return []string{fmt.Sprintf("%s couldn't switch out: %s", side.Active[slot].Name, msg)}
+	}
+
+	oldGopher := side.Active[slot]
+	newGopher := side.Party[index]
+	side.Active[slot] = newGopher
+	side.addParticipant(newGopher)
+
+	messages := []string{
+		fmt.Sprintf("%s, come back!", oldGopher.Name),
+		fmt.Sprintf("%s sent out %s!", side.Name, newGopher.Name),
+	}
+	d.record(BattleEvent{Type: BattleEventSwap, Side: d.sideName(sideIdx), Gopher: newGopher.Name, Messages: messages})
+	return messages
+}
+
+// checkFaints announces gophers that fainted and clears their slots. A wild or NPC gopher
+// fainting gives the trainer's gophers XP.
+func (d *DoubleBattleState) checkFaints() []string {
+	messages := []string{}
+	for sideIdx, side := range d.Sides {
+		for slot, gopher := range side.Active {
+			if gopher == nil || gopher.CurrentHP > 0 {
+				continue
+			}
+			gopher.CurrentHP = 0
+			side.Active[slot] = nil
+
+			message := fmt.Sprintf("%s's %s fainted!", side.Name, gopher.Name)
+			if d.Format == DoubleFormatWild && sideIdx == 1 {
+				message = fmt.Sprintf("The wild %s fainted!", gopher.Name)
+			}
+			messages = append(messages, message)
+			d.record(BattleEvent{Type: BattleEventFaint, Side: d.sideName(sideIdx), Gopher: gopher.Name, Messages: []string{message}})
+
+			if d.Format != DoubleFormatPvP && sideIdx == 1 {
+				messages = append(messages, d.awardXP(gopher)...)
+			}
+		}
+	}
+	return messages
+}
+
+// checkOutcome ends the battle once a side has no gophers left
+func (d *DoubleBattleState) checkOutcome() []string {
+	if d.State != DoubleStateActive {
+		return nil
+	}
+
+	firstOut := d.Sides[0].GophersLeft() == 0
+	secondOut := d.Sides[1].GophersLeft() == 0
+	switch {
+	case firstOut && secondOut:
+		return []string{d.finish(-1, "Both sides are out of gophers! It's a draw!")}
+	case secondOut:
+		switch d.Format {
+		case DoubleFormatWild:
+			return []string{d.finish(0, "The wild gophers were defeated!")}
+		case DoubleFormatTrainer:
+			return []string{d.finish(0, fmt.Sprintf("Defeated %s!", d.Opponent.DisplayName()))}
+		}
+		return []string{d.finish(0, fmt.Sprintf("%s wins the battle! 🏆", d.Sides[0].Name))}
+	case firstOut:
+		if d.Format == DoubleFormatPvP {
+			return []string{d.finish(1, fmt.Sprintf("%s wins the battle! 🏆", d.Sides[1].Name))}
+		}
+		return []string{d.finish(1, "All gophers were defeated!")}
+	}
+	return nil
+}
+
+// finish ends the battle with a winning side, or -1 for a draw, and records the result
+func (d *DoubleBattleState) finish(winner int, message string) string {
+	switch winner {
+	case 0:
+		d.State = DoubleStateWon
+	case 1:
+		d.State = DoubleStateLost
+	default:
+		d.State = DoubleStateDraw
+	}
+
+	side := ""
+	if winner >= 0 {
+		side = d.sideName(winner)
+	}
+	d.record(BattleEvent{Type: BattleEventResult, Side: side, Success: winner >= 0, Messages: []string{message}})
+	return message
+}
+
+// fillSlots sends out the next party gophers into a side's empty slots
+func (d *DoubleBattleState) fillSlots(sideIdx int) []string {
+	side := d.Sides[sideIdx]
+	messages := []string{}
+	for slot, gopher := range side.Active {
+		if gopher != nil {
+			continue
+		}
+		next := side.nextGopher()
+		if next == nil {
+			continue
+		}
+		side.Active[slot] = next
+		side.addParticipant(next)
+
+		message := fmt.Sprintf("%s sent out %s!", side.Name, next.Name)
+		messages = append(messages, message)
+		d.record(BattleEvent{Type: BattleEventSwap, Side: d.sideName(sideIdx), Gopher: next.Name, Messages: []string{message}})
+	}
+	return messages
+}
+
+// chooseComputerActions picks moves for a computer-controlled side. Each gopher goes after
+// the weakest foe with the move its strategy likes best, or rests if it can't afford any.
+func (d *DoubleBattleState) chooseComputerActions(sideIdx int) {
+	side := d.Sides[sideIdx]
+	foes := d.Sides[1-sideIdx]
+
+	for slot, user := range side.Active {
+		if user == nil || user.CurrentHP <= 0 || side.Actions[slot] != nil {
+			continue
+		}
+
+		targetSlot := -1
+		for idx, foe := range foes.Active {
+			if foe != nil && foe.CurrentHP > 0 && (targetSlot < 0 || foe.CurrentHP < foes.Active[targetSlot].CurrentHP) {
+				targetSlot = idx
+			}
+		}
+		if targetSlot < 0 {
+			side.Actions[slot] = &DoubleAction{Type: "rest"}
+			continue
+		}
+
+		// Strategies see the battle from the enemy's side: the player's gopher is their target
+		state := d.effectState(sideIdx, foes.Active[targetSlot], user)
+		affordable := state.affordableAbilities(user)
+		if len(affordable) == 0 {
+			side.Actions[slot] = &DoubleAction{Type: "rest"}
+			continue
+		}
+
+		strategy := side.Strategy
+		if strategy == nil {
+			strategy = StrategyFor(d.Format, user.Rarity)
+		}
+		ability := strategy.ChooseAbility(state, affordable)
+		for idx, known := range user.Abilities {
+			if known == ability {
+				side.Actions[slot] = &DoubleAction{Type: "fight", Index: idx, Target: targetSlot}
+				break
+			}
+		}
+		if side.Actions[slot] == nil {
+			side.Actions[slot] = &DoubleAction{Type: "rest"}
+		}
+	}
+}
+
+// awardXP gives every gopher the trainer has sent out XP for a defeated foe, including
+// fainted ones
+func (d *DoubleBattleState) awardXP(enemy *Gopher) []string {
+	xp := (&BattleState{EnemyGopher: enemy, EventManager: d.EventManager, XPMultiplier: d.XPMultiplier}).calculateXPGain()
+
+	messages := []string{}
+	for _, gopher := range d.Sides[0].Participants {
+		leveledUp, newLevel := gopher.AddXP(xp, d.Rand())
+		d.XPAwarded += xp
+		messages = append(messages, fmt.Sprintf("%s gained %d XP! %s", gopher.Name, xp, GetXPBar(gopher.XP, gopher.Level, 10)))
+		if leveledUp {
+			messages = append(messages, fmt.Sprintf("%s leveled up to level %d! 🎉", gopher.Name, newLevel))
+		}
+	}
+	return messages
+}
+
+// spreadDamage reduces the damage of a move that hits both foes
+func (bs *BattleState) spreadDamage(damage int) int {
+	if !bs.spread {
+		return damage
+	}
+	damage = int(float64(damage) * SpreadMultiplier)
+	if damage < 1 {
+		damage = 1
+	}
+	return damage
+}
+
+// sideName names a side in replay events
+func (d *DoubleBattleState) sideName(sideIdx int) string {
+	if d.Format == DoubleFormatPvP {
+		if sideIdx == 0 {
+			return PvPSideTrainer1
+		}
+		return PvPSideTrainer2
+	}
+	if sideIdx == 0 {
+		return "PLAYER"
+	}
+	return "ENEMY"
+}
+
+// Rand returns the battle's random source, creating it from Seed on first use
+func (d *DoubleBattleState) Rand() *RNG {
+	if d.rng == nil {
+		d.rng = NewRNG(d.Seed)
+	}
+	return d.rng
+}
+
+// effectState builds the battle context abilities run against, seen from a side: player is
+// the gopher acting for that side and enemy the foe it's aimed at. Energy is shared with the
+// double battle.
+func (d *DoubleBattleState) effectState(sideIdx int, player, enemy *Gopher) *BattleState {
+	side := d.Sides[sideIdx]
+	return &BattleState{
+		ID:           d.ID,
+		ChannelID:    d.ChannelID,
+		TrainerID:    side.TrainerID,
+		OpponentType: d.Format,
+		PlayerGopher: player,
+		EnemyGopher:  enemy,
+		PlayerParty:  side.Party,
+		TurnOwner:    "PLAYER",
+		State:        "ACTIVE",
+		EventManager: d.EventManager,
+		EnergyPools:  d.EnergyPools,
+		Opponent:     d.Opponent,
+		Field:        d.Field,
+		Seed:         d.Seed,
+		rng:          d.Rand(),
+	}
+}
+
+// record adds an event to the battle's replay. Replays show each side's first active gopher.
+func (d *DoubleBattleState) record(event BattleEvent) {
+	event.Turn = d.Round
+	event.Bottom = replayGopher(d.Sides[0].lead())
+	event.Top = replayGopher(d.Sides[1].lead())
+	d.Events = append(d.Events, event)
+}
+
+// recordStatus records messages about a gopher's status conditions, if there are any
+func (d *DoubleBattleState) recordStatus(side string, gopher *Gopher, damage int, messages []string) {
+	if len(messages) == 0 {
+		return
+	}
+	d.record(BattleEvent{Type: BattleEventStatus, Side: side, Gopher: gopher.Name, Damage: damage, Messages: messages})
+}
+
+// lead returns the side's first active gopher, or nil if it has none
+func (side *DoubleSide) lead() *Gopher {
+	for _, gopher := range side.Active {
+		if gopher != nil {
+			return gopher
+		}
+	}
+	return nil
+}
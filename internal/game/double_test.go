@@ -0,0 +1,265 @@
+package game
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"testing"
+)
+
+// newTestDouble starts a PvP double battle between two parties of three hackers generated
+// from seed, on a field with no condition
+func newTestDouble(t *testing.T, seed int64) *DoubleBattleState {
+	t.Helper()
+	rng := NewRNG(seed)
+	parties := [2][]*Gopher{}
+	for side := range parties {
+		for n := 0; n < 3; n++ {
+			id := fmt.Sprintf("side%d_gopher%d", side, n)
+			parties[side] = append(parties[side], NewSimGopher(id, SimGopherSpec{Archetype: ArchetypeHacker, Rarity: "RARE", Level: 30}, rng))
+		}
+	}
+
+	d, err := NewPvPDoubleBattle("channel", "trainer1", "One", "trainer2", "Two", parties[0], parties[1], nil, seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Field = &Field{}
+	return d
+}
+
+// chooseAll has every active gopher on both sides choose the action
+func chooseAll(t *testing.T, d *DoubleBattleState, action DoubleAction) []string {
+	t.Helper()
+	var messages []string
+	for _, trainerID := range []string{"trainer1", "trainer2"} {
+		for slot := d.NextSlot(trainerID); slot >= 0; slot = d.NextSlot(trainerID) {
+			if reason := d.CheckAction(trainerID, slot, action); reason != "" {
+				t.Fatalf("%s slot %d: %s", trainerID, slot, reason)
+			}
+			messages = d.Choose(trainerID, slot, action)
+		}
+	}
+	return messages
+}
+
+func TestDoubleTurnOrder(t *testing.T) {
+	d := newTestDouble(t, 3)
+	first, second := d.Sides[0], d.Sides[1]
+	slow := first.Active[0]
+	quickHit, goPanic := indexOfAbility(t, slow, "Quick Hit"), indexOfAbility(t, slow, "Go Panic()")
+
+	slow.Speed = 1
+	first.Active[1].Speed = 50
+	second.Active[0].Speed, second.Active[1].Speed = 100, 200
+
+	first.Actions = [DoubleSlots]*DoubleAction{{Type: "fight", Index: quickHit}, {Type: "fight", Index: goPanic}}
+	second.Actions = [DoubleSlots]*DoubleAction{{Type: "fight", Index: goPanic}, {Type: "swap", Index: 2}}
+
+	order := d.turnOrder()
+	if len(order) != 4 {
+		t.Fatalf("got %d turns, want 4", len(order))
+	}
+	want := []*Gopher{second.Active[1], slow, second.Active[0], first.Active[1]}
+	for idx, turn := range order {
+		if turn.gopher != want[idx] {
+			t.Errorf("turn %d is %s, want %s (swap, then quick_hit, then by speed)", idx, turn.gopher.ID, want[idx].ID)
+		}
+	}
+}
+
+func TestDoubleSideRunsOnce(t *testing.T) {
+	rng := NewRNG(4)
+	party := []*Gopher{
+		NewSimGopher("lead", SimGopherSpec{Archetype: ArchetypeHacker, Rarity: "RARE", Level: 20}, rng),
+		NewSimGopher("backup", SimGopherSpec{Archetype: ArchetypeTank, Rarity: "RARE", Level: 20}, rng),
+	}
+	horde := []*Gopher{
+		NewSimGopher("wild1", SimGopherSpec{Archetype: ArchetypeMage, Rarity: "COMMON", Level: 20}, rng),
+		NewSimGopher("wild2", SimGopherSpec{Archetype: ArchetypeMage, Rarity: "COMMON", Level: 20}, rng),
+	}
+	d, err := NewWildDoubleBattle("channel", "trainer", "Trainer", party, horde, nil, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Choosing would resolve the round straight away against the wild side
+	run := &DoubleAction{Type: "run"}
+	d.Sides[0].Actions = [DoubleSlots]*DoubleAction{run, run}
+	runs := 0
+	for _, turn := range d.turnOrder() {
+		if turn.action.Type == "run" {
+			runs++
+		}
+	}
+	if runs != 1 {
+		t.Errorf("the side tried to run %d times, want 1", runs)
+	}
+}
+
+func TestDoubleSpreadDamage(t *testing.T) {
+	if damage := (&BattleState{spread: true}).spreadDamage(100); damage != 75 {
+		t.Errorf("spread damage of 100 = %d, want 75", damage)
+	}
+	if damage := (&BattleState{spread: true}).spreadDamage(1); damage != 1 {
+		t.Errorf("spread damage of 1 = %d, want at least 1", damage)
+	}
+	if damage := (&BattleState{}).spreadDamage(100); damage != 100 {
+		t.Errorf("single-target damage of 100 = %d, want 100", damage)
+	}
+
+	// The same spread move with the same random draws, with both foes standing and with one.
+	// The first foe is hit first, so it sees the same rolls either way.
+	damageTo := func(bothFoes bool) int {
+		d := newTestDouble(t, 12)
+		user := d.Sides[0].Active[0]
+		apocalypse, err := CreateAbilityFromTemplate("apocalypse", "apocalypse")
+		if err != nil {
+			t.Fatal(err)
+		}
+		user.Abilities = append(user.Abilities, apocalypse)
+
+		target, other := d.Sides[1].Active[0], d.Sides[1].Active[1]
+		target.MaxHP, target.CurrentHP = 10000, 10000
+		if !bothFoes {
+			other.CurrentHP = 0
+		}
+		d.useAbility(0, user, &DoubleAction{Type: "fight", Index: len(user.Abilities) - 1})
+		return target.MaxHP - target.CurrentHP
+	}
+	spread, single := damageTo(true), damageTo(false)
+	if single == 0 {
+		t.Fatal("the move dealt no damage")
+	}
+	// Rounding before a critical hit can be off by a point or two
+	if math.Abs(float64(spread)-float64(single)*SpreadMultiplier) > 2 {
+		t.Errorf("spread damage = %d, want about %.2f of %d", spread, SpreadMultiplier, single)
+	}
+}
+
+func TestDoubleAbilityTargets(t *testing.T) {
+	d := newTestDouble(t, 6)
+	foes := d.Sides[1]
+	single := &Ability{Targeting: TargetingEnemy}
+
+	if targets := d.abilityTargets(0, single, 1); len(targets) != 1 || targets[0] != foes.Active[1] {
+		t.Errorf("targets = %v, want the chosen foe", targets)
+	}
+	if targets := d.abilityTargets(0, &Ability{Targeting: TargetingBoth}, 1); len(targets) != 2 {
+		t.Errorf("a spread move hit %d foes, want 2", len(targets))
+	}
+
+	foes.Active[1].CurrentHP = 0
+	if targets := d.abilityTargets(0, single, 1); len(targets) != 1 || targets[0] != foes.Active[0] {
+		t.Errorf("targets = %v, want the other foe once the chosen one fainted", targets)
+	}
+}
+
+func TestDoubleDrawWhenBothSidesAreOut(t *testing.T) {
+	d := newTestDouble(t, 7)
+	for _, side := range d.Sides {
+		for _, gopher := range side.Party {
+			gopher.CurrentHP = 0
+		}
+	}
+	d.checkFaints()
+
+	messages := d.checkOutcome()
+	if d.State != DoubleStateDraw || d.WinnerSide() != -1 {
+		t.Errorf("state = %s, winner = %d; want a draw", d.State, d.WinnerSide())
+	}
+	if len(messages) != 1 || !strings.Contains(messages[0], "draw") {
+		t.Errorf("messages = %v", messages)
+	}
+}
+
+func TestDoubleMaxRounds(t *testing.T) {
+	d := newTestDouble(t, 8)
+	d.Round = DoubleMaxRounds - 1
+	chooseAll(t, d, DoubleAction{Type: "rest"})
+	if d.State != DoubleStateActive {
+		t.Fatalf("battle ended at round %d", DoubleMaxRounds-1)
+	}
+
+	chooseAll(t, d, DoubleAction{Type: "rest"})
+	if d.State != DoubleStateDraw {
+		t.Errorf("state after %d rounds = %s, want a draw", DoubleMaxRounds, d.State)
+	}
+}
+
+func TestDoubleFillSlots(t *testing.T) {
+	d := newTestDouble(t, 9)
+	side := d.Sides[1]
+	fainted, backup := side.Active[0], side.Party[2]
+
+	fainted.CurrentHP = 0
+	d.checkFaints()
+	if side.Active[0] != nil {
+		t.Fatal("a fainted gopher stayed in its slot")
+	}
+
+	d.fillSlots(1)
+	if side.Active[0] != backup {
+		t.Errorf("slot 0 holds %v, want the backup %s", side.Active[0], backup.ID)
+	}
+	if side.Participants[len(side.Participants)-1] != backup {
+		t.Error("the backup wasn't counted as a participant")
+	}
+
+	// With nobody left to send out, the slot stays empty
+	backup.CurrentHP = 0
+	d.checkFaints()
+	d.fillSlots(1)
+	if side.Active[0] != nil {
+		t.Errorf("slot 0 holds %s with nobody left to send out", side.Active[0].ID)
+	}
+}
+
+func TestDoubleRejectsTwoSwapsToTheSameGopher(t *testing.T) {
+	d := newTestDouble(t, 10)
+	swap := DoubleAction{Type: "swap", Index: 2}
+
+	if reason := d.CheckAction("trainer1", 0, swap); reason != "" {
+		t.Fatalf("first swap rejected: %s", reason)
+	}
+	d.Choose("trainer1", 0, swap)
+	if reason := d.CheckAction("trainer1", 1, swap); reason == "" {
+		t.Error("a second swap to the same party member was accepted")
+	}
+	if reason := d.CheckAction("trainer1", 1, DoubleAction{Type: "swap", Index: 0}); reason == "" {
+		t.Error("a swap to a gopher already in battle was accepted")
+	}
+}
+
+func TestDoubleEnergy(t *testing.T) {
+	d := newTestDouble(t, 11)
+	for _, side := range d.Sides {
+		for _, gopher := range side.Party {
+			gopher.MaxHP, gopher.CurrentHP = 10000, 10000
+		}
+	}
+	attacker, rester := d.Sides[0].Active[0], d.Sides[0].Active[1]
+	index := indexOfAbility(t, attacker, "Go Panic()")
+	ability := attacker.Abilities[index]
+	fight := DoubleAction{Type: "fight", Index: index, Target: 0}
+
+	state := d.effectState(0, attacker, nil)
+	state.setEnergy(attacker, ability.Cost-1)
+	if reason := d.CheckAction("trainer1", 0, fight); !strings.Contains(reason, "Not enough energy") {
+		t.Fatalf("an unaffordable ability was accepted: %q", reason)
+	}
+
+	state.setEnergy(attacker, ability.Cost)
+	state.setEnergy(rester, 0)
+	d.Choose("trainer1", 0, fight)
+	d.Choose("trainer1", 1, DoubleAction{Type: "rest"})
+	chooseAll(t, d, DoubleAction{Type: "rest"})
+
+	// Everyone regenerates at the end of the round, and resting recovers the same again
+	if energy := d.Energy(attacker); energy != EnergyRegen(attacker) {
+		t.Errorf("energy after using %s = %d, want %d", ability.Name, energy, EnergyRegen(attacker))
+	}
+	if energy := d.Energy(rester); energy != 2*EnergyRegen(rester) {
+		t.Errorf("energy after resting = %d, want %d", energy, 2*EnergyRegen(rester))
+	}
+}
@@ -30,9 +30,28 @@ const (
 	ShinyCharmRate      = 1.0 / 2048.0 // Doubles shiny rate
 )
 
+// Item display names
+var ItemNames = map[string]string{
+	ItemTypePotion:         "Potion",
+	ItemTypeRevive:         "Revive",
+	ItemTypeXPBooster:      "XP Booster",
+	ItemTypeEvolutionStone: "Evolution Stone",
+	ItemTypeShinyCharm:     "Shiny Charm",
+
+	ItemTypeMechanicalKeyboard: "Mechanical Keyboard",
+	ItemTypeTitaniumCase:       "Titanium Case",
+	ItemTypeFiberCable:         "Fiber Cable",
+	ItemTypeRubberDuck:         "Rubber Duck",
+	ItemTypeCrystalCompiler:    "Crystal Compiler",
+	ItemTypeCoffeeMug:          "Coffee Mug",
+	ItemTypeRecoverCharm:       "Recover Charm",
+	ItemTypeTurboScarf:         "Turbo Scarf",
+}
+
 type ItemService struct {
-	trainerRepo TrainerRepoInterface
-	itemRepo    ItemRepoInterface
+	trainerRepo      TrainerRepoInterface
+	itemRepo         ItemRepoInterface
+	evolutionService *EvolutionService
 }
 
 func NewItemService(trainerRepo TrainerRepoInterface, itemRepo ItemRepoInterface) *ItemService {
@@ -42,6 +61,11 @@ func NewItemService(trainerRepo TrainerRepoInterface, itemRepo ItemRepoInterface
 	}
 }
 
+// SetEvolutionService sets the evolution service used by Evolution Stones
+func (s *ItemService) SetEvolutionService(es *EvolutionService) {
+	s.evolutionService = es
+}
+
 func (s *ItemService) GetItemPrice(itemType string) int {
 	switch itemType {
 	case ItemTypePotion:
@@ -55,6 +79,9 @@ func (s *ItemService) GetItemPrice(itemType string) int {
 	case ItemTypeShinyCharm:
 		return PriceShinyCharm
 	default:
+		if item, ok := HeldItems[itemType]; ok {
+			return item.Price
+		}
 		return 0
 	}
 }
@@ -97,6 +124,115 @@ func (s *ItemService) UseRevive(gopher *Gopher) error {
 	return nil
 }
 
+// UseItemOn spends one of the trainer's Potions or Revives on a gopher
+func (s *ItemService) UseItemOn(trainerID, itemType string, gopher *Gopher) (string, error) {
+	switch itemType {
+	case ItemTypePotion:
+		if gopher.CurrentHP <= 0 {
+			return "", fmt.Errorf("%s is fainted - use a Revive instead", gopher.Name)
+		}
+		if gopher.CurrentHP >= gopher.MaxHP {
+			return "", fmt.Errorf("%s is already at full HP", gopher.Name)
+		}
+	case ItemTypeRevive:
+		if gopher.CurrentHP > 0 {
+			return "", fmt.Errorf("%s is not fainted", gopher.Name)
+		}
+	default:
+		return "", fmt.Errorf("%s can't be used on a gopher", ItemNames[itemType])
+	}
+
+	if err := s.consumeItem(trainerID, itemType); err != nil {
+		return "", err
+	}
+
+	oldHP := gopher.CurrentHP
+	if itemType == ItemTypePotion {
+		s.UsePotion(gopher)
+		return fmt.Sprintf("Used a Potion on %s! Restored %d HP.", gopher.Name, gopher.CurrentHP-oldHP), nil
+	}
+
+	if err := s.UseRevive(gopher); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Used a Revive! %s is back on its feet with %d HP.", gopher.Name, gopher.CurrentHP), nil
+}
+
+// UseEvolutionStone spends an Evolution Stone to evolve a gopher regardless of its level
+func (s *ItemService) UseEvolutionStone(trainerID string, gopher *Gopher) (string, error) {
+	if s.evolutionService == nil {
+		return "", fmt.Errorf("evolution is not available")
+	}
+	if gopher.EvolutionStage >= 2 {
+		return "", fmt.Errorf("%s is already fully evolved", gopher.Name)
+	}
+
+	if err := s.consumeItem(trainerID, ItemTypeEvolutionStone); err != nil {
+		return "", err
+	}
+
+	message, err := s.evolutionService.ForceEvolve(gopher)
+	if err != nil {
+		// Refund the stone
+		s.itemRepo.AddItem(trainerID, ItemTypeEvolutionStone, 1)
+		return "", err
+	}
+	return message, nil
+}
+
+// ActivateXPBooster spends an XP Booster so the trainer's next battle awards boosted XP
+func (s *ItemService) ActivateXPBooster(trainerID string) error {
+	if err := s.consumeItem(trainerID, ItemTypeXPBooster); err != nil {
+		return err
+	}
+
+	activated, err := s.trainerRepo.ActivateXPBoost(trainerID)
+	if err != nil || !activated {
+		// Refund the booster
+		s.itemRepo.AddItem(trainerID, ItemTypeXPBooster, 1)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("an XP Booster is already active for your next battle")
+	}
+
+	return nil
+}
+
+// ConsumeXPBoost returns the XP multiplier for a battle that is starting, using up an active XP Booster
+func (s *ItemService) ConsumeXPBoost(trainerID string) float64 {
+	if consumed, err := s.trainerRepo.ConsumeXPBoost(trainerID); err == nil && consumed {
+		return XPBoosterMultiplier
+	}
+	return 1.0
+}
+
+// RefundXPBoost reactivates the XP Booster used up by a battle that failed to start.
+// multiplier is the one ConsumeXPBoost returned; there's nothing to refund without a boost.
+func (s *ItemService) RefundXPBoost(trainerID string, multiplier float64) error {
+	if multiplier <= 1.0 {
+		return nil
+	}
+	_, err := s.trainerRepo.ActivateXPBoost(trainerID)
+	return err
+}
+
+// consumeItem removes one item from the trainer's bag
+func (s *ItemService) consumeItem(trainerID, itemType string) error {
+	qty, err := s.itemRepo.GetItemQuantity(trainerID, itemType)
+	if err != nil {
+		return fmt.Errorf("failed to check items: %w", err)
+	}
+	if qty <= 0 {
+		return fmt.Errorf("you don't have any %s", ItemNames[itemType])
+	}
+
+	if err := s.itemRepo.UseItem(trainerID, itemType, 1); err != nil {
+		return fmt.Errorf("failed to use item: %w", err)
+	}
+	return nil
+}
+
 func (s *ItemService) GetShinyRateMultiplier(trainerID string) float64 {
 	// Check if trainer has shiny charm
 	if qty, err := s.itemRepo.GetItemQuantity(trainerID, ItemTypeShinyCharm); err == nil && qty > 0 {
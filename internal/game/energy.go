@@ -0,0 +1,71 @@
+package game
+
+// Energy is spent to use abilities in battle. Every ability costs its template's Cost,
+// and each gopher's pool is refilled a little at the end of every round.
+
+// archetypeEnergy is the base energy pool for each archetype
+var archetypeEnergy = map[Archetype]int{
+	ArchetypeHacker:  30,
+	ArchetypeTank:    25,
+	ArchetypeSpeedy:  30,
+	ArchetypeSupport: 35,
+	ArchetypeMage:    40,
+}
+
+// MaxEnergy returns the size of a gopher's energy pool, based on its archetype and level
+func MaxEnergy(gopher *Gopher) int {
+	base, ok := archetypeEnergy[Archetype(gopher.SpeciesArchetype)]
+	if !ok {
+		base = 30
+	}
+	return base + gopher.Level
+}
+
+// EnergyRegen returns how much energy a gopher recovers at the end of each round
+func EnergyRegen(gopher *Gopher) int {
+	return 5 + MaxEnergy(gopher)/10
+}
+
+// Energy returns a gopher's current energy in this battle. Gophers start with a full pool.
+func (bs *BattleState) Energy(gopher *Gopher) int {
+	if energy, ok := bs.EnergyPools[gopher.ID]; ok {
+		return energy
+	}
+	return MaxEnergy(gopher)
+}
+
+// CanAfford returns whether the gopher has enough energy to use the ability
+func (bs *BattleState) CanAfford(gopher *Gopher, ability *Ability) bool {
+	return bs.Energy(gopher) >= ability.Cost
+}
+
+// setEnergy stores a gopher's energy, clamped to its pool
+func (bs *BattleState) setEnergy(gopher *Gopher, energy int) {
+	if energy < 0 {
+		energy = 0
+	}
+	if max := MaxEnergy(gopher); energy > max {
+		energy = max
+	}
+	if bs.EnergyPools == nil {
+		bs.EnergyPools = make(map[string]int)
+	}
+	bs.EnergyPools[gopher.ID] = energy
+}
+
+// regenerateEnergy refills the active gophers' energy at the end of a round
+func (bs *BattleState) regenerateEnergy() {
+	bs.setEnergy(bs.PlayerGopher, bs.Energy(bs.PlayerGopher)+EnergyRegen(bs.PlayerGopher))
+	bs.setEnergy(bs.EnemyGopher, bs.Energy(bs.EnemyGopher)+EnergyRegen(bs.EnemyGopher))
+}
+
+// affordableAbilities returns the gopher's abilities it has the energy for
+func (bs *BattleState) affordableAbilities(gopher *Gopher) []*Ability {
+	affordable := []*Ability{}
+	for _, ability := range gopher.Abilities {
+		if bs.CanAfford(gopher, ability) {
+			affordable = append(affordable, ability)
+		}
+	}
+	return affordable
+}
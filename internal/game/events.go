@@ -17,8 +17,21 @@ const (
 	EventLuckyDay      EventType = "LUCKY_DAY"      // Better capture rates
 	EventStatBoost     EventType = "STAT_BOOST"     // All gophers get 10% stat boost
 	EventEvolutionFest EventType = "EVOLUTION_FEST" // Faster evolution (reduced level requirements)
+	EventCompileStorm  EventType = "COMPILE_STORM"  // Battles start in a Compile Storm
+	EventGarbageDay    EventType = "GARBAGE_DAY"    // Battles start on Garbage Day
+	EventLagSpike      EventType = "LAG_SPIKE"      // Battles start in a Lag Spike
 )
 
+// fieldEvents are the events that set a field condition in every battle, in order of precedence
+var fieldEvents = []struct {
+	Type      EventType
+	Condition FieldCondition
+}{
+	{EventCompileStorm, FieldCompileStorm},
+	{EventGarbageDay, FieldGarbageDay},
+	{EventLagSpike, FieldLagSpike},
+}
+
 // Event represents an active event
 type Event struct {
 	ID          string
@@ -155,6 +168,12 @@ func (em *EventManager) getEventName(eventType EventType) string {
 		return "💪 Stat Boost Event 💪"
 	case EventEvolutionFest:
 		return "🌟 Evolution Festival 🌟"
+	case EventCompileStorm:
+		return "⛈️ Compile Storm ⛈️"
+	case EventGarbageDay:
+		return "🗑️ Garbage Day 🗑️"
+	case EventLagSpike:
+		return "🐌 Lag Spike 🐌"
 	default:
 		return "Unknown Event"
 	}
@@ -175,6 +194,12 @@ func (em *EventManager) getEventDescription(eventType EventType) string {
 		return "All gophers get a 10% stat boost! Your team is stronger!"
 	case EventEvolutionFest:
 		return "Evolution requirements reduced! Your gophers evolve faster!"
+	case EventCompileStorm:
+		return "A Compile Storm rages over every battle! Hacker-type gophers hit harder!"
+	case EventGarbageDay:
+		return "It's Garbage Day in every battle! Support-type gophers recover HP each round!"
+	case EventLagSpike:
+		return "A Lag Spike slows down every battle! All gophers' Speed is halved!"
 	default:
 		return "An event is active!"
 	}
@@ -253,6 +278,17 @@ func (em *EventManager) GetEvolutionLevelReduction() int {
 	return 0
 }
 
+// GetFieldCondition returns the field condition battles start with while a field event is
+// active, or FieldNone
+func (em *EventManager) GetFieldCondition() FieldCondition {
+	for _, event := range fieldEvents {
+		if em.GetActiveEventByType(event.Type) != nil {
+			return event.Condition
+		}
+	}
+	return FieldNone
+}
+
 // GetRandomEventType returns a random event type for automatic events
 func GetRandomEventType() EventType {
 	events := []EventType{
@@ -262,6 +298,9 @@ func GetRandomEventType() EventType {
 		EventLuckyDay,
 		EventStatBoost,
 		EventEvolutionFest,
+		EventCompileStorm,
+		EventGarbageDay,
+		EventLagSpike,
 	}
 	return events[rand.Intn(len(events))]
 }
@@ -2,8 +2,6 @@ package game
 
 import (
 	"fmt"
-	"math/rand"
-	"time"
 
 	"gophermon-bot/internal/gopherkon"
 )
@@ -13,6 +11,7 @@ type EvolutionService struct {
 	generator *gopherkon.Generator
 	assetsPath string
 	eventManager *EventManager
+	rng *RNG
 }
 
 func NewEvolutionService(generator *gopherkon.Generator, assetsPath string) *EvolutionService {
@@ -20,6 +19,7 @@ func NewEvolutionService(generator *gopherkon.Generator, assetsPath string) *Evo
 		generator:  generator,
 		assetsPath: assetsPath,
 		eventManager: nil, // Will be set by service
+		rng:        NewRNG(NewSeed()),
 	}
 }
 
@@ -28,6 +28,11 @@ func (es *EvolutionService) SetEventManager(em *EventManager) {
 	es.eventManager = em
 }
 
+// SetRNG sets the random source used to pick evolved sprites
+func (es *EvolutionService) SetRNG(rng *RNG) {
+	es.rng = rng
+}
+
 // CheckEvolution checks if a gopher should evolve and performs evolution
 func (es *EvolutionService) EvolveGopher(gopher *Gopher) (*Gopher, error) {
 	// Evolution thresholds: level 16 and 32 (reduced by events)
@@ -61,8 +66,22 @@ func (es *EvolutionService) EvolveGopher(gopher *Gopher) (*Gopher, error) {
 		return nil, nil
 	}
 
+	return es.evolve(gopher, newStage)
+}
+
+// ForceEvolveGopher evolves a gopher to its next stage regardless of level (e.g. with an Evolution Stone)
+func (es *EvolutionService) ForceEvolveGopher(gopher *Gopher) (*Gopher, error) {
+	if gopher.EvolutionStage >= 2 {
+		return nil, fmt.Errorf("%s is already fully evolved", gopher.Name)
+	}
+
+	return es.evolve(gopher, gopher.EvolutionStage+1)
+}
+
+// evolve generates the new sprite and applies the stat boosts for the given stage
+func (es *EvolutionService) evolve(gopher *Gopher, newStage int) (*Gopher, error) {
 	// Preserve some layers from base gopher
-	preserveCount := 1 + rand.Intn(2) // Preserve 1-2 layers
+	preserveCount := 1 + es.rng.Intn(2) // Preserve 1-2 layers
 	if preserveCount > len(gopher.GopherkonLayers) {
 		preserveCount = len(gopher.GopherkonLayers)
 	}
@@ -74,7 +93,7 @@ func (es *EvolutionService) EvolveGopher(gopher *Gopher) (*Gopher, error) {
 
 	// Generate new sprite with increased complexity
 	currentComplexity := gopher.ComplexityScore
-	newComplexity := currentComplexity + 2 + rand.Intn(3) // Add 2-4 complexity
+	newComplexity := currentComplexity + 2 + es.rng.Intn(3) // Add 2-4 complexity
 
 	// Ensure rarity upgrade
 	currentRarity := ComplexityToRarity(currentComplexity)
@@ -90,7 +109,7 @@ func (es *EvolutionService) EvolveGopher(gopher *Gopher) (*Gopher, error) {
 	result, err := es.generator.Generate(gopherkon.GenerateOptions{
 		Complexity:    newComplexity,
 		TargetRarity:  targetRarity.String(),
-		Seed:         time.Now().UnixNano(),
+		Seed:         es.rng.Int63(),
 		PreserveLayers: preservedLayers,
 	})
 	if err != nil {
@@ -119,38 +138,6 @@ func (es *EvolutionService) EvolveGopher(gopher *Gopher) (*Gopher, error) {
 	gopher.Defense += 5 + (newStage * 3)
 	gopher.Speed += 3 + (newStage * 2)
 
-	// Unlock new abilities based on evolution stage
-	abilityTemplates := GetAbilitiesForArchetype(Archetype(gopher.SpeciesArchetype), gopher.EvolutionStage, gopher.Rarity)
-	
-	// Determine how many abilities gopher should have
-	numAbilities := 2
-	if gopher.Level >= 10 {
-		numAbilities = 3
-	}
-	if gopher.Level >= 20 {
-		numAbilities = 4
-	}
-	if gopher.EvolutionStage >= 1 {
-		numAbilities = 5
-	}
-	if gopher.EvolutionStage >= 2 {
-		numAbilities = 6
-	}
-	if gopher.Rarity == "LEGENDARY" {
-		numAbilities = 7 // Legendaries get more abilities
-	}
-	
-	// Add new abilities if needed
-	if len(gopher.Abilities) < numAbilities && len(abilityTemplates) > len(gopher.Abilities) {
-		// Add abilities starting from where we left off
-		for i := len(gopher.Abilities); i < numAbilities && i < len(abilityTemplates); i++ {
-			newAbility, err := CreateAbilityFromTemplate(abilityTemplates[i], fmt.Sprintf("%s_ability_%d", gopher.ID, i))
-			if err == nil {
-				gopher.Abilities = append(gopher.Abilities, newAbility)
-			}
-		}
-	}
-
 	return gopher, nil
 }
 
@@ -173,3 +160,18 @@ func (es *EvolutionService) CheckAndEvolve(gopher *Gopher) (evolved bool, evolut
 	return false, ""
 }
 
+// ForceEvolve evolves a gopher with an Evolution Stone and describes the result
+func (es *EvolutionService) ForceEvolve(gopher *Gopher) (evolutionMessage string, err error) {
+	oldStage := gopher.EvolutionStage
+	oldRarity := gopher.Rarity
+	evolvedGopher, err := es.ForceEvolveGopher(gopher)
+	if err != nil {
+		return "", err
+	}
+
+	evolutionMessage = fmt.Sprintf("🎉 **%s is evolving!** 🎉\n", gopher.Name)
+	evolutionMessage += fmt.Sprintf("Evolution stage: %d → %d\n", oldStage, evolvedGopher.EvolutionStage)
+	evolutionMessage += fmt.Sprintf("Rarity: %s → %s\n", oldRarity, evolvedGopher.Rarity)
+	evolutionMessage += "Stats increased significantly!"
+	return evolutionMessage, nil
+}
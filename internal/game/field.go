@@ -0,0 +1,161 @@
+package game
+
+import (
+	"fmt"
+)
+
+// FieldCondition is a weather-like condition on the battlefield that affects every gopher in
+// the battle. Battles start with one while a field event is running, wild encounters sometimes
+// start with one, and some abilities change it.
+type FieldCondition string
+
+const (
+	FieldNone         FieldCondition = ""
+	FieldCompileStorm FieldCondition = "COMPILE_STORM" // Hacker-type damage is boosted
+	FieldGarbageDay   FieldCondition = "GARBAGE_DAY"   // Support-type gophers heal at the end of every round
+	FieldLagSpike     FieldCondition = "LAG_SPIKE"     // Every gopher's Speed is halved
+)
+
+// Field condition effects
+const (
+	CompileStormMultiplier  = 1.3 // Compile Storm raises Hacker-type damage by 30%
+	GarbageDayHealDivisor   = 16  // Garbage Day heals Support-type gophers 1/16 of max HP every round
+	LagSpikeSpeedMultiplier = 0.5 // Lag Spike halves Speed for turn order and critical hits
+	FieldDuration           = 5   // Rounds a condition from an ability or a wild encounter lasts
+	WildFieldChance         = 0.2 // Chance a wild encounter starts with a random condition
+)
+
+// fieldConditionInfo is how a field condition is shown in battle
+type fieldConditionInfo struct {
+	Name        string
+	Description string
+	Start       string
+	End         string
+}
+
+var fieldConditions = map[FieldCondition]fieldConditionInfo{
+	FieldCompileStorm: {"⛈️ Compile Storm", "Hacker-type damage is boosted", "A Compile Storm is raging!", "The Compile Storm died down."},
+	FieldGarbageDay:   {"🗑️ Garbage Day", "Support-type gophers recover HP every round", "It's Garbage Day! Support gophers feel refreshed.", "Garbage Day is over."},
+	FieldLagSpike:     {"🐌 Lag Spike", "Every gopher's Speed is halved", "A Lag Spike hit the battlefield! Everyone slowed down.", "The Lag Spike cleared up."},
+}
+
+// fieldConditionTypes lists the field conditions in a fixed order, for random picks
+var fieldConditionTypes = []FieldCondition{FieldCompileStorm, FieldGarbageDay, FieldLagSpike}
+
+// Field is the battlefield's current condition. A nil *Field has no condition.
+type Field struct {
+	Condition FieldCondition
+	Turns     int // Rounds left; -1 lasts until the battle ends
+}
+
+// startingField returns the field a battle starts with: the condition of a running field event,
+// or in a wild encounter sometimes a random one
+func startingField(eventManager *EventManager, rng *RNG, wild bool) *Field {
+	if eventManager != nil {
+		if condition := eventManager.GetFieldCondition(); condition != FieldNone {
+			return &Field{Condition: condition, Turns: -1}
+		}
+	}
+	if wild && rng.Float64() < WildFieldChance {
+		return &Field{Condition: fieldConditionTypes[rng.Intn(len(fieldConditionTypes))], Turns: FieldDuration}
+	}
+	return &Field{}
+}
+
+// Active reports whether a field condition is in effect
+func (f *Field) Active() bool {
+	return f != nil && f.Condition != FieldNone
+}
+
+// Is reports whether the given condition is in effect
+func (f *Field) Is(condition FieldCondition) bool {
+	return f != nil && f.Condition == condition
+}
+
+// Name returns the display name of the current condition
+func (f *Field) Name() string {
+	if !f.Active() {
+		return "Clear"
+	}
+	return fieldConditions[f.Condition].Name
+}
+
+// Description describes the current condition and how long it lasts
+func (f *Field) Description() string {
+	if !f.Active() {
+		return "No field condition"
+	}
+	if f.Turns < 0 {
+		return fieldConditions[f.Condition].Description
+	}
+	return fmt.Sprintf("%s (%d turns left)", fieldConditions[f.Condition].Description, f.Turns)
+}
+
+// StartMessage announces the current condition, for the start of a battle
+func (f *Field) StartMessage() string {
+	if !f.Active() {
+		return ""
+	}
+	return fieldConditions[f.Condition].Start
+}
+
+// set replaces the current condition. Returns nil if that condition is already in effect.
+func (f *Field) set(condition FieldCondition, turns int) []string {
+	if f == nil || f.Condition == condition {
+		return nil
+	}
+	f.Condition = condition
+	f.Turns = turns
+	return []string{fieldConditions[condition].Start}
+}
+
+// damageMultiplier returns how much the condition raises an attacker's damage
+func (f *Field) damageMultiplier(attacker *Gopher) float64 {
+	if f.Is(FieldCompileStorm) && (attacker.PrimaryType == TypeHacker || attacker.SecondaryType == TypeHacker) {
+		return CompileStormMultiplier
+	}
+	return 1.0
+}
+
+// speed returns a gopher's Speed under the condition
+func (f *Field) speed(g *Gopher) int {
+	if f.Is(FieldLagSpike) {
+		return int(float64(g.Speed) * LagSpikeSpeedMultiplier)
+	}
+	return g.Speed
+}
+
+// endRound applies the condition to the gophers still battling at the end of a round and
+// counts down its duration
+func (f *Field) endRound(gophers ...*Gopher) []string {
+	if !f.Active() {
+		return nil
+	}
+
+	messages := []string{}
+	if f.Condition == FieldGarbageDay {
+		for _, g := range gophers {
+			if g.CurrentHP <= 0 || g.CurrentHP >= g.MaxHP || (g.PrimaryType != TypeSupport && g.SecondaryType != TypeSupport) {
+				continue
+			}
+			heal := g.MaxHP / GarbageDayHealDivisor
+			if heal < 1 {
+				heal = 1
+			}
+			if heal > g.MaxHP-g.CurrentHP {
+				heal = g.MaxHP - g.CurrentHP
+			}
+			g.CurrentHP += heal
+			messages = append(messages, fmt.Sprintf("%s recycled some garbage and restored %d HP!", g.Name, heal))
+		}
+	}
+
+	if f.Turns > 0 {
+		f.Turns--
+		if f.Turns == 0 {
+			messages = append(messages, fieldConditions[f.Condition].End)
+			f.Condition = FieldNone
+		}
+	}
+	return messages
+}
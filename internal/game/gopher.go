@@ -2,7 +2,6 @@ package game
 
 import (
 	"fmt"
-	"math/rand"
 )
 
 // Archetype represents a gopher's class/type
@@ -31,6 +30,8 @@ const (
 	StatusAttackDown StatusEffectType = "ATTACK_DOWN"
 	StatusDefenseDown StatusEffectType = "DEFENSE_DOWN"
 	StatusSpeedDown   StatusEffectType = "SPEED_DOWN"
+	StatusEvasionUp   StatusEffectType = "EVASION_UP"   // Harder to hit
+	StatusEvasionDown StatusEffectType = "EVASION_DOWN" // Easier to hit
 	StatusProtect     StatusEffectType = "PROTECT" // Prevents next attack
 )
 
@@ -38,7 +39,7 @@ const (
 type StatusEffect struct {
 	Type      StatusEffectType
 	Duration  int // Turns remaining (-1 for permanent until removed)
-	Intensity int // For damage over time effects
+	Intensity int // For damage over time effects, or the number of stages for stat changes
 }
 
 // Gopher represents a gopher with all its stats and abilities
@@ -65,9 +66,12 @@ type Gopher struct {
 	IsInParty       bool
 	PCSlot          *int
 	Abilities       []*Ability
+	ForgottenAbilities []*Ability   // Abilities offered but not in use (forgotten or skipped)
+	UnknownAbilities int            // Stored abilities whose template isn't loaded; kept in storage but can't be used
 	StatusEffects   []*StatusEffect // Active status effects
 	Shiny           bool            // Whether this gopher is shiny (rare color variant)
 	IsFavorite      bool            // Whether this gopher is marked as favorite
+	HeldItem        string          // Item type of the held item (empty if none)
 	BaseAttack      int // Original attack before stat modifiers
 	BaseDefense     int // Original defense before stat modifiers
 	BaseSpeed       int // Original speed before stat modifiers
@@ -78,8 +82,8 @@ func XPNeeded(level int) int {
 	return 50 * level * level
 }
 
-// AddXP adds experience and returns whether the gopher leveled up
-func (g *Gopher) AddXP(amount int) (leveledUp bool, newLevel int) {
+// AddXP adds experience and returns whether the gopher leveled up. Stat gains are rolled with rng.
+func (g *Gopher) AddXP(amount int, rng *RNG) (leveledUp bool, newLevel int) {
 	g.XP += amount
 	oldLevel := g.Level
 	
@@ -89,50 +93,50 @@ func (g *Gopher) AddXP(amount int) (leveledUp bool, newLevel int) {
 	
 	leveledUp = g.Level > oldLevel
 	if leveledUp {
-		g.LevelUp()
+		g.LevelUp(rng)
 	}
 	
 	return leveledUp, g.Level
 }
 
 // LevelUp increases stats when leveling up
-func (g *Gopher) LevelUp() {
+func (g *Gopher) LevelUp(rng *RNG) {
 	// HP increase
-	hpIncrease := 10 + rand.Intn(6) + (g.ComplexityScore / 2)
+	hpIncrease := 10 + rng.Intn(6) + (g.ComplexityScore / 2)
 	g.MaxHP += hpIncrease
 	g.CurrentHP += hpIncrease // Heal on level up
 	
 	// Stat increases based on archetype
 	switch Archetype(g.SpeciesArchetype) {
 	case ArchetypeHacker:
-		g.Attack += 3 + rand.Intn(3)
-		g.Speed += 4 + rand.Intn(3)
-		g.Defense += 1 + rand.Intn(2)
+		g.Attack += 3 + rng.Intn(3)
+		g.Speed += 4 + rng.Intn(3)
+		g.Defense += 1 + rng.Intn(2)
 	case ArchetypeTank:
-		g.MaxHP += 5 + rand.Intn(5) // Extra HP for tanks
-		g.CurrentHP += 5 + rand.Intn(5)
-		g.Defense += 4 + rand.Intn(3)
-		g.Attack += 1 + rand.Intn(2)
+		g.MaxHP += 5 + rng.Intn(5) // Extra HP for tanks
+		g.CurrentHP += 5 + rng.Intn(5)
+		g.Defense += 4 + rng.Intn(3)
+		g.Attack += 1 + rng.Intn(2)
 		g.Speed += 1
 	case ArchetypeSpeedy:
-		g.Speed += 5 + rand.Intn(4)
-		g.Attack += 2 + rand.Intn(3)
-		g.Defense += 1 + rand.Intn(2)
+		g.Speed += 5 + rng.Intn(4)
+		g.Attack += 2 + rng.Intn(3)
+		g.Defense += 1 + rng.Intn(2)
 	case ArchetypeSupport:
-		g.Attack += 2 + rand.Intn(2)
-		g.Defense += 2 + rand.Intn(2)
-		g.Speed += 2 + rand.Intn(2)
-		g.MaxHP += 3 + rand.Intn(3)
-		g.CurrentHP += 3 + rand.Intn(3)
+		g.Attack += 2 + rng.Intn(2)
+		g.Defense += 2 + rng.Intn(2)
+		g.Speed += 2 + rng.Intn(2)
+		g.MaxHP += 3 + rng.Intn(3)
+		g.CurrentHP += 3 + rng.Intn(3)
 	case ArchetypeMage:
-		g.Attack += 4 + rand.Intn(3)
-		g.Defense += 2 + rand.Intn(2)
-		g.Speed += 2 + rand.Intn(2)
+		g.Attack += 4 + rng.Intn(3)
+		g.Defense += 2 + rng.Intn(2)
+		g.Speed += 2 + rng.Intn(2)
 	}
 }
 
 // GenerateBaseStats creates initial stats based on archetype and rarity
-func GenerateBaseStats(archetype Archetype, rarity string, level int) (hp, attack, defense, speed int) {
+func GenerateBaseStats(archetype Archetype, rarity string, level int, rng *RNG) (hp, attack, defense, speed int) {
 	// Base stats by archetype
 	var baseHP, baseAttack, baseDefense, baseSpeed int
 	
@@ -193,82 +197,43 @@ func GenerateBaseStats(archetype Archetype, rarity string, level int) (hp, attac
 	speed = int(float64(baseSpeed) * rarityMultiplier * levelMultiplier)
 	
 	// Add some randomness (±5%)
-	hp = int(float64(hp) * (0.95 + rand.Float64()*0.1))
-	attack = int(float64(attack) * (0.95 + rand.Float64()*0.1))
-	defense = int(float64(defense) * (0.95 + rand.Float64()*0.1))
-	speed = int(float64(speed) * (0.95 + rand.Float64()*0.1))
+	hp = int(float64(hp) * (0.95 + rng.Float64()*0.1))
+	attack = int(float64(attack) * (0.95 + rng.Float64()*0.1))
+	defense = int(float64(defense) * (0.95 + rng.Float64()*0.1))
+	speed = int(float64(speed) * (0.95 + rng.Float64()*0.1))
 	
 	return hp, attack, defense, speed
 }
 
-// GetAbilitiesForArchetype returns ability template IDs for an archetype
-// Includes base abilities, evolution abilities, and legendary abilities
-func GetAbilitiesForArchetype(archetype Archetype, evolutionStage int, rarity string) []string {
-	var baseAbilities []string
-	
-	switch archetype {
-	case ArchetypeHacker:
-		baseAbilities = []string{"quick_hit", "go_panic", "goroutine", "race_condition", "hack_attack", "burn_attack", "confuse_ray"}
-	case ArchetypeTank:
-		baseAbilities = []string{"quick_hit", "interface_guard", "defer_recover", "garbage_collector", "tank_slam", "harden", "break_armor"}
-	case ArchetypeSpeedy:
-		baseAbilities = []string{"quick_hit", "goroutine", "channel_blast", "go_panic", "speed_rush", "agility", "slow_down"}
-	case ArchetypeSupport:
-		baseAbilities = []string{"garbage_collector", "interface_guard", "defer_recover", "quick_hit", "support_boost", "poison_sting", "weaken"}
-	case ArchetypeMage:
-		baseAbilities = []string{"channel_blast", "go_panic", "race_condition", "goroutine", "magic_blast", "paralyze_bolt", "sleep_powder"}
-	default:
-		baseAbilities = []string{"quick_hit", "go_panic"}
-	}
+// GetAbilitiesForArchetype returns ability template IDs for an archetype from the loaded move pools
+// Includes base abilities, evolution abilities, and legendary abilities (chosen with rng)
+func GetAbilitiesForArchetype(archetype Archetype, evolutionStage int, rarity string, rng *RNG) []string {
+	pool := movePools[archetype]
+	baseAbilities := append([]string{}, pool.Base...)
 	
 	// Add evolution stage 1 abilities
 	if evolutionStage >= 1 {
-		switch archetype {
-		case ArchetypeHacker:
-			baseAbilities = append(baseAbilities, "concurrent_strike", "mutex_lock")
-		case ArchetypeTank:
-			baseAbilities = append(baseAbilities, "reflect_guard", "context_timeout")
-		case ArchetypeSpeedy:
-			baseAbilities = append(baseAbilities, "select_storm", "agility")
-		case ArchetypeSupport:
-			baseAbilities = append(baseAbilities, "full_recovery", "power_up")
-		case ArchetypeMage:
-			baseAbilities = append(baseAbilities, "context_timeout", "magic_blast")
-		}
+		baseAbilities = append(baseAbilities, pool.Stage1...)
 	}
 	
 	// Add evolution stage 2 abilities
 	if evolutionStage >= 2 {
-		switch archetype {
-		case ArchetypeHacker:
-			baseAbilities = append(baseAbilities, "deadlock", "goroutine_swarm")
-		case ArchetypeTank:
-			baseAbilities = append(baseAbilities, "ultimate_guard", "channel_overload")
-		case ArchetypeSpeedy:
-			baseAbilities = append(baseAbilities, "goroutine_swarm", "channel_overload")
-		case ArchetypeSupport:
-			baseAbilities = append(baseAbilities, "full_recovery", "ultimate_guard")
-		case ArchetypeMage:
-			baseAbilities = append(baseAbilities, "channel_overload", "deadlock")
-		}
+		baseAbilities = append(baseAbilities, pool.Stage2...)
 	}
 	
 	// Add legendary abilities for legendary gophers
 	if rarity == "LEGENDARY" {
 		// All legendaries get at least one legendary ability
-		legendaryAbilities := []string{"legendary_strike", "divine_heal", "god_mode"}
+		legendaryAbilities := legendaryPool.Signature
 		// Randomly add 1-2 legendary abilities
-		numLegendary := 1 + rand.Intn(2)
+		numLegendary := 1 + rng.Intn(2)
 		for i := 0; i < numLegendary && i < len(legendaryAbilities); i++ {
 			baseAbilities = append(baseAbilities, legendaryAbilities[i])
 		}
 		// Very rare chance for ultimate legendary abilities
-		if rand.Float64() < 0.3 {
-			if rand.Float64() < 0.5 {
-				baseAbilities = append(baseAbilities, "apocalypse")
-			} else {
-				baseAbilities = append(baseAbilities, "time_rewind")
-			}
+		if rng.Float64() < legendaryPool.UltimateChance {
+			pick := int(rng.Float64() * float64(len(legendaryPool.Ultimate)))
+			baseAbilities = append(baseAbilities, legendaryPool.Ultimate[pick])
 		}
 	}
 	
@@ -276,16 +241,16 @@ func GetAbilitiesForArchetype(archetype Archetype, evolutionStage int, rarity st
 }
 
 // GenerateGopherName creates a random name for a gopher
-func GenerateGopherName(archetype Archetype) string {
+func GenerateGopherName(archetype Archetype, rng *RNG) string {
 	prefixes := []string{"Go", "Gopher", "Code", "Byte", "Bit", "Dev", "Hack"}
 	suffixes := []string{"mon", "gopher", "coder", "dev", "hack", "byte", "bit"}
 	
-	if rand.Float64() < 0.3 {
+	if rng.Float64() < 0.3 {
 		// Use archetype-based name
-		return fmt.Sprintf("%s%s", archetype, suffixes[rand.Intn(len(suffixes))])
+		return fmt.Sprintf("%s%s", archetype, suffixes[rng.Intn(len(suffixes))])
 	}
 	
-	return fmt.Sprintf("%s%s", prefixes[rand.Intn(len(prefixes))], suffixes[rand.Intn(len(suffixes))])
+	return fmt.Sprintf("%s%s", prefixes[rng.Intn(len(prefixes))], suffixes[rng.Intn(len(suffixes))])
 }
 
 // AddStatusEffect adds a status effect to the gopher
@@ -358,20 +323,15 @@ func (g *Gopher) RecalculateStats() {
 	g.Speed = g.BaseSpeed
 	
 	// Apply stat modifiers
+	// Stat statuses hold their number of stages in Intensity
 	for _, effect := range g.StatusEffects {
 		switch effect.Type {
-		case StatusAttackUp:
-			g.Attack = int(float64(g.Attack) * 1.5)
-		case StatusDefenseUp:
-			g.Defense = int(float64(g.Defense) * 1.5)
-		case StatusSpeedUp:
-			g.Speed = int(float64(g.Speed) * 1.5)
-		case StatusAttackDown:
-			g.Attack = int(float64(g.Attack) * 0.75)
-		case StatusDefenseDown:
-			g.Defense = int(float64(g.Defense) * 0.75)
-		case StatusSpeedDown:
-			g.Speed = int(float64(g.Speed) * 0.75)
+		case StatusAttackUp, StatusAttackDown:
+			g.Attack = int(float64(g.Attack) * statStageMultiplier(effect))
+		case StatusDefenseUp, StatusDefenseDown:
+			g.Defense = int(float64(g.Defense) * statStageMultiplier(effect))
+		case StatusSpeedUp, StatusSpeedDown:
+			g.Speed = int(float64(g.Speed) * statStageMultiplier(effect))
 		}
 	}
 }
@@ -464,6 +424,8 @@ func (g *Gopher) ProcessStatusEffects() []string {
 					messages = append(messages, fmt.Sprintf("%s's defense returned to normal!", g.Name))
 				case StatusSpeedDown:
 					messages = append(messages, fmt.Sprintf("%s's speed returned to normal!", g.Name))
+				case StatusEvasionUp, StatusEvasionDown:
+					messages = append(messages, fmt.Sprintf("%s's evasion returned to normal!", g.Name))
 				}
 			}
 		}
@@ -472,6 +434,9 @@ func (g *Gopher) ProcessStatusEffects() []string {
 	g.StatusEffects = newEffects
 	g.RecalculateStats()
 	
+	// Held items like the Coffee Mug act after status damage
+	messages = append(messages, heldItemTurnStart(g)...)
+	
 	return messages
 }
 
@@ -0,0 +1,178 @@
+package game
+
+import (
+	"fmt"
+)
+
+// Held items are bought like other items but equipped on a gopher instead of being used up.
+// A gopher holds one item at a time, keeps it through battles and takes it along when traded.
+const (
+	ItemTypeMechanicalKeyboard = "MECHANICAL_KEYBOARD"
+	ItemTypeTitaniumCase       = "TITANIUM_CASE"
+	ItemTypeFiberCable         = "FIBER_CABLE"
+	ItemTypeRubberDuck         = "RUBBER_DUCK"
+	ItemTypeCrystalCompiler    = "CRYSTAL_COMPILER"
+	ItemTypeCoffeeMug          = "COFFEE_MUG"
+	ItemTypeRecoverCharm       = "RECOVER_CHARM"
+	ItemTypeTurboScarf         = "TURBO_SCARF"
+)
+
+// Held item effects
+const (
+	TypeBoosterMultiplier = 1.2 // Type boosters raise the damage of gophers of their type by 20%
+	CoffeeMugHealDivisor  = 16  // Coffee Mug restores 1/16 of max HP every turn
+	RecoverCharmChance    = 0.1 // Recover Charm has a 10% chance to survive a knockout hit
+	TurboScarfMultiplier  = 1.5 // Turbo Scarf counts as 1.5x Speed for turn order
+)
+
+// HeldItem describes an item a gopher can hold
+type HeldItem struct {
+	Type        string
+	Name        string
+	Description string
+	Price       int
+	BoostType   GopherType // Type boosters only: the type whose damage is raised
+}
+
+// HeldItemTypes lists the held items in shop order
+var HeldItemTypes = []string{
+	ItemTypeMechanicalKeyboard,
+	ItemTypeTitaniumCase,
+	ItemTypeFiberCable,
+	ItemTypeRubberDuck,
+	ItemTypeCrystalCompiler,
+	ItemTypeCoffeeMug,
+	ItemTypeRecoverCharm,
+	ItemTypeTurboScarf,
+}
+
+// HeldItems is the catalog of held items by item type
+var HeldItems = map[string]*HeldItem{
+	ItemTypeMechanicalKeyboard: {ItemTypeMechanicalKeyboard, "Mechanical Keyboard", "Hacker-type gophers deal 20% more damage", 400, TypeHacker},
+	ItemTypeTitaniumCase:       {ItemTypeTitaniumCase, "Titanium Case", "Tank-type gophers deal 20% more damage", 400, TypeTank},
+	ItemTypeFiberCable:         {ItemTypeFiberCable, "Fiber Cable", "Speedy-type gophers deal 20% more damage", 400, TypeSpeedy},
+	ItemTypeRubberDuck:         {ItemTypeRubberDuck, "Rubber Duck", "Support-type gophers deal 20% more damage", 400, TypeSupport},
+	ItemTypeCrystalCompiler:    {ItemTypeCrystalCompiler, "Crystal Compiler", "Mage-type gophers deal 20% more damage", 400, TypeMage},
+	ItemTypeCoffeeMug:          {ItemTypeCoffeeMug, "Coffee Mug", "Restores 1/16 of max HP every turn", 600, ""},
+	ItemTypeRecoverCharm:       {ItemTypeRecoverCharm, "Recover Charm", "10% chance to survive a knockout hit with 1 HP", 500, ""},
+	ItemTypeTurboScarf:         {ItemTypeTurboScarf, "Turbo Scarf", "Moves as if 50% faster when deciding who goes first", 500, ""},
+}
+
+// IsHeldItem reports whether an item type is a held item
+func IsHeldItem(itemType string) bool {
+	_, ok := HeldItems[itemType]
+	return ok
+}
+
+// heldItemDamageMultiplier returns how much the attacker's held item raises its damage
+func heldItemDamageMultiplier(attacker *Gopher) float64 {
+	item := HeldItems[attacker.HeldItem]
+	if item == nil || item.BoostType == "" {
+		return 1.0
+	}
+	if attacker.PrimaryType == item.BoostType || attacker.SecondaryType == item.BoostType {
+		return TypeBoosterMultiplier
+	}
+	return 1.0
+}
+
+// heldItemTurnStart applies the held item's effect at the start of the gopher's turn
+func heldItemTurnStart(g *Gopher) []string {
+	if g.HeldItem != ItemTypeCoffeeMug || g.CurrentHP <= 0 || g.CurrentHP >= g.MaxHP {
+		return nil
+	}
+
+	heal := g.MaxHP / CoffeeMugHealDivisor
+	if heal < 1 {
+		heal = 1
+	}
+	if heal > g.MaxHP-g.CurrentHP {
+		heal = g.MaxHP - g.CurrentHP
+	}
+	g.CurrentHP += heal
+	return []string{fmt.Sprintf("%s restored %d HP with its Coffee Mug!", g.Name, heal)}
+}
+
+// TurnSpeed is the speed a gopher moves at when deciding turn order, including its held item
+// and the field condition
+func TurnSpeed(g *Gopher, field *Field) int {
+	speed := field.speed(g)
+	if g.HeldItem == ItemTypeTurboScarf {
+		return int(float64(speed) * TurboScarfMultiplier)
+	}
+	return speed
+}
+
+// takeHit deals an attack's damage to the target. A Recover Charm may leave it with 1 HP
+// instead of fainting.
+func takeHit(rng *RNG, target *Gopher, damage int) []string {
+	if target.HeldItem == ItemTypeRecoverCharm && damage >= target.CurrentHP && target.CurrentHP > 1 &&
+		rng.Float64() < RecoverCharmChance {
+		target.CurrentHP = 1
+		return []string{fmt.Sprintf("%s hung on with its Recover Charm!", target.Name)}
+	}
+	takeDamage(target, damage)
+	return nil
+}
+
+// GiveHeldItem takes an item from the trainer's bag and gives it to the gopher. An item the
+// gopher was already holding goes back in the bag. The caller saves the gopher.
+func (s *ItemService) GiveHeldItem(trainerID string, gopher *Gopher, itemType string) (string, error) {
+	item := HeldItems[itemType]
+	if item == nil {
+		return "", fmt.Errorf("%s can't be held", ItemNames[itemType])
+	}
+	if gopher.HeldItem == itemType {
+		return "", fmt.Errorf("%s is already holding a %s", gopher.Name, item.Name)
+	}
+
+	if err := s.consumeItem(trainerID, itemType); err != nil {
+		return "", err
+	}
+
+	message := fmt.Sprintf("%s is now holding a %s!", gopher.Name, item.Name)
+	if gopher.HeldItem != "" {
+		if err := s.itemRepo.AddItem(trainerID, gopher.HeldItem, 1); err != nil {
+			// Give the new item back rather than lose the old one
+			s.itemRepo.AddItem(trainerID, itemType, 1)
+			return "", fmt.Errorf("failed to return held item: %w", err)
+		}
+		message += fmt.Sprintf(" The %s went back in your bag.", ItemNames[gopher.HeldItem])
+	}
+	gopher.HeldItem = itemType
+	return message, nil
+}
+
+// RevertHeldItem undoes GiveHeldItem or TakeHeldItem when the gopher couldn't be saved: the
+// gopher holds previous again and the bag gets back what it had before
+func (s *ItemService) RevertHeldItem(trainerID string, gopher *Gopher, previous string) error {
+	if gopher.HeldItem == previous {
+		return nil
+	}
+	if gopher.HeldItem != "" {
+		if err := s.itemRepo.AddItem(trainerID, gopher.HeldItem, 1); err != nil {
+			return fmt.Errorf("failed to return held item: %w", err)
+		}
+	}
+	if previous != "" {
+		if err := s.itemRepo.UseItem(trainerID, previous, 1); err != nil {
+			return fmt.Errorf("failed to take back held item: %w", err)
+		}
+	}
+	gopher.HeldItem = previous
+	return nil
+}
+
+// TakeHeldItem puts the gopher's held item back in the trainer's bag. The caller saves the gopher.
+func (s *ItemService) TakeHeldItem(trainerID string, gopher *Gopher) (string, error) {
+	if gopher.HeldItem == "" {
+		return "", fmt.Errorf("%s isn't holding anything", gopher.Name)
+	}
+
+	if err := s.itemRepo.AddItem(trainerID, gopher.HeldItem, 1); err != nil {
+		return "", fmt.Errorf("failed to return held item: %w", err)
+	}
+	message := fmt.Sprintf("Took the %s from %s and put it in your bag.", ItemNames[gopher.HeldItem], gopher.Name)
+	gopher.HeldItem = ""
+	return message, nil
+}
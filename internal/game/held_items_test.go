@@ -0,0 +1,202 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+
+	"gophermon-bot/internal/storage"
+)
+
+// testBag is an in-memory item repository for a single trainer
+type testBag map[string]int
+
+func (b testBag) AddItem(trainerID, itemType string, quantity int) error {
+	b[itemType] += quantity
+	return nil
+}
+
+func (b testBag) UseItem(trainerID, itemType string, quantity int) error {
+	if b[itemType] < quantity {
+		return fmt.Errorf("not enough %s", itemType)
+	}
+	b[itemType] -= quantity
+	return nil
+}
+
+func (b testBag) GetItemQuantity(trainerID, itemType string) (int, error) {
+	return b[itemType], nil
+}
+
+func (b testBag) GetItems(trainerID string) ([]*storage.Item, error) {
+	items := []*storage.Item{}
+	for itemType, quantity := range b {
+		items = append(items, &storage.Item{TrainerID: trainerID, ItemType: itemType, Quantity: quantity})
+	}
+	return items, nil
+}
+
+func TestRecoverCharm(t *testing.T) {
+	rng := NewRNG(13)
+	target := &Gopher{Name: "Charmed", MaxHP: 50, HeldItem: ItemTypeRecoverCharm}
+
+	survived, fainted := 0, 0
+	for i := 0; i < 200; i++ {
+		target.CurrentHP = 30
+		messages := takeHit(rng, target, 40)
+		switch target.CurrentHP {
+		case 1:
+			survived++
+			if len(messages) != 1 {
+				t.Errorf("surviving with the charm said %v", messages)
+			}
+		case 0:
+			fainted++
+		default:
+			t.Fatalf("a knockout hit left %d HP", target.CurrentHP)
+		}
+	}
+	if survived == 0 || fainted == 0 {
+		t.Errorf("survived %d and fainted %d of 200 knockout hits, want some of each", survived, fainted)
+	}
+
+	// At 1 HP there's nothing left to hang on with, so the charm isn't even rolled
+	draws := rng.Draws()
+	for i := 0; i < 50; i++ {
+		target.CurrentHP = 1
+		if takeHit(rng, target, 5); target.CurrentHP != 0 {
+			t.Fatal("the charm saved a gopher at 1 HP")
+		}
+	}
+	if rng.Draws() != draws {
+		t.Error("the charm was rolled for a gopher at 1 HP")
+	}
+
+	// Hits that don't knock out are taken as usual
+	target.CurrentHP = 30
+	if takeHit(rng, target, 10); target.CurrentHP != 20 {
+		t.Errorf("HP after a 10 damage hit = %d, want 20", target.CurrentHP)
+	}
+}
+
+func TestCoffeeMug(t *testing.T) {
+	tests := []struct {
+		name   string
+		hp     int
+		wantHP int
+	}{
+		{"heals a sixteenth", 50, 60},
+		{"capped at max HP", 155, 160},
+		{"full HP", 160, 160},
+		{"fainted", 0, 0},
+	}
+	for _, tt := range tests {
+		gopher := &Gopher{Name: "Mug", MaxHP: 160, CurrentHP: tt.hp, HeldItem: ItemTypeCoffeeMug}
+		messages := heldItemTurnStart(gopher)
+		if gopher.CurrentHP != tt.wantHP {
+			t.Errorf("%s: HP = %d, want %d", tt.name, gopher.CurrentHP, tt.wantHP)
+		}
+		if healed := tt.wantHP > tt.hp; healed != (len(messages) == 1) {
+			t.Errorf("%s: messages = %v", tt.name, messages)
+		}
+	}
+}
+
+func TestTurnSpeed(t *testing.T) {
+	lagSpike := &Field{Condition: FieldLagSpike, Turns: -1}
+	tests := []struct {
+		name  string
+		item  string
+		field *Field
+		want  int
+	}{
+		{"plain", "", &Field{}, 100},
+		{"Turbo Scarf", ItemTypeTurboScarf, &Field{}, 150},
+		{"Lag Spike", "", lagSpike, 50},
+		{"Turbo Scarf in a Lag Spike", ItemTypeTurboScarf, lagSpike, 75},
+		{"other held item", ItemTypeCoffeeMug, &Field{}, 100},
+	}
+	for _, tt := range tests {
+		gopher := &Gopher{Speed: 100, HeldItem: tt.item}
+		if got := TurnSpeed(gopher, tt.field); got != tt.want {
+			t.Errorf("%s: TurnSpeed = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestHeldItemDamageMultiplier(t *testing.T) {
+	tests := []struct {
+		name               string
+		primary, secondary GopherType
+		item               string
+		want               float64
+	}{
+		{"primary type", TypeHacker, "", ItemTypeMechanicalKeyboard, TypeBoosterMultiplier},
+		{"secondary type", TypeTank, TypeHacker, ItemTypeMechanicalKeyboard, TypeBoosterMultiplier},
+		{"other type", TypeTank, TypeMage, ItemTypeMechanicalKeyboard, 1},
+		{"not a booster", TypeHacker, "", ItemTypeCoffeeMug, 1},
+		{"no item", TypeHacker, "", "", 1},
+	}
+	for _, tt := range tests {
+		gopher := &Gopher{PrimaryType: tt.primary, SecondaryType: tt.secondary, HeldItem: tt.item}
+		if got := heldItemDamageMultiplier(gopher); got != tt.want {
+			t.Errorf("%s: multiplier = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGiveHeldItem(t *testing.T) {
+	bag := testBag{ItemTypeTurboScarf: 1, ItemTypeCoffeeMug: 1}
+	items := NewItemService(nil, bag)
+	gopher := &Gopher{Name: "Holder"}
+
+	if _, err := items.GiveHeldItem("trainer", gopher, ItemTypeTurboScarf); err != nil {
+		t.Fatal(err)
+	}
+	if gopher.HeldItem != ItemTypeTurboScarf || bag[ItemTypeTurboScarf] != 0 {
+		t.Fatalf("holding %q with %d scarves in the bag", gopher.HeldItem, bag[ItemTypeTurboScarf])
+	}
+
+	// Swapping items puts the scarf back in the bag
+	if _, err := items.GiveHeldItem("trainer", gopher, ItemTypeCoffeeMug); err != nil {
+		t.Fatal(err)
+	}
+	if gopher.HeldItem != ItemTypeCoffeeMug || bag[ItemTypeCoffeeMug] != 0 || bag[ItemTypeTurboScarf] != 1 {
+		t.Errorf("holding %q with bag %v after swapping", gopher.HeldItem, bag)
+	}
+
+	// Nothing changes when there's none in the bag
+	if _, err := items.GiveHeldItem("trainer", gopher, ItemTypeRecoverCharm); err == nil {
+		t.Error("gave an item that wasn't in the bag")
+	}
+	if gopher.HeldItem != ItemTypeCoffeeMug {
+		t.Errorf("holding %q after a failed give", gopher.HeldItem)
+	}
+}
+
+func TestRevertHeldItem(t *testing.T) {
+	bag := testBag{ItemTypeCoffeeMug: 1}
+	items := NewItemService(nil, bag)
+	gopher := &Gopher{Name: "Holder", HeldItem: ItemTypeTurboScarf}
+
+	previous := gopher.HeldItem
+	if _, err := items.GiveHeldItem("trainer", gopher, ItemTypeCoffeeMug); err != nil {
+		t.Fatal(err)
+	}
+	if err := items.RevertHeldItem("trainer", gopher, previous); err != nil {
+		t.Fatal(err)
+	}
+	if gopher.HeldItem != ItemTypeTurboScarf || bag[ItemTypeCoffeeMug] != 1 || bag[ItemTypeTurboScarf] != 0 {
+		t.Errorf("holding %q with bag %v after reverting a give", gopher.HeldItem, bag)
+	}
+
+	previous = gopher.HeldItem
+	if _, err := items.TakeHeldItem("trainer", gopher); err != nil {
+		t.Fatal(err)
+	}
+	if err := items.RevertHeldItem("trainer", gopher, previous); err != nil {
+		t.Fatal(err)
+	}
+	if gopher.HeldItem != ItemTypeTurboScarf || bag[ItemTypeTurboScarf] != 0 {
+		t.Errorf("holding %q with bag %v after reverting a take", gopher.HeldItem, bag)
+	}
+}
@@ -11,6 +11,8 @@ type TrainerRepoInterface interface {
 	GetCurrency(trainerID string) (int, error)
 	AddCurrency(trainerID string, amount int) error
 	RemoveCurrency(trainerID string, amount int) error
+	ActivateXPBoost(trainerID string) (bool, error)
+	ConsumeXPBoost(trainerID string) (bool, error)
 }
 
 // ItemRepoInterface defines methods needed from item repository
@@ -25,6 +27,7 @@ type ItemRepoInterface interface {
 type AchievementRepoInterface interface {
 	GetOrCreate(trainerID, achievementType string) (*storage.Achievement, error)
 	UpdateProgress(trainerID, achievementType string, progress int) error
+	SetProgress(trainerID, achievementType string, progress int) error
 	Complete(trainerID, achievementType string) error
 	GetAchievements(trainerID string) ([]*storage.Achievement, error)
 }
@@ -34,6 +37,8 @@ type QuestRepoInterface interface {
 	Create(quest *storage.Quest) error
 	GetActiveQuests(trainerID string) ([]*storage.Quest, error)
 	GetQuestByType(trainerID, questType, questName string) (*storage.Quest, error)
+	GetCurrentQuests(trainerID string) ([]*storage.Quest, error)
+	QuestExists(trainerID, questType, questName string) (bool, error)
 	UpdateProgress(questID string, progress int) error
 	Complete(questID string) error
 	CleanupExpired() error
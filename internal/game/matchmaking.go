@@ -0,0 +1,197 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Matchmaking pairs trainers whose PvP ratings are within a window of each other. The window
+// starts narrow and widens the longer a trainer waits, so nobody waits forever for a close match.
+const (
+	MatchmakingBaseWindow = 100              // Rating difference allowed as soon as a trainer queues
+	MatchmakingWindowStep = 50               // How much the window grows every MatchmakingWidenEvery
+	MatchmakingMaxWindow  = 500              // The window never grows past this
+	MatchmakingWidenEvery = 30 * time.Second // How often a waiting trainer's window grows
+	MatchmakingTimeout    = 10 * time.Minute // Trainers are taken out of the queue after this long
+)
+
+// QueueEntry is a trainer waiting for a ranked PvP match
+type QueueEntry struct {
+	TrainerID   string
+	TrainerName string
+	ChannelID   string // Channel the trainer queued from
+	Rating      int
+	JoinedAt    time.Time
+}
+
+// Window returns the rating difference the trainer accepts after waiting until now
+func (e *QueueEntry) Window(now time.Time) int {
+	steps := int(now.Sub(e.JoinedAt) / MatchmakingWidenEvery)
+	window := MatchmakingBaseWindow + steps*MatchmakingWindowStep
+	if window > MatchmakingMaxWindow {
+		window = MatchmakingMaxWindow
+	}
+	return window
+}
+
+// Match is two queued trainers paired for a battle. Trainer1 is the one who waited longer.
+type Match struct {
+	Trainer1 *QueueEntry
+	Trainer2 *QueueEntry
+}
+
+// MatchmakingQueue is the pool of trainers waiting for a ranked PvP match. It is safe for
+// concurrent use.
+type MatchmakingQueue struct {
+	mu      sync.Mutex
+	entries []*QueueEntry // Longest waiting first
+}
+
+// NewMatchmakingQueue creates an empty matchmaking queue
+func NewMatchmakingQueue() *MatchmakingQueue {
+	return &MatchmakingQueue{}
+}
+
+// Join adds a trainer to the queue
+func (q *MatchmakingQueue) Join(entry *QueueEntry) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.find(entry.TrainerID) >= 0 {
+		return fmt.Errorf("already in the matchmaking queue")
+	}
+	if entry.JoinedAt.IsZero() {
+		entry.JoinedAt = time.Now()
+	}
+	q.entries = append(q.entries, entry)
+	sort.SliceStable(q.entries, func(a, b int) bool {
+		return q.entries[a].JoinedAt.Before(q.entries[b].JoinedAt)
+	})
+	return nil
+}
+
+// Leave takes a trainer out of the queue. Returns false if they weren't queued.
+func (q *MatchmakingQueue) Leave(trainerID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	idx := q.find(trainerID)
+	if idx < 0 {
+		return false
+	}
+	q.entries = append(q.entries[:idx], q.entries[idx+1:]...)
+	return true
+}
+
+// Entry returns a copy of the trainer's queue entry, or nil if they aren't queued
+func (q *MatchmakingQueue) Entry(trainerID string) *QueueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	idx := q.find(trainerID)
+	if idx < 0 {
+		return nil
+	}
+	entry := *q.entries[idx]
+	return &entry
+}
+
+// Len returns how many trainers are waiting
+func (q *MatchmakingQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.entries)
+}
+
+// Match pairs up waiting trainers and takes them out of the queue. The longest waiting trainers
+// are matched first, each with the closest rating inside the wider of the two trainers'
+// windows. Trainers for whom available returns false, for example because they are in another
+// battle, stay queued but aren't matched this time.
+func (q *MatchmakingQueue) Match(now time.Time, available func(trainerID string) bool) []*Match {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	matched := make(map[string]bool)
+	skipped := make(map[string]bool)
+	for _, entry := range q.entries {
+		if !available(entry.TrainerID) {
+			skipped[entry.TrainerID] = true
+		}
+	}
+
+	matches := []*Match{}
+	for idx, entry := range q.entries {
+		if matched[entry.TrainerID] || skipped[entry.TrainerID] {
+			continue
+		}
+
+		var best *QueueEntry
+		bestDiff := 0
+		for _, other := range q.entries[idx+1:] {
+			if matched[other.TrainerID] || skipped[other.TrainerID] {
+				continue
+			}
+			diff := entry.Rating - other.Rating
+			if diff < 0 {
+				diff = -diff
+			}
+			window := entry.Window(now)
+			if otherWindow := other.Window(now); otherWindow > window {
+				window = otherWindow
+			}
+			if diff <= window && (best == nil || diff < bestDiff) {
+				best = other
+				bestDiff = diff
+			}
+		}
+
+		if best != nil {
+			matched[entry.TrainerID] = true
+			matched[best.TrainerID] = true
+			matches = append(matches, &Match{Trainer1: entry, Trainer2: best})
+		}
+	}
+
+	q.removeWhere(func(entry *QueueEntry) bool { return matched[entry.TrainerID] })
+	return matches
+}
+
+// Expire takes trainers who have waited longer than MatchmakingTimeout out of the queue and
+// returns them
+func (q *MatchmakingQueue) Expire(now time.Time) []*QueueEntry {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	expired := []*QueueEntry{}
+	q.removeWhere(func(entry *QueueEntry) bool {
+		if now.Sub(entry.JoinedAt) > MatchmakingTimeout {
+			expired = append(expired, entry)
+			return true
+		}
+		return false
+	})
+	return expired
+}
+
+// find returns the index of the trainer's entry, or -1. Caller must hold mu.
+func (q *MatchmakingQueue) find(trainerID string) int {
+	for idx, entry := range q.entries {
+		if entry.TrainerID == trainerID {
+			return idx
+		}
+	}
+	return -1
+}
+
+// removeWhere drops the entries remove returns true for. Caller must hold mu.
+func (q *MatchmakingQueue) removeWhere(remove func(entry *QueueEntry) bool) {
+	kept := q.entries[:0]
+	for _, entry := range q.entries {
+		if !remove(entry) {
+			kept = append(kept, entry)
+		}
+	}
+	q.entries = kept
+}
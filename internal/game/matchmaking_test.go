@@ -0,0 +1,115 @@
+package game
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQueueEntryWindow(t *testing.T) {
+	joined := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	entry := &QueueEntry{TrainerID: "a", Rating: 1000, JoinedAt: joined}
+
+	tests := []struct {
+		waited time.Duration
+		want   int
+	}{
+		{0, MatchmakingBaseWindow},
+		{MatchmakingWidenEvery - time.Second, MatchmakingBaseWindow},
+		{MatchmakingWidenEvery, MatchmakingBaseWindow + MatchmakingWindowStep},
+		{3 * MatchmakingWidenEvery, MatchmakingBaseWindow + 3*MatchmakingWindowStep},
+		{MatchmakingTimeout, MatchmakingMaxWindow},
+	}
+	for _, tt := range tests {
+		if got := entry.Window(joined.Add(tt.waited)); got != tt.want {
+			t.Errorf("Window after %s = %d, want %d", tt.waited, got, tt.want)
+		}
+	}
+}
+
+// queueAt joins trainers to a new queue, one second apart in the order given
+func queueAt(t *testing.T, start time.Time, ratings map[string]int, order ...string) *MatchmakingQueue {
+	t.Helper()
+	queue := NewMatchmakingQueue()
+	for idx, trainerID := range order {
+		err := queue.Join(&QueueEntry{TrainerID: trainerID, TrainerName: trainerID, Rating: ratings[trainerID], JoinedAt: start.Add(time.Duration(idx) * time.Second)})
+		if err != nil {
+			t.Fatalf("Join(%s): %v", trainerID, err)
+		}
+	}
+	return queue
+}
+
+func allAvailable(string) bool { return true }
+
+func TestMatchmakingQueueMatchesClosestRating(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ratings := map[string]int{"a": 1000, "b": 1090, "c": 1020, "d": 2000}
+	queue := queueAt(t, start, ratings, "a", "b", "c", "d")
+
+	matches := queue.Match(start.Add(5*time.Second), allAvailable)
+	if len(matches) != 1 {
+		t.Fatalf("got %d matches, want 1", len(matches))
+	}
+	if matches[0].Trainer1.TrainerID != "a" || matches[0].Trainer2.TrainerID != "c" {
+		t.Errorf("matched %s vs %s, want a vs c", matches[0].Trainer1.TrainerID, matches[0].Trainer2.TrainerID)
+	}
+
+	// b's closest remaining opponent is far outside the window
+	if queue.Len() != 2 || queue.Entry("b") == nil || queue.Entry("d") == nil {
+		t.Errorf("b and d should still be queued, queue has %d entries", queue.Len())
+	}
+}
+
+func TestMatchmakingQueueWidensWindow(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ratings := map[string]int{"a": 1000, "b": 1200}
+	queue := queueAt(t, start, ratings, "a", "b")
+
+	if matches := queue.Match(start.Add(time.Second), allAvailable); len(matches) != 0 {
+		t.Fatalf("matched trainers 200 apart right away")
+	}
+
+	// Two widenings make a's window 200
+	matches := queue.Match(start.Add(2*MatchmakingWidenEvery), allAvailable)
+	if len(matches) != 1 {
+		t.Fatalf("got %d matches after waiting, want 1", len(matches))
+	}
+	if queue.Len() != 0 {
+		t.Errorf("queue has %d entries after matching everyone", queue.Len())
+	}
+}
+
+func TestMatchmakingQueueSkipsUnavailable(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ratings := map[string]int{"a": 1000, "b": 1010, "c": 1050}
+	queue := queueAt(t, start, ratings, "a", "b", "c")
+
+	busy := func(trainerID string) bool { return trainerID != "b" }
+	matches := queue.Match(start.Add(5*time.Second), busy)
+	if len(matches) != 1 || matches[0].Trainer1.TrainerID != "a" || matches[0].Trainer2.TrainerID != "c" {
+		t.Fatalf("want a matched with c while b is busy, got %v", matches)
+	}
+	if queue.Entry("b") == nil {
+		t.Error("busy trainer was taken out of the queue")
+	}
+}
+
+func TestMatchmakingQueueJoinAndExpire(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	queue := queueAt(t, start, map[string]int{"a": 1000, "b": 3000}, "a", "b")
+
+	if err := queue.Join(&QueueEntry{TrainerID: "a", JoinedAt: start}); err == nil {
+		t.Error("joined the queue twice")
+	}
+
+	expired := queue.Expire(start.Add(MatchmakingTimeout + 500*time.Millisecond))
+	if len(expired) != 1 || expired[0].TrainerID != "a" {
+		t.Fatalf("want only a to expire, got %v", expired)
+	}
+	if queue.Len() != 1 {
+		t.Errorf("queue has %d entries, want 1", queue.Len())
+	}
+	if !queue.Leave("b") || queue.Leave("b") {
+		t.Error("Leave should succeed once")
+	}
+}
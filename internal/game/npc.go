@@ -0,0 +1,395 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gophermon-bot/internal/gopherkon"
+)
+
+// NPC trainers and wild areas are defined in JSON files in the data directory:
+// trainers.json holds the trainer roster and areas.json the areas /wild can visit.
+const (
+	trainersFile = "trainers.json"
+	areasFile    = "areas.json"
+)
+
+// NPCTrainer is a computer-controlled trainer with a fixed party. Trainers that award a
+// badge are gym leaders.
+type NPCTrainer struct {
+	ID             string      `json:"id"`
+	Name           string      `json:"name"`
+	Title          string      `json:"title"`                     // Shown before the name, e.g. "Gym Leader"
+	Intro          string      `json:"intro,omitempty"`           // Said when the battle starts
+	Badge          string      `json:"badge,omitempty"`           // ID of the badge awarded for winning
+	BadgeName      string      `json:"badge_name,omitempty"`      // Display name of the badge
+	RequiredBadges []string    `json:"required_badges,omitempty"` // Badges needed to challenge this trainer
+	Strategy       string      `json:"strategy,omitempty"`        // "random", "greedy" or "heuristic"
+	PrizeMoney     int         `json:"prize_money"`               // GoCoins paid to the player on a win
+	Party          []NPCGopher `json:"party"`
+}
+
+// NPCGopher is one gopher in an NPC trainer's party
+type NPCGopher struct {
+	Name          string   `json:"name"`
+	Archetype     string   `json:"archetype"`
+	Rarity        string   `json:"rarity"`
+	Level         int      `json:"level"`
+	SecondaryType string   `json:"secondary_type,omitempty"`
+	Abilities     []string `json:"abilities,omitempty"` // Ability template IDs; learned by level when empty
+}
+
+// WildArea is a place to look for wild gophers. Higher-level areas need a badge.
+type WildArea struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	MinLevel      int    `json:"min_level"`
+	MaxLevel      int    `json:"max_level"`
+	RequiredBadge string `json:"required_badge,omitempty"`
+}
+
+// Roster holds the NPC trainers and wild areas
+type Roster struct {
+	Trainers []*NPCTrainer
+	Areas    []*WildArea
+}
+
+// LoadRoster reads and validates the NPC trainers and wild areas in dir
+func LoadRoster(dir string) (*Roster, error) {
+	roster := &Roster{}
+	if err := readDataFile(filepath.Join(dir, trainersFile), &roster.Trainers); err != nil {
+		return nil, err
+	}
+	if err := readDataFile(filepath.Join(dir, areasFile), &roster.Areas); err != nil {
+		return nil, err
+	}
+	if err := roster.Validate(); err != nil {
+		return nil, err
+	}
+	return roster, nil
+}
+
+func readDataFile(path string, v interface{}) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	defer file.Close()
+
+	// Unknown fields are most likely typos, so reject them
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate checks that IDs are unique and every trainer, gopher and area refers to things that exist
+func (r *Roster) Validate() error {
+	badges := make(map[string]bool)
+	trainerIDs := make(map[string]bool)
+	for _, trainer := range r.Trainers {
+		if trainer.ID == "" || trainer.Name == "" {
+			return fmt.Errorf("every trainer needs an id and a name")
+		}
+		if trainerIDs[trainer.ID] {
+			return fmt.Errorf("duplicate trainer id %q", trainer.ID)
+		}
+		trainerIDs[trainer.ID] = true
+
+		if trainer.Badge != "" {
+			if badges[trainer.Badge] {
+				return fmt.Errorf("trainer %q: badge %q is already awarded by another trainer", trainer.ID, trainer.Badge)
+			}
+			badges[trainer.Badge] = true
+		}
+	}
+
+	validRarities := map[string]bool{"COMMON": true, "UNCOMMON": true, "RARE": true, "EPIC": true, "LEGENDARY": true}
+	validTypes := make(map[GopherType]bool)
+	for _, gopherType := range AllTypes() {
+		validTypes[gopherType] = true
+	}
+
+	for _, trainer := range r.Trainers {
+		if len(trainer.Party) == 0 || len(trainer.Party) > 6 {
+			return fmt.Errorf("trainer %q: party must have 1 to 6 gophers", trainer.ID)
+		}
+		if trainer.Strategy != "" && StrategyNamed(trainer.Strategy) == nil {
+			return fmt.Errorf("trainer %q: unknown strategy %q", trainer.ID, trainer.Strategy)
+		}
+		if trainer.PrizeMoney < 0 {
+			return fmt.Errorf("trainer %q: prize money can't be negative", trainer.ID)
+		}
+		for _, badge := range trainer.RequiredBadges {
+			if !badges[badge] {
+				return fmt.Errorf("trainer %q: no trainer awards required badge %q", trainer.ID, badge)
+			}
+		}
+
+		for slot, gopher := range trainer.Party {
+			if _, ok := archetypeEnergy[Archetype(gopher.Archetype)]; !ok {
+				return fmt.Errorf("trainer %q gopher %d: unknown archetype %q", trainer.ID, slot+1, gopher.Archetype)
+			}
+			if !validRarities[gopher.Rarity] {
+				return fmt.Errorf("trainer %q gopher %d: unknown rarity %q", trainer.ID, slot+1, gopher.Rarity)
+			}
+			if gopher.Level < 1 || gopher.Level > 100 {
+				return fmt.Errorf("trainer %q gopher %d: level must be between 1 and 100", trainer.ID, slot+1)
+			}
+			if gopher.SecondaryType != "" && !validTypes[GopherType(gopher.SecondaryType)] {
+				return fmt.Errorf("trainer %q gopher %d: unknown type %q", trainer.ID, slot+1, gopher.SecondaryType)
+			}
+			if len(gopher.Abilities) > MaxActiveAbilities {
+				return fmt.Errorf("trainer %q gopher %d: at most %d abilities", trainer.ID, slot+1, MaxActiveAbilities)
+			}
+			for _, templateID := range gopher.Abilities {
+				if _, ok := AbilityTemplates[templateID]; !ok {
+					return fmt.Errorf("trainer %q gopher %d: unknown ability %q", trainer.ID, slot+1, templateID)
+				}
+			}
+		}
+	}
+
+	areaIDs := make(map[string]bool)
+	for _, area := range r.Areas {
+		if area.ID == "" || area.Name == "" {
+			return fmt.Errorf("every area needs an id and a name")
+		}
+		if areaIDs[area.ID] {
+			return fmt.Errorf("duplicate area id %q", area.ID)
+		}
+		areaIDs[area.ID] = true
+
+		if area.MinLevel < 1 || area.MaxLevel < area.MinLevel {
+			return fmt.Errorf("area %q: invalid level range %d-%d", area.ID, area.MinLevel, area.MaxLevel)
+		}
+		if area.RequiredBadge != "" && !badges[area.RequiredBadge] {
+			return fmt.Errorf("area %q: no trainer awards required badge %q", area.ID, area.RequiredBadge)
+		}
+	}
+
+	return nil
+}
+
+// Trainer returns the NPC trainer with the given ID, or nil
+func (r *Roster) Trainer(id string) *NPCTrainer {
+	for _, trainer := range r.Trainers {
+		if trainer.ID == id {
+			return trainer
+		}
+	}
+	return nil
+}
+
+// Area returns the wild area with the given ID, or nil
+func (r *Roster) Area(id string) *WildArea {
+	for _, area := range r.Areas {
+		if area.ID == id {
+			return area
+		}
+	}
+	return nil
+}
+
+// DefaultArea returns the first area that needs no badge, where /wild goes when no area is given
+func (r *Roster) DefaultArea() *WildArea {
+	for _, area := range r.Areas {
+		if area.RequiredBadge == "" {
+			return area
+		}
+	}
+	return nil
+}
+
+// GymLeaders returns the trainers that award badges, in roster order
+func (r *Roster) GymLeaders() []*NPCTrainer {
+	leaders := []*NPCTrainer{}
+	for _, trainer := range r.Trainers {
+		if trainer.IsGymLeader() {
+			leaders = append(leaders, trainer)
+		}
+	}
+	return leaders
+}
+
+// BadgeName returns the display name of a badge
+func (r *Roster) BadgeName(badge string) string {
+	for _, trainer := range r.Trainers {
+		if trainer.Badge == badge && trainer.BadgeName != "" {
+			return trainer.BadgeName
+		}
+	}
+	return badge
+}
+
+// BadgeAwarder returns the gym leader who awards a badge, or nil
+func (r *Roster) BadgeAwarder(badge string) *NPCTrainer {
+	for _, trainer := range r.Trainers {
+		if trainer.Badge == badge {
+			return trainer
+		}
+	}
+	return nil
+}
+
+// DisplayName returns the trainer's title and name
+func (t *NPCTrainer) DisplayName() string {
+	if t.Title == "" {
+		return t.Name
+	}
+	return fmt.Sprintf("%s %s", t.Title, t.Name)
+}
+
+// IsGymLeader returns whether beating the trainer awards a badge
+func (t *NPCTrainer) IsGymLeader() bool {
+	return t.Badge != ""
+}
+
+// LevelRange returns the lowest and highest levels in the trainer's party
+func (t *NPCTrainer) LevelRange() (int, int) {
+	minLevel, maxLevel := 0, 0
+	for idx, member := range t.Party {
+		if idx == 0 || member.Level < minLevel {
+			minLevel = member.Level
+		}
+		if member.Level > maxLevel {
+			maxLevel = member.Level
+		}
+	}
+	return minLevel, maxLevel
+}
+
+// MissingBadges returns the badges needed to challenge the trainer that have not been earned
+func (t *NPCTrainer) MissingBadges(earned map[string]bool) []string {
+	missing := []string{}
+	for _, badge := range t.RequiredBadges {
+		if !earned[badge] {
+			missing = append(missing, badge)
+		}
+	}
+	return missing
+}
+
+// EnemyStrategy returns how the trainer's gophers pick moves
+func (t *NPCTrainer) EnemyStrategy() EnemyStrategy {
+	if strategy := StrategyNamed(t.Strategy); strategy != nil {
+		return strategy
+	}
+	return StrategyFor("TRAINER", "")
+}
+
+// Unlocked returns whether the area can be visited with the earned badges
+func (a *WildArea) Unlocked(earned map[string]bool) bool {
+	return a.RequiredBadge == "" || earned[a.RequiredBadge]
+}
+
+// npcGopherID returns the ID of a gopher in an NPC trainer's party. NPC gophers are never
+// stored, so the ID only has to be stable between loads.
+func npcGopherID(trainerID string, slot int) string {
+	return fmt.Sprintf("npc_%s_%d", trainerID, slot)
+}
+
+// SetRoster sets the NPC trainers and wild areas
+func (s *Service) SetRoster(roster *Roster) {
+	s.roster = roster
+}
+
+// Roster returns the NPC trainers and wild areas
+func (s *Service) Roster() *Roster {
+	return s.roster
+}
+
+// NPCParty builds an NPC trainer's party. The gophers only exist in memory and come out the
+// same every time, so a battle loaded from storage gets the same party back.
+func (s *Service) NPCParty(trainer *NPCTrainer) ([]*Gopher, error) {
+	party := []*Gopher{}
+	for slot := range trainer.Party {
+		gopher, err := s.newNPCGopher(trainer, slot)
+		if err != nil {
+			return nil, err
+		}
+		party = append(party, gopher)
+	}
+	return party, nil
+}
+
+// NPCGopher builds the NPC trainer's gopher with the given ID, or returns nil if no trainer has it
+func (s *Service) NPCGopher(id string) (*Gopher, error) {
+	for _, trainer := range s.roster.Trainers {
+		for slot := range trainer.Party {
+			if npcGopherID(trainer.ID, slot) == id {
+				return s.newNPCGopher(trainer, slot)
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (s *Service) newNPCGopher(trainer *NPCTrainer, slot int) (*Gopher, error) {
+	spec := trainer.Party[slot]
+	id := npcGopherID(trainer.ID, slot)
+	archetype := Archetype(spec.Archetype)
+
+	// Everything random about the gopher is drawn from its ID
+	rng := keyRNG(id)
+
+	hp, attack, defense, speed := GenerateBaseStats(archetype, spec.Rarity, spec.Level, rng)
+
+	name := spec.Name
+	if name == "" {
+		name = GenerateGopherName(archetype, rng)
+	}
+
+	result, err := s.generator.Generate(gopherkon.GenerateOptions{
+		TargetRarity: spec.Rarity,
+		Seed:         rng.Int63(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate sprite: %w", err)
+	}
+	spriteData, err := s.generator.EncodeImageToBase64(result.Image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode sprite: %w", err)
+	}
+
+	gopher := &Gopher{
+		ID:               id,
+		Name:             name,
+		Level:            spec.Level,
+		CurrentHP:        hp,
+		MaxHP:            hp,
+		Attack:           attack,
+		Defense:          defense,
+		Speed:            speed,
+		Rarity:           spec.Rarity,
+		ComplexityScore:  result.Complexity,
+		SpeciesArchetype: spec.Archetype,
+		PrimaryType:      GetTypeFromArchetype(archetype),
+		SecondaryType:    GopherType(spec.SecondaryType),
+		SpriteData:       spriteData,
+		GopherkonLayers:  result.Layers,
+		Abilities:        []*Ability{},
+		StatusEffects:    []*StatusEffect{},
+		BaseAttack:       attack,
+		BaseDefense:      defense,
+		BaseSpeed:        speed,
+	}
+
+	if len(spec.Abilities) == 0 {
+		LearnFreeAbilities(gopher)
+	}
+	for i, templateID := range spec.Abilities {
+		ability, err := CreateAbilityFromTemplate(templateID, fmt.Sprintf("%s_ability_%d", id, i))
+		if err != nil {
+			return nil, err
+		}
+		gopher.Abilities = append(gopher.Abilities, ability)
+	}
+
+	return gopher, nil
+}
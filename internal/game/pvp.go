@@ -3,64 +3,592 @@ package game
 import (
 	"fmt"
 	"math"
+	"time"
 )
 
+// PvP sides and states
+const (
+	PvPSideTrainer1 = "TRAINER1"
+	PvPSideTrainer2 = "TRAINER2"
+
+	PvPStatePending     = "PENDING"
+	PvPStateActive      = "ACTIVE"
+	PvPStateTrainer1Won = "TRAINER1_WON"
+	PvPStateTrainer2Won = "TRAINER2_WON"
+	PvPStateDraw        = "DRAW"
+)
+
+// PvPMaxTurns is the number of actions after which a PvP battle is declared a draw
+const PvPMaxTurns = 100
+
+// PvPChallengeTimeout is how long a challenge can stay pending before it expires
+const PvPChallengeTimeout = 5 * time.Minute
+
 // PvPBattleState represents a PvP battle between two trainers
 type PvPBattleState struct {
-	ID                string
-	ChannelID         string
-	MessageID         string
-	Trainer1ID        string
-	Trainer2ID        string
-	Trainer1Gopher    *Gopher
-	Trainer2Gopher    *Gopher
-	Trainer1Party     []*Gopher
-	Trainer2Party     []*Gopher
-	TurnOwner         string // "TRAINER1" or "TRAINER2"
-	State             string // "PENDING", "ACTIVE", "TRAINER1_WON", "TRAINER2_WON", "DRAW"
-	Log               []string
-	EventManager      *EventManager
-}
-
-// NewPvPBattleState creates a new PvP battle state
-func NewPvPBattleState(channelID string, trainer1ID, trainer2ID string, 
-	trainer1Gopher, trainer2Gopher *Gopher, trainer1Party, trainer2Party []*Gopher,
-	eventManager *EventManager) *PvPBattleState {
-	
+	ID             string
+	ChannelID      string
+	MessageID      string
+	Trainer1ID     string
+	Trainer2ID     string
+	Trainer1Name   string
+	Trainer2Name   string
+	Trainer1Gopher *Gopher
+	Trainer2Gopher *Gopher
+	Trainer1Party  []*Gopher
+	Trainer2Party  []*Gopher
+	TurnOwner      string   // "TRAINER1" or "TRAINER2"
+	State          string   // "PENDING", "ACTIVE", "TRAINER1_WON", "TRAINER2_WON", "DRAW"
+	Double         bool     // A pending challenge to a double battle, which starts as a DoubleBattleState
+	ForcedSwaps    []string // Sides that must send in a replacement before play continues
+	NextTurn       string   // Whose turn it is once forced swaps are resolved
+	Turn           int      // Number of actions taken so far
+	Log            []string
+	Events         []BattleEvent // Everything that happened, for replays
+	EventManager   *EventManager
+	Field          *Field         // Battlefield condition, set when the battle starts
+	EnergyPools    map[string]int // Energy left per gopher ID; gophers not listed have a full pool
+	Seed           int64          // Seed of the battle's random source
+	CreatedAt      time.Time
+	rng            *RNG
+}
+
+// NewPvPChallenge creates a pending PvP battle waiting for the challenged trainer to accept
+func NewPvPChallenge(channelID, trainer1ID, trainer2ID, trainer1Name, trainer2Name string, eventManager *EventManager, seed int64) *PvPBattleState {
 	return &PvPBattleState{
-		ChannelID:      channelID,
-		Trainer1ID:     trainer1ID,
-		Trainer2ID:     trainer2ID,
-		Trainer1Gopher: trainer1Gopher,
-		Trainer2Gopher: trainer2Gopher,
-		Trainer1Party:  trainer1Party,
-		Trainer2Party:  trainer2Party,
-		TurnOwner:      "TRAINER1", // Trainer who initiated goes first
-		State:          "ACTIVE",
-		Log:            []string{fmt.Sprintf("Battle between trainers started!")},
-		EventManager:   eventManager,
+		ChannelID:    channelID,
+		Trainer1ID:   trainer1ID,
+		Trainer2ID:   trainer2ID,
+		Trainer1Name: trainer1Name,
+		Trainer2Name: trainer2Name,
+		TurnOwner:    PvPSideTrainer1,
+		State:        PvPStatePending,
+		EventManager: eventManager,
+		Seed:         seed,
+		CreatedAt:    time.Now(),
 	}
 }
 
+// IsExpired reports whether a pending challenge has timed out
+func (pvp *PvPBattleState) IsExpired() bool {
+	return pvp.State == PvPStatePending && time.Since(pvp.CreatedAt) > PvPChallengeTimeout
+}
+
+// Accept starts a pending battle with both trainers' parties.
+// PvP battles are fought on copies at full health, so gophers in storage are never affected.
+func (pvp *PvPBattleState) Accept(trainer1Party, trainer2Party []*Gopher) error {
+	if pvp.State != PvPStatePending {
+		return fmt.Errorf("challenge is no longer pending")
+	}
+
+	pvp.Trainer1Party = preparePvPParty(trainer1Party)
+	pvp.Trainer2Party = preparePvPParty(trainer2Party)
+	if len(pvp.Trainer1Party) == 0 || len(pvp.Trainer2Party) == 0 {
+		return fmt.Errorf("both trainers need at least one gopher")
+	}
+
+	pvp.Trainer1Gopher = pvp.Trainer1Party[0]
+	pvp.Trainer2Gopher = pvp.Trainer2Party[0]
+	pvp.TurnOwner = PvPSideTrainer1
+	pvp.State = PvPStateActive
+	pvp.EnergyPools = make(map[string]int)
+	pvp.Log = []string{
+		fmt.Sprintf("%s accepted the challenge!", pvp.Trainer2Name),
+		fmt.Sprintf("%s sent out %s! %s sent out %s!", pvp.Trainer1Name, pvp.Trainer1Gopher.Name, pvp.Trainer2Name, pvp.Trainer2Gopher.Name),
+	}
+	pvp.Field = startingField(pvp.EventManager, pvp.Rand(), false)
+	if message := pvp.Field.StartMessage(); message != "" {
+		pvp.Log = append(pvp.Log, message)
+	}
+	pvp.record(BattleEvent{Type: BattleEventStart, Messages: pvp.Log})
+	return nil
+}
+
+// preparePvPParty restores every gopher to full health with no lingering status effects
+func preparePvPParty(party []*Gopher) []*Gopher {
+	prepared := []*Gopher{}
+	for _, gopher := range party {
+		if gopher == nil {
+			continue
+		}
+		gopher.StatusEffects = []*StatusEffect{}
+		gopher.RecalculateStats()
+		gopher.CurrentHP = gopher.MaxHP
+		prepared = append(prepared, gopher)
+	}
+	return prepared
+}
+
+// SideOf returns the side a trainer is fighting on, or "" if they are not part of the battle
+func (pvp *PvPBattleState) SideOf(trainerID string) string {
+	switch trainerID {
+	case pvp.Trainer1ID:
+		return PvPSideTrainer1
+	case pvp.Trainer2ID:
+		return PvPSideTrainer2
+	}
+	return ""
+}
+
+// TrainerIDFor returns the trainer ID for a side
+func (pvp *PvPBattleState) TrainerIDFor(side string) string {
+	if side == PvPSideTrainer1 {
+		return pvp.Trainer1ID
+	}
+	return pvp.Trainer2ID
+}
+
+// TrainerNameFor returns the trainer display name for a side
+func (pvp *PvPBattleState) TrainerNameFor(side string) string {
+	if side == PvPSideTrainer1 {
+		return pvp.Trainer1Name
+	}
+	return pvp.Trainer2Name
+}
+
+// ActiveGopher returns the gopher currently in battle for a side
+func (pvp *PvPBattleState) ActiveGopher(side string) *Gopher {
+	if side == PvPSideTrainer1 {
+		return pvp.Trainer1Gopher
+	}
+	return pvp.Trainer2Gopher
+}
+
+// Party returns the full party for a side
+func (pvp *PvPBattleState) Party(side string) []*Gopher {
+	if side == PvPSideTrainer1 {
+		return pvp.Trainer1Party
+	}
+	return pvp.Trainer2Party
+}
+
+func (pvp *PvPBattleState) setActiveGopher(side string, gopher *Gopher) {
+	if side == PvPSideTrainer1 {
+		pvp.Trainer1Gopher = gopher
+	} else {
+		pvp.Trainer2Gopher = gopher
+	}
+}
+
+// opposingSide returns the other side of the battle
+func opposingSide(side string) string {
+	if side == PvPSideTrainer1 {
+		return PvPSideTrainer2
+	}
+	return PvPSideTrainer1
+}
+
+// NeedsForcedSwap reports whether the current turn owner must replace a fainted gopher
+func (pvp *PvPBattleState) NeedsForcedSwap() bool {
+	return len(pvp.ForcedSwaps) > 0
+}
+
+// IsOver reports whether the battle has finished
+func (pvp *PvPBattleState) IsOver() bool {
+	return pvp.State == PvPStateTrainer1Won || pvp.State == PvPStateTrainer2Won || pvp.State == PvPStateDraw
+}
+
+// WinnerSide returns the winning side, or "" for a draw or unfinished battle
+func (pvp *PvPBattleState) WinnerSide() string {
+	switch pvp.State {
+	case PvPStateTrainer1Won:
+		return PvPSideTrainer1
+	case PvPStateTrainer2Won:
+		return PvPSideTrainer2
+	}
+	return ""
+}
+
+// Action executes an action for a trainer. Valid actions are "fight" (index = ability),
+// "rest", "swap" (index = party member) and "forfeit".
+func (pvp *PvPBattleState) Action(trainerID, action string, index int) ([]string, error) {
+	if pvp.State != PvPStateActive {
+		return []string{"Battle is already over!"}, nil
+	}
+
+	side := pvp.SideOf(trainerID)
+	if side == "" {
+		return []string{"You're not part of this battle!"}, nil
+	}
+
+	if action == "forfeit" {
+		messages := pvp.forfeit(side)
+		pvp.Log = append(pvp.Log, messages...)
+		return messages, nil
+	}
+
+	if side != pvp.TurnOwner {
+		return []string{"It's not your turn!"}, nil
+	}
+
+	// A fainted gopher has to be replaced before anything else can happen
+	if pvp.NeedsForcedSwap() {
+		if action != "swap" {
+			return []string{"You must send in another gopher first!"}, nil
+		}
+		messages, ok := pvp.swap(side, index)
+		if !ok {
+			return messages, nil
+		}
+		pvp.record(BattleEvent{Type: BattleEventSwap, Side: side, Gopher: pvp.ActiveGopher(side).Name, Messages: messages})
+		pvp.ForcedSwaps = pvp.ForcedSwaps[1:]
+		if len(pvp.ForcedSwaps) > 0 {
+			pvp.TurnOwner = pvp.ForcedSwaps[0]
+		} else {
+			pvp.TurnOwner = pvp.NextTurn
+		}
+		pvp.Log = append(pvp.Log, messages...)
+		return messages, nil
+	}
+
+	// Validate the requested action before any turn effects are applied
+	user := pvp.ActiveGopher(side)
+	switch action {
+	case "fight":
+		if index < 0 || index >= len(user.Abilities) {
+			return []string{"Invalid ability!"}, nil
+		}
+		if ability := user.Abilities[index]; !pvp.CanAfford(user, ability) {
+			return []string{fmt.Sprintf("Not enough energy! %s needs %d ⚡ but %s only has %d.",
+				ability.Name, ability.Cost, user.Name, pvp.Energy(user))}, nil
+		}
+	case "rest":
+	case "swap":
+		if msg := pvp.validateSwap(side, index); msg != "" {
+			return []string{msg}, nil
+		}
+	default:
+		return []string{"Unknown action!"}, nil
+	}
+
+	messages := []string{}
+	pvp.Turn++
+
+	// Process status effects at start of turn
+	hpBefore := user.CurrentHP
+	statusMsgs := user.ProcessStatusEffects()
+	messages = append(messages, statusMsgs...)
+	pvp.recordStatus(side, user, hpBefore-user.CurrentHP, statusMsgs)
+
+	if user.CurrentHP > 0 {
+		hpBefore = user.CurrentHP
+		acted, turnMsgs := checkCanAct(pvp.Rand(), user, action)
+		messages = append(messages, turnMsgs...)
+		pvp.recordStatus(side, user, hpBefore-user.CurrentHP, turnMsgs)
+
+		if acted {
+			switch action {
+			case "fight":
+				messages = append(messages, pvp.useAbility(side, index)...)
+			case "rest":
+				messages = append(messages, pvp.rest(side)...)
+			case "swap":
+				swapMsgs, _ := pvp.swap(side, index)
+				messages = append(messages, swapMsgs...)
+				pvp.record(BattleEvent{Type: BattleEventSwap, Side: side, Gopher: pvp.ActiveGopher(side).Name, Messages: swapMsgs})
+			}
+		}
+	}
+
+	// Energy refills and the field condition acts once both trainers have moved
+	if pvp.Turn%2 == 0 {
+		pvp.regenerateEnergy()
+		fieldMsgs := pvp.Field.endRound(pvp.Trainer1Gopher, pvp.Trainer2Gopher)
+		if len(fieldMsgs) > 0 {
+			messages = append(messages, fieldMsgs...)
+			pvp.record(BattleEvent{Type: BattleEventField, Messages: fieldMsgs})
+		}
+	}
+
+	messages = append(messages, pvp.resolveTurn(opposingSide(side))...)
+	pvp.recordResult(messages)
+	pvp.Log = append(pvp.Log, messages...)
+	return messages, nil
+}
+
+// checkCanAct applies sleep, paralysis and confusion checks before a gopher acts
+func checkCanAct(rng *RNG, user *Gopher, action string) (bool, []string) {
+	if user.HasStatusEffect(StatusSleep) {
+		if rng.Float64() < 0.3 { // 30% chance to wake up
+			user.RemoveStatusEffect(StatusSleep)
+			return true, []string{fmt.Sprintf("%s woke up!", user.Name)}
+		}
+		return false, []string{fmt.Sprintf("%s is fast asleep!", user.Name)}
+	}
+
+	if user.HasStatusEffect(StatusParalysis) && rng.Float64() < 0.25 {
+		return false, []string{fmt.Sprintf("%s is paralyzed! It can't move!", user.Name)}
+	}
+
+	if action == "fight" && user.HasStatusEffect(StatusConfusion) && rng.Float64() < 0.33 {
+		damage := user.MaxHP / 8
+		user.CurrentHP -= damage
+		if user.CurrentHP < 0 {
+			user.CurrentHP = 0
+		}
+		return false, []string{fmt.Sprintf("%s is confused! It hurt itself in confusion for %d damage!", user.Name, damage)}
+	}
+
+	return true, nil
+}
+
+// useAbility executes an ability from the side's active gopher against the opposing gopher
+func (pvp *PvPBattleState) useAbility(side string, index int) []string {
+	user := pvp.ActiveGopher(side)
+	target := pvp.ActiveGopher(opposingSide(side))
+	ability := user.Abilities[index]
+	event := BattleEvent{Type: BattleEventAbility, Side: side, Gopher: user.Name, Target: target.Name, Ability: ability.Name}
+	pvp.setEnergy(user, pvp.Energy(user)-ability.Cost)
+
+	// Protection is consumed when the protected gopher attacks
+	if user.HasStatusEffect(StatusProtect) {
+		user.RemoveStatusEffect(StatusProtect)
+	}
+
+	if target.HasStatusEffect(StatusProtect) && ability.Targeting != TargetingSelf {
+		target.RemoveStatusEffect(StatusProtect)
+		event.Messages = []string{fmt.Sprintf("%s was protected from the attack!", target.Name)}
+		pvp.record(event)
+		return event.Messages
+	}
+
+	hpBefore := target.CurrentHP
+	msgs, err := ability.EffectFunc(pvp.effectState(side), user, target)
+	if err != nil {
+		msgs = []string{fmt.Sprintf("%s's %s failed!", user.Name, ability.Name)}
+	}
+
+	if hpBefore > target.CurrentHP {
+		event.Damage = hpBefore - target.CurrentHP
+	}
+	if ability.Targeting != TargetingSelf && ability.Power > 0 {
+		event.Effectiveness = typeEffectiveness(user, target)
+	}
+	event.Messages = msgs
+	pvp.record(event)
+	return msgs
+}
+
+// rest skips the side's attack to recover energy on top of the usual regeneration
+func (pvp *PvPBattleState) rest(side string) []string {
+	user := pvp.ActiveGopher(side)
+	recovered := EnergyRegen(user)
+	pvp.setEnergy(user, pvp.Energy(user)+recovered)
+	messages := []string{fmt.Sprintf("%s is resting and recovered %d ⚡!", user.Name, recovered)}
+	pvp.record(BattleEvent{Type: BattleEventRest, Side: side, Gopher: user.Name, Messages: messages})
+	return messages
+}
+
+// Energy returns a gopher's current energy in this battle
+func (pvp *PvPBattleState) Energy(gopher *Gopher) int {
+	return pvp.effectState(PvPSideTrainer1).Energy(gopher)
+}
+
+// CanAfford returns whether the gopher has enough energy to use the ability
+func (pvp *PvPBattleState) CanAfford(gopher *Gopher, ability *Ability) bool {
+	return pvp.Energy(gopher) >= ability.Cost
+}
+
+// setEnergy stores a gopher's energy, clamped to its pool
+func (pvp *PvPBattleState) setEnergy(gopher *Gopher, energy int) {
+	pvp.effectState(PvPSideTrainer1).setEnergy(gopher, energy)
+}
+
+// regenerateEnergy refills both active gophers' energy at the end of a round
+func (pvp *PvPBattleState) regenerateEnergy() {
+	for _, side := range []string{PvPSideTrainer1, PvPSideTrainer2} {
+		gopher := pvp.ActiveGopher(side)
+		pvp.setEnergy(gopher, pvp.Energy(gopher)+EnergyRegen(gopher))
+	}
+}
+
+// Rand returns the battle's random source, creating it from Seed on first use
+func (pvp *PvPBattleState) Rand() *RNG {
+	if pvp.rng == nil {
+		pvp.rng = NewRNG(pvp.Seed)
+	}
+	return pvp.rng
+}
+
+// effectState builds the battle context ability effects run against, seen from the acting
+// side. Energy is shared with the PvP battle.
+func (pvp *PvPBattleState) effectState(side string) *BattleState {
+	if pvp.EnergyPools == nil {
+		pvp.EnergyPools = make(map[string]int)
+	}
+	return &BattleState{
+		ID:           pvp.ID,
+		ChannelID:    pvp.ChannelID,
+		TrainerID:    pvp.TrainerIDFor(side),
+		OpponentType: "PVP",
+		PlayerGopher: pvp.ActiveGopher(side),
+		EnemyGopher:  pvp.ActiveGopher(opposingSide(side)),
+		PlayerParty:  pvp.Party(side),
+		TurnOwner:    "PLAYER",
+		State:        "ACTIVE",
+		EventManager: pvp.EventManager,
+		Field:        pvp.Field,
+		EnergyPools:  pvp.EnergyPools,
+		Seed:         pvp.Seed,
+		rng:          pvp.Rand(),
+	}
+}
+
+// validateSwap returns an error message if the side can't swap to the given party member
+func (pvp *PvPBattleState) validateSwap(side string, index int) string {
+	party := pvp.Party(side)
+	if index < 0 || index >= len(party) {
+		return "Invalid party member!"
+	}
+	newGopher := party[index]
+	if newGopher.CurrentHP <= 0 {
+		return fmt.Sprintf("%s is fainted and can't battle!", newGopher.Name)
+	}
+	if newGopher.ID == pvp.ActiveGopher(side).ID {
+		return "That gopher is already in battle!"
+	}
+	return ""
+}
+
+// swap switches the side's active gopher. Returns false if the swap was invalid.
+func (pvp *PvPBattleState) swap(side string, index int) ([]string, bool) {
+	if msg := pvp.validateSwap(side, index); msg != "" {
+		return []string{msg}, false
+	}
+
+	oldGopher := pvp.ActiveGopher(side)
+	newGopher := pvp.Party(side)[index]
+	pvp.setActiveGopher(side, newGopher)
+
+	messages := []string{}
+	if oldGopher.CurrentHP > 0 {
+		messages = append(messages, fmt.Sprintf("%s, come back!", oldGopher.Name))
+	}
+	messages = append(messages, fmt.Sprintf("%s sent out %s!", pvp.TrainerNameFor(side), newGopher.Name))
+	return messages, true
+}
+
+// hasRemainingGophers checks if a side has any gopher that can still fight
+func (pvp *PvPBattleState) hasRemainingGophers(side string) bool {
+	for _, gopher := range pvp.Party(side) {
+		if gopher.CurrentHP > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveTurn handles fainting, forced swaps and win conditions after an action, then
+// passes the turn to nextTurn
+func (pvp *PvPBattleState) resolveTurn(nextTurn string) []string {
+	messages := []string{}
+
+	for _, side := range []string{PvPSideTrainer1, PvPSideTrainer2} {
+		if pvp.ActiveGopher(side).CurrentHP <= 0 {
+			messages = append(messages, fmt.Sprintf("%s's %s fainted!", pvp.TrainerNameFor(side), pvp.ActiveGopher(side).Name))
+			pvp.record(BattleEvent{Type: BattleEventFaint, Side: side, Gopher: pvp.ActiveGopher(side).Name, Messages: messages[len(messages)-1:]})
+		}
+	}
+
+	trainer1Out := !pvp.hasRemainingGophers(PvPSideTrainer1)
+	trainer2Out := !pvp.hasRemainingGophers(PvPSideTrainer2)
+
+	switch {
+	case trainer1Out && trainer2Out:
+		pvp.State = PvPStateDraw
+		messages = append(messages, "Both trainers are out of gophers! It's a draw!")
+		return messages
+	case trainer1Out:
+		pvp.State = PvPStateTrainer2Won
+		messages = append(messages, fmt.Sprintf("%s wins the battle! 🏆", pvp.Trainer2Name))
+		return messages
+	case trainer2Out:
+		pvp.State = PvPStateTrainer1Won
+		messages = append(messages, fmt.Sprintf("%s wins the battle! 🏆", pvp.Trainer1Name))
+		return messages
+	}
+
+	if pvp.Turn >= PvPMaxTurns {
+		pvp.State = PvPStateDraw
+		messages = append(messages, fmt.Sprintf("The battle reached %d turns and ended in a draw!", PvPMaxTurns))
+		return messages
+	}
+
+	// Every side whose active gopher fainted must choose a replacement
+	pvp.ForcedSwaps = nil
+	for _, side := range []string{nextTurn, opposingSide(nextTurn)} {
+		if pvp.ActiveGopher(side).CurrentHP <= 0 {
+			pvp.ForcedSwaps = append(pvp.ForcedSwaps, side)
+		}
+	}
+
+	pvp.NextTurn = nextTurn
+	if len(pvp.ForcedSwaps) > 0 {
+		pvp.TurnOwner = pvp.ForcedSwaps[0]
+	} else {
+		pvp.TurnOwner = nextTurn
+	}
+
+	return messages
+}
+
+// forfeit ends the battle with the other side as the winner
+func (pvp *PvPBattleState) forfeit(side string) []string {
+	if side == PvPSideTrainer1 {
+		pvp.State = PvPStateTrainer2Won
+	} else {
+		pvp.State = PvPStateTrainer1Won
+	}
+	winner := opposingSide(side)
+	messages := []string{
+		fmt.Sprintf("%s forfeited the battle!", pvp.TrainerNameFor(side)),
+		fmt.Sprintf("%s wins the battle! 🏆", pvp.TrainerNameFor(winner)),
+	}
+	pvp.recordResult(messages)
+	return messages
+}
+
+// recordStatus records messages about a gopher's status conditions, if there are any
+func (pvp *PvPBattleState) recordStatus(side string, gopher *Gopher, damage int, messages []string) {
+	if len(messages) == 0 {
+		return
+	}
+	pvp.record(BattleEvent{Type: BattleEventStatus, Side: side, Gopher: gopher.Name, Damage: damage, Messages: messages})
+}
+
+// recordResult records how the battle ended, once it is over. The result is the last message.
+func (pvp *PvPBattleState) recordResult(messages []string) {
+	if !pvp.IsOver() || len(messages) == 0 {
+		return
+	}
+	pvp.record(BattleEvent{Type: BattleEventResult, Side: pvp.WinnerSide(), Success: pvp.WinnerSide() != "",
+		Messages: messages[len(messages)-1:]})
+}
+
 // CalculateELO calculates new ELO ratings after a battle
 func CalculateELO(rating1, rating2 int, player1Won bool) (newRating1, newRating2 int) {
+	if player1Won {
+		return calculateELOWithScore(rating1, rating2, 1.0)
+	}
+	return calculateELOWithScore(rating1, rating2, 0.0)
+}
+
+// CalculateELODraw calculates new ELO ratings after a drawn battle
+func CalculateELODraw(rating1, rating2 int) (newRating1, newRating2 int) {
+	return calculateELOWithScore(rating1, rating2, 0.5)
+}
+
+// calculateELOWithScore applies the ELO formula for player 1's score (1 = win, 0.5 = draw, 0 = loss)
+func calculateELOWithScore(rating1, rating2 int, score1 float64) (newRating1, newRating2 int) {
 	k := 32 // K-factor
-	
+
 	expected1 := 1.0 / (1.0 + math.Pow(10.0, float64(rating2-rating1)/400.0))
 	expected2 := 1.0 - expected1
-	
-	var score1, score2 float64
-	if player1Won {
-		score1 = 1.0
-		score2 = 0.0
-	} else {
-		score1 = 0.0
-		score2 = 1.0
-	}
-	
+
+	score2 := 1.0 - score1
+
 	newRating1 = rating1 + int(float64(k)*(score1-expected1))
 	newRating2 = rating2 + int(float64(k)*(score2-expected2))
-	
+
 	return newRating1, newRating2
 }
-
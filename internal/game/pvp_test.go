@@ -0,0 +1,69 @@
+package game
+
+import "testing"
+
+// newTestPvP starts a PvP battle between two single-gopher parties generated from seed
+func newTestPvP(t *testing.T, seed int64) *PvPBattleState {
+	t.Helper()
+	rng := NewRNG(seed)
+	gopher1 := NewSimGopher("gopher1", SimGopherSpec{Archetype: ArchetypeMage, Rarity: "RARE", Level: 30}, rng)
+	gopher2 := NewSimGopher("gopher2", SimGopherSpec{Archetype: ArchetypeTank, Rarity: "RARE", Level: 30}, rng)
+	pvp := NewPvPChallenge("channel", "trainer1", "trainer2", "One", "Two", nil, seed)
+	if err := pvp.Accept([]*Gopher{gopher1}, []*Gopher{gopher2}); err != nil {
+		t.Fatal(err)
+	}
+	return pvp
+}
+
+func TestPvPAbilitiesSpendEnergy(t *testing.T) {
+	pvp := newTestPvP(t, 5)
+	user := pvp.Trainer1Gopher
+	ability := user.Abilities[0]
+	if ability.Cost == 0 {
+		t.Fatalf("%s costs no energy; pick a different first move", ability.Name)
+	}
+
+	pvp.setEnergy(user, ability.Cost-1)
+	if _, err := pvp.Action("trainer1", "fight", 0); err != nil {
+		t.Fatal(err)
+	}
+	if pvp.Turn != 0 || pvp.TurnOwner != PvPSideTrainer1 {
+		t.Fatal("an unaffordable ability used up the turn")
+	}
+
+	pvp.setEnergy(user, ability.Cost)
+	if _, err := pvp.Action("trainer1", "fight", 0); err != nil {
+		t.Fatal(err)
+	}
+	if energy := pvp.Energy(user); energy != 0 {
+		t.Errorf("energy after using %s = %d, want 0", ability.Name, energy)
+	}
+}
+
+func TestPvPRestAndRegeneration(t *testing.T) {
+	pvp := newTestPvP(t, 5)
+	gopher1, gopher2 := pvp.Trainer1Gopher, pvp.Trainer2Gopher
+	pvp.setEnergy(gopher1, 0)
+	pvp.setEnergy(gopher2, 0)
+
+	if _, err := pvp.Action("trainer1", "rest", -1); err != nil {
+		t.Fatal(err)
+	}
+	if energy := pvp.Energy(gopher1); energy != EnergyRegen(gopher1) {
+		t.Errorf("energy after resting = %d, want %d", energy, EnergyRegen(gopher1))
+	}
+	if energy := pvp.Energy(gopher2); energy != 0 {
+		t.Errorf("energy refilled before the round ended: %d", energy)
+	}
+
+	// Both gophers regenerate once the second trainer has moved
+	if _, err := pvp.Action("trainer2", "rest", -1); err != nil {
+		t.Fatal(err)
+	}
+	if energy := pvp.Energy(gopher1); energy != 2*EnergyRegen(gopher1) {
+		t.Errorf("trainer 1's energy after the round = %d, want %d", energy, 2*EnergyRegen(gopher1))
+	}
+	if energy := pvp.Energy(gopher2); energy != 2*EnergyRegen(gopher2) {
+		t.Errorf("trainer 2's energy after the round = %d, want %d", energy, 2*EnergyRegen(gopher2))
+	}
+}
@@ -33,85 +33,128 @@ func NewQuestService(questRepo QuestRepoInterface, trainerRepo TrainerRepoInterf
 	}
 }
 
+// questTemplate describes a quest that gets generated for every trainer
+type questTemplate struct {
+	Name        string
+	Description string
+	Target      int
+	Reward      int
+	RewardXP    int
+}
+
+var dailyQuests = []questTemplate{
+	{QuestWinBattles, "Win 3 battles today", 3, 100, 50},
+	{QuestCatchGophers, "Catch 5 gophers today", 5, 150, 75},
+	{QuestEvolveGopher, "Evolve 1 gopher today", 1, 200, 100},
+}
+
+var weeklyQuests = []questTemplate{
+	{QuestWin10Battles, "Win 10 battles this week", 10, 500, 250},
+	{QuestCatch20Gophers, "Catch 20 gophers this week", 20, 600, 300},
+	{QuestCatchShiny, "Catch a shiny gopher this week", 1, 1000, 500},
+}
+
 func (s *QuestService) GenerateDailyQuests(trainerID string) error {
 	now := time.Now()
 	tomorrow := now.Add(24 * time.Hour)
 	// Set to midnight tomorrow
 	tomorrow = time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 0, 0, 0, 0, tomorrow.Location())
 
-	quests := []struct {
-		Name        string
-		Description string
-		Target      int
-		Reward      int
-	}{
-		{QuestWinBattles, "Win 3 battles today", 3, 100},
-		{QuestCatchGophers, "Catch 5 gophers today", 5, 150},
-		{QuestEvolveGopher, "Evolve 1 gopher today", 1, 200},
+	return s.generateQuests(trainerID, QuestTypeDaily, tomorrow, dailyQuests)
+}
+
+func (s *QuestService) GenerateWeeklyQuests(trainerID string) error {
+	now := time.Now()
+	// Weeks start on Monday
+	daysUntilMonday := (8 - int(now.Weekday())) % 7
+	if daysUntilMonday == 0 {
+		daysUntilMonday = 7
+	}
+	nextMonday := now.AddDate(0, 0, daysUntilMonday)
+	nextMonday = time.Date(nextMonday.Year(), nextMonday.Month(), nextMonday.Day(), 0, 0, 0, 0, nextMonday.Location())
+
+	return s.generateQuests(trainerID, QuestTypeWeekly, nextMonday, weeklyQuests)
+}
+
+// EnsureQuests lazily creates this day's and week's quests if the trainer doesn't have them yet
+func (s *QuestService) EnsureQuests(trainerID string) error {
+	if err := s.GenerateDailyQuests(trainerID); err != nil {
+		return err
 	}
+	return s.GenerateWeeklyQuests(trainerID)
+}
 
-		for _, q := range quests {
-		// Check if quest already exists
-		existing, _ := s.questRepo.GetQuestByType(trainerID, QuestTypeDaily, q.Name)
-		if existing != nil {
-			continue // Quest already exists
+func (s *QuestService) generateQuests(trainerID, questType string, expiresAt time.Time, templates []questTemplate) error {
+	for _, q := range templates {
+		// Skip quests that already exist for this period, including completed ones
+		exists, err := s.questRepo.QuestExists(trainerID, questType, q.Name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
 		}
 
-		// Create quest
 		quest := &storage.Quest{
-			TrainerID:      trainerID,
-			QuestType:      QuestTypeDaily,
-			QuestName:      q.Name,
-			Description:    q.Description,
-			TargetValue:    q.Target,
+			TrainerID:       trainerID,
+			QuestType:       questType,
+			QuestName:       q.Name,
+			Description:     q.Description,
+			TargetValue:     q.Target,
 			CurrentProgress: 0,
-			RewardCurrency: q.Reward,
-			RewardXP:      0,
-			Completed:     false,
-			ExpiresAt:     tomorrow,
+			RewardCurrency:  q.Reward,
+			RewardXP:        q.RewardXP,
+			Completed:       false,
+			ExpiresAt:       expiresAt,
 		}
 		if err := s.questRepo.Create(quest); err != nil {
-			// Log error but continue with other quests
-			// Return error would prevent other quests from being created
-			// In production, you might want to collect errors and return them
-			continue
+			return err
 		}
 	}
 
 	return nil
 }
 
-func (s *QuestService) UpdateQuestProgress(trainerID, questName string, progress int) error {
-	// Try daily first
-	quest, err := s.questRepo.GetQuestByType(trainerID, QuestTypeDaily, questName)
-	if err == nil && quest != nil {
-		s.questRepo.UpdateProgress(quest.ID, progress)
-		// Check completion
-		if quest.CurrentProgress+progress >= quest.TargetValue && !quest.Completed {
-			s.questRepo.Complete(quest.ID)
-			// Give rewards
-			if quest.RewardCurrency > 0 {
-				s.trainerRepo.AddCurrency(trainerID, quest.RewardCurrency)
-			}
+// GetCurrentQuests returns this period's quests, including completed ones
+func (s *QuestService) GetCurrentQuests(trainerID string) ([]*storage.Quest, error) {
+	return s.questRepo.GetCurrentQuests(trainerID)
+}
+
+// UpdateQuestProgress adds progress to the trainer's active quest with this name and pays the
+// currency reward when it completes. It returns the quest if this update completed it; the caller
+// is responsible for awarding the quest's RewardXP.
+func (s *QuestService) UpdateQuestProgress(trainerID, questName string, progress int) (*storage.Quest, error) {
+	for _, questType := range []string{QuestTypeDaily, QuestTypeWeekly} {
+		quest, err := s.questRepo.GetQuestByType(trainerID, questType, questName)
+		if err != nil {
+			return nil, err
+		}
+		if quest == nil {
+			continue
 		}
-		return nil
-	}
 
-	// Try weekly
-	quest, err = s.questRepo.GetQuestByType(trainerID, QuestTypeWeekly, questName)
-	if err == nil && quest != nil {
-		s.questRepo.UpdateProgress(quest.ID, progress)
+		if err := s.questRepo.UpdateProgress(quest.ID, progress); err != nil {
+			return nil, err
+		}
+		quest.CurrentProgress += progress
+
 		// Check completion
-		if quest.CurrentProgress+progress >= quest.TargetValue && !quest.Completed {
-			s.questRepo.Complete(quest.ID)
-			// Give rewards
-			if quest.RewardCurrency > 0 {
-				s.trainerRepo.AddCurrency(trainerID, quest.RewardCurrency)
+		if quest.CurrentProgress < quest.TargetValue {
+			return nil, nil
+		}
+		if err := s.questRepo.Complete(quest.ID); err != nil {
+			return nil, err
+		}
+		quest.Completed = true
+
+		// Give rewards
+		if quest.RewardCurrency > 0 {
+			if err := s.trainerRepo.AddCurrency(trainerID, quest.RewardCurrency); err != nil {
+				return quest, err
 			}
 		}
-		return nil
+		return quest, nil
 	}
 
-	return nil
+	return nil, nil
 }
-
@@ -0,0 +1,647 @@
+package game
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Raid states
+const (
+	RaidStateOpen    = "OPEN" // Waiting for trainers to join
+	RaidStateActive  = "ACTIVE"
+	RaidStateWon     = "WON"
+	RaidStateLost    = "LOST"    // Every gopher fainted or the boss outlasted the raid
+	RaidStateExpired = "EXPIRED" // Nobody started the raid before the lobby closed, or everyone left
+)
+
+const (
+	// RaidMaxParticipants is how many trainers can join a raid, with one gopher each
+	RaidMaxParticipants = 4
+
+	// RaidJoinWindow is how long a raid lobby stays open before it expires
+	RaidJoinWindow = 10 * time.Minute
+
+	// RaidRoundTimeout is how long a round waits for every participant to choose a move.
+	// After that the next choice resolves the round and anyone who hasn't chosen misses out.
+	RaidRoundTimeout = time.Minute
+
+	// RaidAbandonTimeout is how long a round can go unresolved before the raid is given up on
+	RaidAbandonTimeout = 10 * time.Minute
+
+	// RaidMaxRounds is how many rounds the raid has to beat the boss before it escapes
+	RaidMaxRounds = 30
+
+	// RaidHPPerParticipant is how many times its normal max HP the boss has per participant
+	RaidHPPerParticipant = 1
+
+	// RaidDefaultLevel is the boss's level when none is given
+	RaidDefaultLevel = 25
+
+	// A legendary's attack and defense would make it untouchable for most parties, so raid
+	// bosses trade them for their large HP pool
+	RaidBossAttackScale  = 0.25
+	RaidBossDefenseScale = 0.15
+)
+
+// Raid rewards for a beaten boss, per participant and boss level. The pools are split by
+// damage dealt.
+const (
+	RaidCurrencyPerLevel = 30
+	RaidXPPerLevel       = 20
+	RaidMinRewardShare   = 0.25 // Anyone who hit the boss gets at least this much of an even split
+)
+
+// Participant choices besides an ability index
+const (
+	RaidChoiceNone = -1 // Nothing chosen yet this round
+	RaidChoiceRest = -2 // Skip the attack to recover energy
+)
+
+// RaidMVPItem is awarded to the participant who dealt the most damage
+const RaidMVPItem = ItemTypeEvolutionStone
+
+// RaidBossAbilities are the legendary abilities a raid boss fights with
+var RaidBossAbilities = []string{"legendary_strike", "apocalypse", "god_mode", "divine_heal", "time_rewind"}
+
+// RaidParticipant is a trainer fighting in a raid
+type RaidParticipant struct {
+	TrainerID   string
+	TrainerName string
+	Gopher      *Gopher
+	Damage      int // Total damage dealt to the boss
+	Choice      int // Ability chosen for the current round, or RaidChoiceNone / RaidChoiceRest
+}
+
+// RaidBattleState is a cooperative battle between a raid boss and up to RaidMaxParticipants
+// trainers. Each round every participant chooses a move, the moves resolve in priority
+// then speed order, then the boss strikes back at random participants.
+type RaidBattleState struct {
+	ID           string
+	ChannelID    string
+	MessageID    string
+	Boss         *Gopher
+	Participants []*RaidParticipant
+	State        string // "OPEN", "ACTIVE", "WON", "LOST", "EXPIRED"
+	Round        int
+	RoundStarted time.Time
+	BossHealed   bool   // The boss can only heal itself once
+	Field        *Field // Battlefield condition, set when the raid starts
+	Log          []string
+	EventManager *EventManager
+	EnergyPools  map[string]int // Energy left per gopher ID; gophers not listed have a full pool
+	Seed         int64          // Seed of the raid's random source
+	CreatedAt    time.Time
+	rng          *RNG
+}
+
+// RaidReward is what a participant earns from a won raid
+type RaidReward struct {
+	TrainerID string
+	GopherID  string
+	Damage    int
+	Currency  int
+	XP        int
+	Item      string // RaidMVPItem for the top damage dealer, "" otherwise
+}
+
+// NewRaidBattleState opens a raid lobby for a boss
+func NewRaidBattleState(channelID string, boss *Gopher, eventManager *EventManager, seed int64) *RaidBattleState {
+	return &RaidBattleState{
+		ChannelID:    channelID,
+		Boss:         boss,
+		Participants: []*RaidParticipant{},
+		State:        RaidStateOpen,
+		Log:          []string{fmt.Sprintf("A raid boss appeared: %s (Lv.%d)!", boss.Name, boss.Level)},
+		EventManager: eventManager,
+		EnergyPools:  make(map[string]int),
+		Seed:         seed,
+		CreatedAt:    time.Now(),
+		Field:        &Field{},
+	}
+}
+
+// IsExpired reports whether the lobby closed before the raid was started, or the raid was
+// abandoned mid-round
+func (raid *RaidBattleState) IsExpired() bool {
+	switch raid.State {
+	case RaidStateOpen:
+		return time.Since(raid.CreatedAt) > RaidJoinWindow
+	case RaidStateActive:
+		return time.Since(raid.RoundStarted) > RaidAbandonTimeout
+	}
+	return false
+}
+
+// Expire ends a raid that IsExpired
+func (raid *RaidBattleState) Expire() {
+	raid.State = RaidStateExpired
+	raid.Log = append(raid.Log, fmt.Sprintf("%s wandered off...", raid.Boss.Name))
+}
+
+// IsOver reports whether the raid has finished
+func (raid *RaidBattleState) IsOver() bool {
+	return raid.State == RaidStateWon || raid.State == RaidStateLost || raid.State == RaidStateExpired
+}
+
+// Participant returns the trainer's participant, or nil if they haven't joined
+func (raid *RaidBattleState) Participant(trainerID string) *RaidParticipant {
+	for _, participant := range raid.Participants {
+		if participant.TrainerID == trainerID {
+			return participant
+		}
+	}
+	return nil
+}
+
+// Join adds a trainer to an open raid. Raids are fought on a copy of the gopher at full
+// health, so the gopher in storage is never affected.
+func (raid *RaidBattleState) Join(trainerID, trainerName string, gopher *Gopher) error {
+	switch {
+	case raid.State != RaidStateOpen:
+		return fmt.Errorf("the raid has already started")
+	case raid.Participant(trainerID) != nil:
+		return fmt.Errorf("you've already joined this raid")
+	case len(raid.Participants) >= RaidMaxParticipants:
+		return fmt.Errorf("the raid is full")
+	}
+
+	prepared := preparePvPParty([]*Gopher{gopher})
+	if len(prepared) == 0 {
+		return fmt.Errorf("you need a gopher to join")
+	}
+
+	raid.Participants = append(raid.Participants, &RaidParticipant{
+		TrainerID:   trainerID,
+		TrainerName: trainerName,
+		Gopher:      prepared[0],
+		Choice:      RaidChoiceNone,
+	})
+	raid.Log = append(raid.Log, fmt.Sprintf("%s joined the raid with %s!", trainerName, gopher.Name))
+	return nil
+}
+
+// IsFull reports whether no more trainers can join
+func (raid *RaidBattleState) IsFull() bool {
+	return len(raid.Participants) >= RaidMaxParticipants
+}
+
+// Start closes the lobby, scales the boss's HP to the number of participants and begins
+// the first round
+func (raid *RaidBattleState) Start() error {
+	if raid.State != RaidStateOpen {
+		return fmt.Errorf("the raid has already started")
+	}
+	if len(raid.Participants) == 0 {
+		return fmt.Errorf("nobody has joined the raid")
+	}
+
+	raid.Boss.MaxHP *= RaidHPPerParticipant * len(raid.Participants)
+	raid.Boss.CurrentHP = raid.Boss.MaxHP
+	raid.State = RaidStateActive
+	raid.Round = 1
+	raid.RoundStarted = time.Now()
+	raid.Log = append(raid.Log, fmt.Sprintf("The raid begins! %s has %d HP!", raid.Boss.Name, raid.Boss.MaxHP))
+	raid.Field = startingField(raid.EventManager, raid.Rand(), false)
+	if message := raid.Field.StartMessage(); message != "" {
+		raid.Log = append(raid.Log, message)
+	}
+	return nil
+}
+
+// RoundTimedOut reports whether the current round has waited long enough for moves
+func (raid *RaidBattleState) RoundTimedOut() bool {
+	return raid.State == RaidStateActive && time.Since(raid.RoundStarted) > RaidRoundTimeout
+}
+
+// CheckChoice returns why the trainer can't choose the ability this round, or "" if they can.
+// RaidChoiceRest is always allowed.
+func (raid *RaidBattleState) CheckChoice(trainerID string, index int) string {
+	if raid.State != RaidStateActive {
+		return "This raid isn't active!"
+	}
+	participant := raid.Participant(trainerID)
+	if participant == nil {
+		return "You're not part of this raid!"
+	}
+	if participant.Gopher.CurrentHP <= 0 {
+		return fmt.Sprintf("%s has fainted and can't fight!", participant.Gopher.Name)
+	}
+	if participant.Choice != RaidChoiceNone {
+		if raid.RoundTimedOut() {
+			return ""
+		}
+		return "You've already chosen a move this round! Waiting for the others..."
+	}
+	if index == RaidChoiceRest {
+		return ""
+	}
+	if index < 0 || index >= len(participant.Gopher.Abilities) {
+		return "Invalid ability!"
+	}
+	user := participant.Gopher
+	if ability := user.Abilities[index]; !raid.CanAfford(user, ability) {
+		return fmt.Sprintf("Not enough energy! %s needs %d ⚡ but %s only has %d.",
+			ability.Name, ability.Cost, user.Name, raid.Energy(user))
+	}
+	return ""
+}
+
+// Choose records the trainer's move for the round. Once every participant who can still
+// fight has chosen, or the round has timed out, the round resolves and its messages are
+// returned; until then it returns nil. CheckChoice must pass first.
+func (raid *RaidBattleState) Choose(trainerID string, index int) []string {
+	participant := raid.Participant(trainerID)
+	if participant.Choice == RaidChoiceNone {
+		participant.Choice = index
+	}
+
+	if !raid.RoundTimedOut() {
+		for _, other := range raid.Participants {
+			if other.Gopher.CurrentHP > 0 && other.Choice == RaidChoiceNone {
+				return nil
+			}
+		}
+	}
+	return raid.resolveRound()
+}
+
+// resolveRound plays out the participants' moves in priority then speed order, then the
+// boss's turn
+func (raid *RaidBattleState) resolveRound() []string {
+	boss := raid.Boss
+	messages := []string{fmt.Sprintf("⚔️ Round %d", raid.Round)}
+
+	for _, participant := range raid.turnOrder() {
+		if boss.CurrentHP <= 0 {
+			break
+		}
+		if participant.Gopher.CurrentHP > 0 {
+			messages = append(messages, raid.participantTurn(participant)...)
+		}
+	}
+
+	if boss.CurrentHP > 0 {
+		messages = append(messages, raid.bossTurn()...)
+	}
+
+	gophers := []*Gopher{boss}
+	for _, participant := range raid.Participants {
+		gophers = append(gophers, participant.Gopher)
+	}
+	messages = append(messages, raid.Field.endRound(gophers...)...)
+	raid.regenerateEnergy()
+
+	switch {
+	case boss.CurrentHP <= 0:
+		raid.State = RaidStateWon
+		messages = append(messages, fmt.Sprintf("%s was defeated! The raid is won! 🏆", boss.Name))
+	case len(raid.aliveParticipants()) == 0:
+		raid.State = RaidStateLost
+		messages = append(messages, fmt.Sprintf("Every gopher fainted! %s was too strong...", boss.Name))
+	case raid.Round >= RaidMaxRounds:
+		raid.State = RaidStateLost
+		messages = append(messages, fmt.Sprintf("%s escaped after %d rounds!", boss.Name, RaidMaxRounds))
+	default:
+		raid.Round++
+		raid.RoundStarted = time.Now()
+	}
+
+	for _, participant := range raid.Participants {
+		participant.Choice = RaidChoiceNone
+	}
+	raid.Log = append(raid.Log, messages...)
+	return messages
+}
+
+// turnOrder returns the participants in the order their moves resolve: priority moves
+// first, then faster gophers, with ties going to whoever joined first
+func (raid *RaidBattleState) turnOrder() []*RaidParticipant {
+	order := append([]*RaidParticipant{}, raid.Participants...)
+	sort.SliceStable(order, func(a, b int) bool {
+		if pa, pb := order[a].choicePriority(), order[b].choicePriority(); pa != pb {
+			return pa > pb
+		}
+		return TurnSpeed(order[a].Gopher, raid.Field) > TurnSpeed(order[b].Gopher, raid.Field)
+	})
+	return order
+}
+
+// participantTurn runs a participant's status effects and chosen move against the boss
+func (raid *RaidBattleState) participantTurn(participant *RaidParticipant) []string {
+	user, boss := participant.Gopher, raid.Boss
+	messages := user.ProcessStatusEffects()
+	if user.CurrentHP <= 0 {
+		return append(messages, fmt.Sprintf("%s's %s fainted!", participant.TrainerName, user.Name))
+	}
+
+	action := "fight"
+	switch participant.Choice {
+	case RaidChoiceNone:
+		return append(messages, fmt.Sprintf("%s didn't choose a move in time!", user.Name))
+	case RaidChoiceRest:
+		action = "rest"
+	}
+
+	acted, turnMsgs := checkCanAct(raid.Rand(), user, action)
+	messages = append(messages, turnMsgs...)
+	if user.CurrentHP <= 0 {
+		return append(messages, fmt.Sprintf("%s's %s fainted!", participant.TrainerName, user.Name))
+	}
+	if !acted {
+		return messages
+	}
+	if action == "rest" {
+		return append(messages, raid.rest(user)...)
+	}
+
+	// Protection is consumed when the protected gopher attacks
+	if user.HasStatusEffect(StatusProtect) {
+		user.RemoveStatusEffect(StatusProtect)
+	}
+
+	ability := user.Abilities[participant.Choice]
+	raid.setEnergy(user, raid.Energy(user)-ability.Cost)
+	hpBefore := boss.CurrentHP
+	msgs, err := ability.EffectFunc(raid.effectState(user, boss), user, boss)
+	if err != nil {
+		msgs = []string{fmt.Sprintf("%s's %s failed!", user.Name, ability.Name)}
+	}
+	if boss.CurrentHP < 0 {
+		boss.CurrentHP = 0
+	}
+	if hpBefore > boss.CurrentHP {
+		participant.Damage += hpBefore - boss.CurrentHP
+	}
+	return append(messages, msgs...)
+}
+
+// bossTurn has the boss strike random participants: once for every two participants still
+// standing, rounded up. The boss rests instead once it can't afford any of its moves.
+func (raid *RaidBattleState) bossTurn() []string {
+	boss := raid.Boss
+	messages := boss.ProcessStatusEffects()
+	if boss.CurrentHP <= 0 {
+		return messages
+	}
+
+	acted, turnMsgs := checkCanAct(raid.Rand(), boss, "fight")
+	messages = append(messages, turnMsgs...)
+	if !acted || boss.CurrentHP <= 0 {
+		return messages
+	}
+
+	attacks := (len(raid.aliveParticipants()) + 1) / 2
+	for n := 0; n < attacks && boss.CurrentHP > 0; n++ {
+		targets := raid.aliveParticipants()
+		if len(targets) == 0 {
+			break
+		}
+		if len(raid.bossOptions()) == 0 {
+			messages = append(messages, raid.rest(boss)...)
+			break
+		}
+		target := targets[raid.Rand().Intn(len(targets))]
+		messages = append(messages, raid.bossAttack(target)...)
+	}
+	return messages
+}
+
+// bossOptions returns the moves the boss can use: those it can afford, without its heal once
+// it has healed
+func (raid *RaidBattleState) bossOptions() []*Ability {
+	options := []*Ability{}
+	for _, ability := range raid.Boss.Abilities {
+		if raid.CanAfford(raid.Boss, ability) && (!raid.BossHealed || !healingAbility(ability)) {
+			options = append(options, ability)
+		}
+	}
+	return options
+}
+
+// bossAttack has the boss use its best affordable move against a participant
+func (raid *RaidBattleState) bossAttack(participant *RaidParticipant) []string {
+	boss, target := raid.Boss, participant.Gopher
+	state := raid.effectState(target, boss)
+
+	options := raid.bossOptions()
+	if len(options) == 0 {
+		return nil
+	}
+	ability := HeuristicStrategy{}.ChooseAbility(state, options)
+	if healingAbility(ability) {
+		raid.BossHealed = true
+	}
+	raid.setEnergy(boss, raid.Energy(boss)-ability.Cost)
+
+	if target.HasStatusEffect(StatusProtect) && ability.Targeting != TargetingSelf {
+		target.RemoveStatusEffect(StatusProtect)
+		return []string{fmt.Sprintf("%s was protected from %s's attack!", target.Name, boss.Name)}
+	}
+
+	messages, err := ability.EffectFunc(state, boss, target)
+	if err != nil {
+		messages = []string{fmt.Sprintf("%s's %s failed!", boss.Name, ability.Name)}
+	}
+	if boss.CurrentHP < 0 {
+		boss.CurrentHP = 0
+	}
+	if target.CurrentHP <= 0 {
+		target.CurrentHP = 0
+		messages = append(messages, fmt.Sprintf("%s's %s fainted!", participant.TrainerName, target.Name))
+	}
+	return messages
+}
+
+// choicePriority returns the priority of the participant's choice this round
+func (participant *RaidParticipant) choicePriority() int {
+	if participant.Choice >= 0 && participant.Choice < len(participant.Gopher.Abilities) {
+		return participant.Gopher.Abilities[participant.Choice].Priority
+	}
+	return 0
+}
+
+// aliveParticipants returns the participants whose gopher can still fight
+func (raid *RaidBattleState) aliveParticipants() []*RaidParticipant {
+	alive := []*RaidParticipant{}
+	for _, participant := range raid.Participants {
+		if participant.Gopher.CurrentHP > 0 {
+			alive = append(alive, participant)
+		}
+	}
+	return alive
+}
+
+// rest skips the gopher's attack to recover energy on top of the usual regeneration
+func (raid *RaidBattleState) rest(gopher *Gopher) []string {
+	recovered := EnergyRegen(gopher)
+	raid.setEnergy(gopher, raid.Energy(gopher)+recovered)
+	return []string{fmt.Sprintf("%s is resting and recovered %d ⚡!", gopher.Name, recovered)}
+}
+
+// Energy returns a gopher's current energy in this raid
+func (raid *RaidBattleState) Energy(gopher *Gopher) int {
+	return raid.effectState(gopher, raid.Boss).Energy(gopher)
+}
+
+// CanAfford returns whether the gopher has enough energy to use the ability
+func (raid *RaidBattleState) CanAfford(gopher *Gopher, ability *Ability) bool {
+	return raid.Energy(gopher) >= ability.Cost
+}
+
+// setEnergy stores a gopher's energy, clamped to its pool
+func (raid *RaidBattleState) setEnergy(gopher *Gopher, energy int) {
+	raid.effectState(gopher, raid.Boss).setEnergy(gopher, energy)
+}
+
+// regenerateEnergy refills every standing gopher's energy at the end of a round. The boss
+// recovers once per participant, like its HP is scaled, so it can keep up with the party.
+func (raid *RaidBattleState) regenerateEnergy() {
+	for _, participant := range raid.aliveParticipants() {
+		gopher := participant.Gopher
+		raid.setEnergy(gopher, raid.Energy(gopher)+EnergyRegen(gopher))
+	}
+	if boss := raid.Boss; boss.CurrentHP > 0 {
+		raid.setEnergy(boss, raid.Energy(boss)+EnergyRegen(boss)*len(raid.Participants))
+	}
+}
+
+// Rand returns the raid's random source, creating it from Seed on first use
+func (raid *RaidBattleState) Rand() *RNG {
+	if raid.rng == nil {
+		raid.rng = NewRNG(raid.Seed)
+	}
+	return raid.rng
+}
+
+// effectState builds the battle context abilities and the boss's strategy run against.
+// The enemy is the gopher choosing a move. Energy is shared with the raid.
+func (raid *RaidBattleState) effectState(player, enemy *Gopher) *BattleState {
+	if raid.EnergyPools == nil {
+		raid.EnergyPools = make(map[string]int)
+	}
+	return &BattleState{
+		ID:           raid.ID,
+		ChannelID:    raid.ChannelID,
+		OpponentType: "PVE_BOSS",
+		PlayerGopher: player,
+		EnemyGopher:  enemy,
+		TurnOwner:    "PLAYER",
+		State:        "ACTIVE",
+		EventManager: raid.EventManager,
+		EnergyPools:  raid.EnergyPools,
+		Seed:         raid.Seed,
+		Field:        raid.Field,
+		rng:          raid.Rand(),
+	}
+}
+
+// Rewards splits a won raid's currency and XP by the share of damage each participant
+// dealt, and gives the top damage dealer RaidMVPItem. Participants who dealt no damage get
+// nothing. Shares raised to the minimum are rescaled with the rest so the payout never
+// exceeds the pools.
+func (raid *RaidBattleState) Rewards() []*RaidReward {
+	if raid.State != RaidStateWon {
+		return nil
+	}
+
+	totalDamage := 0
+	var mvp *RaidParticipant
+	for _, participant := range raid.Participants {
+		totalDamage += participant.Damage
+		if participant.Damage > 0 && (mvp == nil || participant.Damage > mvp.Damage) {
+			mvp = participant
+		}
+	}
+	if totalDamage == 0 {
+		return nil
+	}
+
+	count := len(raid.Participants)
+	currencyPool := float64(raid.Boss.Level * RaidCurrencyPerLevel * count)
+	xpPool := float64(raid.Boss.Level * RaidXPPerLevel * count)
+	if raid.EventManager != nil {
+		xpPool *= raid.EventManager.GetXPMultiplier()
+	}
+
+	shares := make(map[*RaidParticipant]float64)
+	totalShare := 0.0
+	for _, participant := range raid.Participants {
+		if participant.Damage == 0 {
+			continue
+		}
+		share := float64(participant.Damage) / float64(totalDamage)
+		if minShare := RaidMinRewardShare / float64(count); share < minShare {
+			share = minShare
+		}
+		shares[participant] = share
+		totalShare += share
+	}
+
+	rewards := []*RaidReward{}
+	for _, participant := range raid.Participants {
+		if participant.Damage == 0 {
+			continue
+		}
+
+		share := shares[participant] / totalShare
+		reward := &RaidReward{
+			TrainerID: participant.TrainerID,
+			GopherID:  participant.Gopher.ID,
+			Damage:    participant.Damage,
+			Currency:  int(currencyPool * share),
+			XP:        int(xpPool * share),
+		}
+		if participant == mvp {
+			reward.Item = RaidMVPItem
+		}
+		rewards = append(rewards, reward)
+	}
+	return rewards
+}
+
+// GenerateRaidBoss creates a legendary raid boss that fights with RaidBossAbilities.
+// Bosses only exist for the raid and are never stored.
+func (s *Service) GenerateRaidBoss(id string, level int) (*Gopher, error) {
+	spec, err := s.generateGopher(RarityLegendary, 0, false, level, level)
+	if err != nil {
+		return nil, err
+	}
+
+	attack := int(float64(spec.Attack) * RaidBossAttackScale)
+	defense := int(float64(spec.Defense) * RaidBossDefenseScale)
+
+	boss := &Gopher{
+		ID:               id,
+		Name:             spec.Name,
+		Level:            spec.Level,
+		CurrentHP:        spec.MaxHP,
+		MaxHP:            spec.MaxHP,
+		Attack:           attack,
+		Defense:          defense,
+		Speed:            spec.Speed,
+		Rarity:           spec.Rarity,
+		ComplexityScore:  spec.ComplexityScore,
+		SpeciesArchetype: spec.SpeciesArchetype,
+		PrimaryType:      GopherType(spec.PrimaryType),
+		SecondaryType:    GopherType(spec.SecondaryType),
+		SpriteData:       spec.SpriteData,
+		GopherkonLayers:  spec.GopherkonLayers,
+		Shiny:            spec.Shiny,
+		Abilities:        []*Ability{},
+		StatusEffects:    []*StatusEffect{},
+		BaseAttack:       attack,
+		BaseDefense:      defense,
+		BaseSpeed:        spec.Speed,
+	}
+
+	for i, templateID := range RaidBossAbilities {
+		ability, err := CreateAbilityFromTemplate(templateID, fmt.Sprintf("%s_ability_%d", id, i))
+		if err != nil {
+			return nil, err
+		}
+		boss.Abilities = append(boss.Abilities, ability)
+	}
+
+	return boss, nil
+}
@@ -0,0 +1,182 @@
+package game
+
+import (
+	"fmt"
+	"testing"
+)
+
+// newTestRaid builds a raid against a level 10 boss with one participant per damage total
+func newTestRaid(t *testing.T, state string, damage ...int) *RaidBattleState {
+	t.Helper()
+	rng := NewRNG(1)
+	boss := NewSimGopher("boss", SimGopherSpec{Archetype: ArchetypeTank, Rarity: "LEGENDARY", Level: 10}, rng)
+	raid := NewRaidBattleState("channel", boss, nil, 1)
+	for idx, dealt := range damage {
+		gopher := NewSimGopher(fmt.Sprintf("gopher%d", idx), SimGopherSpec{Archetype: ArchetypeHacker, Rarity: "COMMON", Level: 10}, rng)
+		raid.Participants = append(raid.Participants, &RaidParticipant{
+			TrainerID: fmt.Sprintf("trainer%d", idx),
+			Gopher:    gopher,
+			Damage:    dealt,
+			Choice:    RaidChoiceNone,
+		})
+	}
+	raid.State = state
+	return raid
+}
+
+func TestRaidRewardsOnlyForWonRaids(t *testing.T) {
+	for _, state := range []string{RaidStateOpen, RaidStateActive, RaidStateLost, RaidStateExpired} {
+		if rewards := newTestRaid(t, state, 100, 50).Rewards(); rewards != nil {
+			t.Errorf("%s raid gave %d rewards", state, len(rewards))
+		}
+	}
+	if rewards := newTestRaid(t, RaidStateWon, 0, 0).Rewards(); rewards != nil {
+		t.Errorf("raid nobody damaged gave %d rewards", len(rewards))
+	}
+}
+
+func TestRaidRewardsSplitByDamage(t *testing.T) {
+	raid := newTestRaid(t, RaidStateWon, 900, 90, 10, 0)
+	rewards := raid.Rewards()
+	if len(rewards) != 3 {
+		t.Fatalf("got %d rewards, want 3; participants who dealt no damage get nothing", len(rewards))
+	}
+
+	count := len(raid.Participants)
+	currencyPool := raid.Boss.Level * RaidCurrencyPerLevel * count
+	xpPool := raid.Boss.Level * RaidXPPerLevel * count
+
+	totalCurrency, totalXP := 0, 0
+	for idx, reward := range rewards {
+		totalCurrency += reward.Currency
+		totalXP += reward.XP
+		if idx > 0 && reward.Currency > rewards[idx-1].Currency {
+			t.Errorf("%s dealt less damage but earned more than %s", reward.TrainerID, rewards[idx-1].TrainerID)
+		}
+		if wantItem := idx == 0; (reward.Item == RaidMVPItem) != wantItem {
+			t.Errorf("%s got item %q", reward.TrainerID, reward.Item)
+		}
+	}
+	if totalCurrency > currencyPool || totalXP > xpPool {
+		t.Errorf("paid %d currency and %d XP from pools of %d and %d", totalCurrency, totalXP, currencyPool, xpPool)
+	}
+	// Rounding down loses at most one point per reward
+	if totalCurrency < currencyPool-len(rewards) {
+		t.Errorf("paid %d currency from a pool of %d", totalCurrency, currencyPool)
+	}
+
+	// The smallest contribution is raised above its share of the damage
+	if damageShare := currencyPool * 10 / 1000; rewards[2].Currency <= damageShare {
+		t.Errorf("minimum share not applied: got %d, damage share is %d", rewards[2].Currency, damageShare)
+	}
+}
+
+func TestRaidRewardsEvenSplit(t *testing.T) {
+	rewards := newTestRaid(t, RaidStateWon, 100, 100).Rewards()
+	if len(rewards) != 2 {
+		t.Fatalf("got %d rewards, want 2", len(rewards))
+	}
+	if rewards[0].Currency != rewards[1].Currency || rewards[0].XP != rewards[1].XP {
+		t.Errorf("equal damage should earn equal rewards, got %+v and %+v", rewards[0], rewards[1])
+	}
+	// Ties go to the first participant to reach the top damage
+	if rewards[0].Item != RaidMVPItem || rewards[1].Item != "" {
+		t.Errorf("want only the first participant to get the MVP item, got %q and %q", rewards[0].Item, rewards[1].Item)
+	}
+}
+
+func TestRaidChoicesSpendEnergy(t *testing.T) {
+	raid := newTestRaid(t, RaidStateActive, 0)
+	participant := raid.Participants[0]
+	user := participant.Gopher
+	ability := user.Abilities[0]
+	if ability.Cost == 0 {
+		t.Fatalf("%s costs no energy; pick a different first move", ability.Name)
+	}
+
+	raid.setEnergy(user, ability.Cost-1)
+	if reason := raid.CheckChoice("trainer0", 0); reason == "" {
+		t.Fatal("an unaffordable ability was accepted")
+	}
+	if reason := raid.CheckChoice("trainer0", RaidChoiceRest); reason != "" {
+		t.Fatalf("resting was rejected: %s", reason)
+	}
+
+	raid.setEnergy(user, ability.Cost)
+	if reason := raid.CheckChoice("trainer0", 0); reason != "" {
+		t.Fatalf("an affordable ability was rejected: %s", reason)
+	}
+	participant.Choice = 0
+	raid.participantTurn(participant)
+	if energy := raid.Energy(user); energy != 0 {
+		t.Errorf("energy after using %s = %d, want 0", ability.Name, energy)
+	}
+
+	raid.regenerateEnergy()
+	if energy := raid.Energy(user); energy != EnergyRegen(user) {
+		t.Errorf("energy after the round = %d, want %d", energy, EnergyRegen(user))
+	}
+
+	participant.Choice = RaidChoiceRest
+	raid.participantTurn(participant)
+	if energy := raid.Energy(user); energy != 2*EnergyRegen(user) {
+		t.Errorf("energy after resting = %d, want %d", energy, 2*EnergyRegen(user))
+	}
+}
+
+func TestRaidBossRestsWithoutEnergy(t *testing.T) {
+	raid := newTestRaid(t, RaidStateActive, 0, 0)
+	boss := raid.Boss
+	hp := []int{raid.Participants[0].Gopher.CurrentHP, raid.Participants[1].Gopher.CurrentHP}
+
+	raid.setEnergy(boss, 0)
+	raid.bossTurn()
+	for idx, participant := range raid.Participants {
+		if participant.Gopher.CurrentHP != hp[idx] {
+			t.Errorf("the boss attacked %s without the energy to", participant.Gopher.Name)
+		}
+	}
+	if energy := raid.Energy(boss); energy != EnergyRegen(boss) {
+		t.Errorf("boss energy after resting = %d, want %d", energy, EnergyRegen(boss))
+	}
+
+	// The boss's regeneration scales with the number of participants
+	raid.setEnergy(boss, 0)
+	raid.regenerateEnergy()
+	if energy := raid.Energy(boss); energy != 2*EnergyRegen(boss) {
+		t.Errorf("boss energy after the round = %d, want %d", energy, 2*EnergyRegen(boss))
+	}
+}
+
+func TestRaidTurnOrder(t *testing.T) {
+	raid := newTestRaid(t, RaidStateActive, 0, 0, 0)
+	slow, fast, rested := raid.Participants[0], raid.Participants[1], raid.Participants[2]
+	slow.Gopher.Speed, fast.Gopher.Speed, rested.Gopher.Speed = 10, 100, 50
+
+	quickHit, goPanic := indexOfAbility(t, slow.Gopher, "Quick Hit"), indexOfAbility(t, slow.Gopher, "Go Panic()")
+	fast.Choice, rested.Choice = goPanic, RaidChoiceRest
+
+	// Without priority the faster gophers go first
+	slow.Choice = goPanic
+	if order := raid.turnOrder(); order[0] != fast || order[1] != rested || order[2] != slow {
+		t.Errorf("speed order = %s, %s, %s", order[0].TrainerID, order[1].TrainerID, order[2].TrainerID)
+	}
+
+	// quick_hit beats speed
+	slow.Choice = quickHit
+	if order := raid.turnOrder(); order[0] != slow {
+		t.Errorf("%s moved before quick_hit", order[0].TrainerID)
+	}
+}
+
+// indexOfAbility returns the index of the gopher's ability with the given name
+func indexOfAbility(t *testing.T, gopher *Gopher, name string) int {
+	t.Helper()
+	for idx, ability := range gopher.Abilities {
+		if ability.Name == name {
+			return idx
+		}
+	}
+	t.Fatalf("%s has no %s", gopher.Name, name)
+	return -1
+}
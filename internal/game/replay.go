@@ -0,0 +1,144 @@
+package game
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BattleEventType identifies what happened in a battle event
+type BattleEventType string
+
+const (
+	BattleEventStart   BattleEventType = "START"
+	BattleEventAbility BattleEventType = "ABILITY"
+	BattleEventStatus  BattleEventType = "STATUS" // Status ticks, sleep, paralysis and confusion
+	BattleEventRest    BattleEventType = "REST"
+	BattleEventSwap    BattleEventType = "SWAP"
+	BattleEventItem    BattleEventType = "ITEM"
+	BattleEventFaint   BattleEventType = "FAINT"
+	BattleEventCapture BattleEventType = "CAPTURE"
+	BattleEventRun     BattleEventType = "RUN"
+	BattleEventField   BattleEventType = "FIELD" // Field conditions acting at the end of a round
+	BattleEventResult  BattleEventType = "RESULT"
+)
+
+// BattleEvent is one step of a battle, recorded so the battle can be replayed
+type BattleEvent struct {
+	Turn          int
+	Type          BattleEventType
+	Side          string  // Acting side: "PLAYER" or "ENEMY" in wild battles, TRAINER1 or TRAINER2 in PvP
+	Gopher        string  // Name of the acting gopher
+	Target        string  // Name of the targeted gopher
+	Ability       string  // Name of the ability used
+	Damage        int     // HP lost by the target, or by the gopher itself for status damage
+	Effectiveness float64 // Type effectiveness of a damaging ability, 0 otherwise
+	Success       bool    // Whether a capture or escape worked, or the battle was won
+	Messages      []string
+	Bottom        ReplayGopher // Player's (or trainer 1's) active gopher after the event
+	Top           ReplayGopher // Enemy's (or trainer 2's) active gopher after the event
+}
+
+// ReplayGopher is an active gopher as shown on the battle card
+type ReplayGopher struct {
+	ID    string
+	Name  string
+	Level int
+	HP    int
+	MaxHP int
+}
+
+func replayGopher(gopher *Gopher) ReplayGopher {
+	if gopher == nil {
+		return ReplayGopher{}
+	}
+	return ReplayGopher{
+		ID:    gopher.ID,
+		Name:  gopher.Name,
+		Level: gopher.Level,
+		HP:    gopher.CurrentHP,
+		MaxHP: gopher.MaxHP,
+	}
+}
+
+// ReplayTurns groups a battle's events by turn, in order
+func ReplayTurns(events []BattleEvent) [][]BattleEvent {
+	turns := [][]BattleEvent{}
+	for _, event := range events {
+		if len(turns) == 0 || turns[len(turns)-1][0].Turn != event.Turn {
+			turns = append(turns, []BattleEvent{})
+		}
+		turns[len(turns)-1] = append(turns[len(turns)-1], event)
+	}
+	return turns
+}
+
+// battleEventIcons mark each kind of event in a replay
+var battleEventIcons = map[BattleEventType]string{
+	BattleEventStart:   "⚔️",
+	BattleEventAbility: "💥",
+	BattleEventStatus:  "🌀",
+	BattleEventRest:    "💤",
+	BattleEventSwap:    "🔄",
+	BattleEventItem:    "🎒",
+	BattleEventFaint:   "💀",
+	BattleEventCapture: "🥅",
+	BattleEventRun:     "🏃",
+	BattleEventField:   "🌦️",
+	BattleEventResult:  "🏁",
+}
+
+// Describe returns the event as a line of a replay
+func (event BattleEvent) Describe() string {
+	return fmt.Sprintf("%s %s", battleEventIcons[event.Type], strings.Join(event.Messages, " "))
+}
+
+// record adds an event to the battle's replay, stamped with the turn and active gophers
+func (bs *BattleState) record(event BattleEvent) {
+	event.Turn = bs.Turn
+	event.Bottom = replayGopher(bs.PlayerGopher)
+	event.Top = replayGopher(bs.EnemyGopher)
+	bs.Events = append(bs.Events, event)
+}
+
+// recordStatus records messages about a gopher's status conditions, if there are any
+func (bs *BattleState) recordStatus(side string, gopher *Gopher, damage int, messages ...string) {
+	if len(messages) == 0 {
+		return
+	}
+	bs.record(BattleEvent{Type: BattleEventStatus, Side: side, Gopher: gopher.Name, Damage: damage, Messages: messages})
+}
+
+// recordResult records how the battle ended, once it has
+func (bs *BattleState) recordResult() {
+	if bs.State == "ACTIVE" {
+		return
+	}
+	if n := len(bs.Events); n > 0 && bs.Events[n-1].Type == BattleEventResult {
+		return
+	}
+
+	var result string
+	switch {
+	case bs.Captured:
+		result = fmt.Sprintf("Captured %s!", bs.EnemyGopher.Name)
+	case bs.State == "WON" && bs.Opponent != nil:
+		result = fmt.Sprintf("Defeated %s!", bs.Opponent.DisplayName())
+	case bs.State == "WON":
+		result = fmt.Sprintf("Defeated %s!", bs.EnemyGopher.Name)
+	case bs.State == "LOST":
+		result = "All gophers were defeated!"
+	case bs.State == "ESCAPED":
+		result = "Got away safely!"
+	default:
+		result = bs.State
+	}
+	bs.record(BattleEvent{Type: BattleEventResult, Side: "PLAYER", Success: bs.State == "WON", Messages: []string{result}})
+}
+
+// record adds an event to the PvP battle's replay. Trainer 1 is shown at the bottom of the card.
+func (pvp *PvPBattleState) record(event BattleEvent) {
+	event.Turn = pvp.Turn
+	event.Bottom = replayGopher(pvp.Trainer1Gopher)
+	event.Top = replayGopher(pvp.Trainer2Gopher)
+	pvp.Events = append(pvp.Events, event)
+}
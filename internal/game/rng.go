@@ -0,0 +1,78 @@
+package game
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RNG is a seeded random source. Battles, gopher generation and evolution draw from an RNG
+// instead of the global math/rand source, so the same seed reproduces the same results.
+// It is safe for concurrent use.
+type RNG struct {
+	*rand.Rand
+	src *countingSource
+}
+
+// countingSource serializes access to a rand.Source and counts the values drawn from it,
+// so a random sequence can be resumed where it left off
+type countingSource struct {
+	mu    sync.Mutex
+	src   rand.Source
+	draws int64
+}
+
+func (s *countingSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+	s.draws = 0
+}
+
+// NewRNG returns a random source seeded with seed
+func NewRNG(seed int64) *RNG {
+	src := &countingSource{src: rand.NewSource(seed)}
+	return &RNG{Rand: rand.New(src), src: src}
+}
+
+// ResumeRNG returns a random source seeded with seed that has already produced draws values
+func ResumeRNG(seed, draws int64) *RNG {
+	rng := NewRNG(seed)
+	for i := int64(0); i < draws; i++ {
+		rng.src.Int63()
+	}
+	return rng
+}
+
+// Draws returns how many values have been drawn from the source
+func (r *RNG) Draws() int64 {
+	r.src.mu.Lock()
+	defer r.src.mu.Unlock()
+	return r.src.draws
+}
+
+// NewSeed returns a seed for a new random source
+func NewSeed() int64 {
+	return time.Now().UnixNano()
+}
+
+// gopherRNG returns a random source seeded from the gopher's ID, for choices that must come
+// out the same every time the gopher is loaded
+func gopherRNG(gopher *Gopher) *RNG {
+	return keyRNG(gopher.ID)
+}
+
+// keyRNG returns a random source seeded from a string, so the same key always gives the same sequence
+func keyRNG(key string) *RNG {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	return NewRNG(int64(h.Sum64()))
+}
@@ -0,0 +1,44 @@
+package game
+
+import "testing"
+
+func TestNewRNGIsDeterministic(t *testing.T) {
+	a, b := NewRNG(42), NewRNG(42)
+	for i := 0; i < 100; i++ {
+		if x, y := a.Int63(), b.Int63(); x != y {
+			t.Fatalf("draw %d: got %d and %d from the same seed", i, x, y)
+		}
+	}
+}
+
+func TestResumeRNGContinuesSequence(t *testing.T) {
+	rng := NewRNG(7)
+	// Mix the kinds of draws battles make
+	for i := 0; i < 25; i++ {
+		rng.Float64()
+		rng.Intn(10)
+	}
+	draws := rng.Draws()
+	if draws == 0 {
+		t.Fatal("Draws() = 0 after drawing values")
+	}
+
+	resumed := ResumeRNG(7, draws)
+	if resumed.Draws() != draws {
+		t.Fatalf("resumed Draws() = %d, want %d", resumed.Draws(), draws)
+	}
+	for i := 0; i < 50; i++ {
+		if x, y := rng.Float64(), resumed.Float64(); x != y {
+			t.Fatalf("draw %d after resuming: got %v, want %v", i, y, x)
+		}
+	}
+}
+
+func TestKeyRNGIsStable(t *testing.T) {
+	if keyRNG("gopher-1").Int63() != keyRNG("gopher-1").Int63() {
+		t.Error("the same key gave different sequences")
+	}
+	if keyRNG("gopher-1").Int63() == keyRNG("gopher-2").Int63() {
+		t.Error("different keys gave the same sequence")
+	}
+}
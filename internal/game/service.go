@@ -4,8 +4,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"image"
-	"math/rand"
-	"time"
 
 	"gophermon-bot/internal/gopherkon"
 	"gophermon-bot/internal/storage"
@@ -16,10 +14,13 @@ type Service struct {
 	gopherRepo       *storage.GopherRepo
 	partyRepo        *storage.PartyRepo
 	battleRepo       *storage.BattleRepo
+	abilityRepo      *storage.AbilityRepo
 	generator        *gopherkon.Generator
 	evolutionService *EvolutionService
 	eventManager     *EventManager
 	assetsPath       string
+	rng              *RNG
+	roster           *Roster
 }
 
 func NewService(
@@ -27,6 +28,7 @@ func NewService(
 	gopherRepo *storage.GopherRepo,
 	partyRepo *storage.PartyRepo,
 	battleRepo *storage.BattleRepo,
+	abilityRepo *storage.AbilityRepo,
 	generator *gopherkon.Generator,
 	evolutionService *EvolutionService,
 	assetsPath string,
@@ -36,13 +38,35 @@ func NewService(
 		gopherRepo:       gopherRepo,
 		partyRepo:        partyRepo,
 		battleRepo:       battleRepo,
+		abilityRepo:      abilityRepo,
 		generator:        generator,
 		evolutionService: evolutionService,
 		eventManager:     NewEventManager(),
 		assetsPath:       assetsPath,
+		rng:              NewRNG(NewSeed()),
+		roster:           &Roster{},
 	}
 }
 
+// SetRNG replaces the random source used for gopher generation and evolution,
+// so a fixed seed reproduces the same gophers
+func (s *Service) SetRNG(rng *RNG) {
+	s.rng = rng
+	if s.evolutionService != nil {
+		s.evolutionService.SetRNG(rng)
+	}
+}
+
+// Rand returns the service's random source
+func (s *Service) Rand() *RNG {
+	return s.rng
+}
+
+// NewBattleSeed returns the seed for a new battle's random source
+func (s *Service) NewBattleSeed() int64 {
+	return s.rng.Int63()
+}
+
 // GetEventManager returns the event manager
 func (s *Service) GetEventManager() *EventManager {
 	return s.eventManager
@@ -55,21 +79,21 @@ func (s *Service) GenerateStarterGophers() ([]*storage.Gopher, error) {
 
 	for i := 0; i < 3; i++ {
 		// Random archetype
-		archetype := archetypes[rand.Intn(len(archetypes))]
+		archetype := archetypes[s.rng.Intn(len(archetypes))]
 
 		// Complexity 2-3 for starters (COMMON/UNCOMMON)
-		complexity := 2 + rand.Intn(2)
+		complexity := 2 + s.rng.Intn(2)
 		rarity := ComplexityToRarity(complexity).String()
 
 		// Check for shiny (rate affected by events)
 		shinyRate := s.eventManager.GetShinyRate()
-		isShiny := rand.Float64() < shinyRate
+		isShiny := s.rng.Float64() < shinyRate
 
 		// Generate sprite
 		result, err := s.generator.Generate(gopherkon.GenerateOptions{
 			Complexity:   complexity,
 			TargetRarity: rarity,
-			Seed:         time.Now().UnixNano() + int64(i),
+			Seed:         s.rng.Int63() + int64(i),
 		})
 		if err != nil {
 			return nil, fmt.Errorf("failed to generate sprite: %w", err)
@@ -89,7 +113,7 @@ func (s *Service) GenerateStarterGophers() ([]*storage.Gopher, error) {
 		}
 
 		// Generate stats
-		hp, attack, defense, speed := GenerateBaseStats(archetype, rarity, 1)
+		hp, attack, defense, speed := GenerateBaseStats(archetype, rarity, 1, s.rng)
 
 		// Apply 25% stat boost for shiny gophers
 		if isShiny {
@@ -103,13 +127,13 @@ func (s *Service) GenerateStarterGophers() ([]*storage.Gopher, error) {
 		primaryType := GetTypeFromArchetype(archetype)
 		secondaryType := ""
 		// 30% chance for dual type (rarer gophers more likely)
-		if rand.Float64() < 0.3 || rarity == "RARE" || rarity == "EPIC" || rarity == "LEGENDARY" {
-			secondaryType = string(GetRandomSecondaryType(primaryType))
+		if s.rng.Float64() < 0.3 || rarity == "RARE" || rarity == "EPIC" || rarity == "LEGENDARY" {
+			secondaryType = string(GetRandomSecondaryType(primaryType, s.rng))
 		}
 
 		// Create gopher
 		gopher := &storage.Gopher{
-			Name:             GenerateGopherName(archetype),
+			Name:             GenerateGopherName(archetype, s.rng),
 			Level:            1,
 			XP:               0,
 			CurrentHP:        hp,
@@ -210,6 +234,52 @@ func (s *Service) GenerateBattleCard(enemyGopher, playerGopher *storage.Gopher)
 	return cardBase64, nil
 }
 
+// GenerateDoubleBattleCard creates a battle card with up to two gophers per side and returns
+// base64. Nil gophers (empty slots) are left out.
+func (s *Service) GenerateDoubleBattleCard(enemyGophers, playerGophers []*storage.Gopher) (string, error) {
+	enemies, err := s.battleCardGophers(enemyGophers)
+	if err != nil {
+		return "", fmt.Errorf("failed to load enemy sprite: %w", err)
+	}
+	players, err := s.battleCardGophers(playerGophers)
+	if err != nil {
+		return "", fmt.Errorf("failed to load player sprite: %w", err)
+	}
+
+	cardBase64, err := s.generator.GenerateDoubleBattleCardFromImagesToBase64(enemies, players)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate battle card: %w", err)
+	}
+
+	return cardBase64, nil
+}
+
+// battleCardGophers loads the sprites of gophers to draw on a battle card
+func (s *Service) battleCardGophers(gophers []*storage.Gopher) ([]gopherkon.BattleCardGopher, error) {
+	cardGophers := []gopherkon.BattleCardGopher{}
+	for _, gopher := range gophers {
+		if gopher == nil {
+			continue
+		}
+
+		var img image.Image
+		var err error
+		if gopher.SpriteData != "" {
+			img, err = s.generator.DecodeImageFromBase64(gopher.SpriteData)
+		} else if gopher.SpritePath != "" {
+			img, err = s.generator.LoadImageFromPath(gopher.SpritePath)
+		} else {
+			return nil, fmt.Errorf("%s has no sprite data", gopher.Name)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		cardGophers = append(cardGophers, gopherkon.BattleCardGopher{Image: img, Name: gopher.Name, Level: gopher.Level})
+	}
+	return cardGophers, nil
+}
+
 // GenerateGopherCard creates a card with N gophers arranged in a grid and returns base64
 func (s *Service) GenerateGopherCard(gophers []*storage.Gopher, cols int) (string, error) {
 	if len(gophers) == 0 {
@@ -256,21 +326,27 @@ func (s *Service) GenerateGopherWithRarity(targetRarity Rarity, seedOffset int64
 
 // GenerateGopherWithRarityAndShiny creates a gopher with a specific rarity and shiny status
 func (s *Service) GenerateGopherWithRarityAndShiny(targetRarity Rarity, seedOffset int64, forceShiny bool) (*storage.Gopher, error) {
+	return s.generateGopher(targetRarity, seedOffset, forceShiny, 1, 10)
+}
+
+// generateGopher creates a gopher with a specific rarity and shiny status at a random level
+// between minLevel and maxLevel
+func (s *Service) generateGopher(targetRarity Rarity, seedOffset int64, forceShiny bool, minLevel, maxLevel int) (*storage.Gopher, error) {
 	// Random archetype
 	archetypes := []Archetype{ArchetypeHacker, ArchetypeTank, ArchetypeSpeedy, ArchetypeSupport, ArchetypeMage}
-	archetype := archetypes[rand.Intn(len(archetypes))]
+	archetype := archetypes[s.rng.Intn(len(archetypes))]
 
-	// Random level (1-10 for now)
-	level := 1 + rand.Intn(10)
+	// Random level in range
+	level := minLevel + s.rng.Intn(maxLevel-minLevel+1)
 
 	// Check for shiny (rate affected by events) or force shiny
 	shinyRate := s.eventManager.GetShinyRate()
-	isShiny := forceShiny || rand.Float64() < shinyRate
+	isShiny := forceShiny || s.rng.Float64() < shinyRate
 
 	// Generate sprite with specific rarity
 	result, err := s.generator.Generate(gopherkon.GenerateOptions{
 		TargetRarity: targetRarity.String(),
-		Seed:         time.Now().UnixNano() + seedOffset,
+		Seed:         s.rng.Int63() + seedOffset,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate sprite: %w", err)
@@ -290,7 +366,7 @@ func (s *Service) GenerateGopherWithRarityAndShiny(targetRarity Rarity, seedOffs
 	}
 
 	// Generate stats
-	hp, attack, defense, speed := GenerateBaseStats(archetype, targetRarity.String(), level)
+	hp, attack, defense, speed := GenerateBaseStats(archetype, targetRarity.String(), level, s.rng)
 
 	// Apply 25% stat boost for shiny gophers
 	if isShiny {
@@ -304,13 +380,13 @@ func (s *Service) GenerateGopherWithRarityAndShiny(targetRarity Rarity, seedOffs
 	primaryType := GetTypeFromArchetype(archetype)
 	secondaryType := ""
 	// 30% chance for dual type (rarer gophers more likely)
-	if rand.Float64() < 0.3 || targetRarity == RarityRare || targetRarity == RarityEpic || targetRarity == RarityLegendary {
-		secondaryType = string(GetRandomSecondaryType(primaryType))
+	if s.rng.Float64() < 0.3 || targetRarity == RarityRare || targetRarity == RarityEpic || targetRarity == RarityLegendary {
+		secondaryType = string(GetRandomSecondaryType(primaryType, s.rng))
 	}
 
 	// Create gopher
 	gopher := &storage.Gopher{
-		Name:             GenerateGopherName(archetype),
+		Name:             GenerateGopherName(archetype, s.rng),
 		Level:            level,
 		XP:               0,
 		CurrentHP:        hp,
@@ -334,10 +410,11 @@ func (s *Service) GenerateGopherWithRarityAndShiny(targetRarity Rarity, seedOffs
 	return gopher, nil
 }
 
-// GenerateWildGopher creates a wild gopher for encounters
-func (s *Service) GenerateWildGopher() (*storage.Gopher, error) {
+// GenerateWildGopher creates a wild gopher for encounters in an area. Without an area the
+// gopher is level 1-10.
+func (s *Service) GenerateWildGopher(area *WildArea) (*storage.Gopher, error) {
 	// Determine rarity from distribution (affected by events)
-	randFloat := rand.Float64()
+	randFloat := s.rng.Float64()
 
 	// Apply rarity boost from events (e.g., Rare Encounter event)
 	rarityBoost := s.eventManager.GetRarityBoost()
@@ -356,38 +433,31 @@ func (s *Service) GenerateWildGopher() (*storage.Gopher, error) {
 	}
 
 	rarity := GetWildRarityDistribution(randFloat)
+	if area != nil {
+		return s.generateGopher(rarity, 0, false, area.MinLevel, area.MaxLevel)
+	}
 	return s.GenerateGopherWithRarity(rarity, 0)
 }
 
 // CreateGopherWithAbilities creates a gopher and assigns abilities
 func (s *Service) CreateGopherWithAbilities(gopher *storage.Gopher) error {
 	// Create gopher in DB
-	_, err := s.gopherRepo.Create(gopher)
+	created, err := s.gopherRepo.Create(gopher)
 	if err != nil {
 		return err
 	}
 
-	// Get ability templates for archetype
-	abilityTemplates := GetAbilitiesForArchetype(Archetype(gopher.SpeciesArchetype), gopher.EvolutionStage, gopher.Rarity)
-
-	// Assign first 2 abilities (more unlock at higher levels)
-	numAbilities := 2
-	if gopher.Level >= 10 {
-		numAbilities = 3
-	}
-	if gopher.Level >= 20 {
-		numAbilities = 4
-	}
+	return s.StoreStartingAbilities(created)
+}
 
-	if numAbilities > len(abilityTemplates) {
-		numAbilities = len(abilityTemplates)
+// StoreStartingAbilities stores the abilities a gopher starts with, for gophers that have none
+// stored yet
+func (s *Service) StoreStartingAbilities(storageGopher *storage.Gopher) error {
+	gameGopher, err := s.StorageGopherToGameGopher(storageGopher)
+	if err != nil {
+		return err
 	}
-
-	// Create abilities (we'll store them in memory for now, can persist later)
-	// For now, abilities are stored in the Gopher struct's Abilities field
-	// which we'll need to handle differently since DB stores them separately
-
-	return nil
+	return s.SaveAbilities(gameGopher)
 }
 
 // StorageGopherToGameGopher converts storage.Gopher to game.Gopher with abilities
@@ -434,34 +504,97 @@ func (s *Service) StorageGopherToGameGopher(storageGopher *storage.Gopher) (*Gop
 		StatusEffects:    statusEffects,
 		Shiny:            storageGopher.Shiny,
 		IsFavorite:       storageGopher.IsFavorite,
+		HeldItem:         storageGopher.HeldItem,
 		BaseAttack:       storageGopher.Attack,
 		BaseDefense:      storageGopher.Defense,
 		BaseSpeed:        storageGopher.Speed,
 	}
 
-	// Create abilities for this gopher
-	abilityTemplates := GetAbilitiesForArchetype(Archetype(gameGopher.SpeciesArchetype), gameGopher.EvolutionStage, gameGopher.Rarity)
-	numAbilities := 2
-	if gameGopher.Level >= 10 {
-		numAbilities = 3
+	// Load the abilities this gopher has learned. Abilities whose template is no longer loaded
+	// can't be used, but stay stored so they come back once the ability data is fixed.
+	storedAbilities, err := s.abilityRepo.GetByGopherID(gameGopher.ID)
+	if err != nil {
+		return nil, err
 	}
-	if gameGopher.Level >= 20 {
-		numAbilities = 4
+	for _, stored := range storedAbilities {
+		ability, err := CreateAbilityFromTemplate(stored.TemplateID, stored.ID)
+		if err != nil {
+			gameGopher.UnknownAbilities++
+			continue
+		}
+		if stored.Active {
+			gameGopher.Abilities = append(gameGopher.Abilities, ability)
+		} else {
+			gameGopher.ForgottenAbilities = append(gameGopher.ForgottenAbilities, ability)
+		}
 	}
 
-	if numAbilities > len(abilityTemplates) {
-		numAbilities = len(abilityTemplates)
+	// Gophers that never had abilities stored, like freshly generated wild gophers, start with
+	// the abilities they're eligible for. Loading never writes: these are stored when the gopher
+	// is caught or chosen, by the startup backfill, or when it next learns a move.
+	if len(storedAbilities) == 0 {
+		LearnFreeAbilities(gameGopher)
 	}
 
-	for i := 0; i < numAbilities; i++ {
-		ability, err := CreateAbilityFromTemplate(abilityTemplates[i], fmt.Sprintf("%s_ability_%d", gameGopher.ID, i))
+	return gameGopher, nil
+}
+
+// SaveAbilities stores a gopher's current and forgotten abilities. Stored abilities whose
+// template isn't loaded are kept as they are.
+func (s *Service) SaveAbilities(gameGopher *Gopher) error {
+	abilities := []*storage.Ability{}
+	for i, ability := range append(append([]*Ability{}, gameGopher.Abilities...), gameGopher.ForgottenAbilities...) {
+		abilities = append(abilities, &storage.Ability{
+			ID:          ability.ID,
+			GopherID:    gameGopher.ID,
+			TemplateID:  ability.TemplateID,
+			Name:        ability.Name,
+			Description: ability.Description,
+			Power:       ability.Power,
+			Cost:        ability.Cost,
+			Targeting:   string(ability.Targeting),
+			Slot:        i,
+			Active:      i < len(gameGopher.Abilities),
+		})
+	}
+
+	if gameGopher.UnknownAbilities > 0 {
+		storedAbilities, err := s.abilityRepo.GetByGopherID(gameGopher.ID)
 		if err != nil {
-			continue
+			return err
+		}
+		for _, stored := range storedAbilities {
+			if _, ok := AbilityTemplates[stored.TemplateID]; ok {
+				continue
+			}
+			stored.Slot = len(abilities)
+			abilities = append(abilities, stored)
 		}
-		gameGopher.Abilities = append(gameGopher.Abilities, ability)
 	}
 
-	return gameGopher, nil
+	return s.abilityRepo.ReplaceForGopher(gameGopher.ID, abilities)
+}
+
+// BackfillAbilities stores abilities for owned gophers that were caught before abilities were persisted.
+// Returns how many gophers were updated.
+func (s *Service) BackfillAbilities() (int, error) {
+	gopherIDs, err := s.abilityRepo.GetOwnedGopherIDsWithoutAbilities()
+	if err != nil {
+		return 0, err
+	}
+
+	updated := 0
+	for _, gopherID := range gopherIDs {
+		storageGopher, err := s.gopherRepo.GetByID(gopherID)
+		if err != nil || storageGopher == nil {
+			continue
+		}
+		if err := s.StoreStartingAbilities(storageGopher); err != nil {
+			return updated, fmt.Errorf("failed to backfill abilities for %s: %w", gopherID, err)
+		}
+		updated++
+	}
+	return updated, nil
 }
 
 // CheckEvolution checks if a gopher should evolve
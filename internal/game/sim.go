@@ -0,0 +1,122 @@
+package game
+
+import (
+	"fmt"
+)
+
+// SimMaxTurns ends a simulated battle as a draw when neither gopher can finish the other off
+const SimMaxTurns = 200
+
+// Sides of a simulated battle, as recorded in BattleEvent.Side
+const (
+	SimSidePlayer = "PLAYER"
+	SimSideEnemy  = "ENEMY"
+)
+
+// SimGopherSpec describes a gopher to build for a simulated battle
+type SimGopherSpec struct {
+	Archetype      Archetype
+	Rarity         string
+	Level          int
+	EvolutionStage int
+	DualType       bool // Roll a random secondary type, as wild rare and stronger gophers always have
+}
+
+// SimResult is the outcome of one simulated battle
+type SimResult struct {
+	Winner string // SimSidePlayer, SimSideEnemy, or "" for a draw
+	Turns  int
+	Events []BattleEvent
+}
+
+// NewSimGopher builds a gopher the way wild gophers are generated, but without a sprite or
+// storage, for battle simulations
+func NewSimGopher(id string, spec SimGopherSpec, rng *RNG) *Gopher {
+	hp, attack, defense, speed := GenerateBaseStats(spec.Archetype, spec.Rarity, spec.Level, rng)
+
+	primaryType := GetTypeFromArchetype(spec.Archetype)
+	var secondaryType GopherType
+	if spec.DualType {
+		secondaryType = GetRandomSecondaryType(primaryType, rng)
+	}
+
+	gopher := &Gopher{
+		ID:               id,
+		Name:             GenerateGopherName(spec.Archetype, rng),
+		Level:            spec.Level,
+		CurrentHP:        hp,
+		MaxHP:            hp,
+		Attack:           attack,
+		Defense:          defense,
+		Speed:            speed,
+		Rarity:           spec.Rarity,
+		SpeciesArchetype: string(spec.Archetype),
+		EvolutionStage:   spec.EvolutionStage,
+		PrimaryType:      primaryType,
+		SecondaryType:    secondaryType,
+		Abilities:        []*Ability{},
+		StatusEffects:    []*StatusEffect{},
+		BaseAttack:       attack,
+		BaseDefense:      defense,
+		BaseSpeed:        speed,
+	}
+	LearnFreeAbilities(gopher)
+	return gopher
+}
+
+// SimulateBattle fights two gophers to the end through the regular battle engine, with each
+// side's moves picked by its strategy. A side that can't afford any move rests.
+func SimulateBattle(player, enemy *Gopher, playerStrategy, enemyStrategy EnemyStrategy, seed int64) (*SimResult, error) {
+	bs := NewBattleState("sim", "sim", player, enemy, []*Gopher{player}, nil, seed)
+	bs.EnemyStrategy = enemyStrategy
+	bs.Field = &Field{} // Compare gophers on a clear field
+
+	for bs.State == "ACTIVE" && bs.Turn < SimMaxTurns {
+		action, index := "rest", -1
+		if ability := bs.choosePlayerAbility(playerStrategy); ability != nil {
+			action = "fight"
+			for idx, option := range bs.PlayerGopher.Abilities {
+				if option == ability {
+					index = idx
+				}
+			}
+		}
+
+		if _, err := bs.PlayerAction(action, index); err != nil {
+			return nil, fmt.Errorf("failed to simulate turn %d: %w", bs.Turn, err)
+		}
+	}
+
+	result := &SimResult{Turns: bs.Turn, Events: bs.Events}
+	switch bs.State {
+	case "WON":
+		result.Winner = SimSidePlayer
+	case "LOST":
+		result.Winner = SimSideEnemy
+	}
+	return result, nil
+}
+
+// choosePlayerAbility picks the player's move with an enemy strategy, by showing it the
+// battle from the player's side. Returns nil if the player can't afford any move.
+func (bs *BattleState) choosePlayerAbility(strategy EnemyStrategy) *Ability {
+	options := bs.affordableAbilities(bs.PlayerGopher)
+	if len(options) == 0 {
+		return nil
+	}
+
+	mirrored := &BattleState{
+		ID:           bs.ID,
+		OpponentType: bs.OpponentType,
+		PlayerGopher: bs.EnemyGopher,
+		EnemyGopher:  bs.PlayerGopher,
+		TurnOwner:    "ENEMY",
+		State:        bs.State,
+		EventManager: bs.EventManager,
+		EnergyPools:  bs.EnergyPools,
+		Field:        bs.Field,
+		Seed:         bs.Seed,
+		rng:          bs.Rand(),
+	}
+	return strategy.ChooseAbility(mirrored, options)
+}
@@ -0,0 +1,156 @@
+package game
+
+// BattleSnapshot is the part of a battle's state that changes turn by turn and is not stored
+// in the gophers themselves, so a battle can be resumed after the bot restarts
+type BattleSnapshot struct {
+	PartyIDs       []string                      // Player party, in order
+	ParticipantIDs []string                      // Gophers that have fought and share the XP
+	EnemyID        string                        // Active enemy; NPC trainers switch gophers during a battle
+	Gophers        map[string]*GopherBattleState // Battle state per gopher ID, enemy included
+	Log            []string
+	Turn           int
+	Events         []BattleEvent
+	XPAwarded      int
+	Captured       bool
+	Field          Field
+}
+
+// GopherBattleState is a gopher's in-battle condition: HP, stats with buffs and event
+// boosts applied, and status effects
+type GopherBattleState struct {
+	Level         int
+	XP            int
+	CurrentHP     int
+	MaxHP         int
+	Attack        int
+	Defense       int
+	Speed         int
+	BaseAttack    int
+	BaseDefense   int
+	BaseSpeed     int
+	StatusEffects []*StatusEffect
+}
+
+// Snapshot captures the battle's current state
+func (bs *BattleState) Snapshot() *BattleSnapshot {
+	snapshot := &BattleSnapshot{
+		PartyIDs:       []string{},
+		ParticipantIDs: []string{},
+		Gophers:        make(map[string]*GopherBattleState),
+		Log:            append([]string{}, bs.Log...),
+		Turn:           bs.Turn,
+		Events:         bs.Events,
+		XPAwarded:      bs.XPAwarded,
+		Captured:       bs.Captured,
+	}
+	if bs.Field != nil {
+		snapshot.Field = *bs.Field
+	}
+
+	for _, gopher := range bs.PlayerParty {
+		snapshot.PartyIDs = append(snapshot.PartyIDs, gopher.ID)
+		snapshot.Gophers[gopher.ID] = gopherBattleState(gopher)
+	}
+	for _, gopher := range bs.ParticipatingGophers {
+		snapshot.ParticipantIDs = append(snapshot.ParticipantIDs, gopher.ID)
+	}
+	for _, gopher := range bs.EnemyParty {
+		snapshot.Gophers[gopher.ID] = gopherBattleState(gopher)
+	}
+	snapshot.EnemyID = bs.EnemyGopher.ID
+
+	// The active gophers' instances hold their current state
+	snapshot.Gophers[bs.PlayerGopher.ID] = gopherBattleState(bs.PlayerGopher)
+	snapshot.Gophers[bs.EnemyGopher.ID] = gopherBattleState(bs.EnemyGopher)
+
+	return snapshot
+}
+
+// Restore applies a snapshot to a battle rebuilt from storage. PlayerGopher, EnemyGopher and
+// PlayerParty, and EnemyParty in trainer battles, must already be set to the gophers listed
+// in the snapshot.
+func (bs *BattleState) Restore(snapshot *BattleSnapshot) {
+	gophers := make(map[string]*Gopher)
+	for _, gopher := range bs.PlayerParty {
+		gophers[gopher.ID] = gopher
+	}
+
+	// Send the trainer's active gopher back out
+	for _, gopher := range bs.EnemyParty {
+		if gopher.ID == snapshot.EnemyID {
+			bs.EnemyGopher = gopher
+		}
+	}
+
+	// Swaps make the active gopher the party's instance, so share it again
+	if active, ok := gophers[bs.PlayerGopher.ID]; ok {
+		bs.PlayerGopher = active
+	} else {
+		gophers[bs.PlayerGopher.ID] = bs.PlayerGopher
+	}
+	gophers[bs.EnemyGopher.ID] = bs.EnemyGopher
+	for _, gopher := range bs.EnemyParty {
+		gophers[gopher.ID] = gopher
+	}
+
+	for id, state := range snapshot.Gophers {
+		if gopher, ok := gophers[id]; ok {
+			state.apply(gopher)
+		}
+	}
+
+	participantIDs := snapshot.ParticipantIDs
+	if len(participantIDs) == 0 {
+		for _, gopher := range bs.ParticipatingGophers {
+			participantIDs = append(participantIDs, gopher.ID)
+		}
+	}
+	bs.ParticipatingGophers = []*Gopher{}
+	for _, id := range participantIDs {
+		if gopher, ok := gophers[id]; ok {
+			bs.ParticipatingGophers = append(bs.ParticipatingGophers, gopher)
+		}
+	}
+
+	if len(snapshot.Log) > 0 {
+		bs.Log = snapshot.Log
+	}
+	if len(snapshot.Events) > 0 {
+		bs.Events = snapshot.Events
+	}
+	bs.Turn = snapshot.Turn
+	bs.XPAwarded = snapshot.XPAwarded
+	bs.Captured = snapshot.Captured
+	field := snapshot.Field
+	bs.Field = &field
+}
+
+func gopherBattleState(gopher *Gopher) *GopherBattleState {
+	return &GopherBattleState{
+		Level:         gopher.Level,
+		XP:            gopher.XP,
+		CurrentHP:     gopher.CurrentHP,
+		MaxHP:         gopher.MaxHP,
+		Attack:        gopher.Attack,
+		Defense:       gopher.Defense,
+		Speed:         gopher.Speed,
+		BaseAttack:    gopher.BaseAttack,
+		BaseDefense:   gopher.BaseDefense,
+		BaseSpeed:     gopher.BaseSpeed,
+		StatusEffects: gopher.StatusEffects,
+	}
+}
+
+func (state *GopherBattleState) apply(gopher *Gopher) {
+	gopher.Level = state.Level
+	gopher.XP = state.XP
+	gopher.CurrentHP = state.CurrentHP
+	gopher.MaxHP = state.MaxHP
+	gopher.Attack = state.Attack
+	gopher.Defense = state.Defense
+	gopher.Speed = state.Speed
+	gopher.BaseAttack = state.BaseAttack
+	gopher.BaseDefense = state.BaseDefense
+	gopher.BaseSpeed = state.BaseSpeed
+	gopher.StatusEffects = state.StatusEffects
+}
@@ -0,0 +1,116 @@
+package game
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// newTestBattle builds the same wild battle every time: a two-gopher party against a wild
+// gopher, with gophers generated from seed
+func newTestBattle(seed int64) *BattleState {
+	rng := NewRNG(seed)
+	lead := NewSimGopher("lead", SimGopherSpec{Archetype: ArchetypeHacker, Rarity: "RARE", Level: 20}, rng)
+	backup := NewSimGopher("backup", SimGopherSpec{Archetype: ArchetypeTank, Rarity: "COMMON", Level: 18}, rng)
+	enemy := NewSimGopher("wild", SimGopherSpec{Archetype: ArchetypeSupport, Rarity: "UNCOMMON", Level: 25}, rng)
+	return NewBattleState("trainer", "channel", lead, enemy, []*Gopher{lead, backup}, nil, seed)
+}
+
+// playTestTurns fights with the first ability the active gopher can afford, or rests
+func playTestTurns(t *testing.T, bs *BattleState, turns int) {
+	t.Helper()
+	for i := 0; i < turns && bs.State == "ACTIVE"; i++ {
+		action, index := "rest", -1
+		if affordable := bs.affordableAbilities(bs.PlayerGopher); len(affordable) > 0 {
+			for idx, ability := range bs.PlayerGopher.Abilities {
+				if ability == affordable[0] {
+					action, index = "fight", idx
+				}
+			}
+		}
+		if _, err := bs.PlayerAction(action, index); err != nil {
+			t.Fatalf("turn %d: %v", bs.Turn, err)
+		}
+	}
+}
+
+// storeTestSnapshot round-trips a snapshot through JSON, as saving and loading a battle does
+func storeTestSnapshot(t *testing.T, snapshot *BattleSnapshot) *BattleSnapshot {
+	t.Helper()
+	contents, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := &BattleSnapshot{}
+	if err := json.Unmarshal(contents, stored); err != nil {
+		t.Fatal(err)
+	}
+	return stored
+}
+
+// restoreTestBattle rebuilds a battle from freshly generated gophers the way the bot does
+// after a restart: resume the random source, restore energy, then apply the stored snapshot
+func restoreTestBattle(t *testing.T, original *BattleState, snapshot *BattleSnapshot) *BattleState {
+	t.Helper()
+	fresh := newTestBattle(original.Seed)
+	restored := &BattleState{
+		TrainerID:            original.TrainerID,
+		ChannelID:            original.ChannelID,
+		OpponentType:         original.OpponentType,
+		PlayerGopher:         fresh.PlayerGopher,
+		EnemyGopher:          fresh.EnemyGopher,
+		PlayerParty:          fresh.PlayerParty,
+		ParticipatingGophers: []*Gopher{fresh.PlayerGopher},
+		TurnOwner:            original.TurnOwner,
+		State:                original.State,
+		EnergyPools:          make(map[string]int),
+		Seed:                 original.Seed,
+	}
+	for id, energy := range original.EnergyPools {
+		restored.EnergyPools[id] = energy
+	}
+	restored.ResumeRand(original.Rand().Draws())
+	restored.Restore(storeTestSnapshot(t, snapshot))
+	return restored
+}
+
+func TestSnapshotRestoreRoundTrip(t *testing.T) {
+	original := newTestBattle(99)
+	playTestTurns(t, original, 3)
+	if original.State != "ACTIVE" {
+		t.Fatalf("battle ended after %d turns; pick a longer matchup", original.Turn)
+	}
+
+	snapshot := original.Snapshot()
+	restored := restoreTestBattle(t, original, snapshot)
+
+	if !reflect.DeepEqual(restored.Snapshot(), snapshot) {
+		t.Fatal("restored battle's snapshot differs from the one it was restored from")
+	}
+	if restored.PlayerGopher != restored.PlayerParty[0] {
+		t.Error("the active gopher isn't the party's instance")
+	}
+	if restored.Field == nil || *restored.Field != *original.Field {
+		t.Errorf("field = %+v, want %+v", restored.Field, original.Field)
+	}
+}
+
+func TestRestoredBattleReplaysTheSame(t *testing.T) {
+	original := newTestBattle(1234)
+	playTestTurns(t, original, 2)
+	restored := restoreTestBattle(t, original, original.Snapshot())
+
+	// Both battles draw the same numbers from here on, so they play out identically
+	playTestTurns(t, original, 4)
+	playTestTurns(t, restored, 4)
+
+	if original.State != restored.State || original.Turn != restored.Turn {
+		t.Fatalf("original is %s on turn %d, restored is %s on turn %d", original.State, original.Turn, restored.State, restored.Turn)
+	}
+	if !reflect.DeepEqual(restored.Snapshot(), original.Snapshot()) {
+		t.Error("restored battle played out differently")
+	}
+	if original.Rand().Draws() != restored.Rand().Draws() {
+		t.Errorf("draws: original %d, restored %d", original.Rand().Draws(), restored.Rand().Draws())
+	}
+}
@@ -0,0 +1,186 @@
+package game
+
+// EnemyStrategy chooses which move an enemy gopher uses each round
+type EnemyStrategy interface {
+	// ChooseAbility picks one of the abilities the enemy can afford. Options is never empty.
+	ChooseAbility(bs *BattleState, options []*Ability) *Ability
+}
+
+// StrategyFor returns the strategy for an opponent: wild commons pick moves at random,
+// stronger wild gophers and trainers go for damage, and legendaries and bosses play smart
+func StrategyFor(opponentType, rarity string) EnemyStrategy {
+	switch opponentType {
+	case "BOSS", "PVE_BOSS":
+		return HeuristicStrategy{}
+	case "TRAINER", "GYM":
+		return GreedyStrategy{}
+	}
+
+	switch rarity {
+	case "LEGENDARY":
+		return HeuristicStrategy{}
+	case "RARE", "EPIC":
+		return GreedyStrategy{}
+	default:
+		return RandomStrategy{}
+	}
+}
+
+// StrategyNamed returns the strategy with the given name, as used in NPC trainer data, or nil
+func StrategyNamed(name string) EnemyStrategy {
+	switch name {
+	case "random":
+		return RandomStrategy{}
+	case "greedy":
+		return GreedyStrategy{}
+	case "heuristic":
+		return HeuristicStrategy{}
+	}
+	return nil
+}
+
+// RandomStrategy uses a random move
+type RandomStrategy struct{}
+
+func (RandomStrategy) ChooseAbility(bs *BattleState, options []*Ability) *Ability {
+	return options[bs.Rand().Intn(len(options))]
+}
+
+// GreedyStrategy uses the move with the highest expected damage against the player's gopher,
+// taking type matchups into account. Without a damaging move it avoids healing at full HP.
+type GreedyStrategy struct{}
+
+func (GreedyStrategy) ChooseAbility(bs *BattleState, options []*Ability) *Ability {
+	if best, damage := strongestAttack(bs.Field, bs.EnemyGopher, bs.PlayerGopher, options); damage > 0 {
+		return best
+	}
+
+	useful := []*Ability{}
+	for _, ability := range options {
+		if !healingAbility(ability) || bs.EnemyGopher.CurrentHP < bs.EnemyGopher.MaxHP {
+			useful = append(useful, ability)
+		}
+	}
+	if len(useful) == 0 {
+		useful = options
+	}
+	return useful[bs.Rand().Intn(len(useful))]
+}
+
+// HeuristicStrategy finishes off weakened targets, heals when low, buffs while healthy
+// and otherwise attacks like GreedyStrategy
+type HeuristicStrategy struct{}
+
+func (HeuristicStrategy) ChooseAbility(bs *BattleState, options []*Ability) *Ability {
+	self, target := bs.EnemyGopher, bs.PlayerGopher
+
+	// Finish off the target with the cheapest move that should knock it out
+	var finisher *Ability
+	for _, ability := range options {
+		if expectedDamage(bs.Field, self, target, ability) >= float64(target.CurrentHP) {
+			if finisher == nil || ability.Cost < finisher.Cost {
+				finisher = ability
+			}
+		}
+	}
+	if finisher != nil {
+		return finisher
+	}
+
+	// Heal when low
+	if self.CurrentHP*100 < self.MaxHP*35 {
+		for _, ability := range options {
+			if healingAbility(ability) {
+				return ability
+			}
+		}
+	}
+
+	// Buff early, while healthy and not buffed yet
+	buffed := self.HasStatusEffect(StatusAttackUp) || self.HasStatusEffect(StatusDefenseUp) || self.HasStatusEffect(StatusSpeedUp)
+	if !buffed && self.CurrentHP*100 >= self.MaxHP*75 {
+		for _, ability := range options {
+			if buffAbility(ability) {
+				return ability
+			}
+		}
+	}
+
+	return GreedyStrategy{}.ChooseAbility(bs, options)
+}
+
+// healingAbility reports whether an ability restores the user's HP
+func healingAbility(ability *Ability) bool {
+	for _, effect := range AbilityTemplates[ability.TemplateID].Effects {
+		if effect.Type == EffectHeal {
+			return true
+		}
+	}
+	return false
+}
+
+// buffAbility reports whether an ability raises the user's stats or protects it, without healing
+func buffAbility(ability *Ability) bool {
+	if healingAbility(ability) {
+		return false
+	}
+	for _, effect := range AbilityTemplates[ability.TemplateID].Effects {
+		if effect.Type == EffectProtect || (effect.Type == EffectStatStage && effect.Stages > 0 && effect.Target == EffectTargetSelf) ||
+			(effect.Type == EffectStatBoost && effect.Target == EffectTargetSelf) {
+			return true
+		}
+	}
+	return false
+}
+
+// averageHits is the average number of hits of an ability
+func averageHits(ability *Ability) float64 {
+	for _, effect := range AbilityTemplates[ability.TemplateID].Effects {
+		if effect.Type == EffectMultiHit {
+			return float64(effect.MinHits+effect.MaxHits) / 2
+		}
+	}
+	return 1
+}
+
+// expectedDamage estimates the damage an ability deals to the target, without random variance
+func expectedDamage(field *Field, user, target *Gopher, ability *Ability) float64 {
+	if ability.Targeting == TargetingSelf || ability.Power <= 0 {
+		return 0
+	}
+
+	damage := float64(user.Attack)*(float64(ability.Power)/100.0) - float64(target.Defense)*0.5
+	if damage < 1 {
+		damage = 1
+	}
+	damage *= typeEffectiveness(user, target) * heldItemDamageMultiplier(user) * field.damageMultiplier(user)
+
+	template := AbilityTemplates[ability.TemplateID]
+	damage *= 1 + criticalChance(user, field, template.HighCrit)*(CriticalMultiplier-1)
+	return damage * averageHits(ability) * hitChance(template.Accuracy, user, target)
+}
+
+// strongestAttack returns the ability with the highest expected damage and that damage
+func strongestAttack(field *Field, user, target *Gopher, options []*Ability) (*Ability, float64) {
+	var best *Ability
+	bestDamage := 0.0
+	for _, ability := range options {
+		if damage := expectedDamage(field, user, target, ability); damage > bestDamage {
+			best, bestDamage = ability, damage
+		}
+	}
+	return best, bestDamage
+}
+
+// typeEffectiveness returns the attacker's type effectiveness against the defender
+func typeEffectiveness(attacker, defender *Gopher) float64 {
+	if attacker.SecondaryType != "" {
+		return GetDualTypeEffectiveness(attacker.PrimaryType, attacker.SecondaryType, defender.PrimaryType, defender.SecondaryType)
+	}
+	if defender.SecondaryType != "" {
+		eff1 := GetTypeEffectiveness(attacker.PrimaryType, defender.PrimaryType)
+		eff2 := GetTypeEffectiveness(attacker.PrimaryType, defender.SecondaryType)
+		return (eff1 + eff2) / 2.0
+	}
+	return GetTypeEffectiveness(attacker.PrimaryType, defender.PrimaryType)
+}
@@ -0,0 +1,123 @@
+package game
+
+import "testing"
+
+// newStrategyBattle sets a level-20 Hacker enemy against a player gopher of the given type
+func newStrategyBattle(t *testing.T, playerType GopherType) *BattleState {
+	t.Helper()
+	rng := NewRNG(12)
+	enemy := NewSimGopher("enemy", SimGopherSpec{Archetype: ArchetypeHacker, Rarity: "RARE", Level: 20}, rng)
+	player := NewSimGopher("player", SimGopherSpec{Archetype: ArchetypeTank, Rarity: "RARE", Level: 20}, rng)
+	player.PrimaryType = playerType
+	bs := NewBattleState("trainer", "channel", player, enemy, []*Gopher{player}, nil, 12)
+	bs.Field = &Field{}
+	return bs
+}
+
+// testAbilities creates abilities from their template IDs
+func testAbilities(t *testing.T, templateIDs ...string) []*Ability {
+	t.Helper()
+	abilities := []*Ability{}
+	for _, templateID := range templateIDs {
+		ability, err := CreateAbilityFromTemplate(templateID, templateID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		abilities = append(abilities, ability)
+	}
+	return abilities
+}
+
+func TestGreedyStrategyPicksStrongestAttack(t *testing.T) {
+	bs := newStrategyBattle(t, TypeSpeedy)
+	options := testAbilities(t, "quick_hit", "garbage_collector", "channel_overload", "go_panic")
+
+	if got := (GreedyStrategy{}).ChooseAbility(bs, options); got.TemplateID != "channel_overload" {
+		t.Errorf("chose %s, want channel_overload", got.TemplateID)
+	}
+
+	// The matchup is part of the expected damage
+	superEffective := expectedDamage(bs.Field, bs.EnemyGopher, bs.PlayerGopher, options[2])
+	bs.PlayerGopher.PrimaryType = TypeHacker
+	neutral := expectedDamage(bs.Field, bs.EnemyGopher, bs.PlayerGopher, options[2])
+	if !approxEqual(superEffective, 2*neutral) {
+		t.Errorf("expected damage against a Speedy gopher = %.1f, want twice %.1f", superEffective, neutral)
+	}
+}
+
+func TestGreedyStrategySkipsHealingAtFullHP(t *testing.T) {
+	bs := newStrategyBattle(t, TypeHacker)
+	options := testAbilities(t, "garbage_collector", "power_up")
+
+	for i := 0; i < 20; i++ {
+		if got := (GreedyStrategy{}).ChooseAbility(bs, options); got.TemplateID == "garbage_collector" {
+			t.Fatal("healed at full HP")
+		}
+	}
+
+	bs.EnemyGopher.CurrentHP = bs.EnemyGopher.MaxHP / 2
+	healed := false
+	for i := 0; i < 20 && !healed; i++ {
+		healed = (GreedyStrategy{}).ChooseAbility(bs, options).TemplateID == "garbage_collector"
+	}
+	if !healed {
+		t.Error("never healed after taking damage")
+	}
+}
+
+func TestHeuristicStrategy(t *testing.T) {
+	tests := []struct {
+		name     string
+		enemyHP  int // Percent of max HP
+		playerHP int // 0 keeps the player at full HP
+		buffed   bool
+		want     string
+	}{
+		{name: "cheapest finisher", enemyHP: 100, playerHP: 1, want: "quick_hit"},
+		{name: "heal when low", enemyHP: 30, want: "garbage_collector"},
+		{name: "no heal above 35%", enemyHP: 40, want: "channel_overload"},
+		{name: "buff while healthy", enemyHP: 80, want: "power_up"},
+		{name: "attack once buffed", enemyHP: 100, buffed: true, want: "channel_overload"},
+		{name: "attack once hurt", enemyHP: 70, want: "channel_overload"},
+	}
+	for _, tt := range tests {
+		bs := newStrategyBattle(t, TypeHacker)
+		options := testAbilities(t, "channel_overload", "power_up", "go_panic", "garbage_collector", "quick_hit")
+
+		enemy, player := bs.EnemyGopher, bs.PlayerGopher
+		enemy.CurrentHP = enemy.MaxHP * tt.enemyHP / 100
+		player.MaxHP, player.CurrentHP = 10000, 10000
+		if tt.playerHP > 0 {
+			player.CurrentHP = tt.playerHP
+		}
+		if tt.buffed {
+			enemy.AddStatusEffect(StatusAttackUp, 3, 1)
+		}
+
+		if got := (HeuristicStrategy{}).ChooseAbility(bs, options); got.TemplateID != tt.want {
+			t.Errorf("%s: chose %s, want %s", tt.name, got.TemplateID, tt.want)
+		}
+	}
+}
+
+func TestStrategyFor(t *testing.T) {
+	tests := []struct {
+		opponentType, rarity string
+		want                 EnemyStrategy
+	}{
+		{"WILD", "COMMON", RandomStrategy{}},
+		{"WILD", "UNCOMMON", RandomStrategy{}},
+		{"WILD", "RARE", GreedyStrategy{}},
+		{"WILD", "EPIC", GreedyStrategy{}},
+		{"WILD", "LEGENDARY", HeuristicStrategy{}},
+		{"TRAINER", "COMMON", GreedyStrategy{}},
+		{"GYM", "COMMON", GreedyStrategy{}},
+		{"BOSS", "COMMON", HeuristicStrategy{}},
+		{"PVE_BOSS", "RARE", HeuristicStrategy{}},
+	}
+	for _, tt := range tests {
+		if got := StrategyFor(tt.opponentType, tt.rarity); got != tt.want {
+			t.Errorf("StrategyFor(%s, %s) = %T, want %T", tt.opponentType, tt.rarity, got, tt.want)
+		}
+	}
+}
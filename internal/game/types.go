@@ -1,9 +1,5 @@
 package game
 
-import (
-	"math/rand"
-)
-
 // GopherType represents a gopher's elemental type
 type GopherType string
 
@@ -20,50 +16,49 @@ func AllTypes() []GopherType {
 	return []GopherType{TypeHacker, TypeTank, TypeSpeedy, TypeSupport, TypeMage}
 }
 
+// typeChart is the type effectiveness chart (like Pokemon)
+// Format: typeChart[attacker][defender] = effectiveness
+var typeChart = map[GopherType]map[GopherType]float64{
+	TypeHacker: {
+		TypeHacker:  1.0,  // Hacker vs Hacker: normal
+		TypeTank:    0.5,  // Hacker vs Tank: not very effective (code can't break through armor)
+		TypeSpeedy:  2.0,  // Hacker vs Speedy: super effective (code exploits speed)
+		TypeSupport: 1.5,  // Hacker vs Support: effective (code disrupts support systems)
+		TypeMage:    1.0,  // Hacker vs Mage: normal
+	},
+	TypeTank: {
+		TypeHacker:  2.0,  // Tank vs Hacker: super effective (armor blocks code)
+		TypeTank:    0.5,  // Tank vs Tank: not very effective (armor vs armor)
+		TypeSpeedy:  0.5,  // Tank vs Speedy: not very effective (too slow to hit)
+		TypeSupport: 1.5,  // Tank vs Support: effective (physical pressure)
+		TypeMage:    1.5,  // Tank vs Mage: effective (armor resists magic)
+	},
+	TypeSpeedy: {
+		TypeHacker:  1.5,  // Speedy vs Hacker: effective (speed outruns code)
+		TypeTank:    2.0,  // Speedy vs Tank: super effective (speed bypasses armor)
+		TypeSpeedy:  1.0,  // Speedy vs Speedy: normal
+		TypeSupport: 1.0,  // Speedy vs Support: normal
+		TypeMage:    1.5,  // Speedy vs Mage: effective (speed disrupts casting)
+	},
+	TypeSupport: {
+		TypeHacker:  1.0,  // Support vs Hacker: normal
+		TypeTank:    1.5,  // Support vs Tank: effective (support counters defense)
+		TypeSpeedy:  1.0,  // Support vs Speedy: normal
+		TypeSupport: 0.5,  // Support vs Support: not very effective (support cancels support)
+		TypeMage:    2.0,  // Support vs Mage: super effective (support amplifies against magic)
+	},
+	TypeMage: {
+		TypeHacker:  1.5,  // Mage vs Hacker: effective (magic disrupts code)
+		TypeTank:    1.0,  // Mage vs Tank: normal (magic vs armor)
+		TypeSpeedy:  1.5,  // Mage vs Speedy: effective (magic catches speed)
+		TypeSupport: 0.5,  // Mage vs Support: not very effective (support counters magic)
+		TypeMage:    1.0,  // Mage vs Mage: normal
+	},
+}
+
 // GetTypeEffectiveness returns the effectiveness multiplier (0.5 = not very effective, 1.0 = normal, 2.0 = super effective)
 func GetTypeEffectiveness(attackerType, defenderType GopherType) float64 {
-	// Type effectiveness chart (like Pokemon)
-	// Format: attacker > defender = effectiveness
-	
-	effectiveness := map[GopherType]map[GopherType]float64{
-		TypeHacker: {
-			TypeHacker:  1.0,  // Hacker vs Hacker: normal
-			TypeTank:    0.5,  // Hacker vs Tank: not very effective (code can't break through armor)
-			TypeSpeedy:  2.0,  // Hacker vs Speedy: super effective (code exploits speed)
-			TypeSupport: 1.5,  // Hacker vs Support: effective (code disrupts support systems)
-			TypeMage:    1.0,  // Hacker vs Mage: normal
-		},
-		TypeTank: {
-			TypeHacker:  2.0,  // Tank vs Hacker: super effective (armor blocks code)
-			TypeTank:    0.5,  // Tank vs Tank: not very effective (armor vs armor)
-			TypeSpeedy:  0.5,  // Tank vs Speedy: not very effective (too slow to hit)
-			TypeSupport: 1.5,  // Tank vs Support: effective (physical pressure)
-			TypeMage:    1.5,  // Tank vs Mage: effective (armor resists magic)
-		},
-		TypeSpeedy: {
-			TypeHacker:  1.5,  // Speedy vs Hacker: effective (speed outruns code)
-			TypeTank:    2.0,  // Speedy vs Tank: super effective (speed bypasses armor)
-			TypeSpeedy:  1.0,  // Speedy vs Speedy: normal
-			TypeSupport: 1.0,  // Speedy vs Support: normal
-			TypeMage:    1.5,  // Speedy vs Mage: effective (speed disrupts casting)
-		},
-		TypeSupport: {
-			TypeHacker:  1.0,  // Support vs Hacker: normal
-			TypeTank:    1.5,  // Support vs Tank: effective (support counters defense)
-			TypeSpeedy:  1.0,  // Support vs Speedy: normal
-			TypeSupport: 0.5,  // Support vs Support: not very effective (support cancels support)
-			TypeMage:    2.0,  // Support vs Mage: super effective (support amplifies against magic)
-		},
-		TypeMage: {
-			TypeHacker:  1.5,  // Mage vs Hacker: effective (magic disrupts code)
-			TypeTank:    1.0,  // Mage vs Tank: normal (magic vs armor)
-			TypeSpeedy:  1.5,  // Mage vs Speedy: effective (magic catches speed)
-			TypeSupport: 0.5,  // Mage vs Support: not very effective (support counters magic)
-			TypeMage:    1.0,  // Mage vs Mage: normal
-		},
-	}
-
-	if chart, ok := effectiveness[attackerType]; ok {
+	if chart, ok := typeChart[attackerType]; ok {
 		if mult, ok := chart[defenderType]; ok {
 			return mult
 		}
@@ -122,7 +117,7 @@ func GetTypeFromArchetype(archetype Archetype) GopherType {
 }
 
 // GetRandomSecondaryType returns a random secondary type (for dual-type gophers)
-func GetRandomSecondaryType(primaryType GopherType) GopherType {
+func GetRandomSecondaryType(primaryType GopherType, rng *RNG) GopherType {
 	allTypes := AllTypes()
 	// Remove primary type from options
 	options := []GopherType{}
@@ -136,7 +131,7 @@ func GetRandomSecondaryType(primaryType GopherType) GopherType {
 		return primaryType
 	}
 	
-	return options[rand.Intn(len(options))]
+	return options[rng.Intn(len(options))]
 }
 
 // GetTypeEffectivenessMessage returns a message describing type effectiveness
@@ -381,6 +381,82 @@ func (g *Generator) generateBattleCardImage(enemyImg, playerImg image.Image, ene
 	return card, nil
 }
 
+// BattleCardGopher is one gopher drawn on a double battle card
+type BattleCardGopher struct {
+	Image image.Image
+	Name  string
+	Level int
+}
+
+// GenerateDoubleBattleCardFromImagesToBase64 creates a battle card with up to two gophers on
+// each platform and returns base64
+func (g *Generator) GenerateDoubleBattleCardFromImagesToBase64(enemies, players []BattleCardGopher) (string, error) {
+	card, err := g.generateDoubleBattleCardImage(enemies, players)
+	if err != nil {
+		return "", err
+	}
+	return g.EncodeImageToBase64(card)
+}
+
+// generateDoubleBattleCardImage creates a double battle card on the battle screen background.
+// Each side's gophers share its platform, drawn smaller and side by side.
+func (g *Generator) generateDoubleBattleCardImage(enemies, players []BattleCardGopher) (image.Image, error) {
+	assetsDir := filepath.Dir(g.assetsPath)
+	battleScreenPath := filepath.Join(assetsDir, "battle_screen.png")
+	battleScreen, err := g.loadImage(battleScreenPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load battle screen from %s: %w", battleScreenPath, err)
+	}
+
+	battleBounds := battleScreen.Bounds()
+	card := image.NewRGBA(image.Rect(0, 0, battleBounds.Dx(), battleBounds.Dy()))
+	draw.Draw(card, card.Bounds(), battleScreen, image.Point{}, draw.Src)
+
+	// Same platforms as a single battle, with the pair offset to either side of the center
+	g.drawPlatformPair(card, players, 360, 720)
+	g.drawPlatformPair(card, enemies, 1120, 720)
+
+	// Names and levels, one gopher per line, in the frames at the top
+	cyanColor := color.RGBA{R: 0, G: 255, B: 255, A: 255}
+	fontSize := 26
+	lineHeight := fontSize + 8
+	g.drawTextMultilineScaled(card, battleCardLines(players), 150, 130, cyanColor, fontSize, lineHeight)
+	g.drawTextMultilineScaled(card, battleCardLines(enemies), 935, 130, cyanColor, fontSize, lineHeight)
+
+	return card, nil
+}
+
+// drawPlatformPair draws up to two gophers on the platform centered at (platformX, platformY).
+// The second gopher stands slightly behind the first.
+func (g *Generator) drawPlatformPair(card *image.RGBA, gophers []BattleCardGopher, platformX, platformY int) {
+	maxGopherSize := 220
+	offsets := []image.Point{{X: -120, Y: 20}, {X: 120, Y: -20}}
+	if len(gophers) == 1 {
+		offsets = []image.Point{{}}
+	}
+
+	// Draw back to front so the first gopher overlaps the second
+	for idx := len(gophers) - 1; idx >= 0; idx-- {
+		if idx >= len(offsets) || gophers[idx].Image == nil {
+			continue
+		}
+		scaled := g.resizeImage(gophers[idx].Image, maxGopherSize, maxGopherSize)
+		bounds := scaled.Bounds()
+		x := platformX + offsets[idx].X - bounds.Dx()/2
+		y := platformY + offsets[idx].Y - bounds.Dy()/2
+		draw.Draw(card, image.Rect(x, y, x+bounds.Dx(), y+bounds.Dy()), scaled, image.Point{}, draw.Over)
+	}
+}
+
+// battleCardLines lists gophers' names and levels, one per line
+func battleCardLines(gophers []BattleCardGopher) string {
+	lines := []string{}
+	for _, gopher := range gophers {
+		lines = append(lines, fmt.Sprintf("%s Lv.%d", gopher.Name, gopher.Level))
+	}
+	return strings.Join(lines, "\n")
+}
+
 // resizeImage resizes an image to fit within maxWidth and maxHeight while maintaining aspect ratio
 func (g *Generator) resizeImage(img image.Image, maxWidth, maxHeight int) image.Image {
 	bounds := img.Bounds()
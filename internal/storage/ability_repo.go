@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"fmt"
+)
+
+// Ability is an ability a gopher has learned. TemplateID refers to a game ability template.
+type Ability struct {
+	ID          string
+	GopherID    string
+	TemplateID  string
+	Name        string
+	Description string
+	Power       int
+	Cost        int
+	Targeting   string
+	Slot        int
+	Active      bool // False for abilities the gopher forgot or chose not to learn
+}
+
+type AbilityRepo struct {
+	db *DB
+}
+
+func NewAbilityRepo(db *DB) *AbilityRepo {
+	return &AbilityRepo{db: db}
+}
+
+// GetByGopherID returns a gopher's active abilities in slot order, followed by its inactive ones
+func (r *AbilityRepo) GetByGopherID(gopherID string) ([]*Ability, error) {
+	rows, err := r.db.Conn().Query(
+		`SELECT id, gopher_id, COALESCE(template_id, ''), name, description, power, cost, targeting, COALESCE(slot, 0), COALESCE(active, TRUE)
+		 FROM abilities WHERE gopher_id = ? ORDER BY COALESCE(active, TRUE) DESC, slot`,
+		gopherID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query abilities: %w", err)
+	}
+	defer rows.Close()
+
+	var abilities []*Ability
+	for rows.Next() {
+		a := &Ability{}
+		if err := rows.Scan(&a.ID, &a.GopherID, &a.TemplateID, &a.Name, &a.Description, &a.Power, &a.Cost, &a.Targeting, &a.Slot, &a.Active); err != nil {
+			return nil, fmt.Errorf("failed to scan ability: %w", err)
+		}
+		abilities = append(abilities, a)
+	}
+	return abilities, nil
+}
+
+// ReplaceForGopher stores a gopher's full set of abilities, replacing any it had before
+func (r *AbilityRepo) ReplaceForGopher(gopherID string, abilities []*Ability) error {
+	tx, err := r.db.Conn().Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM abilities WHERE gopher_id = ?", gopherID); err != nil {
+		return fmt.Errorf("failed to clear abilities: %w", err)
+	}
+
+	for _, a := range abilities {
+		_, err := tx.Exec(
+			`INSERT INTO abilities (id, gopher_id, template_id, name, description, power, cost, targeting, slot, active)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			a.ID, gopherID, a.TemplateID, a.Name, a.Description, a.Power, a.Cost, a.Targeting, a.Slot, a.Active,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save ability: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit abilities: %w", err)
+	}
+	return nil
+}
+
+// GetOwnedGopherIDsWithoutAbilities returns trainer-owned gophers that have no stored abilities yet
+func (r *AbilityRepo) GetOwnedGopherIDsWithoutAbilities() ([]string, error) {
+	rows, err := r.db.Conn().Query(
+		`SELECT id FROM gophers
+		 WHERE trainer_id IS NOT NULL
+		   AND NOT EXISTS (SELECT 1 FROM abilities WHERE abilities.gopher_id = gophers.id)`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query gophers without abilities: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan gopher id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
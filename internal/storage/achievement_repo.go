@@ -56,10 +56,10 @@ func (r *AchievementRepo) GetOrCreate(trainerID, achievementType string) (*Achie
 	}
 
 	if completedAt.Valid {
-		t, _ := time.Parse("2006-01-02 15:04:05", completedAt.String)
+		t := parseTimestamp(completedAt.String)
 		ach.CompletedAt = &t
 	}
-	ach.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
+	ach.CreatedAt = parseTimestamp(createdAt)
 	return &ach, nil
 }
 
@@ -77,6 +77,20 @@ func (r *AchievementRepo) UpdateProgress(trainerID, achievementType string, prog
 	return err
 }
 
+// SetProgress raises progress to an absolute value; it never lowers it
+func (r *AchievementRepo) SetProgress(trainerID, achievementType string, progress int) error {
+	ach, err := r.GetOrCreate(trainerID, achievementType)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Conn().Exec(
+		"UPDATE achievements SET progress = MAX(progress, ?) WHERE id = ?",
+		progress, ach.ID,
+	)
+	return err
+}
+
 func (r *AchievementRepo) Complete(trainerID, achievementType string) error {
 	ach, err := r.GetOrCreate(trainerID, achievementType)
 	if err != nil {
@@ -113,10 +127,10 @@ func (r *AchievementRepo) GetAchievements(trainerID string) ([]*Achievement, err
 			return nil, fmt.Errorf("failed to scan achievement: %w", err)
 		}
 		if completedAt.Valid {
-			t, _ := time.Parse("2006-01-02 15:04:05", completedAt.String)
+			t := parseTimestamp(completedAt.String)
 			ach.CompletedAt = &t
 		}
-		ach.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
+		ach.CreatedAt = parseTimestamp(createdAt)
 		achievements = append(achievements, ach)
 	}
 	return achievements, nil
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Badge is a gym badge a trainer has earned
+type Badge struct {
+	TrainerID string
+	BadgeID   string
+	NPCID     string // Gym leader who awarded it
+	EarnedAt  time.Time
+}
+
+type BadgeRepo struct {
+	db *DB
+}
+
+func NewBadgeRepo(db *DB) *BadgeRepo {
+	return &BadgeRepo{db: db}
+}
+
+// Award gives a trainer a badge. Returns false if they already had it.
+func (r *BadgeRepo) Award(trainerID, badgeID, npcID string) (bool, error) {
+	result, err := r.db.Conn().Exec(
+		`INSERT OR IGNORE INTO trainer_badges (trainer_id, badge_id, npc_id) VALUES (?, ?, ?)`,
+		trainerID, badgeID, npcID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to award badge: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to award badge: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// GetByTrainerID returns a trainer's badges in the order they were earned
+func (r *BadgeRepo) GetByTrainerID(trainerID string) ([]*Badge, error) {
+	rows, err := r.db.Conn().Query(
+		`SELECT trainer_id, badge_id, npc_id, earned_at FROM trainer_badges
+		 WHERE trainer_id = ? ORDER BY earned_at, rowid`,
+		trainerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get badges: %w", err)
+	}
+	defer rows.Close()
+
+	badges := []*Badge{}
+	for rows.Next() {
+		var badge Badge
+		var earnedAt string
+		if err := rows.Scan(&badge.TrainerID, &badge.BadgeID, &badge.NPCID, &earnedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan badge: %w", err)
+		}
+		badge.EarnedAt = parseTimestamp(earnedAt)
+		badges = append(badges, &badge)
+	}
+	return badges, rows.Err()
+}
@@ -16,8 +16,14 @@ type Battle struct {
 	OpponentType string
 	GopherIDPlayer *string
 	GopherIDEnemy  *string
+	NPCID          *string // NPC trainer being battled; nil in wild battles
 	TurnOwner    string
 	State        string
+	XPMultiplier float64
+	Energy       string // JSON map of gopher ID to remaining energy
+	Seed         int64  // Seed of the battle's random source
+	RNGDraws     int64  // Values drawn from the random source so far
+	Snapshot     string // JSON snapshot of the rest of the battle state
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 }
@@ -35,14 +41,31 @@ func (r *BattleRepo) Create(b *Battle) (*Battle, error) {
 		b.ID = uuid.New().String()
 	}
 
+	xpMultiplier := b.XPMultiplier
+	if xpMultiplier <= 0 {
+		xpMultiplier = 1.0
+	}
+
+	energy := b.Energy
+	if energy == "" {
+		energy = "{}"
+	}
+
+	snapshot := b.Snapshot
+	if snapshot == "" {
+		snapshot = "{}"
+	}
+
 	query := `INSERT INTO battles (
 		id, channel_id, message_id, trainer_id, opponent_type,
-		gopher_id_player, gopher_id_enemy, turn_owner, state
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		gopher_id_player, gopher_id_enemy, npc_id, turn_owner, state, xp_multiplier, energy,
+		seed, rng_draws, snapshot
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	_, err := r.db.Conn().Exec(query,
 		b.ID, b.ChannelID, b.MessageID, b.TrainerID, b.OpponentType,
-		b.GopherIDPlayer, b.GopherIDEnemy, b.TurnOwner, b.State,
+		b.GopherIDPlayer, b.GopherIDEnemy, b.NPCID, b.TurnOwner, b.State, xpMultiplier, energy,
+		b.Seed, b.RNGDraws, snapshot,
 	)
 
 	if err != nil {
@@ -54,19 +77,20 @@ func (r *BattleRepo) Create(b *Battle) (*Battle, error) {
 
 func (r *BattleRepo) GetByID(id string) (*Battle, error) {
 	query := `SELECT id, channel_id, message_id, trainer_id, opponent_type,
-	          gopher_id_player, gopher_id_enemy, turn_owner, state,
-	          created_at, updated_at
+	          gopher_id_player, gopher_id_enemy, npc_id, turn_owner, state,
+	          COALESCE(xp_multiplier, 1.0), COALESCE(energy, '{}'), COALESCE(seed, 0), COALESCE(rng_draws, 0), COALESCE(snapshot, '{}'), created_at, updated_at
 	          FROM battles WHERE id = ?`
 
 	var b Battle
 	var playerID sql.NullString
 	var enemyID sql.NullString
+	var npcID sql.NullString
 	var createdAt, updatedAt string
 
 	err := r.db.Conn().QueryRow(query, id).Scan(
 		&b.ID, &b.ChannelID, &b.MessageID, &b.TrainerID, &b.OpponentType,
-		&playerID, &enemyID, &b.TurnOwner, &b.State,
-		&createdAt, &updatedAt,
+		&playerID, &enemyID, &npcID, &b.TurnOwner, &b.State,
+		&b.XPMultiplier, &b.Energy, &b.Seed, &b.RNGDraws, &b.Snapshot, &createdAt, &updatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -82,6 +106,9 @@ func (r *BattleRepo) GetByID(id string) (*Battle, error) {
 	if enemyID.Valid {
 		b.GopherIDEnemy = &enemyID.String
 	}
+	if npcID.Valid {
+		b.NPCID = &npcID.String
+	}
 
 	b.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
 	b.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
@@ -91,19 +118,20 @@ func (r *BattleRepo) GetByID(id string) (*Battle, error) {
 
 func (r *BattleRepo) GetByMessageID(channelID, messageID string) (*Battle, error) {
 	query := `SELECT id, channel_id, message_id, trainer_id, opponent_type,
-	          gopher_id_player, gopher_id_enemy, turn_owner, state,
-	          created_at, updated_at
+	          gopher_id_player, gopher_id_enemy, npc_id, turn_owner, state,
+	          COALESCE(xp_multiplier, 1.0), COALESCE(energy, '{}'), COALESCE(seed, 0), COALESCE(rng_draws, 0), COALESCE(snapshot, '{}'), created_at, updated_at
 	          FROM battles WHERE channel_id = ? AND message_id = ? AND state = 'ACTIVE'`
 
 	var b Battle
 	var playerID sql.NullString
 	var enemyID sql.NullString
+	var npcID sql.NullString
 	var createdAt, updatedAt string
 
 	err := r.db.Conn().QueryRow(query, channelID, messageID).Scan(
 		&b.ID, &b.ChannelID, &b.MessageID, &b.TrainerID, &b.OpponentType,
-		&playerID, &enemyID, &b.TurnOwner, &b.State,
-		&createdAt, &updatedAt,
+		&playerID, &enemyID, &npcID, &b.TurnOwner, &b.State,
+		&b.XPMultiplier, &b.Energy, &b.Seed, &b.RNGDraws, &b.Snapshot, &createdAt, &updatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -119,6 +147,9 @@ func (r *BattleRepo) GetByMessageID(channelID, messageID string) (*Battle, error
 	if enemyID.Valid {
 		b.GopherIDEnemy = &enemyID.String
 	}
+	if npcID.Valid {
+		b.NPCID = &npcID.String
+	}
 
 	b.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
 	b.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
@@ -128,13 +159,14 @@ func (r *BattleRepo) GetByMessageID(channelID, messageID string) (*Battle, error
 
 func (r *BattleRepo) Update(b *Battle) error {
 	query := `UPDATE battles SET
-		gopher_id_player = ?, gopher_id_enemy = ?,
-		turn_owner = ?, state = ?, updated_at = CURRENT_TIMESTAMP
+		message_id = ?, gopher_id_player = ?, gopher_id_enemy = ?,
+		turn_owner = ?, state = ?, energy = ?, rng_draws = ?, snapshot = ?,
+		updated_at = CURRENT_TIMESTAMP
 		WHERE id = ?`
 
 	_, err := r.db.Conn().Exec(query,
-		b.GopherIDPlayer, b.GopherIDEnemy,
-		b.TurnOwner, b.State, b.ID,
+		b.MessageID, b.GopherIDPlayer, b.GopherIDEnemy,
+		b.TurnOwner, b.State, b.Energy, b.RNGDraws, b.Snapshot, b.ID,
 	)
 
 	return err
@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
@@ -50,6 +51,16 @@ func (db *DB) Conn() *sql.DB {
 	return db.conn
 }
 
+// parseTimestamp parses a DATETIME column. The driver returns RFC3339 for columns declared
+// as DATETIME, while values built with SQLite's datetime() may come back in SQL format.
+func parseTimestamp(value string) time.Time {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	t, _ := time.Parse("2006-01-02 15:04:05", value)
+	return t
+}
+
 func (db *DB) runMigrations() error {
 	// Create migrations tracking table
 	_, err := db.conn.Exec(`
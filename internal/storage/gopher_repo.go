@@ -32,6 +32,7 @@ type Gopher struct {
 	StatusEffects   string  // JSON string of status effects
 	Shiny           bool    // Whether this gopher is shiny (rare color variant)
 	IsFavorite      bool    // Whether this gopher is marked as favorite
+	HeldItem        string  // Item type the gopher holds in battle, empty if none
 	IsInParty       bool
 	PCSlot          *int
 	CreatedAt       time.Time
@@ -65,15 +66,15 @@ func (r *GopherRepo) Create(g *Gopher) (*Gopher, error) {
 		id, trainer_id, name, level, xp, current_hp, max_hp, 
 		attack, defense, speed, rarity, complexity_score, 
 		species_archetype, evolution_stage, primary_type, secondary_type,
-		sprite_path, sprite_data, gopherkon_layers, status_effects, shiny, is_favorite, is_in_party, pc_slot
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+		sprite_path, sprite_data, gopherkon_layers, status_effects, shiny, is_favorite, held_item, is_in_party, pc_slot
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	_, err = r.db.Conn().Exec(query,
 		g.ID, g.TrainerID, g.Name, g.Level, g.XP,
 		g.CurrentHP, g.MaxHP, g.Attack, g.Defense, g.Speed,
 		g.Rarity, g.ComplexityScore, g.SpeciesArchetype,
 		g.EvolutionStage, g.PrimaryType, g.SecondaryType,
-		g.SpritePath, g.SpriteData, string(layersJSON), statusEffectsJSON, g.Shiny, g.IsFavorite,
+		g.SpritePath, g.SpriteData, string(layersJSON), statusEffectsJSON, g.Shiny, g.IsFavorite, g.HeldItem,
 		g.IsInParty, g.PCSlot,
 	)
 
@@ -88,7 +89,7 @@ func (r *GopherRepo) GetByID(id string) (*Gopher, error) {
 	query := `SELECT id, trainer_id, name, level, xp, current_hp, max_hp,
 	          attack, defense, speed, rarity, complexity_score,
 	          species_archetype, evolution_stage, primary_type, secondary_type,
-	          sprite_path, sprite_data, gopherkon_layers, status_effects, shiny, is_favorite, is_in_party, pc_slot, created_at
+	          sprite_path, sprite_data, gopherkon_layers, status_effects, shiny, is_favorite, held_item, is_in_party, pc_slot, created_at
 	          FROM gophers WHERE id = ?`
 
 	var g Gopher
@@ -107,7 +108,7 @@ func (r *GopherRepo) GetByID(id string) (*Gopher, error) {
 		&g.CurrentHP, &g.MaxHP, &g.Attack, &g.Defense, &g.Speed,
 		&g.Rarity, &g.ComplexityScore, &g.SpeciesArchetype,
 		&g.EvolutionStage, &primaryType, &secondaryType,
-		&spritePath, &spriteData, &layersJSON, &statusEffectsJSON, &g.Shiny, &g.IsFavorite,
+		&spritePath, &spriteData, &layersJSON, &statusEffectsJSON, &g.Shiny, &g.IsFavorite, &g.HeldItem,
 		&g.IsInParty, &pcSlot, &createdAt,
 	)
 
@@ -156,7 +157,7 @@ func (r *GopherRepo) GetByTrainerID(trainerID string) ([]*Gopher, error) {
 	query := `SELECT id, trainer_id, name, level, xp, current_hp, max_hp,
 	          attack, defense, speed, rarity, complexity_score,
 	          species_archetype, evolution_stage, primary_type, secondary_type,
-	          sprite_path, sprite_data, gopherkon_layers, status_effects, shiny, is_favorite, is_in_party, pc_slot, created_at
+	          sprite_path, sprite_data, gopherkon_layers, status_effects, shiny, is_favorite, held_item, is_in_party, pc_slot, created_at
 	          FROM gophers WHERE trainer_id = ? ORDER BY is_in_party DESC, created_at ASC`
 
 	rows, err := r.db.Conn().Query(query, trainerID)
@@ -181,7 +182,7 @@ func (r *GopherRepo) GetParty(trainerID string) ([]*Gopher, error) {
 	query := `SELECT id, trainer_id, name, level, xp, current_hp, max_hp,
 	          attack, defense, speed, rarity, complexity_score,
 	          species_archetype, evolution_stage, primary_type, secondary_type,
-	          sprite_path, sprite_data, gopherkon_layers, status_effects, shiny, is_favorite, is_in_party, pc_slot, created_at
+	          sprite_path, sprite_data, gopherkon_layers, status_effects, shiny, is_favorite, held_item, is_in_party, pc_slot, created_at
 	          FROM gophers WHERE trainer_id = ? AND is_in_party = TRUE
 	          ORDER BY created_at ASC LIMIT 6`
 
@@ -207,7 +208,7 @@ func (r *GopherRepo) GetPC(trainerID string, limit, offset int) ([]*Gopher, erro
 	query := `SELECT id, trainer_id, name, level, xp, current_hp, max_hp,
 	          attack, defense, speed, rarity, complexity_score,
 	          species_archetype, evolution_stage, primary_type, secondary_type,
-	          sprite_path, sprite_data, gopherkon_layers, status_effects, shiny, is_favorite, is_in_party, pc_slot, created_at
+	          sprite_path, sprite_data, gopherkon_layers, status_effects, shiny, is_favorite, held_item, is_in_party, pc_slot, created_at
 	          FROM gophers WHERE trainer_id = ? AND is_in_party = FALSE
 	          ORDER BY pc_slot ASC LIMIT ? OFFSET ?`
 
@@ -246,7 +247,7 @@ func (r *GopherRepo) Update(g *Gopher) error {
 		attack = ?, defense = ?, speed = ?, rarity = ?,
 		complexity_score = ?, species_archetype = ?,
 		evolution_stage = ?, primary_type = ?, secondary_type = ?,
-		sprite_path = ?, sprite_data = ?, gopherkon_layers = ?, status_effects = ?, shiny = ?, is_favorite = ?,
+		sprite_path = ?, sprite_data = ?, gopherkon_layers = ?, status_effects = ?, shiny = ?, is_favorite = ?, held_item = ?,
 		is_in_party = ?, pc_slot = ?
 		WHERE id = ?`
 
@@ -255,14 +256,54 @@ func (r *GopherRepo) Update(g *Gopher) error {
 		g.Attack, g.Defense, g.Speed, g.Rarity,
 		g.ComplexityScore, g.SpeciesArchetype,
 		g.EvolutionStage, g.PrimaryType, g.SecondaryType,
-		g.SpritePath, g.SpriteData, string(layersJSON), statusEffectsJSON, g.Shiny, g.IsFavorite,
+		g.SpritePath, g.SpriteData, string(layersJSON), statusEffectsJSON, g.Shiny, g.IsFavorite, g.HeldItem,
 		g.IsInParty, g.PCSlot, g.ID,
 	)
 
 	return err
 }
 
+// UpdateStats saves a gopher's battle-relevant fields (level, XP, HP, stats, evolution, status
+// effects and held item) without touching ownership. Battles hold their own copy of each gopher,
+// so saving them with Update could undo a trade or party change made while the battle ran.
+func (r *GopherRepo) UpdateStats(g *Gopher) error {
+	layersJSON, err := json.Marshal(g.GopherkonLayers)
+	if err != nil {
+		return fmt.Errorf("failed to marshal layers: %w", err)
+	}
+
+	statusEffectsJSON := g.StatusEffects
+	if statusEffectsJSON == "" {
+		statusEffectsJSON = "[]"
+	}
+
+	query := `UPDATE gophers SET
+		name = ?, level = ?, xp = ?, current_hp = ?, max_hp = ?,
+		attack = ?, defense = ?, speed = ?, rarity = ?,
+		complexity_score = ?, species_archetype = ?,
+		evolution_stage = ?, primary_type = ?, secondary_type = ?,
+		sprite_path = ?, sprite_data = ?, gopherkon_layers = ?, status_effects = ?, held_item = ?
+		WHERE id = ?`
+
+	_, err = r.db.Conn().Exec(query,
+		g.Name, g.Level, g.XP, g.CurrentHP, g.MaxHP,
+		g.Attack, g.Defense, g.Speed, g.Rarity,
+		g.ComplexityScore, g.SpeciesArchetype,
+		g.EvolutionStage, g.PrimaryType, g.SecondaryType,
+		g.SpritePath, g.SpriteData, string(layersJSON), statusEffectsJSON, g.HeldItem,
+		g.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update gopher stats: %w", err)
+	}
+	return nil
+}
+
 func (r *GopherRepo) Delete(id string) error {
+	if _, err := r.db.Conn().Exec(`DELETE FROM abilities WHERE gopher_id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete abilities: %w", err)
+	}
+
 	query := `DELETE FROM gophers WHERE id = ?`
 	_, err := r.db.Conn().Exec(query, id)
 	return err
@@ -275,6 +316,22 @@ func (r *GopherRepo) CountPC(trainerID string) (int, error) {
 	return count, err
 }
 
+// CountByRarity returns how many gophers of a rarity the trainer owns
+func (r *GopherRepo) CountByRarity(trainerID, rarity string) (int, error) {
+	query := `SELECT COUNT(*) FROM gophers WHERE trainer_id = ? AND rarity = ?`
+	var count int
+	err := r.db.Conn().QueryRow(query, trainerID, rarity).Scan(&count)
+	return count, err
+}
+
+// GetHighestLevel returns the level of the trainer's highest level gopher
+func (r *GopherRepo) GetHighestLevel(trainerID string) (int, error) {
+	query := `SELECT COALESCE(MAX(level), 0) FROM gophers WHERE trainer_id = ?`
+	var level int
+	err := r.db.Conn().QueryRow(query, trainerID).Scan(&level)
+	return level, err
+}
+
 // scanGopherRow is a helper to scan a gopher row from a query result
 func (r *GopherRepo) scanGopherRow(rows *sql.Rows) (*Gopher, error) {
 	var g Gopher
@@ -293,7 +350,7 @@ func (r *GopherRepo) scanGopherRow(rows *sql.Rows) (*Gopher, error) {
 		&g.CurrentHP, &g.MaxHP, &g.Attack, &g.Defense, &g.Speed,
 		&g.Rarity, &g.ComplexityScore, &g.SpeciesArchetype,
 		&g.EvolutionStage, &primaryType, &secondaryType,
-		&spritePath, &spriteData, &layersJSON, &statusEffectsJSON, &g.Shiny, &g.IsFavorite,
+		&spritePath, &spriteData, &layersJSON, &statusEffectsJSON, &g.Shiny, &g.IsFavorite, &g.HeldItem,
 		&g.IsInParty, &pcSlot, &createdAt,
 	)
 	if err != nil {
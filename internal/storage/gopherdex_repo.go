@@ -98,7 +98,7 @@ func (r *GopherdexRepo) GetEntries(trainerID string) ([]*GopherdexEntry, error)
 	rows, err := r.db.Conn().Query(
 		`SELECT trainer_id, gopher_name, archetype, rarity, first_encountered_at, 
 		 times_encountered, times_caught, owned
-		 FROM gopherdex WHERE trainer_id = ?`,
+		 FROM gopherdex WHERE trainer_id = ? ORDER BY archetype, gopher_name`,
 		trainerID,
 	)
 	if err != nil {
@@ -114,7 +114,7 @@ func (r *GopherdexRepo) GetEntries(trainerID string) ([]*GopherdexEntry, error)
 			&firstEncountered, &entry.TimesEncountered, &entry.TimesCaught, &entry.Owned); err != nil {
 			return nil, fmt.Errorf("failed to scan gopherdex entry: %w", err)
 		}
-		entry.FirstEncounteredAt, _ = time.Parse("2006-01-02 15:04:05", firstEncountered)
+		entry.FirstEncounteredAt = parseTimestamp(firstEncountered)
 		entries = append(entries, entry)
 	}
 	return entries, nil
@@ -123,10 +123,25 @@ func (r *GopherdexRepo) GetEntries(trainerID string) ([]*GopherdexEntry, error)
 func (r *GopherdexRepo) GetCompletion(trainerID string) (int, int, error) {
 	var total, owned int
 	err := r.db.Conn().QueryRow(
-		`SELECT COUNT(*), SUM(CASE WHEN owned = TRUE THEN 1 ELSE 0 END) 
+		`SELECT COUNT(*), COALESCE(SUM(CASE WHEN owned = TRUE THEN 1 ELSE 0 END), 0)
 		 FROM gopherdex WHERE trainer_id = ?`,
 		trainerID,
 	).Scan(&total, &owned)
 	return owned, total, err
 }
 
+
+// RefreshOwned marks an entry as owned only while the trainer still has a matching gopher
+func (r *GopherdexRepo) RefreshOwned(trainerID, gopherName, archetype, rarity string) error {
+	_, err := r.db.Conn().Exec(
+		`UPDATE gopherdex SET owned = EXISTS(
+		     SELECT 1 FROM gophers WHERE trainer_id = ? AND name = ? AND species_archetype = ? AND rarity = ?
+		 ) WHERE trainer_id = ? AND gopher_name = ? AND archetype = ? AND rarity = ?`,
+		trainerID, gopherName, archetype, rarity,
+		trainerID, gopherName, archetype, rarity,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to refresh gopherdex ownership: %w", err)
+	}
+	return nil
+}
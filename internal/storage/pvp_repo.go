@@ -53,23 +53,27 @@ func (r *PvPRepo) GetOrCreate(trainerID string) (*PvPStats, error) {
 		return nil, fmt.Errorf("failed to get pvp stats: %w", err)
 	}
 
-	stats.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
+	stats.UpdatedAt = parseTimestamp(updatedAt)
 	return &stats, nil
 }
 
 func (r *PvPRepo) UpdateRating(trainerID string, newRating int, won bool) error {
+	if won {
+		return r.recordResult(trainerID, newRating, 1, 0, 0)
+	}
+	return r.recordResult(trainerID, newRating, 0, 1, 0)
+}
+
+func (r *PvPRepo) UpdateRatingDraw(trainerID string, newRating int) error {
+	return r.recordResult(trainerID, newRating, 0, 0, 1)
+}
+
+func (r *PvPRepo) recordResult(trainerID string, newRating int, wins, losses, draws int) error {
 	stats, err := r.GetOrCreate(trainerID)
 	if err != nil {
 		return err
 	}
 
-	var wins, losses, draws int
-	if won {
-		wins = 1
-	} else {
-		losses = 1
-	}
-
 	highestRating := stats.HighestRating
 	if newRating > highestRating {
 		highestRating = newRating
@@ -81,14 +85,25 @@ func (r *PvPRepo) UpdateRating(trainerID string, newRating int, won bool) error
 		 WHERE trainer_id = ?`,
 		wins, losses, draws, newRating, highestRating, trainerID,
 	)
-	return err
+	if err != nil {
+		return fmt.Errorf("failed to update pvp stats: %w", err)
+	}
+	return nil
 }
 
 func (r *PvPRepo) GetLeaderboard(limit int) ([]*PvPStats, error) {
+	return r.GetLeaderboardPage(nil, limit, 0)
+}
+
+// GetLeaderboardPage returns one page of the rating leaderboard, optionally limited to the given trainers
+func (r *PvPRepo) GetLeaderboardPage(trainerIDs []string, limit, offset int) ([]*PvPStats, error) {
+	filter, args := trainerFilter(trainerIDs)
+	args = append(args, limit, offset)
+
 	rows, err := r.db.Conn().Query(
 		`SELECT trainer_id, wins, losses, draws, rating, highest_rating, total_battles, updated_at
-		 FROM pvp_stats ORDER BY rating DESC LIMIT ?`,
-		limit,
+		 FROM pvp_stats WHERE `+filter+` AND total_battles > 0 ORDER BY rating DESC, trainer_id LIMIT ? OFFSET ?`,
+		args...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
@@ -103,9 +118,46 @@ func (r *PvPRepo) GetLeaderboard(limit int) ([]*PvPStats, error) {
 			&s.Rating, &s.HighestRating, &s.TotalBattles, &updatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan pvp stats: %w", err)
 		}
-		s.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
+		s.UpdatedAt = parseTimestamp(updatedAt)
 		stats = append(stats, s)
 	}
 	return stats, nil
 }
 
+// CountLeaderboard returns how many trainers have played a PvP battle
+func (r *PvPRepo) CountLeaderboard(trainerIDs []string) (int, error) {
+	filter, args := trainerFilter(trainerIDs)
+
+	var count int
+	err := r.db.Conn().QueryRow(`SELECT COUNT(*) FROM pvp_stats WHERE `+filter+` AND total_battles > 0`, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count leaderboard: %w", err)
+	}
+	return count, nil
+}
+
+// GetRank returns a trainer's 1-based rating position, or 0 if they haven't played PvP yet
+func (r *PvPRepo) GetRank(trainerID string, trainerIDs []string) (int, error) {
+	var rating int
+	err := r.db.Conn().QueryRow(`SELECT rating FROM pvp_stats WHERE trainer_id = ? AND total_battles > 0`, trainerID).Scan(&rating)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rank: %w", err)
+	}
+
+	filter, args := trainerFilter(trainerIDs)
+	args = append(args, rating, rating, trainerID)
+
+	var ahead int
+	err = r.db.Conn().QueryRow(
+		`SELECT COUNT(*) FROM pvp_stats WHERE `+filter+` AND total_battles > 0
+		 AND (rating > ? OR (rating = ? AND trainer_id < ?))`,
+		args...,
+	).Scan(&ahead)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rank: %w", err)
+	}
+	return ahead + 1, nil
+}
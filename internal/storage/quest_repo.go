@@ -41,7 +41,7 @@ func (r *QuestRepo) Create(quest *Quest) error {
 		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
 		quest.ID, quest.TrainerID, quest.QuestType, quest.QuestName, quest.Description,
 		quest.TargetValue, quest.CurrentProgress, quest.RewardCurrency, quest.RewardXP,
-		quest.Completed, quest.ExpiresAt,
+		quest.Completed, quest.ExpiresAt.UTC().Format("2006-01-02 15:04:05"),
 	)
 	return err
 }
@@ -62,13 +62,30 @@ func (r *QuestRepo) Complete(questID string) error {
 	return err
 }
 
+// questColumns is the column list shared by every quest query
+const questColumns = `id, trainer_id, quest_type, quest_name, description, target_value,
+	 current_progress, reward_currency, reward_xp, completed, expires_at, created_at`
+
 func (r *QuestRepo) GetActiveQuests(trainerID string) ([]*Quest, error) {
-	rows, err := r.db.Conn().Query(
-		`SELECT id, trainer_id, quest_type, quest_name, description, target_value, 
-		 current_progress, reward_currency, reward_xp, completed, expires_at, created_at
+	return r.queryQuests(
+		`SELECT `+questColumns+`
 		 FROM quests WHERE trainer_id = ? AND completed = FALSE AND expires_at > CURRENT_TIMESTAMP`,
 		trainerID,
 	)
+}
+
+// GetCurrentQuests returns every unexpired quest, including completed ones
+func (r *QuestRepo) GetCurrentQuests(trainerID string) ([]*Quest, error) {
+	return r.queryQuests(
+		`SELECT `+questColumns+`
+		 FROM quests WHERE trainer_id = ? AND expires_at > CURRENT_TIMESTAMP
+		 ORDER BY quest_type, target_value`,
+		trainerID,
+	)
+}
+
+func (r *QuestRepo) queryQuests(query string, args ...interface{}) ([]*Quest, error) {
+	rows, err := r.db.Conn().Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query quests: %w", err)
 	}
@@ -76,44 +93,45 @@ func (r *QuestRepo) GetActiveQuests(trainerID string) ([]*Quest, error) {
 
 	var quests []*Quest
 	for rows.Next() {
-		quest := &Quest{}
-		var expiresAt, createdAt string
-		if err := rows.Scan(&quest.ID, &quest.TrainerID, &quest.QuestType, &quest.QuestName,
-			&quest.Description, &quest.TargetValue, &quest.CurrentProgress, &quest.RewardCurrency,
-			&quest.RewardXP, &quest.Completed, &expiresAt, &createdAt); err != nil {
+		quest, err := scanQuestRow(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan quest: %w", err)
 		}
-		quest.ExpiresAt, _ = time.Parse("2006-01-02 15:04:05", expiresAt)
-		quest.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
 		quests = append(quests, quest)
 	}
 	return quests, nil
 }
 
 func (r *QuestRepo) GetQuestByType(trainerID, questType, questName string) (*Quest, error) {
-	query := `SELECT id, trainer_id, quest_type, quest_name, description, target_value, 
-	          current_progress, reward_currency, reward_xp, completed, expires_at, created_at
+	query := `SELECT ` + questColumns + `
 	          FROM quests WHERE trainer_id = ? AND quest_type = ? AND quest_name = ? 
 	          AND completed = FALSE AND expires_at > CURRENT_TIMESTAMP`
-	
-	quest := &Quest{}
-	var expiresAt, createdAt string
-	err := r.db.Conn().QueryRow(query, trainerID, questType, questName).Scan(
-		&quest.ID, &quest.TrainerID, &quest.QuestType, &quest.QuestName,
-		&quest.Description, &quest.TargetValue, &quest.CurrentProgress, &quest.RewardCurrency,
-		&quest.RewardXP, &quest.Completed, &expiresAt, &createdAt,
-	)
+
+	quest, err := scanQuestRow(r.db.Conn().QueryRow(query, trainerID, questType, questName))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get quest: %w", err)
 	}
-	quest.ExpiresAt, _ = time.Parse("2006-01-02 15:04:05", expiresAt)
-	quest.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
 	return quest, nil
 }
 
+// QuestExists reports whether the trainer already has this quest for the current period,
+// whether or not it has been completed
+func (r *QuestRepo) QuestExists(trainerID, questType, questName string) (bool, error) {
+	var count int
+	err := r.db.Conn().QueryRow(
+		`SELECT COUNT(*) FROM quests WHERE trainer_id = ? AND quest_type = ? AND quest_name = ?
+		 AND expires_at > CURRENT_TIMESTAMP`,
+		trainerID, questType, questName,
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check quest: %w", err)
+	}
+	return count > 0, nil
+}
+
 func (r *QuestRepo) CleanupExpired() error {
 	_, err := r.db.Conn().Exec(
 		"DELETE FROM quests WHERE expires_at < CURRENT_TIMESTAMP AND completed = FALSE",
@@ -121,3 +139,15 @@ func (r *QuestRepo) CleanupExpired() error {
 	return err
 }
 
+func scanQuestRow(row rowScanner) (*Quest, error) {
+	quest := &Quest{}
+	var expiresAt, createdAt string
+	if err := row.Scan(&quest.ID, &quest.TrainerID, &quest.QuestType, &quest.QuestName,
+		&quest.Description, &quest.TargetValue, &quest.CurrentProgress, &quest.RewardCurrency,
+		&quest.RewardXP, &quest.Completed, &expiresAt, &createdAt); err != nil {
+		return nil, err
+	}
+	quest.ExpiresAt = parseTimestamp(expiresAt)
+	quest.CreatedAt = parseTimestamp(createdAt)
+	return quest, nil
+}
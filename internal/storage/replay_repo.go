@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Replay battle types
+const (
+	ReplayTypeWild    = "WILD"
+	ReplayTypeTrainer = "TRAINER"
+	ReplayTypePvP     = "PVP"
+)
+
+// BattleReplay is a finished battle with its recorded event stream
+type BattleReplay struct {
+	ID         string // ID of the battle
+	BattleType string
+	Trainer1ID string  // Trainer of a wild or NPC battle, or the challenger in PvP
+	Trainer2ID *string // Challenged trainer in PvP
+	Title      string
+	Result     string
+	Turns      int
+	Seed       int64
+	Events     string // JSON list of battle events
+	CreatedAt  time.Time
+}
+
+type ReplayRepo struct {
+	db *DB
+}
+
+func NewReplayRepo(db *DB) *ReplayRepo {
+	return &ReplayRepo{db: db}
+}
+
+const replayColumns = `id, battle_type, trainer1_id, trainer2_id, title, result, turns, seed, events, created_at`
+
+func (r *ReplayRepo) Create(replay *BattleReplay) error {
+	events := replay.Events
+	if events == "" {
+		events = "[]"
+	}
+
+	_, err := r.db.Conn().Exec(
+		`INSERT INTO battle_replays (id, battle_type, trainer1_id, trainer2_id, title, result, turns, seed, events)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		replay.ID, replay.BattleType, replay.Trainer1ID, replay.Trainer2ID,
+		replay.Title, replay.Result, replay.Turns, replay.Seed, events,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create battle replay: %w", err)
+	}
+	return nil
+}
+
+func (r *ReplayRepo) GetByID(id string) (*BattleReplay, error) {
+	row := r.db.Conn().QueryRow(`SELECT `+replayColumns+` FROM battle_replays WHERE id = ?`, id)
+	replay, err := scanReplayRow(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get battle replay: %w", err)
+	}
+	return replay, nil
+}
+
+// GetByTrainerID returns the trainer's most recent battles, newest first
+func (r *ReplayRepo) GetByTrainerID(trainerID string, limit int) ([]*BattleReplay, error) {
+	rows, err := r.db.Conn().Query(
+		`SELECT `+replayColumns+` FROM battle_replays
+		 WHERE trainer1_id = ? OR trainer2_id = ?
+		 ORDER BY created_at DESC, rowid DESC LIMIT ?`,
+		trainerID, trainerID, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get battle replays: %w", err)
+	}
+	defer rows.Close()
+
+	var replays []*BattleReplay
+	for rows.Next() {
+		replay, err := scanReplayRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan battle replay: %w", err)
+		}
+		replays = append(replays, replay)
+	}
+	return replays, rows.Err()
+}
+
+func scanReplayRow(row rowScanner) (*BattleReplay, error) {
+	replay := &BattleReplay{}
+	var trainer2ID sql.NullString
+	var createdAt string
+
+	err := row.Scan(
+		&replay.ID, &replay.BattleType, &replay.Trainer1ID, &trainer2ID,
+		&replay.Title, &replay.Result, &replay.Turns, &replay.Seed, &replay.Events, &createdAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if trainer2ID.Valid {
+		replay.Trainer2ID = &trainer2ID.String
+	}
+	replay.CreatedAt = parseTimestamp(createdAt)
+	return replay, nil
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -64,11 +65,15 @@ func (r *StatsRepo) GetOrCreate(trainerID string) (*TrainerStats, error) {
 	if mostUsedGopherID.Valid {
 		stats.MostUsedGopherID = &mostUsedGopherID.String
 	}
-	stats.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
+	stats.UpdatedAt = parseTimestamp(updatedAt)
 	return &stats, nil
 }
 
 func (r *StatsRepo) IncrementBattles(trainerID string, won bool) error {
+	if err := r.ensureStats(trainerID); err != nil {
+		return err
+	}
+
 	var wins, losses int
 	if won {
 		wins = 1
@@ -86,6 +91,10 @@ func (r *StatsRepo) IncrementBattles(trainerID string, won bool) error {
 }
 
 func (r *StatsRepo) IncrementGophersCaught(trainerID string, isShiny bool) error {
+	if err := r.ensureStats(trainerID); err != nil {
+		return err
+	}
+
 	var shinyCount int
 	if isShiny {
 		shinyCount = 1
@@ -100,6 +109,10 @@ func (r *StatsRepo) IncrementGophersCaught(trainerID string, isShiny bool) error
 }
 
 func (r *StatsRepo) IncrementEvolutions(trainerID string) error {
+	if err := r.ensureStats(trainerID); err != nil {
+		return err
+	}
+
 	_, err := r.db.Conn().Exec(
 		`UPDATE trainer_stats SET evolutions = evolutions + 1, updated_at = CURRENT_TIMESTAMP 
 		 WHERE trainer_id = ?`,
@@ -109,6 +122,10 @@ func (r *StatsRepo) IncrementEvolutions(trainerID string) error {
 }
 
 func (r *StatsRepo) AddXP(trainerID string, xp int) error {
+	if err := r.ensureStats(trainerID); err != nil {
+		return err
+	}
+
 	_, err := r.db.Conn().Exec(
 		`UPDATE trainer_stats SET total_xp_earned = total_xp_earned + ?, updated_at = CURRENT_TIMESTAMP 
 		 WHERE trainer_id = ?`,
@@ -117,26 +134,136 @@ func (r *StatsRepo) AddXP(trainerID string, xp int) error {
 	return err
 }
 
-func (r *StatsRepo) GetLeaderboard(statType string, limit int) ([]*TrainerStats, error) {
-	var orderBy string
+// RecordGopherUsage counts a battle for each gopher and refreshes the trainer's
+// most used gopher and favorite archetype
+func (r *StatsRepo) RecordGopherUsage(trainerID string, gopherArchetypes map[string]string) error {
+	if len(gopherArchetypes) == 0 {
+		return nil
+	}
+	if err := r.ensureStats(trainerID); err != nil {
+		return err
+	}
+
+	tx, err := r.db.Conn().Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin usage transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for gopherID, archetype := range gopherArchetypes {
+		_, err := tx.Exec(
+			`INSERT INTO gopher_usage (trainer_id, gopher_id, archetype, battles) VALUES (?, ?, ?, 1)
+			 ON CONFLICT(trainer_id, gopher_id) DO UPDATE SET battles = battles + 1, archetype = excluded.archetype`,
+			trainerID, gopherID, archetype,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to record gopher usage: %w", err)
+		}
+	}
+
+	_, err = tx.Exec(
+		`UPDATE trainer_stats SET
+		 most_used_gopher_id = (SELECT u.gopher_id FROM gopher_usage u JOIN gophers g ON g.id = u.gopher_id
+		                        WHERE u.trainer_id = ? AND g.trainer_id = ? ORDER BY u.battles DESC LIMIT 1),
+		 favorite_archetype = (SELECT archetype FROM gopher_usage WHERE trainer_id = ?
+		                       GROUP BY archetype ORDER BY SUM(battles) DESC LIMIT 1),
+		 updated_at = CURRENT_TIMESTAMP
+		 WHERE trainer_id = ?`,
+		trainerID, trainerID, trainerID, trainerID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update usage stats: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit usage stats: %w", err)
+	}
+	return nil
+}
+
+// ensureStats creates the trainer's stats row if it doesn't exist yet
+func (r *StatsRepo) ensureStats(trainerID string) error {
+	_, err := r.db.Conn().Exec(`INSERT OR IGNORE INTO trainer_stats (trainer_id) VALUES (?)`, trainerID)
+	if err != nil {
+		return fmt.Errorf("failed to create stats: %w", err)
+	}
+	return nil
+}
+
+// Leaderboard stat types
+const (
+	StatWins       = "wins"
+	StatCaught     = "caught"
+	StatShinies    = "shinies"
+	StatEvolutions = "evolutions"
+	StatXP         = "xp"
+)
+
+// statColumn maps a leaderboard stat type to its trainer_stats column
+func statColumn(statType string) string {
+	switch statType {
+	case StatShinies:
+		return "shiny_count"
+	case StatCaught:
+		return "gophers_caught"
+	case StatEvolutions:
+		return "evolutions"
+	case StatXP:
+		return "total_xp_earned"
+	default:
+		return "battles_won"
+	}
+}
+
+// StatValue returns the value of a leaderboard stat type
+func (s *TrainerStats) StatValue(statType string) int {
 	switch statType {
-	case "wins":
-		orderBy = "battles_won DESC"
-	case "shinies":
-		orderBy = "shiny_count DESC"
-	case "caught":
-		orderBy = "gophers_caught DESC"
-	case "xp":
-		orderBy = "total_xp_earned DESC"
+	case StatShinies:
+		return s.ShinyCount
+	case StatCaught:
+		return s.GophersCaught
+	case StatEvolutions:
+		return s.Evolutions
+	case StatXP:
+		return s.TotalXPEarned
 	default:
-		orderBy = "battles_won DESC"
+		return s.BattlesWon
+	}
+}
+
+// trainerFilter builds a "trainer_id IN (...)" clause. A nil slice means no filter.
+func trainerFilter(trainerIDs []string) (string, []interface{}) {
+	if trainerIDs == nil {
+		return "1 = 1", nil
+	}
+	if len(trainerIDs) == 0 {
+		return "1 = 0", nil
+	}
+
+	placeholders := make([]string, len(trainerIDs))
+	args := make([]interface{}, len(trainerIDs))
+	for i, id := range trainerIDs {
+		placeholders[i] = "?"
+		args[i] = id
 	}
+	return "trainer_id IN (" + strings.Join(placeholders, ", ") + ")", args
+}
+
+func (r *StatsRepo) GetLeaderboard(statType string, limit int) ([]*TrainerStats, error) {
+	return r.GetLeaderboardPage(statType, nil, limit, 0)
+}
+
+// GetLeaderboardPage returns one page of the leaderboard, optionally limited to the given trainers
+func (r *StatsRepo) GetLeaderboardPage(statType string, trainerIDs []string, limit, offset int) ([]*TrainerStats, error) {
+	column := statColumn(statType)
+	filter, args := trainerFilter(trainerIDs)
+	args = append(args, limit, offset)
 
 	rows, err := r.db.Conn().Query(
 		`SELECT trainer_id, total_battles, battles_won, battles_lost, gophers_caught,
 		 shiny_count, evolutions, total_xp_earned, favorite_archetype, most_used_gopher_id, updated_at
-		 FROM trainer_stats ORDER BY `+orderBy+` LIMIT ?`,
-		limit,
+		 FROM trainer_stats WHERE `+filter+` ORDER BY `+column+` DESC, trainer_id LIMIT ? OFFSET ?`,
+		args...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query leaderboard: %w", err)
@@ -159,9 +286,49 @@ func (r *StatsRepo) GetLeaderboard(statType string, limit int) ([]*TrainerStats,
 		if mostUsedGopherID.Valid {
 			s.MostUsedGopherID = &mostUsedGopherID.String
 		}
-		s.UpdatedAt, _ = time.Parse("2006-01-02 15:04:05", updatedAt)
+		s.UpdatedAt = parseTimestamp(updatedAt)
 		stats = append(stats, s)
 	}
 	return stats, nil
 }
 
+// CountLeaderboard returns how many trainers are on the leaderboard
+func (r *StatsRepo) CountLeaderboard(trainerIDs []string) (int, error) {
+	filter, args := trainerFilter(trainerIDs)
+
+	var count int
+	err := r.db.Conn().QueryRow(`SELECT COUNT(*) FROM trainer_stats WHERE `+filter, args...).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count leaderboard: %w", err)
+	}
+	return count, nil
+}
+
+// GetRank returns a trainer's 1-based leaderboard position, or 0 if they have no stats.
+// Ties are broken the same way as GetLeaderboardPage orders them.
+func (r *StatsRepo) GetRank(statType, trainerID string, trainerIDs []string) (int, error) {
+	column := statColumn(statType)
+
+	var value int
+	err := r.db.Conn().QueryRow(`SELECT `+column+` FROM trainer_stats WHERE trainer_id = ?`, trainerID).Scan(&value)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rank: %w", err)
+	}
+
+	filter, args := trainerFilter(trainerIDs)
+	args = append(args, value, value, trainerID)
+
+	var ahead int
+	err = r.db.Conn().QueryRow(
+		`SELECT COUNT(*) FROM trainer_stats WHERE `+filter+`
+		 AND (`+column+` > ? OR (`+column+` = ? AND trainer_id < ?))`,
+		args...,
+	).Scan(&ahead)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rank: %w", err)
+	}
+	return ahead + 1, nil
+}
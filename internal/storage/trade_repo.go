@@ -2,22 +2,44 @@ package storage
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Trade statuses
+const (
+	TradeStatusPending   = "PENDING"
+	TradeStatusAccepted  = "ACCEPTED"
+	TradeStatusRejected  = "REJECTED"
+	TradeStatusCancelled = "CANCELLED"
+	TradeStatusExpired   = "EXPIRED"
+)
+
+// TradeExpiry is how long a trade offer stays open before its escrow is returned
+const TradeExpiry = 24 * time.Hour
+
+var (
+	ErrTradeNotPending      = errors.New("trade is no longer pending")
+	ErrTradeExpired         = errors.New("trade has expired")
+	ErrTradeGopherMoved     = errors.New("a traded gopher has changed hands")
+	ErrGopherInTrade        = errors.New("gopher is already part of a pending trade")
+	ErrInsufficientCurrency = errors.New("insufficient currency")
+)
+
 type Trade struct {
 	ID          string
-	Trainer1ID  string
-	Trainer2ID  string
+	Trainer1ID  string // Trainer who made the offer
+	Trainer2ID  string // Trainer the offer was sent to
 	Gopher1ID   *string
 	Gopher2ID   *string
-	Currency1  int
-	Currency2  int
+	Currency1   int
+	Currency2   int
 	Status      string
 	CreatedAt   time.Time
+	ExpiresAt   time.Time
 	CompletedAt *time.Time
 }
 
@@ -29,59 +51,243 @@ func NewTradeRepo(db *DB) *TradeRepo {
 	return &TradeRepo{db: db}
 }
 
+const tradeColumns = `id, trainer1_id, trainer2_id, gopher1_id, gopher2_id,
+	currency1, currency2, status, created_at, expires_at, completed_at`
+
 func (r *TradeRepo) Create(trade *Trade) error {
 	if trade.ID == "" {
 		trade.ID = uuid.New().String()
 	}
 	_, err := r.db.Conn().Exec(
-		`INSERT INTO trades (id, trainer1_id, trainer2_id, gopher1_id, gopher2_id, 
-		 currency1, currency2, status) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		`INSERT INTO trades (id, trainer1_id, trainer2_id, gopher1_id, gopher2_id,
+		 currency1, currency2, status, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now', ?))`,
 		trade.ID, trade.Trainer1ID, trade.Trainer2ID, trade.Gopher1ID, trade.Gopher2ID,
-		trade.Currency1, trade.Currency2, trade.Status,
+		trade.Currency1, trade.Currency2, trade.Status, expiryModifier(),
 	)
 	return err
 }
 
-func (r *TradeRepo) GetByID(tradeID string) (*Trade, error) {
-	query := `SELECT id, trainer1_id, trainer2_id, gopher1_id, gopher2_id, 
-	          currency1, currency2, status, created_at, completed_at
-	          FROM trades WHERE id = ?`
-	
-	trade := &Trade{}
-	var gopher1ID, gopher2ID sql.NullString
-	var completedAt sql.NullString
-	var createdAt string
-	
-	err := r.db.Conn().QueryRow(query, tradeID).Scan(
-		&trade.ID, &trade.Trainer1ID, &trade.Trainer2ID, &gopher1ID, &gopher2ID,
-		&trade.Currency1, &trade.Currency2, &trade.Status, &createdAt, &completedAt,
+// CreateOffer records a new pending trade and moves the offering trainer's GoCoins into escrow.
+// The offered gopher stays with its owner but is locked until the trade is resolved.
+func (r *TradeRepo) CreateOffer(trade *Trade) error {
+	if trade.ID == "" {
+		trade.ID = uuid.New().String()
+	}
+	trade.Status = TradeStatusPending
+
+	tx, err := r.db.Conn().Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin trade transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if trade.Gopher1ID != nil {
+		if err := checkGopherOwnerTx(tx, *trade.Gopher1ID, trade.Trainer1ID); err != nil {
+			return err
+		}
+		if err := checkGopherNotInTradeTx(tx, *trade.Gopher1ID); err != nil {
+			return err
+		}
+	}
+
+	if trade.Currency1 > 0 {
+		if err := removeCurrencyTx(tx, trade.Trainer1ID, trade.Currency1); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(
+		`INSERT INTO trades (id, trainer1_id, trainer2_id, gopher1_id, gopher2_id,
+		 currency1, currency2, status, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now', ?))`,
+		trade.ID, trade.Trainer1ID, trade.Trainer2ID, trade.Gopher1ID, trade.Gopher2ID,
+		trade.Currency1, trade.Currency2, trade.Status, expiryModifier(),
 	)
-	if err == sql.ErrNoRows {
-		return nil, nil
+	if err != nil {
+		return fmt.Errorf("failed to create trade: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit trade: %w", err)
 	}
+	return nil
+}
+
+// Accept completes a pending trade in a single transaction: the receiving trainer pays their
+// side, escrowed GoCoins are released and both gophers switch owners. Expired trades and
+// trades whose gophers changed hands are closed and refunded instead.
+func (r *TradeRepo) Accept(tradeID string) error {
+	tx, err := r.db.Conn().Begin()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get trade: %w", err)
+		return fmt.Errorf("failed to begin trade transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	if gopher1ID.Valid {
-		trade.Gopher1ID = &gopher1ID.String
+	trade, expired, err := getPendingTradeTx(tx, tradeID)
+	if err != nil {
+		return err
 	}
-	if gopher2ID.Valid {
-		trade.Gopher2ID = &gopher2ID.String
+
+	if expired {
+		return closeTradeAndCommit(tx, trade, TradeStatusExpired, ErrTradeExpired)
 	}
-	if completedAt.Valid {
-		t, _ := time.Parse("2006-01-02 15:04:05", completedAt.String)
-		trade.CompletedAt = &t
+
+	if trade.Gopher1ID != nil {
+		if err := checkGopherOwnerTx(tx, *trade.Gopher1ID, trade.Trainer1ID); err != nil {
+			return closeTradeAndCommit(tx, trade, TradeStatusRejected, ErrTradeGopherMoved)
+		}
+	}
+	if trade.Gopher2ID != nil {
+		if err := checkGopherOwnerTx(tx, *trade.Gopher2ID, trade.Trainer2ID); err != nil {
+			return closeTradeAndCommit(tx, trade, TradeStatusRejected, ErrTradeGopherMoved)
+		}
+	}
+
+	if trade.Currency2 > 0 {
+		if err := removeCurrencyTx(tx, trade.Trainer2ID, trade.Currency2); err != nil {
+			return err
+		}
+		if err := addCurrencyTx(tx, trade.Trainer1ID, trade.Currency2); err != nil {
+			return err
+		}
+	}
+	if trade.Currency1 > 0 {
+		if err := addCurrencyTx(tx, trade.Trainer2ID, trade.Currency1); err != nil {
+			return err
+		}
+	}
+
+	if trade.Gopher1ID != nil {
+		if err := transferGopherTx(tx, *trade.Gopher1ID, trade.Trainer2ID); err != nil {
+			return err
+		}
+	}
+	if trade.Gopher2ID != nil {
+		if err := transferGopherTx(tx, *trade.Gopher2ID, trade.Trainer1ID); err != nil {
+			return err
+		}
+	}
+
+	if err := updatePartySlotsTx(tx, trade.Trainer1ID, trade.Trainer2ID); err != nil {
+		return err
+	}
+
+	if err := setTradeStatusTx(tx, trade.ID, TradeStatusAccepted); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit trade: %w", err)
+	}
+	return nil
+}
+
+// Close ends a pending trade with the given status (REJECTED or CANCELLED) and refunds escrow
+func (r *TradeRepo) Close(tradeID, status string) error {
+	tx, err := r.db.Conn().Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin trade transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	trade, _, err := getPendingTradeTx(tx, tradeID)
+	if err != nil {
+		return err
+	}
+
+	return closeTradeAndCommit(tx, trade, status, nil)
+}
+
+// ExpireTrades closes every pending trade past its expiry and refunds escrow.
+// Returns the number of trades expired.
+func (r *TradeRepo) ExpireTrades() (int, error) {
+	rows, err := r.db.Conn().Query(
+		`SELECT id FROM trades WHERE status = 'PENDING' AND expires_at <= datetime('now')`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query expired trades: %w", err)
+	}
+
+	var tradeIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan trade id: %w", err)
+		}
+		tradeIDs = append(tradeIDs, id)
+	}
+	rows.Close()
+
+	expired := 0
+	for _, tradeID := range tradeIDs {
+		err := r.Close(tradeID, TradeStatusExpired)
+		if errors.Is(err, ErrTradeNotPending) {
+			continue // Resolved while we were expiring
+		}
+		if err != nil {
+			return expired, err
+		}
+		expired++
+	}
+	return expired, nil
+}
+
+// IsGopherInPendingTrade reports whether a gopher is offered or requested in an open trade
+func (r *TradeRepo) IsGopherInPendingTrade(gopherID string) (bool, error) {
+	var count int
+	err := r.db.Conn().QueryRow(
+		`SELECT COUNT(*) FROM trades WHERE status = 'PENDING' AND (gopher1_id = ? OR gopher2_id = ?)`,
+		gopherID, gopherID,
+	).Scan(&count)
+	if err != nil {
+		return false, fmt.Errorf("failed to check pending trades: %w", err)
+	}
+	return count > 0, nil
+}
+
+// GetEscrowedGopherIDs returns the IDs of a trainer's gophers that are offered or requested in
+// an open trade
+func (r *TradeRepo) GetEscrowedGopherIDs(trainerID string) (map[string]bool, error) {
+	rows, err := r.db.Conn().Query(
+		`SELECT gopher1_id FROM trades WHERE status = 'PENDING' AND trainer1_id = ? AND gopher1_id IS NOT NULL
+		 UNION
+		 SELECT gopher2_id FROM trades WHERE status = 'PENDING' AND trainer2_id = ? AND gopher2_id IS NOT NULL`,
+		trainerID, trainerID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query escrowed gophers: %w", err)
+	}
+	defer rows.Close()
+
+	gopherIDs := make(map[string]bool)
+	for rows.Next() {
+		var gopherID string
+		if err := rows.Scan(&gopherID); err != nil {
+			return nil, fmt.Errorf("failed to scan escrowed gopher: %w", err)
+		}
+		gopherIDs[gopherID] = true
+	}
+	return gopherIDs, nil
+}
+
+func (r *TradeRepo) GetByID(tradeID string) (*Trade, error) {
+	query := `SELECT ` + tradeColumns + ` FROM trades WHERE id = ?`
+
+	trade, err := scanTradeRow(r.db.Conn().QueryRow(query, tradeID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trade: %w", err)
 	}
-	trade.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
 	return trade, nil
 }
 
 func (r *TradeRepo) GetPendingTrades(trainerID string) ([]*Trade, error) {
 	rows, err := r.db.Conn().Query(
-		`SELECT id, trainer1_id, trainer2_id, gopher1_id, gopher2_id, 
-		 currency1, currency2, status, created_at, completed_at
-		 FROM trades WHERE (trainer1_id = ? OR trainer2_id = ?) AND status = 'PENDING'`,
+		`SELECT `+tradeColumns+`
+		 FROM trades WHERE (trainer1_id = ? OR trainer2_id = ?) AND status = 'PENDING'
+		 ORDER BY created_at DESC`,
 		trainerID, trainerID,
 	)
 	if err != nil {
@@ -91,25 +297,10 @@ func (r *TradeRepo) GetPendingTrades(trainerID string) ([]*Trade, error) {
 
 	var trades []*Trade
 	for rows.Next() {
-		trade := &Trade{}
-		var gopher1ID, gopher2ID sql.NullString
-		var completedAt sql.NullString
-		var createdAt string
-		if err := rows.Scan(&trade.ID, &trade.Trainer1ID, &trade.Trainer2ID, &gopher1ID, &gopher2ID,
-			&trade.Currency1, &trade.Currency2, &trade.Status, &createdAt, &completedAt); err != nil {
+		trade, err := scanTradeRow(rows)
+		if err != nil {
 			return nil, fmt.Errorf("failed to scan trade: %w", err)
 		}
-		if gopher1ID.Valid {
-			trade.Gopher1ID = &gopher1ID.String
-		}
-		if gopher2ID.Valid {
-			trade.Gopher2ID = &gopher2ID.String
-		}
-		if completedAt.Valid {
-			t, _ := time.Parse("2006-01-02 15:04:05", completedAt.String)
-			trade.CompletedAt = &t
-		}
-		trade.CreatedAt, _ = time.Parse("2006-01-02 15:04:05", createdAt)
 		trades = append(trades, trade)
 	}
 	return trades, nil
@@ -121,3 +312,181 @@ func (r *TradeRepo) UpdateStatus(tradeID, status string) error {
 	return err
 }
 
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTradeRow(row rowScanner) (*Trade, error) {
+	trade := &Trade{}
+	var gopher1ID, gopher2ID sql.NullString
+	var expiresAt, completedAt sql.NullString
+	var createdAt string
+
+	err := row.Scan(
+		&trade.ID, &trade.Trainer1ID, &trade.Trainer2ID, &gopher1ID, &gopher2ID,
+		&trade.Currency1, &trade.Currency2, &trade.Status, &createdAt, &expiresAt, &completedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if gopher1ID.Valid {
+		trade.Gopher1ID = &gopher1ID.String
+	}
+	if gopher2ID.Valid {
+		trade.Gopher2ID = &gopher2ID.String
+	}
+	trade.CreatedAt = parseTimestamp(createdAt)
+	if expiresAt.Valid {
+		trade.ExpiresAt = parseTimestamp(expiresAt.String)
+	} else {
+		trade.ExpiresAt = trade.CreatedAt.Add(TradeExpiry)
+	}
+	if completedAt.Valid {
+		t := parseTimestamp(completedAt.String)
+		trade.CompletedAt = &t
+	}
+	return trade, nil
+}
+
+// expiryModifier returns the SQLite datetime() modifier for TradeExpiry
+func expiryModifier() string {
+	return fmt.Sprintf("+%d seconds", int(TradeExpiry.Seconds()))
+}
+
+// getPendingTradeTx loads a trade inside a transaction, failing if it isn't pending
+func getPendingTradeTx(tx *sql.Tx, tradeID string) (trade *Trade, expired bool, err error) {
+	trade, err = scanTradeRow(tx.QueryRow(`SELECT `+tradeColumns+` FROM trades WHERE id = ?`, tradeID))
+	if err == sql.ErrNoRows {
+		return nil, false, fmt.Errorf("trade not found")
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get trade: %w", err)
+	}
+	if trade.Status != TradeStatusPending {
+		return nil, false, ErrTradeNotPending
+	}
+	return trade, time.Now().After(trade.ExpiresAt), nil
+}
+
+// closeTradeAndCommit refunds escrowed GoCoins, sets the final status and commits.
+// result is returned after a successful commit so callers can report why the trade closed.
+func closeTradeAndCommit(tx *sql.Tx, trade *Trade, status string, result error) error {
+	if trade.Currency1 > 0 {
+		if err := addCurrencyTx(tx, trade.Trainer1ID, trade.Currency1); err != nil {
+			return err
+		}
+	}
+	if err := setTradeStatusTx(tx, trade.ID, status); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit trade: %w", err)
+	}
+	return result
+}
+
+func setTradeStatusTx(tx *sql.Tx, tradeID, status string) error {
+	_, err := tx.Exec(
+		`UPDATE trades SET status = ?, completed_at = CURRENT_TIMESTAMP WHERE id = ? AND status = 'PENDING'`,
+		status, tradeID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update trade status: %w", err)
+	}
+	return nil
+}
+
+func checkGopherOwnerTx(tx *sql.Tx, gopherID, trainerID string) error {
+	var ownerID sql.NullString
+	err := tx.QueryRow(`SELECT trainer_id FROM gophers WHERE id = ?`, gopherID).Scan(&ownerID)
+	if err == sql.ErrNoRows {
+		return ErrTradeGopherMoved
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get gopher owner: %w", err)
+	}
+	if !ownerID.Valid || ownerID.String != trainerID {
+		return ErrTradeGopherMoved
+	}
+	return nil
+}
+
+func checkGopherNotInTradeTx(tx *sql.Tx, gopherID string) error {
+	var count int
+	err := tx.QueryRow(
+		`SELECT COUNT(*) FROM trades WHERE status = 'PENDING' AND (gopher1_id = ? OR gopher2_id = ?)`,
+		gopherID, gopherID,
+	).Scan(&count)
+	if err != nil {
+		return fmt.Errorf("failed to check pending trades: %w", err)
+	}
+	if count > 0 {
+		return ErrGopherInTrade
+	}
+	return nil
+}
+
+func removeCurrencyTx(tx *sql.Tx, trainerID string, amount int) error {
+	result, err := tx.Exec(
+		`UPDATE trainers SET currency = COALESCE(currency, 100) - ? WHERE id = ? AND COALESCE(currency, 100) >= ?`,
+		amount, trainerID, amount,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to remove currency: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to remove currency: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrInsufficientCurrency
+	}
+	return nil
+}
+
+func addCurrencyTx(tx *sql.Tx, trainerID string, amount int) error {
+	_, err := tx.Exec(
+		`UPDATE trainers SET currency = COALESCE(currency, 100) + ? WHERE id = ?`,
+		amount, trainerID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to add currency: %w", err)
+	}
+	return nil
+}
+
+// transferGopherTx gives a gopher to a new trainer, placing it in their party if there's room
+func transferGopherTx(tx *sql.Tx, gopherID, newTrainerID string) error {
+	var partySize int
+	err := tx.QueryRow(
+		`SELECT COUNT(*) FROM gophers WHERE trainer_id = ? AND is_in_party = 1`,
+		newTrainerID,
+	).Scan(&partySize)
+	if err != nil {
+		return fmt.Errorf("failed to get party size: %w", err)
+	}
+
+	_, err = tx.Exec(
+		`UPDATE gophers SET trainer_id = ?, is_in_party = ?, pc_slot = NULL, is_favorite = 0 WHERE id = ?`,
+		newTrainerID, partySize < 6, gopherID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to transfer gopher: %w", err)
+	}
+	return nil
+}
+
+func updatePartySlotsTx(tx *sql.Tx, trainerIDs ...string) error {
+	for _, trainerID := range trainerIDs {
+		_, err := tx.Exec(
+			`UPDATE trainers SET active_party_slots =
+			 (SELECT COUNT(*) FROM gophers WHERE trainer_id = ? AND is_in_party = 1) WHERE id = ?`,
+			trainerID, trainerID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update party slots: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// tradeTest is a fresh database with two trainers, each owning a gopher in their party and
+// one in their PC
+type tradeTest struct {
+	db       *DB
+	trades   *TradeRepo
+	trainers *TrainerRepo
+	gophers  *GopherRepo
+
+	trainer1, trainer2 *Trainer
+	party1, party2     *Gopher
+	pc1, pc2           *Gopher
+}
+
+func newTradeTest(t *testing.T) *tradeTest {
+	t.Helper()
+	db, err := NewDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	tt := &tradeTest{db: db, trades: NewTradeRepo(db), trainers: NewTrainerRepo(db), gophers: NewGopherRepo(db)}
+	tt.trainer1 = tt.createTrainer(t, "one")
+	tt.trainer2 = tt.createTrainer(t, "two")
+	tt.party1 = tt.createGopher(t, tt.trainer1, true)
+	tt.pc1 = tt.createGopher(t, tt.trainer1, false)
+	tt.party2 = tt.createGopher(t, tt.trainer2, true)
+	tt.pc2 = tt.createGopher(t, tt.trainer2, false)
+	return tt
+}
+
+func (tt *tradeTest) createTrainer(t *testing.T, name string) *Trainer {
+	t.Helper()
+	trainer, err := tt.trainers.Create("discord_"+name, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return trainer
+}
+
+func (tt *tradeTest) createGopher(t *testing.T, trainer *Trainer, inParty bool) *Gopher {
+	t.Helper()
+	gopher, err := tt.gophers.Create(&Gopher{
+		TrainerID: &trainer.ID, Name: "Gopher", Level: 5, CurrentHP: 20, MaxHP: 20,
+		Attack: 10, Defense: 10, Speed: 10, Rarity: "COMMON", SpeciesArchetype: "Hacker",
+		EvolutionStage: 1, PrimaryType: "Hacker", IsInParty: inParty,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gopher
+}
+
+// offer creates a pending trade from trainer1 to trainer2
+func (tt *tradeTest) offer(t *testing.T, gopher1, gopher2 *Gopher, currency1, currency2 int) *Trade {
+	t.Helper()
+	trade := &Trade{Trainer1ID: tt.trainer1.ID, Trainer2ID: tt.trainer2.ID, Currency1: currency1, Currency2: currency2}
+	if gopher1 != nil {
+		trade.Gopher1ID = &gopher1.ID
+	}
+	if gopher2 != nil {
+		trade.Gopher2ID = &gopher2.ID
+	}
+	if err := tt.trades.CreateOffer(trade); err != nil {
+		t.Fatal(err)
+	}
+	return trade
+}
+
+func (tt *tradeTest) checkCurrency(t *testing.T, trainer *Trainer, want int) {
+	t.Helper()
+	currency, err := tt.trainers.GetCurrency(trainer.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if currency != want {
+		t.Errorf("%s has %d GoCoins, want %d", trainer.Name, currency, want)
+	}
+}
+
+func (tt *tradeTest) checkOwner(t *testing.T, gopher *Gopher, want *Trainer) {
+	t.Helper()
+	stored, err := tt.gophers.GetByID(gopher.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.TrainerID == nil || *stored.TrainerID != want.ID {
+		t.Errorf("gopher %s isn't owned by %s", gopher.ID, want.Name)
+	}
+}
+
+func (tt *tradeTest) checkStatus(t *testing.T, trade *Trade, want string) {
+	t.Helper()
+	stored, err := tt.trades.GetByID(trade.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Status != want {
+		t.Errorf("trade status = %s, want %s", stored.Status, want)
+	}
+}
+
+func TestTradeAccept(t *testing.T) {
+	tt := newTradeTest(t)
+	trade := tt.offer(t, tt.party1, tt.pc2, 30, 20)
+	tt.checkCurrency(t, tt.trainer1, 70)
+
+	if err := tt.trades.Accept(trade.ID); err != nil {
+		t.Fatal(err)
+	}
+	tt.checkStatus(t, trade, TradeStatusAccepted)
+	tt.checkOwner(t, tt.party1, tt.trainer2)
+	tt.checkOwner(t, tt.pc2, tt.trainer1)
+	tt.checkCurrency(t, tt.trainer1, 90)
+	tt.checkCurrency(t, tt.trainer2, 110)
+
+	// Both traded gophers had room in their new party
+	for _, trainer := range []*Trainer{tt.trainer1, tt.trainer2} {
+		stored, err := tt.trainers.GetByID(trainer.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		party, err := tt.gophers.GetParty(trainer.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.ActivePartySlots != len(party) {
+			t.Errorf("%s has %d party slots recorded, want %d", trainer.Name, stored.ActivePartySlots, len(party))
+		}
+	}
+	if party, _ := tt.gophers.GetParty(tt.trainer2.ID); len(party) != 2 {
+		t.Errorf("%s's party has %d gophers, want 2", tt.trainer2.Name, len(party))
+	}
+
+	if err := tt.trades.Accept(trade.ID); !errors.Is(err, ErrTradeNotPending) {
+		t.Errorf("accepting twice returned %v, want ErrTradeNotPending", err)
+	}
+}
+
+func TestTradeCloseRefundsEscrow(t *testing.T) {
+	for _, status := range []string{TradeStatusRejected, TradeStatusCancelled} {
+		t.Run(status, func(t *testing.T) {
+			tt := newTradeTest(t)
+			trade := tt.offer(t, tt.party1, nil, 40, 0)
+			tt.checkCurrency(t, tt.trainer1, 60)
+
+			if err := tt.trades.Close(trade.ID, status); err != nil {
+				t.Fatal(err)
+			}
+			tt.checkStatus(t, trade, status)
+			tt.checkCurrency(t, tt.trainer1, 100)
+			tt.checkOwner(t, tt.party1, tt.trainer1)
+
+			// The escrow is only returned once
+			if err := tt.trades.Close(trade.ID, status); !errors.Is(err, ErrTradeNotPending) {
+				t.Errorf("closing twice returned %v, want ErrTradeNotPending", err)
+			}
+			tt.checkCurrency(t, tt.trainer1, 100)
+		})
+	}
+}
+
+func TestTradeExpiry(t *testing.T) {
+	tt := newTradeTest(t)
+	expired := tt.offer(t, nil, tt.party2, 25, 0)
+	open := tt.offer(t, nil, tt.pc2, 15, 0)
+	tt.checkCurrency(t, tt.trainer1, 60)
+
+	if _, err := tt.db.Conn().Exec(`UPDATE trades SET expires_at = datetime('now', '-1 hour') WHERE id = ?`, expired.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := tt.trades.ExpireTrades()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 1 {
+		t.Errorf("expired %d trades, want 1", count)
+	}
+	tt.checkStatus(t, expired, TradeStatusExpired)
+	tt.checkStatus(t, open, TradeStatusPending)
+	tt.checkCurrency(t, tt.trainer1, 85)
+}
+
+func TestTradeAcceptAfterExpiry(t *testing.T) {
+	tt := newTradeTest(t)
+	trade := tt.offer(t, tt.party1, tt.party2, 25, 0)
+	if _, err := tt.db.Conn().Exec(`UPDATE trades SET expires_at = datetime('now', '-1 hour') WHERE id = ?`, trade.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tt.trades.Accept(trade.ID); !errors.Is(err, ErrTradeExpired) {
+		t.Fatalf("accepting an expired trade returned %v, want ErrTradeExpired", err)
+	}
+	tt.checkStatus(t, trade, TradeStatusExpired)
+	tt.checkCurrency(t, tt.trainer1, 100)
+	tt.checkOwner(t, tt.party1, tt.trainer1)
+	tt.checkOwner(t, tt.party2, tt.trainer2)
+}
+
+func TestTradeRejectedWhenGopherChangedHands(t *testing.T) {
+	tt := newTradeTest(t)
+	trade := tt.offer(t, tt.party1, tt.party2, 30, 10)
+
+	// trainer2 released their gopher to someone else after the offer was made
+	other := tt.createTrainer(t, "three")
+	if _, err := tt.db.Conn().Exec(`UPDATE gophers SET trainer_id = ? WHERE id = ?`, other.ID, tt.party2.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := tt.trades.Accept(trade.ID); !errors.Is(err, ErrTradeGopherMoved) {
+		t.Fatalf("accept returned %v, want ErrTradeGopherMoved", err)
+	}
+	tt.checkStatus(t, trade, TradeStatusRejected)
+	tt.checkCurrency(t, tt.trainer1, 100)
+	tt.checkCurrency(t, tt.trainer2, 100)
+	tt.checkOwner(t, tt.party1, tt.trainer1)
+}
+
+func TestTradeAcceptRollsBackWithoutCurrency(t *testing.T) {
+	tt := newTradeTest(t)
+	trade := tt.offer(t, tt.party1, tt.party2, 30, 500)
+
+	if err := tt.trades.Accept(trade.ID); !errors.Is(err, ErrInsufficientCurrency) {
+		t.Fatalf("accept returned %v, want ErrInsufficientCurrency", err)
+	}
+
+	// Nothing moved and the offer is still open with its escrow held
+	tt.checkStatus(t, trade, TradeStatusPending)
+	tt.checkCurrency(t, tt.trainer1, 70)
+	tt.checkCurrency(t, tt.trainer2, 100)
+	tt.checkOwner(t, tt.party1, tt.trainer1)
+	tt.checkOwner(t, tt.party2, tt.trainer2)
+}
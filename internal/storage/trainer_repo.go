@@ -130,3 +130,59 @@ func (r *TrainerRepo) GetCurrency(trainerID string) (int, error) {
 	return currency, err
 }
 
+// ActivateXPBoost marks an XP Booster as active for the trainer's next battle.
+// Returns false if one is already active.
+func (r *TrainerRepo) ActivateXPBoost(trainerID string) (bool, error) {
+	result, err := r.db.Conn().Exec(
+		"UPDATE trainers SET xp_boost_active = TRUE WHERE id = ? AND COALESCE(xp_boost_active, FALSE) = FALSE",
+		trainerID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to activate xp boost: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// ConsumeXPBoost clears an active XP Booster, returning whether there was one
+func (r *TrainerRepo) ConsumeXPBoost(trainerID string) (bool, error) {
+	result, err := r.db.Conn().Exec(
+		"UPDATE trainers SET xp_boost_active = FALSE WHERE id = ? AND xp_boost_active = TRUE",
+		trainerID,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to consume xp boost: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// GetAll returns every trainer
+func (r *TrainerRepo) GetAll() ([]*Trainer, error) {
+	rows, err := r.db.Conn().Query(
+		`SELECT id, discord_id, name, created_at, active_party_slots, COALESCE(currency, 100) FROM trainers`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query trainers: %w", err)
+	}
+	defer rows.Close()
+
+	var trainers []*Trainer
+	for rows.Next() {
+		trainer := &Trainer{}
+		var createdAt string
+		if err := rows.Scan(&trainer.ID, &trainer.DiscordID, &trainer.Name, &createdAt,
+			&trainer.ActivePartySlots, &trainer.Currency); err != nil {
+			return nil, fmt.Errorf("failed to scan trainer: %w", err)
+		}
+		trainer.CreatedAt = parseTimestamp(createdAt)
+		trainers = append(trainers, trainer)
+	}
+	return trainers, nil
+}
@@ -0,0 +1,32 @@
+-- Migration to add trade escrow (expiry and EXPIRED status)
+-- SQLite can't alter CHECK constraints, so the trades table is rebuilt
+
+CREATE TABLE IF NOT EXISTS trades_new (
+    id TEXT PRIMARY KEY,
+    trainer1_id TEXT NOT NULL,
+    trainer2_id TEXT NOT NULL,
+    gopher1_id TEXT,
+    gopher2_id TEXT,
+    currency1 INTEGER DEFAULT 0,
+    currency2 INTEGER DEFAULT 0,
+    status TEXT NOT NULL CHECK(status IN ('PENDING', 'ACCEPTED', 'REJECTED', 'CANCELLED', 'EXPIRED')),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    expires_at DATETIME,
+    completed_at DATETIME,
+    FOREIGN KEY (trainer1_id) REFERENCES trainers(id) ON DELETE CASCADE,
+    FOREIGN KEY (trainer2_id) REFERENCES trainers(id) ON DELETE CASCADE,
+    FOREIGN KEY (gopher1_id) REFERENCES gophers(id) ON DELETE SET NULL,
+    FOREIGN KEY (gopher2_id) REFERENCES gophers(id) ON DELETE SET NULL
+);
+
+INSERT INTO trades_new (id, trainer1_id, trainer2_id, gopher1_id, gopher2_id, currency1, currency2, status, created_at, expires_at, completed_at)
+SELECT id, trainer1_id, trainer2_id, gopher1_id, gopher2_id, currency1, currency2, status, created_at, datetime(created_at, '+1 day'), completed_at
+FROM trades;
+
+DROP TABLE trades;
+ALTER TABLE trades_new RENAME TO trades;
+
+CREATE INDEX IF NOT EXISTS idx_trades_trainer1 ON trades(trainer1_id);
+CREATE INDEX IF NOT EXISTS idx_trades_trainer2 ON trades(trainer2_id);
+CREATE INDEX IF NOT EXISTS idx_trades_status ON trades(status);
+CREATE INDEX IF NOT EXISTS idx_trades_expires_at ON trades(expires_at);
@@ -0,0 +1,13 @@
+-- Migration to track how often each gopher battles for trainer statistics
+
+CREATE TABLE IF NOT EXISTS gopher_usage (
+    trainer_id TEXT NOT NULL,
+    gopher_id TEXT NOT NULL,
+    archetype TEXT NOT NULL,
+    battles INTEGER DEFAULT 0,
+    PRIMARY KEY (trainer_id, gopher_id),
+    FOREIGN KEY (trainer_id) REFERENCES trainers(id) ON DELETE CASCADE,
+    FOREIGN KEY (gopher_id) REFERENCES gophers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_gopher_usage_trainer ON gopher_usage(trainer_id);
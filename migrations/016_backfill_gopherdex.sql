@@ -0,0 +1,7 @@
+-- Migration to backfill the Gopherdex with gophers trainers already own
+
+INSERT OR IGNORE INTO gopherdex (trainer_id, gopher_name, archetype, rarity, first_encountered_at, times_encountered, times_caught, owned)
+SELECT trainer_id, name, species_archetype, rarity, MIN(created_at), 0, 0, TRUE
+FROM gophers
+WHERE trainer_id IS NOT NULL
+GROUP BY trainer_id, name, species_archetype, rarity;
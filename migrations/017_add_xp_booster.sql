@@ -0,0 +1,6 @@
+-- Migration to track XP Boosters activated with /item use
+-- The booster is consumed by the trainer's next battle, which stores its XP multiplier
+
+ALTER TABLE trainers ADD COLUMN xp_boost_active BOOLEAN DEFAULT FALSE;
+
+ALTER TABLE battles ADD COLUMN xp_multiplier REAL DEFAULT 1.0;
@@ -0,0 +1,7 @@
+-- Migration to persist learned abilities per gopher
+-- template_id identifies the ability template (and its effect); slot keeps the learned order
+
+ALTER TABLE abilities ADD COLUMN template_id TEXT;
+ALTER TABLE abilities ADD COLUMN slot INTEGER DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_abilities_gopher_slot ON abilities(gopher_id, slot);
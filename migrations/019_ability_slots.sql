@@ -0,0 +1,7 @@
+-- Migration to cap gophers at four active abilities
+-- Forgotten and skipped abilities stay on record (active = FALSE) so they are not offered again
+
+ALTER TABLE abilities ADD COLUMN active BOOLEAN DEFAULT TRUE;
+
+-- Gophers that learned more than four abilities before the cap keep their first four
+UPDATE abilities SET active = FALSE WHERE slot >= 4;
@@ -0,0 +1,4 @@
+-- Migration to persist battle energy
+-- JSON object mapping gopher IDs to their remaining energy; missing gophers have a full pool
+
+ALTER TABLE battles ADD COLUMN energy TEXT DEFAULT '{}';
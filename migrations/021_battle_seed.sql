@@ -0,0 +1,6 @@
+-- Migration to record each battle's random seed
+-- seed starts the battle's random source; rng_draws is how far into the sequence the battle is,
+-- so a battle loaded from the database continues with the same rolls
+
+ALTER TABLE battles ADD COLUMN seed INTEGER DEFAULT 0;
+ALTER TABLE battles ADD COLUMN rng_draws INTEGER DEFAULT 0;
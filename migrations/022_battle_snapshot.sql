@@ -0,0 +1,5 @@
+-- Migration to persist the full battle state
+-- JSON snapshot of the party, participants, log and each gopher's HP, stats and status effects,
+-- saved every turn so battles can be resumed after a restart
+
+ALTER TABLE battles ADD COLUMN snapshot TEXT DEFAULT '{}';
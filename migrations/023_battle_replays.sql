@@ -0,0 +1,20 @@
+-- Migration to store finished battles for replays
+-- events is the JSON event stream of the battle; trainer2_id is only set for PvP battles
+
+CREATE TABLE IF NOT EXISTS battle_replays (
+    id TEXT PRIMARY KEY,
+    battle_type TEXT NOT NULL CHECK(battle_type IN ('WILD', 'PVP')),
+    trainer1_id TEXT NOT NULL,
+    trainer2_id TEXT,
+    title TEXT NOT NULL,
+    result TEXT NOT NULL,
+    turns INTEGER DEFAULT 0,
+    seed INTEGER DEFAULT 0,
+    events TEXT NOT NULL DEFAULT '[]',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (trainer1_id) REFERENCES trainers(id) ON DELETE CASCADE,
+    FOREIGN KEY (trainer2_id) REFERENCES trainers(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_battle_replays_trainer1 ON battle_replays(trainer1_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_battle_replays_trainer2 ON battle_replays(trainer2_id, created_at);
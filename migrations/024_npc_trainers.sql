@@ -0,0 +1,42 @@
+-- Migration for NPC trainer battles and gym badges
+-- npc_id is the NPC trainer being battled. Their gophers are rebuilt from the roster and only
+-- kept in the battle snapshot, so gopher_id_enemy stays NULL in trainer battles.
+
+ALTER TABLE battles ADD COLUMN npc_id TEXT;
+
+-- Badges earned by beating gym leaders
+CREATE TABLE IF NOT EXISTS trainer_badges (
+    trainer_id TEXT NOT NULL,
+    badge_id TEXT NOT NULL,
+    npc_id TEXT NOT NULL,
+    earned_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (trainer_id, badge_id),
+    FOREIGN KEY (trainer_id) REFERENCES trainers(id) ON DELETE CASCADE
+);
+
+-- Replays can be of any battle type. SQLite can't alter CHECK constraints, so the
+-- battle_replays table is rebuilt
+CREATE TABLE IF NOT EXISTS battle_replays_new (
+    id TEXT PRIMARY KEY,
+    battle_type TEXT NOT NULL CHECK(battle_type IN ('WILD', 'TRAINER', 'PVE_BOSS', 'PVP')),
+    trainer1_id TEXT NOT NULL,
+    trainer2_id TEXT,
+    title TEXT NOT NULL,
+    result TEXT NOT NULL,
+    turns INTEGER DEFAULT 0,
+    seed INTEGER DEFAULT 0,
+    events TEXT NOT NULL DEFAULT '[]',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (trainer1_id) REFERENCES trainers(id) ON DELETE CASCADE,
+    FOREIGN KEY (trainer2_id) REFERENCES trainers(id) ON DELETE CASCADE
+);
+
+INSERT INTO battle_replays_new (id, battle_type, trainer1_id, trainer2_id, title, result, turns, seed, events, created_at)
+SELECT id, battle_type, trainer1_id, trainer2_id, title, result, turns, seed, events, created_at
+FROM battle_replays;
+
+DROP TABLE battle_replays;
+ALTER TABLE battle_replays_new RENAME TO battle_replays;
+
+CREATE INDEX IF NOT EXISTS idx_battle_replays_trainer1 ON battle_replays(trainer1_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_battle_replays_trainer2 ON battle_replays(trainer2_id, created_at);
@@ -0,0 +1,5 @@
+-- Migration for held items
+-- held_item is the item type a gopher holds in battle, or empty. It stays with the gopher when
+-- it is traded.
+
+ALTER TABLE gophers ADD COLUMN held_item TEXT NOT NULL DEFAULT '';
@@ -34,6 +34,7 @@ func main() {
 		*seed = time.Now().UnixNano()
 	}
 	rand.Seed(*seed)
+	rng := game.NewRNG(*seed)
 
 	fmt.Println("=== Gopher Generation Test ===")
 	fmt.Printf("Seed: %d\n\n", *seed)
@@ -169,17 +170,17 @@ func main() {
 	}
 
 	// Generate stats
-	hp, attack, defense, speed := game.GenerateBaseStats(selectedArchetype, selectedRarity, selectedLevel)
+	hp, attack, defense, speed := game.GenerateBaseStats(selectedArchetype, selectedRarity, selectedLevel, rng)
 
 	// Assign types
 	primaryType := game.GetTypeFromArchetype(selectedArchetype)
 	secondaryType := ""
 	if rand.Float64() < 0.3 || selectedRarity == "RARE" || selectedRarity == "EPIC" || selectedRarity == "LEGENDARY" {
-		secondaryType = string(game.GetRandomSecondaryType(primaryType))
+		secondaryType = string(game.GetRandomSecondaryType(primaryType, rng))
 	}
 
 	// Create gopher name
-	gopherName := game.GenerateGopherName(selectedArchetype)
+	gopherName := game.GenerateGopherName(selectedArchetype, rng)
 
 	// Display information
 	fmt.Println("\n=== Generated Gopher ===")
@@ -205,7 +206,7 @@ func main() {
 	}
 
 	// Get abilities
-	abilityTemplates := game.GetAbilitiesForArchetype(selectedArchetype, selectedEvolutionStage, selectedRarity)
+	abilityTemplates := game.GetAbilitiesForArchetype(selectedArchetype, selectedEvolutionStage, selectedRarity, rng)
 	
 	// Determine number of abilities based on level and evolution
 	numAbilities := 2